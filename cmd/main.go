@@ -21,6 +21,12 @@ import (
 	"github.com/artrsyf/avito-trainee-assignment/config"
 	"github.com/artrsyf/avito-trainee-assignment/internal/middleware"
 
+	adjustmentRepository "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/repository/postgres"
+	allowanceRepository "github.com/artrsyf/avito-trainee-assignment/internal/allowance/repository/postgres"
+	idempotencyRepository "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository/postgres"
+	ledgerRepository "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/postgres"
+	lotRepository "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/postgres"
+	promoRepository "github.com/artrsyf/avito-trainee-assignment/internal/promo/repository/postgres"
 	purchaseRepository "github.com/artrsyf/avito-trainee-assignment/internal/purchase/repository/postgres"
 	sessionRepository "github.com/artrsyf/avito-trainee-assignment/internal/session/repository/redis"
 	transactionRepository "github.com/artrsyf/avito-trainee-assignment/internal/transaction/repository/postgres"
@@ -28,11 +34,23 @@ import (
 
 	uow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/postgres"
 
+	adjustmentUsecase "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/usecase"
+	allowanceUsecase "github.com/artrsyf/avito-trainee-assignment/internal/allowance/usecase"
+	idempotencyUsecase "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/usecase"
+	ledgerUsecase "github.com/artrsyf/avito-trainee-assignment/internal/ledger/usecase"
+	lotUsecase "github.com/artrsyf/avito-trainee-assignment/internal/lot/usecase"
+	promoUsecase "github.com/artrsyf/avito-trainee-assignment/internal/promo/usecase"
 	purchaseUsecase "github.com/artrsyf/avito-trainee-assignment/internal/purchase/usecase"
 	sessionUsecase "github.com/artrsyf/avito-trainee-assignment/internal/session/usecase"
 	transactionUsecase "github.com/artrsyf/avito-trainee-assignment/internal/transaction/usecase"
 	userUsecase "github.com/artrsyf/avito-trainee-assignment/internal/user/usecase"
 
+	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
+
+	adjustmentDelivery "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/delivery/http"
+	idempotencyDelivery "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/delivery/http"
+	ledgerDelivery "github.com/artrsyf/avito-trainee-assignment/internal/ledger/delivery/http"
+	promoDelivery "github.com/artrsyf/avito-trainee-assignment/internal/promo/delivery/http"
 	purchaseDelivery "github.com/artrsyf/avito-trainee-assignment/internal/purchase/delivery/http"
 	sessionDelivery "github.com/artrsyf/avito-trainee-assignment/internal/session/delivery/http"
 	transactionDelivery "github.com/artrsyf/avito-trainee-assignment/internal/transaction/delivery/http"
@@ -60,6 +78,11 @@ func main() {
 		logger.WithError(err).Fatal("Ошибка при загрузке конфиг файла")
 	}
 
+	// Код приглашения - секрет, поэтому он задается через окружение.
+	if inviteCode := os.Getenv("INVITE_CODE"); inviteCode != "" {
+		cfg.User.Registration.InviteCode = inviteCode
+	}
+
 	postgresDSN := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		os.Getenv("POSTGRES_HOST"),
 		os.Getenv("POSTGRES_PORT"),
@@ -95,61 +118,282 @@ func main() {
 
 	userRepo := userRepository.NewUserPostgresRepository(postgresConnect, logger)
 	sessionRepo := sessionRepository.NewSessionRedisRepository(redisClient, logger)
+	loginAttemptRepo := sessionRepository.NewLoginAttemptRedisRepository(redisClient, logger)
 	transactionRepo := transactionRepository.NewTransactionPostgresRepository(postgresConnect, logger)
 	purchaseRepo := purchaseRepository.NewPurchasePostgresRepository(postgresConnect, logger)
+	idempotencyRepo := idempotencyRepository.NewIdempotencyPostgresRepository(postgresConnect, logger)
+	promoRepo := promoRepository.NewPromoPostgresRepository(postgresConnect, logger)
+	ledgerRepo := ledgerRepository.NewLedgerPostgresRepository(postgresConnect, logger)
+	adjustmentRepo := adjustmentRepository.NewAdjustmentPostgresRepository(postgresConnect, logger)
+	allowanceRepo := allowanceRepository.NewAllowancePostgresRepository(postgresConnect, logger)
+	lotRepo := lotRepository.NewLotPostgresRepository(postgresConnect, logger)
 
 	uowFactory := uow.NewFactory(postgresConnect)
 
 	sessionUC := sessionUsecase.NewSessionUsecase(
 		sessionRepo,
+		loginAttemptRepo,
 		userRepo,
+		ledgerRepo,
+		lotRepo,
+		uowFactory,
 		cfg.User,
+		cfg.Expiry,
 		logger,
 	)
 	transactionUC := transactionUsecase.NewTransactionUsecase(
 		transactionRepo,
 		userRepo,
+		idempotencyRepo,
+		ledgerRepo,
+		lotRepo,
 		uowFactory,
 		logger,
 	)
 	purchaseUC := purchaseUsecase.NewPurchaseUsecase(
 		purchaseRepo,
 		userRepo,
+		idempotencyRepo,
+		promoRepo,
+		ledgerRepo,
+		lotRepo,
+		uowFactory,
+		cfg.Purchase,
+		logger,
+	)
+	promoUC := promoUsecase.NewPromoUsecase(
+		promoRepo,
+		logger,
+	)
+	ledgerUC := ledgerUsecase.NewLedgerUsecase(
+		ledgerRepo,
+		logger,
+	)
+	adjustmentUC := adjustmentUsecase.NewAdjustmentUsecase(
+		adjustmentRepo,
+		userRepo,
+		ledgerRepo,
+		lotRepo,
+		uowFactory,
+		cfg.Expiry,
+		logger,
+	)
+	allowanceUC := allowanceUsecase.NewAllowanceUsecase(
+		allowanceRepo,
+		userRepo,
+		ledgerRepo,
+		lotRepo,
 		uowFactory,
+		cfg.Allowance,
+		cfg.Expiry,
+		logger,
+	)
+	lotUC := lotUsecase.NewLotUsecase(
+		lotRepo,
+		userRepo,
+		ledgerRepo,
+		uowFactory,
+		cfg.Expiry,
+		logger,
+	)
+	idempotencyUC := idempotencyUsecase.NewIdempotencyUsecase(
+		idempotencyRepo,
+		cfg.Idempotency,
 		logger,
 	)
 	userUC := userUsecase.NewUserUsecase(
 		purchaseRepo,
 		transactionRepo,
+		adjustmentRepo,
+		lotRepo,
 		userRepo,
+		cfg.Expiry,
 		logger,
 	)
 
-	authHandler := sessionDelivery.NewSessionHandler(sessionUC, validate, logger)
-	transactionHandler := transactionDelivery.NewTransactionHandler(transactionUC, validate, logger)
-	purchaseHandler := purchaseDelivery.NewPurchaseHandler(purchaseUC, validate, logger)
+	authHandler := sessionDelivery.NewSessionHandler(sessionUC, validate, cfg.User.Lockout, logger)
+	idempotencyHandler := idempotencyDelivery.NewIdempotencyHandler(idempotencyUC, logger)
+	transactionHandler := transactionDelivery.NewTransactionHandler(transactionUC, idempotencyHandler, validate, logger)
+	purchaseHandler := purchaseDelivery.NewPurchaseHandler(purchaseUC, idempotencyHandler, validate, logger)
+	promoHandler := promoDelivery.NewPromoHandler(promoUC, validate, logger)
+	ledgerHandler := ledgerDelivery.NewLedgerHandler(ledgerUC, logger)
+	adjustmentHandler := adjustmentDelivery.NewAdjustmentHandler(adjustmentUC, validate, logger)
 	userHandler := userDelivery.NewUserHandler(userUC, logger)
 
 	router.Handle("/api/auth",
 		http.HandlerFunc(authHandler.Auth)).Methods("POST")
 
+	router.Handle("/api/register",
+		http.HandlerFunc(authHandler.Register)).Methods("POST")
+
+	router.Handle("/api/auth/refresh",
+		http.HandlerFunc(authHandler.Refresh)).Methods("POST")
+
+	router.Handle("/api/auth/logout",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(authHandler.Logout), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/auth/logout/all",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(authHandler.LogoutAll), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/sessions",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(authHandler.GetSessions), sessionUC, logger)).Methods("GET")
+
+	router.Handle("/api/sessions/{id}",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(authHandler.DeleteSession), sessionUC, logger)).Methods("DELETE")
+
 	router.Handle("/api/sendCoin",
 		middleware.ValidateJWTToken(
-			http.HandlerFunc(transactionHandler.SendCoins), logger)).Methods("POST")
+			http.HandlerFunc(transactionHandler.SendCoins), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/transactions",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(transactionHandler.GetHistory), sessionUC, logger)).Methods("GET")
+
+	router.Handle("/api/items",
+		http.HandlerFunc(purchaseHandler.GetItems)).Methods("GET")
+
+	router.Handle("/api/items/{name}",
+		http.HandlerFunc(purchaseHandler.GetItem)).Methods("GET")
+
+	router.Handle("/api/admin/items",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.CreateItem), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/admin/items/{name}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.UpdateItem), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("PATCH")
+
+	router.Handle("/api/admin/items/{name}/restock",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.RestockItem), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/admin/items/{name}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.RetireItem), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("DELETE")
 
 	router.Handle("/api/buy/{item}",
 		middleware.ValidateJWTToken(
-			http.HandlerFunc(purchaseHandler.BuyItem), logger)).Methods("GET")
+			http.HandlerFunc(purchaseHandler.BuyItem), sessionUC, logger)).Methods("GET")
+
+	router.Handle("/api/gift",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(purchaseHandler.GiftItem), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/purchases",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(purchaseHandler.Checkout), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/purchases",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(purchaseHandler.GetPurchases), sessionUC, logger)).Methods("GET")
+
+	router.Handle("/api/purchases/{id}/refund",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(purchaseHandler.RefundPurchase), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/admin/purchases/{id}/refund",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.AdminRefundPurchase), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/admin/purchases",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.AdminGetPurchases), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("GET")
+
+	router.Handle("/api/admin/purchases/{id}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.UpdatePurchaseStatus), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("PATCH")
+
+	router.Handle("/api/admin/promos",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(promoHandler.CreatePromoCode), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/admin/promos",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(promoHandler.GetPromoCodes), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("GET")
+
+	router.Handle("/api/admin/promos/{code}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(promoHandler.DeactivatePromoCode), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("DELETE")
+
+	router.Handle("/api/admin/ledger/reconciliation",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(ledgerHandler.GetReconciliation), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("GET")
+
+	router.Handle("/api/admin/users/{username}/credit",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(adjustmentHandler.CreditBalance), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/admin/users/{username}/debit",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(adjustmentHandler.DebitBalance), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("POST")
+
+	router.Handle("/api/admin/lockouts/users/{username}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(authHandler.UnlockUser), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("DELETE")
+
+	router.Handle("/api/admin/lockouts/ips/{ip}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(authHandler.UnlockIP), logger, userEntity.RoleAdmin), sessionUC, logger)).Methods("DELETE")
 
 	router.Handle("/api/info",
 		middleware.ValidateJWTToken(
-			http.HandlerFunc(userHandler.GetInfo), logger)).Methods("GET")
+			http.HandlerFunc(userHandler.GetInfo), sessionUC, logger)).Methods("GET")
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", 8080),
 		Handler: router,
 	}
 
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	reconciliationInterval, err := cfg.Ledger.GetReconciliationInterval()
+	if err != nil {
+		logger.WithError(err).Warn("Некорректный интервал сверки балансов, периодическая сверка отключена")
+	} else if reconciliationInterval > 0 {
+		go ledgerUC.RunPeriodicReconciliation(backgroundCtx, reconciliationInterval)
+	}
+
+	allowanceInterval, err := cfg.Allowance.GetCheckInterval()
+	if err != nil {
+		logger.WithError(err).Warn("Некорректный интервал начисления коинов, начисление по расписанию отключено")
+	} else if allowanceInterval > 0 {
+		go allowanceUC.RunPeriodically(backgroundCtx, allowanceInterval)
+	}
+
+	expirySweepInterval, err := cfg.Expiry.GetSweepInterval()
+	if err != nil {
+		logger.WithError(err).Warn("Некорректный интервал списания сгоревших коинов, списание отключено")
+	} else if expirySweepInterval > 0 {
+		go lotUC.RunPeriodicExpiry(backgroundCtx, expirySweepInterval)
+	}
+
+	idempotencySweepInterval, err := cfg.Idempotency.GetSweepInterval()
+	if err != nil {
+		logger.WithError(err).Warn("Некорректный интервал удаления ключей идемпотентности, удаление отключено")
+	} else if idempotencySweepInterval > 0 {
+		go idempotencyUC.RunPeriodicCleanup(backgroundCtx, idempotencySweepInterval)
+	}
+
 	go func() {
 		logger.WithField("port", 8080).Info("Сервер запущен")
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -162,6 +406,8 @@ func main() {
 	<-quit
 	logger.Info("Завершение работы сервера...")
 
+	stopBackground()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -0,0 +1,161 @@
+// Command reconcile checks users.coins against the ledger and against the
+// balance recomputed from transactions and purchases, and prints the users
+// whose balances differ. It exits with status 1 if anything doesn't
+// reconcile, so it can be run from cron or CI.
+//
+//	go run ./cmd/reconcile -format csv -output mismatches.csv
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/dto"
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerRepository "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/postgres"
+	ledgerUsecase "github.com/artrsyf/avito-trainee-assignment/internal/ledger/usecase"
+)
+
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+func initLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	// Stdout is reserved for the report.
+	logger.SetOutput(os.Stderr)
+	return logger
+}
+
+func main() {
+	format := flag.String("format", formatJSON, "report format: json or csv")
+	output := flag.String("output", "", "report file, stdout if empty")
+	timeout := flag.Duration("timeout", time.Minute, "reconciliation timeout")
+	flag.Parse()
+
+	logger := initLogger()
+
+	if *format != formatJSON && *format != formatCSV {
+		logger.WithField("format", *format).Fatal("Неизвестный формат отчета")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		logger.WithError(err).Warn("Отсутствует .env файл, используются переменные окружения")
+	}
+
+	consistent, err := run(*format, *output, *timeout, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Ошибка при сверке балансов")
+	}
+
+	if !consistent {
+		logger.Error("Балансы не сходятся")
+		os.Exit(1)
+	}
+
+	logger.Info("Балансы сходятся")
+}
+
+func run(format, output string, timeout time.Duration, logger *logrus.Logger) (bool, error) {
+	postgresDSN := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		os.Getenv("POSTGRES_HOST"),
+		os.Getenv("POSTGRES_PORT"),
+		os.Getenv("POSTGRES_USER"),
+		os.Getenv("POSTGRES_PASSWORD"),
+		os.Getenv("POSTGRES_DB"),
+	)
+
+	postgresConnect, err := sql.Open("postgres", postgresDSN)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := postgresConnect.Close(); err != nil {
+			logger.WithError(err).Error("Ошибка при закрытии подключения к PostgreSQL")
+		}
+	}()
+
+	ledgerRepo := ledgerRepository.NewLedgerPostgresRepository(postgresConnect, logger)
+	ledgerUC := ledgerUsecase.NewLedgerUsecase(ledgerRepo, logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	reconciliation, err := ledgerUC.Reconcile(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	writer := io.Writer(os.Stdout)
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return false, err
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				logger.WithError(err).Error("Ошибка при закрытии файла отчета")
+			}
+		}()
+		writer = file
+	}
+
+	switch format {
+	case formatCSV:
+		err = writeCSV(writer, reconciliation)
+	default:
+		err = writeJSON(writer, reconciliation)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return reconciliation.IsConsistent(), nil
+}
+
+func writeJSON(w io.Writer, reconciliation *entity.Reconciliation) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dto.ReconciliationToResponse(reconciliation))
+}
+
+// writeCSV writes one row per user whose balance doesn't reconcile.
+// Unbalanced entries aren't per user, so they are only logged.
+func writeCSV(w io.Writer, reconciliation *entity.Reconciliation) error {
+	csvWriter := csv.NewWriter(w)
+
+	err := csvWriter.Write([]string{"user_id", "username", "coins", "ledger_balance", "expected_balance"})
+	if err != nil {
+		return err
+	}
+
+	for _, mismatch := range reconciliation.Mismatches {
+		err := csvWriter.Write([]string{
+			strconv.FormatUint(uint64(mismatch.UserID), 10),
+			mismatch.Username,
+			strconv.FormatUint(uint64(mismatch.Coins), 10),
+			strconv.FormatInt(mismatch.LedgerBalance, 10),
+			strconv.FormatInt(mismatch.ExpectedBalance, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
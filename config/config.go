@@ -2,23 +2,101 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	User UserConfig `mapstructure:"user"`
+	User        UserConfig        `mapstructure:"user"`
+	Purchase    PurchaseConfig    `mapstructure:"purchase"`
+	Ledger      LedgerConfig      `mapstructure:"ledger"`
+	Allowance   AllowanceConfig   `mapstructure:"allowance"`
+	Expiry      ExpiryConfig      `mapstructure:"expiry"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
 }
 
 type UserConfig struct {
-	InitCoinsBalance uint       `mapstructure:"init_coins_balance"`
-	Auth             AuthConfig `mapstructure:"auth"`
+	InitCoinsBalance uint               `mapstructure:"init_coins_balance"`
+	Auth             AuthConfig         `mapstructure:"auth"`
+	Registration     RegistrationConfig `mapstructure:"registration"`
+	Lockout          LockoutConfig      `mapstructure:"lockout"`
 }
 
 type AuthConfig struct {
 	AccessTokenExpiration  string `mapstructure:"access_token_expiration"`
 	RefreshTokenExpiration string `mapstructure:"refresh_token_expiration"`
+	SessionCacheTTL        string `mapstructure:"session_cache_ttl"`
+}
+
+// RegistrationConfig describes who can create an account. With AutoSignup
+// an unknown username is registered on the first login, otherwise accounts
+// are created only by the register endpoint. Empty InviteCode and
+// UsernamePattern don't restrict registration.
+type RegistrationConfig struct {
+	AutoSignup      bool   `mapstructure:"auto_signup"`
+	InviteCode      string `mapstructure:"invite_code"`
+	UsernamePattern string `mapstructure:"username_pattern"`
+}
+
+// LockoutConfig throttles password guessing. Failed logins are counted per
+// username and per client IP, once a counter reaches its limit logins are
+// locked for BaseDuration, doubled by every next failure up to MaxDuration.
+// Counters are forgotten after ResetAfter without failures. A zero limit
+// disables its counter. The client IP is taken from X-Forwarded-For only
+// when the request comes from one of TrustedProxies (addresses or CIDR
+// ranges), otherwise anyone could pick the IP they are counted under.
+type LockoutConfig struct {
+	MaxAttempts    uint     `mapstructure:"max_attempts"`
+	MaxIPAttempts  uint     `mapstructure:"max_ip_attempts"`
+	ResetAfter     string   `mapstructure:"reset_after"`
+	BaseDuration   string   `mapstructure:"base_duration"`
+	MaxDuration    string   `mapstructure:"max_duration"`
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+type PurchaseConfig struct {
+	CatalogCacheTTL string `mapstructure:"catalog_cache_ttl"`
+	RefundWindow    string `mapstructure:"refund_window"`
+}
+
+type LedgerConfig struct {
+	ReconciliationInterval string `mapstructure:"reconciliation_interval"`
+}
+
+// AllowanceConfig describes campaigns that credit every user with coins once
+// per period.
+type AllowanceConfig struct {
+	CheckInterval string           `mapstructure:"check_interval"`
+	LeaseTTL      string           `mapstructure:"lease_ttl"`
+	BatchSize     uint             `mapstructure:"batch_size"`
+	Campaigns     []CampaignConfig `mapstructure:"campaigns"`
+}
+
+type CampaignConfig struct {
+	Name   string `mapstructure:"name"`
+	Amount uint   `mapstructure:"amount"`
+	Period string `mapstructure:"period"`
+}
+
+// ExpiryConfig describes when granted coins expire and how the expired
+// coins are written off.
+type ExpiryConfig struct {
+	CoinTTL       string `mapstructure:"coin_ttl"`
+	WarningWindow string `mapstructure:"warning_window"`
+	SweepInterval string `mapstructure:"sweep_interval"`
+	BatchSize     uint   `mapstructure:"batch_size"`
+}
+
+// IdempotencyConfig describes how long stored outcomes of requests sent
+// with an idempotency key are kept. A key can be reused for another request
+// once it's deleted.
+type IdempotencyConfig struct {
+	KeyTTL        string `mapstructure:"key_ttl"`
+	SweepInterval string `mapstructure:"sweep_interval"`
 }
 
 func LoadConfig() (Config, error) {
@@ -45,3 +123,116 @@ func (c *AuthConfig) GetAccessTokenExpiration() (time.Duration, error) {
 func (c *AuthConfig) GetRefreshTokenExpiration() (time.Duration, error) {
 	return time.ParseDuration(c.RefreshTokenExpiration)
 }
+
+func (c *AuthConfig) GetSessionCacheTTL() (time.Duration, error) {
+	return time.ParseDuration(c.SessionCacheTTL)
+}
+
+// GetUsernamePattern returns nil if any username is allowed. The pattern
+// must match the whole username.
+func (c *RegistrationConfig) GetUsernamePattern() (*regexp.Regexp, error) {
+	if c.UsernamePattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + c.UsernamePattern + ")$")
+}
+
+func (c *LockoutConfig) GetResetAfter() (time.Duration, error) {
+	return time.ParseDuration(c.ResetAfter)
+}
+
+func (c *LockoutConfig) GetBaseDuration() (time.Duration, error) {
+	return time.ParseDuration(c.BaseDuration)
+}
+
+func (c *LockoutConfig) GetMaxDuration() (time.Duration, error) {
+	return time.ParseDuration(c.MaxDuration)
+}
+
+// GetTrustedProxies accepts single addresses as well as CIDR ranges.
+func (c *LockoutConfig) GetTrustedProxies() ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0, len(c.TrustedProxies))
+	for _, proxy := range c.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %w", err)
+		}
+
+		proxies = append(proxies, network)
+	}
+
+	return proxies, nil
+}
+
+func (c *PurchaseConfig) GetCatalogCacheTTL() (time.Duration, error) {
+	return time.ParseDuration(c.CatalogCacheTTL)
+}
+
+func (c *PurchaseConfig) GetRefundWindow() (time.Duration, error) {
+	return time.ParseDuration(c.RefundWindow)
+}
+
+// GetReconciliationInterval returns zero if periodic reconciliation is
+// disabled.
+func (c *LedgerConfig) GetReconciliationInterval() (time.Duration, error) {
+	if c.ReconciliationInterval == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(c.ReconciliationInterval)
+}
+
+// GetCheckInterval returns zero if the allowance scheduler is disabled.
+func (c *AllowanceConfig) GetCheckInterval() (time.Duration, error) {
+	if c.CheckInterval == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(c.CheckInterval)
+}
+
+func (c *AllowanceConfig) GetLeaseTTL() (time.Duration, error) {
+	return time.ParseDuration(c.LeaseTTL)
+}
+
+// GetCoinTTL returns zero if granted coins never expire.
+func (c *ExpiryConfig) GetCoinTTL() (time.Duration, error) {
+	if c.CoinTTL == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(c.CoinTTL)
+}
+
+func (c *ExpiryConfig) GetWarningWindow() (time.Duration, error) {
+	return time.ParseDuration(c.WarningWindow)
+}
+
+// GetSweepInterval returns zero if expired coins aren't written off.
+func (c *ExpiryConfig) GetSweepInterval() (time.Duration, error) {
+	if c.SweepInterval == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(c.SweepInterval)
+}
+
+// GetKeyTTL returns zero if idempotency keys are kept forever.
+func (c *IdempotencyConfig) GetKeyTTL() (time.Duration, error) {
+	if c.KeyTTL == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(c.KeyTTL)
+}
+
+// GetSweepInterval returns zero if expired idempotency keys aren't deleted.
+func (c *IdempotencyConfig) GetSweepInterval() (time.Duration, error) {
+	if c.SweepInterval == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(c.SweepInterval)
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/dto"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/usecase"
+	"github.com/artrsyf/avito-trainee-assignment/internal/middleware"
+	JSONResponse "github.com/artrsyf/avito-trainee-assignment/pkg/json_response"
+)
+
+type AdjustmentHandler struct {
+	adjustmentUC usecase.AdjustmentUsecaseI
+	validate     *validator.Validate
+	logger       *logrus.Logger
+}
+
+func NewAdjustmentHandler(
+	adjustmentUsecase usecase.AdjustmentUsecaseI,
+	validate *validator.Validate,
+	logger *logrus.Logger,
+) *AdjustmentHandler {
+	return &AdjustmentHandler{
+		adjustmentUC: adjustmentUsecase,
+		validate:     validate,
+		logger:       logger,
+	}
+}
+
+func (h *AdjustmentHandler) CreditBalance(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming CreditBalance request")
+	h.adjust(w, r, false)
+}
+
+func (h *AdjustmentHandler) DebitBalance(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming DebitBalance request")
+	h.adjust(w, r, true)
+}
+
+func (h *AdjustmentHandler) adjust(w http.ResponseWriter, r *http.Request, debit bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	adminUserID, ok := ctx.Value(middleware.UserIDContextKey).(uint)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to read request body")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+	defer func() {
+		if err = r.Body.Close(); err != nil {
+			h.logger.WithError(err).Warn("Failed to close request body")
+		}
+	}()
+
+	adjustmentRequest := &dto.AdjustmentRequest{}
+	if err = json.Unmarshal(body, adjustmentRequest); err != nil {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+
+	if err = adjustmentRequest.ValidateAdjustmentRequest(h.validate); err != nil {
+		h.logger.WithError(err).Warn("Failed validation for adjustment request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	adjustment, err := h.adjustmentUC.Adjust(ctx, dto.AdjustmentRequestToEntity(
+		adjustmentRequest,
+		mux.Vars(r)["username"],
+		adminUserID,
+		debit,
+	))
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Adjustment error handling")
+
+		switch err {
+		case entity.ErrUserNotFound:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusNotFound,
+				map[string]string{"errors": "can't find such user"},
+			)
+		case entity.ErrNotEnoughBalance:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusBadRequest,
+				map[string]string{"errors": "not enough balance"},
+			)
+		default:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusInternalServerError,
+				map[string]string{"errors": "internal error"},
+			)
+		}
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusCreated, dto.AdjustmentToResponse(adjustment))
+}
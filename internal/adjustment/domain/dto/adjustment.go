@@ -0,0 +1,98 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/model"
+)
+
+// AdjustmentRequest is the body of both credit and debit requests, the
+// direction comes from the route.
+type AdjustmentRequest struct {
+	Amount uint   `json:"amount" validate:"required,gt=0,lte=2147483647"`
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type AdjustmentResponse struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Amount    int64     `json:"amount"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (req *AdjustmentRequest) ValidateAdjustmentRequest(validate *validator.Validate) error {
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	err := validate.Struct(req)
+	if err != nil {
+		if validationErrs, ok := err.(validator.ValidationErrors); ok {
+			for _, err := range validationErrs {
+				field := err.Field()
+
+				switch err.Tag() {
+				case "required":
+					return errors.New(field + " is required")
+				case "gt":
+					return errors.New(field + " must be greater than 0")
+				case "max":
+					return errors.New(field + " is too long")
+				case "lte":
+					return errors.New(field + " is too large")
+				default:
+					return errors.New(field + " is invalid")
+				}
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// AdjustmentRequestToEntity turns the request into a signed adjustment:
+// debits are negative.
+func AdjustmentRequestToEntity(
+	req *AdjustmentRequest,
+	username string,
+	adminID uint,
+	debit bool,
+) *entity.Adjustment {
+	amount := int64(req.Amount)
+	if debit {
+		amount = -amount
+	}
+
+	return &entity.Adjustment{
+		Username: username,
+		AdminID:  adminID,
+		Amount:   amount,
+		Reason:   req.Reason,
+	}
+}
+
+func AdjustmentModelToEntity(adjustment *model.Adjustment, username string) *entity.Adjustment {
+	return &entity.Adjustment{
+		ID:        adjustment.ID,
+		Username:  username,
+		AdminID:   adjustment.AdminID,
+		Amount:    adjustment.Amount,
+		Reason:    adjustment.Reason,
+		CreatedAt: adjustment.CreatedAt,
+	}
+}
+
+func AdjustmentToResponse(adjustment *entity.Adjustment) *AdjustmentResponse {
+	return &AdjustmentResponse{
+		ID:        adjustment.ID,
+		Username:  adjustment.Username,
+		Amount:    adjustment.Amount,
+		Reason:    adjustment.Reason,
+		CreatedAt: adjustment.CreatedAt,
+	}
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// Adjustment is a balance change made by an admin, e.g. a bonus or a fix of
+// a mistake. Positive amounts credit the user, negative amounts debit them.
+type Adjustment struct {
+	ID        uint
+	Username  string
+	AdminID   uint
+	Amount    int64
+	Reason    string
+	CreatedAt time.Time
+}
+
+type HistoryItem struct {
+	Amount    int64     `json:"amount"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type History []HistoryItem
@@ -0,0 +1,8 @@
+package entity
+
+import "errors"
+
+var (
+	ErrUserNotFound     = errors.New("can't find such user")
+	ErrNotEnoughBalance = errors.New("not enough balance")
+)
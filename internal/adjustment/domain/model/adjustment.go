@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type Adjustment struct {
+	ID        uint      `db:"id"`
+	UserID    uint      `db:"user_id"`
+	AdminID   uint      `db:"admin_id"`
+	Amount    int64     `db:"amount"`
+	Reason    string    `db:"reason"`
+	CreatedAt time.Time `db:"created_at"`
+}
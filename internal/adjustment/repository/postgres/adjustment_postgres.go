@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/model"
+	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+type AdjustmentPostgresRepository struct {
+	DB     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewAdjustmentPostgresRepository(
+	db *sql.DB,
+	logger *logrus.Logger,
+) *AdjustmentPostgresRepository {
+	return &AdjustmentPostgresRepository{
+		DB:     db,
+		logger: logger,
+	}
+}
+
+func (repo *AdjustmentPostgresRepository) Create(
+	ctx context.Context,
+	uow uowI.Executor,
+	adjustment *model.Adjustment,
+) (*model.Adjustment, error) {
+	createdAdjustment := model.Adjustment{}
+	err := uow.QueryRowContext(
+		ctx,
+		`INSERT INTO balance_adjustments (user_id, admin_id, amount, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, COALESCE(admin_id, 0), amount, reason, created_at`,
+		adjustment.UserID, adjustment.AdminID, adjustment.Amount, adjustment.Reason,
+	).Scan(
+		&createdAdjustment.ID,
+		&createdAdjustment.UserID,
+		&createdAdjustment.AdminID,
+		&createdAdjustment.Amount,
+		&createdAdjustment.Reason,
+		&createdAdjustment.CreatedAt,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to create balance adjustment")
+		return nil, err
+	}
+
+	repo.logger.WithFields(logrus.Fields{
+		"adjustment_id": createdAdjustment.ID,
+		"user_id":       createdAdjustment.UserID,
+	}).Debug("Created balance adjustment in Postgres")
+
+	return &createdAdjustment, nil
+}
+
+// GetHistoryByUserID returns adjustments of the user, newest first. The
+// admin isn't exposed to the user.
+func (repo *AdjustmentPostgresRepository) GetHistoryByUserID(
+	ctx context.Context,
+	userID uint,
+) (entity.History, error) {
+	rows, err := repo.DB.QueryContext(
+		ctx,
+		`SELECT amount, reason, created_at
+		FROM balance_adjustments
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select balance adjustments")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting balance adjustments")
+		}
+	}()
+
+	history := entity.History{}
+	for rows.Next() {
+		item := entity.HistoryItem{}
+		err := rows.Scan(
+			&item.Amount,
+			&item.Reason,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			repo.logger.WithError(err).Error("Failed to scan balance adjustment")
+			return nil, err
+		}
+
+		history = append(history, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate balance adjustments")
+		return nil, err
+	}
+
+	return history, nil
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/model"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+func TestAdjustmentPostgresRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAdjustmentPostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+
+	createdAt := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO balance_adjustments .* RETURNING .*").
+			WithArgs(2, 1, -100, "duplicate bonus").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "admin_id", "amount", "reason", "created_at"}).
+				AddRow(1, 2, 1, -100, "duplicate bonus", createdAt))
+
+		adjustment, err := repo.Create(context.Background(), mockUOW, &model.Adjustment{
+			UserID:  2,
+			AdminID: 1,
+			Amount:  -100,
+			Reason:  "duplicate bonus",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Adjustment{
+			ID:        1,
+			UserID:    2,
+			AdminID:   1,
+			Amount:    -100,
+			Reason:    "duplicate bonus",
+			CreatedAt: createdAt,
+		}, adjustment)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO balance_adjustments .* RETURNING .*").
+			WithArgs(2, 1, 100, "bonus").
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.Create(context.Background(), mockUOW, &model.Adjustment{
+			UserID:  2,
+			AdminID: 1,
+			Amount:  100,
+			Reason:  "bonus",
+		})
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestAdjustmentPostgresRepository_GetHistoryByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAdjustmentPostgresRepository(db, logrus.New())
+
+	createdAt := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+	columns := []string{"amount", "reason", "created_at"}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT amount, reason, created_at FROM balance_adjustments WHERE user_id = \$1`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(-100, "duplicate bonus", createdAt.Add(time.Hour)).
+				AddRow(500, "hackathon prize", createdAt))
+
+		history, err := repo.GetHistoryByUserID(context.Background(), 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.History{
+			{Amount: -100, Reason: "duplicate bonus", CreatedAt: createdAt.Add(time.Hour)},
+			{Amount: 500, Reason: "hackathon prize", CreatedAt: createdAt},
+		}, history)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT amount, reason, created_at FROM balance_adjustments .*`).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		history, err := repo.GetHistoryByUserID(context.Background(), 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.History{}, history)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT amount, reason, created_at FROM balance_adjustments .*`).
+			WithArgs(2).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.GetHistoryByUserID(context.Background(), 2)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+type MockUnitOfWork struct {
+	uow.Executor
+	db *sql.DB
+}
+
+func (m *MockUnitOfWork) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return m.db.ExecContext(ctx, query, args...)
+}
+
+func (m *MockUnitOfWork) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return m.db.QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/model"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+//go:generate mockgen -source=repository.go -destination=mock_repository/adjustment_mock.go -package=mock_repository MockAdjustmentRepository
+type AdjustmentRepositoryI interface {
+	Create(ctx context.Context, uow uow.Executor, adjustment *model.Adjustment) (*model.Adjustment, error)
+	GetHistoryByUserID(ctx context.Context, userID uint) (entity.History, error)
+}
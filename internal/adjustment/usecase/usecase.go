@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/dto"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/model"
+	adjustmentRepo "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/repository"
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository"
+	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
+	userRepo "github.com/artrsyf/avito-trainee-assignment/internal/user/repository"
+	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+type AdjustmentUsecaseI interface {
+	Adjust(ctx context.Context, adjustment *entity.Adjustment) (*entity.Adjustment, error)
+}
+
+type AdjustmentUsecase struct {
+	adjustmentRepo adjustmentRepo.AdjustmentRepositoryI
+	userRepo       userRepo.UserRepositoryI
+	ledgerRepo     ledgerRepo.LedgerRepositoryI
+	lotRepo        lotRepo.LotRepositoryI
+	uowFactory     uowI.Factory
+	coinTTL        time.Duration
+	logger         *logrus.Logger
+}
+
+func NewAdjustmentUsecase(
+	adjustmentRepository adjustmentRepo.AdjustmentRepositoryI,
+	userRepository userRepo.UserRepositoryI,
+	ledgerRepository ledgerRepo.LedgerRepositoryI,
+	lotRepository lotRepo.LotRepositoryI,
+	uowFactory uowI.Factory,
+	cfg config.ExpiryConfig,
+	logger *logrus.Logger,
+) *AdjustmentUsecase {
+	coinTTL, err := cfg.GetCoinTTL()
+	if err != nil {
+		logger.WithError(err).Warn("Coin TTL is invalid, credited coins won't expire")
+	}
+
+	return &AdjustmentUsecase{
+		adjustmentRepo: adjustmentRepository,
+		userRepo:       userRepository,
+		ledgerRepo:     ledgerRepository,
+		lotRepo:        lotRepository,
+		uowFactory:     uowFactory,
+		coinTTL:        coinTTL,
+		logger:         logger,
+	}
+}
+
+// Adjust changes the user's balance, records the adjustment with its reason
+// and journals it in one unit of work. Credited coins come from the issuer
+// account and expire like granted ones, debited coins are taken from the
+// lots that expire first and go back to the issuer.
+func (uc *AdjustmentUsecase) Adjust(
+	ctx context.Context,
+	adjustment *entity.Adjustment,
+) (*entity.Adjustment, error) {
+	user, err := uc.userRepo.GetByUsername(ctx, adjustment.Username)
+	if err == userEntity.ErrIsNotExist {
+		uc.logger.WithField("username", adjustment.Username).Info("Couldn't find user to adjust")
+		return nil, entity.ErrUserNotFound
+	}
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to get user by username")
+		return nil, err
+	}
+
+	uow := uc.uowFactory.NewUnitOfWork()
+
+	err = uow.Begin(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction begin error")
+		return nil, err
+	}
+
+	from, to := ledgerEntity.IssuerAccount, ledgerEntity.UserAccount(user.ID)
+	amount := uint(adjustment.Amount)
+	if adjustment.Amount < 0 {
+		from, to = to, from
+		amount = uint(-adjustment.Amount)
+		err = uc.debit(ctx, uow, user.ID, amount)
+	} else {
+		err = uc.credit(ctx, uow, user.ID, amount)
+	}
+	if err != nil {
+		uc.rollback(uow)
+		if err == userEntity.ErrNotEnoughBalance || err == lotEntity.ErrNotEnoughCoins {
+			uc.logger.WithField("user_id", user.ID).Info("User doesn't have enough balance to debit")
+			return nil, entity.ErrNotEnoughBalance
+		}
+		uc.logger.WithError(err).Error("Rollback adjustment due user updating")
+		return nil, err
+	}
+
+	createdAdjustment, err := uc.adjustmentRepo.Create(ctx, uow, &model.Adjustment{
+		UserID:  user.ID,
+		AdminID: adjustment.AdminID,
+		Amount:  adjustment.Amount,
+		Reason:  adjustment.Reason,
+	})
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback adjustment due adjustment creating")
+		return nil, err
+	}
+
+	_, err = uc.ledgerRepo.Post(ctx, uow, ledgerEntity.NewTransfer(
+		ledgerEntity.KindAdjustment,
+		fmt.Sprintf("adjustment:%d", createdAdjustment.ID),
+		from,
+		to,
+		amount,
+	))
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback adjustment due ledger posting")
+		return nil, err
+	}
+
+	err = uow.Commit()
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction commit error due adjustment creating")
+		return nil, err
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"adjustment_id": createdAdjustment.ID,
+		"user_id":       user.ID,
+		"admin_id":      adjustment.AdminID,
+		"amount":        adjustment.Amount,
+		"reason":        adjustment.Reason,
+	}).Info("Successfully adjusted user balance")
+
+	return dto.AdjustmentModelToEntity(createdAdjustment, user.Username), nil
+}
+
+func (uc *AdjustmentUsecase) credit(ctx context.Context, uow uowI.Executor, userID, amount uint) error {
+	err := uc.userRepo.Credit(ctx, uow, userID, amount)
+	if err != nil {
+		return err
+	}
+
+	return uc.lotRepo.Create(ctx, uow, userID, lotEntity.Portions{
+		lotEntity.NewGrant(amount, time.Now(), uc.coinTTL),
+	})
+}
+
+func (uc *AdjustmentUsecase) debit(ctx context.Context, uow uowI.Executor, userID, amount uint) error {
+	err := uc.userRepo.Debit(ctx, uow, userID, amount)
+	if err != nil {
+		return err
+	}
+
+	_, err = uc.lotRepo.Consume(ctx, uow, userID, amount)
+	return err
+}
+
+func (uc *AdjustmentUsecase) rollback(uow uowI.UnitOfWork) {
+	if err := uow.Rollback(); err != nil {
+		uc.logger.WithError(err).Error("Rollback error encountered")
+	}
+}
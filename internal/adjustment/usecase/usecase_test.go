@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/model"
+	mockAdjustment "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/repository/mock_repository"
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerModel "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/model"
+	mockLedger "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/mock_repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	mockLot "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/mock_repository"
+	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
+	userModel "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/model"
+	mockUser "github.com/artrsyf/avito-trainee-assignment/internal/user/repository/mock_repository"
+	mockUow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/mock_uow"
+)
+
+func TestAdjustmentUsecase_Adjust(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdjustmentRepo := mockAdjustment.NewMockAdjustmentRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+	mockUow := mockUow.NewMockUnitOfWork(ctrl)
+
+	uc := NewAdjustmentUsecase(
+		mockAdjustmentRepo,
+		mockUserRepo,
+		mockLedgerRepo,
+		mockLotRepo,
+		mockUowFactory,
+		config.ExpiryConfig{CoinTTL: "8760h"},
+		logrus.New(),
+	)
+
+	ctx := context.Background()
+	user := &userModel.User{ID: 2, Username: "user1", Coins: 1000}
+	createdAt := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+	testErr := errors.New("database error")
+
+	t.Run("successful credit", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByUsername(ctx, "user1").Return(user, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(500)).Return(nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ interface{}, _ uint, portions lotEntity.Portions) error {
+				if len(portions) != 1 || portions[0].Amount != 500 || portions[0].ExpiresAt == nil {
+					t.Errorf("expected one expiring lot of 500 coins, got %+v", portions)
+				} else if time.Until(*portions[0].ExpiresAt) < 8759*time.Hour {
+					t.Errorf("expected lot to expire in a year, got %v", *portions[0].ExpiresAt)
+				}
+				return nil
+			})
+		mockAdjustmentRepo.EXPECT().Create(ctx, mockUow, &model.Adjustment{
+			UserID:  2,
+			AdminID: 1,
+			Amount:  500,
+			Reason:  "hackathon prize",
+		}).Return(&model.Adjustment{
+			ID:        7,
+			UserID:    2,
+			AdminID:   1,
+			Amount:    500,
+			Reason:    "hackathon prize",
+			CreatedAt: createdAt,
+		}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, ledgerEntity.NewTransfer(
+			ledgerEntity.KindAdjustment,
+			"adjustment:7",
+			ledgerEntity.IssuerAccount,
+			ledgerEntity.UserAccount(2),
+			500,
+		)).Return(&ledgerModel.Entry{ID: 1}, nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		adjustment, err := uc.Adjust(ctx, &entity.Adjustment{
+			Username: "user1",
+			AdminID:  1,
+			Amount:   500,
+			Reason:   "hackathon prize",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &entity.Adjustment{
+			ID:        7,
+			Username:  "user1",
+			AdminID:   1,
+			Amount:    500,
+			Reason:    "hackathon prize",
+			CreatedAt: createdAt,
+		}
+		if !reflect.DeepEqual(adjustment, expected) {
+			t.Errorf("expected %+v, got %+v", expected, adjustment)
+		}
+	})
+
+	t.Run("successful debit", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByUsername(ctx, "user1").Return(user, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(2), uint(200)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(2), uint(200)).Return(lotEntity.Portions{{Amount: 200}}, nil)
+		mockAdjustmentRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&model.Adjustment{
+			ID:     8,
+			UserID: 2,
+			Amount: -200,
+		}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, ledgerEntity.NewTransfer(
+			ledgerEntity.KindAdjustment,
+			"adjustment:8",
+			ledgerEntity.UserAccount(2),
+			ledgerEntity.IssuerAccount,
+			200,
+		)).Return(&ledgerModel.Entry{ID: 2}, nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		adjustment, err := uc.Adjust(ctx, &entity.Adjustment{
+			Username: "user1",
+			AdminID:  1,
+			Amount:   -200,
+			Reason:   "duplicate bonus",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if adjustment.Amount != -200 {
+			t.Errorf("expected amount -200, got %d", adjustment.Amount)
+		}
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByUsername(ctx, "nobody").Return(nil, userEntity.ErrIsNotExist)
+
+		_, err := uc.Adjust(ctx, &entity.Adjustment{Username: "nobody", Amount: 100, Reason: "bonus"})
+		if !errors.Is(err, entity.ErrUserNotFound) {
+			t.Errorf("expected ErrUserNotFound, got %v", err)
+		}
+	})
+
+	t.Run("not enough balance", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByUsername(ctx, "user1").Return(user, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(2), uint(5000)).Return(userEntity.ErrNotEnoughBalance)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.Adjust(ctx, &entity.Adjustment{Username: "user1", Amount: -5000, Reason: "fix"})
+		if !errors.Is(err, entity.ErrNotEnoughBalance) {
+			t.Errorf("expected ErrNotEnoughBalance, got %v", err)
+		}
+	})
+
+	t.Run("coin lot create error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByUsername(ctx, "user1").Return(user, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), gomock.Any()).Return(testErr)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.Adjust(ctx, &entity.Adjustment{Username: "user1", Amount: 100, Reason: "bonus"})
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+
+	t.Run("adjustment create error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByUsername(ctx, "user1").Return(user, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), gomock.Any()).Return(nil)
+		mockAdjustmentRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(nil, testErr)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.Adjust(ctx, &entity.Adjustment{Username: "user1", Amount: 100, Reason: "bonus"})
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+
+	t.Run("ledger posting error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByUsername(ctx, "user1").Return(user, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), gomock.Any()).Return(nil)
+		mockAdjustmentRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&model.Adjustment{ID: 9}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(nil, testErr)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.Adjust(ctx, &entity.Adjustment{Username: "user1", Amount: 100, Reason: "bonus"})
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+
+	t.Run("commit error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByUsername(ctx, "user1").Return(user, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), gomock.Any()).Return(nil)
+		mockAdjustmentRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&model.Adjustment{ID: 10}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 3}, nil)
+		mockUow.EXPECT().Commit().Return(testErr)
+
+		_, err := uc.Adjust(ctx, &entity.Adjustment{Username: "user1", Amount: 100, Reason: "bonus"})
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package entity
+
+import "time"
+
+const (
+	PeriodDaily   = "daily"
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+
+	maxCampaignNameLength = 50
+)
+
+// Campaign credits every user with Amount coins once per Period. Periods
+// start at midnight UTC: every day, every Monday or on the first day of the
+// month.
+type Campaign struct {
+	Name   string
+	Amount uint
+	Period string
+}
+
+func (c *Campaign) Validate() error {
+	if c.Name == "" || len(c.Name) > maxCampaignNameLength {
+		return ErrInvalidName
+	}
+	if c.Amount == 0 {
+		return ErrInvalidAmount
+	}
+
+	switch c.Period {
+	case PeriodDaily, PeriodWeekly, PeriodMonthly:
+		return nil
+	default:
+		return ErrInvalidPeriod
+	}
+}
+
+// PeriodStart returns the start of the period that contains now.
+func (c *Campaign) PeriodStart(now time.Time) time.Time {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch c.Period {
+	case PeriodWeekly:
+		// Weeks start on Monday.
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case PeriodMonthly:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// PeriodKey identifies the period that starts at start, e.g. "2026-10" for
+// a monthly campaign or "2026-10-12" for daily and weekly ones.
+func (c *Campaign) PeriodKey(start time.Time) string {
+	if c.Period == PeriodMonthly {
+		return start.Format("2006-01")
+	}
+	return start.Format("2006-01-02")
+}
+
+// EligibleFilter selects a batch of users who should be credited for the
+// period: users created before the period started who weren't credited yet.
+type EligibleFilter struct {
+	Campaign      string
+	Period        string
+	CreatedBefore time.Time
+	AfterID       uint
+	Limit         uint
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "errors"
+
+var (
+	ErrInvalidName   = errors.New("invalid campaign name")
+	ErrInvalidAmount = errors.New("campaign amount must be positive")
+	ErrInvalidPeriod = errors.New("campaign period must be daily, weekly or monthly")
+	ErrLeaseLost     = errors.New("scheduler lease is held by another replica")
+)
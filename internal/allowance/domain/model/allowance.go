@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type Credit struct {
+	Campaign  string    `db:"campaign"`
+	Period    string    `db:"period"`
+	UserID    uint      `db:"user_id"`
+	Amount    uint      `db:"amount"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type Run struct {
+	Campaign      string    `db:"campaign"`
+	Period        string    `db:"period"`
+	CreditedUsers uint      `db:"credited_users"`
+	CompletedAt   time.Time `db:"completed_at"`
+}
@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/domain/model"
+	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+type AllowancePostgresRepository struct {
+	DB     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewAllowancePostgresRepository(
+	db *sql.DB,
+	logger *logrus.Logger,
+) *AllowancePostgresRepository {
+	return &AllowancePostgresRepository{
+		DB:     db,
+		logger: logger,
+	}
+}
+
+// AcquireLease takes the lease if it's free or expired, or extends it if
+// holder already has it. It returns false if another holder has the lease.
+func (repo *AllowancePostgresRepository) AcquireLease(
+	ctx context.Context,
+	name string,
+	holder string,
+	ttl time.Duration,
+) (bool, error) {
+	result, err := repo.DB.ExecContext(
+		ctx,
+		`INSERT INTO scheduler_leases (name, holder, expires_at)
+		VALUES ($1, $2, NOW() + make_interval(secs => $3))
+		ON CONFLICT (name) DO UPDATE
+		SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE scheduler_leases.expires_at < NOW() OR scheduler_leases.holder = EXCLUDED.holder`,
+		name, holder, ttl.Seconds(),
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to acquire scheduler lease")
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get acquired scheduler leases count")
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (repo *AllowancePostgresRepository) ReleaseLease(
+	ctx context.Context,
+	name string,
+	holder string,
+) error {
+	_, err := repo.DB.ExecContext(
+		ctx,
+		"DELETE FROM scheduler_leases WHERE name = $1 AND holder = $2",
+		name, holder,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to release scheduler lease")
+		return err
+	}
+
+	return nil
+}
+
+func (repo *AllowancePostgresRepository) IsRunCompleted(
+	ctx context.Context,
+	campaign string,
+	period string,
+) (bool, error) {
+	var completed bool
+	err := repo.DB.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM allowance_runs WHERE campaign = $1 AND period = $2)",
+		campaign, period,
+	).Scan(&completed)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to check allowance run")
+		return false, err
+	}
+
+	return completed, nil
+}
+
+// GetEligibleUserIDs returns up to filter.Limit users with ID greater than
+// filter.AfterID, ordered by ID, so the caller can page through all of them.
+func (repo *AllowancePostgresRepository) GetEligibleUserIDs(
+	ctx context.Context,
+	filter *entity.EligibleFilter,
+) ([]uint, error) {
+	rows, err := repo.DB.QueryContext(
+		ctx,
+		`SELECT u.id
+		FROM users u
+		WHERE u.created_at < $3 AND u.id > $4
+			AND NOT EXISTS (
+				SELECT 1 FROM allowance_credits c
+				WHERE c.campaign = $1 AND c.period = $2 AND c.user_id = u.id
+			)
+		ORDER BY u.id
+		LIMIT $5`,
+		filter.Campaign, filter.Period, filter.CreatedBefore, filter.AfterID, filter.Limit,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select users eligible for allowance")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting users eligible for allowance")
+		}
+	}()
+
+	userIDs := []uint{}
+	for rows.Next() {
+		var userID uint
+		if err := rows.Scan(&userID); err != nil {
+			repo.logger.WithError(err).Error("Failed to scan user eligible for allowance")
+			return nil, err
+		}
+
+		userIDs = append(userIDs, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate users eligible for allowance")
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// CreateCredit records the credit and returns false if the user was already
+// credited for the campaign period.
+func (repo *AllowancePostgresRepository) CreateCredit(
+	ctx context.Context,
+	uow uowI.Executor,
+	credit *model.Credit,
+) (bool, error) {
+	result, err := uow.ExecContext(
+		ctx,
+		`INSERT INTO allowance_credits (campaign, period, user_id, amount)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (campaign, period, user_id) DO NOTHING`,
+		credit.Campaign, credit.Period, credit.UserID, credit.Amount,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to create allowance credit")
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get created allowance credits count")
+		return false, err
+	}
+	if affected == 0 {
+		repo.logger.WithFields(logrus.Fields{
+			"campaign": credit.Campaign,
+			"period":   credit.Period,
+			"user_id":  credit.UserID,
+		}).Info("User is already credited for allowance period")
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (repo *AllowancePostgresRepository) CompleteRun(
+	ctx context.Context,
+	run *model.Run,
+) error {
+	_, err := repo.DB.ExecContext(
+		ctx,
+		`INSERT INTO allowance_runs (campaign, period, credited_users)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (campaign, period) DO NOTHING`,
+		run.Campaign, run.Period, run.CreditedUsers,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to complete allowance run")
+		return err
+	}
+
+	repo.logger.WithFields(logrus.Fields{
+		"campaign": run.Campaign,
+		"period":   run.Period,
+	}).Debug("Completed allowance run in Postgres")
+
+	return nil
+}
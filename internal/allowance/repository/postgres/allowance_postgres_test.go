@@ -0,0 +1,245 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/domain/model"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+func TestAllowancePostgresRepository_AcquireLease(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAllowancePostgresRepository(db, logrus.New())
+
+	t.Run("Acquired", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO scheduler_leases .* ON CONFLICT .*").
+			WithArgs("allowance", "replica-1", float64(300)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		acquired, err := repo.AcquireLease(context.Background(), "allowance", "replica-1", 5*time.Minute)
+
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+	})
+
+	t.Run("HeldByAnotherReplica", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO scheduler_leases .* ON CONFLICT .*").
+			WithArgs("allowance", "replica-2", float64(300)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		acquired, err := repo.AcquireLease(context.Background(), "allowance", "replica-2", 5*time.Minute)
+
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO scheduler_leases .*").
+			WithArgs("allowance", "replica-1", float64(300)).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.AcquireLease(context.Background(), "allowance", "replica-1", 5*time.Minute)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestAllowancePostgresRepository_ReleaseLease(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAllowancePostgresRepository(db, logrus.New())
+
+	mock.ExpectExec(`DELETE FROM scheduler_leases WHERE name = \$1 AND holder = \$2`).
+		WithArgs("allowance", "replica-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.ReleaseLease(context.Background(), "allowance", "replica-1")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAllowancePostgresRepository_IsRunCompleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAllowancePostgresRepository(db, logrus.New())
+
+	t.Run("Completed", func(t *testing.T) {
+		mock.ExpectQuery("SELECT EXISTS .* FROM allowance_runs .*").
+			WithArgs("monthly", "2026-10").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		completed, err := repo.IsRunCompleted(context.Background(), "monthly", "2026-10")
+
+		assert.NoError(t, err)
+		assert.True(t, completed)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery("SELECT EXISTS .* FROM allowance_runs .*").
+			WithArgs("monthly", "2026-10").
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.IsRunCompleted(context.Background(), "monthly", "2026-10")
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestAllowancePostgresRepository_GetEligibleUserIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAllowancePostgresRepository(db, logrus.New())
+
+	periodStart := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	filter := &entity.EligibleFilter{
+		Campaign:      "monthly",
+		Period:        "2026-10",
+		CreatedBefore: periodStart,
+		AfterID:       10,
+		Limit:         2,
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT u.id FROM users u .* NOT EXISTS .* ORDER BY u.id LIMIT").
+			WithArgs("monthly", "2026-10", periodStart, 10, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11).AddRow(14))
+
+		userIDs, err := repo.GetEligibleUserIDs(context.Background(), filter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{11, 14}, userIDs)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		mock.ExpectQuery("SELECT u.id FROM users u .*").
+			WithArgs("monthly", "2026-10", periodStart, 10, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		userIDs, err := repo.GetEligibleUserIDs(context.Background(), filter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{}, userIDs)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery("SELECT u.id FROM users u .*").
+			WithArgs("monthly", "2026-10", periodStart, 10, 2).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.GetEligibleUserIDs(context.Background(), filter)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestAllowancePostgresRepository_CreateCredit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAllowancePostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+
+	credit := &model.Credit{
+		Campaign: "monthly",
+		Period:   "2026-10",
+		UserID:   2,
+		Amount:   200,
+	}
+
+	t.Run("Created", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO allowance_credits .* ON CONFLICT .* DO NOTHING").
+			WithArgs("monthly", "2026-10", 2, 200).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		created, err := repo.CreateCredit(context.Background(), mockUOW, credit)
+
+		assert.NoError(t, err)
+		assert.True(t, created)
+	})
+
+	t.Run("AlreadyCredited", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO allowance_credits .*").
+			WithArgs("monthly", "2026-10", 2, 200).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		created, err := repo.CreateCredit(context.Background(), mockUOW, credit)
+
+		assert.NoError(t, err)
+		assert.False(t, created)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO allowance_credits .*").
+			WithArgs("monthly", "2026-10", 2, 200).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.CreateCredit(context.Background(), mockUOW, credit)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestAllowancePostgresRepository_CompleteRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAllowancePostgresRepository(db, logrus.New())
+
+	mock.ExpectExec("INSERT INTO allowance_runs .* ON CONFLICT .* DO NOTHING").
+		WithArgs("monthly", "2026-10", 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.CompleteRun(context.Background(), &model.Run{
+		Campaign:      "monthly",
+		Period:        "2026-10",
+		CreditedUsers: 3,
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type MockUnitOfWork struct {
+	uow.Executor
+	db *sql.DB
+}
+
+func (m *MockUnitOfWork) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return m.db.ExecContext(ctx, query, args...)
+}
+
+func (m *MockUnitOfWork) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return m.db.QueryRowContext(ctx, query, args...)
+}
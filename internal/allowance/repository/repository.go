@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/domain/model"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+//go:generate mockgen -source=repository.go -destination=mock_repository/allowance_mock.go -package=mock_repository MockAllowanceRepository
+type AllowanceRepositoryI interface {
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+	IsRunCompleted(ctx context.Context, campaign, period string) (bool, error)
+	GetEligibleUserIDs(ctx context.Context, filter *entity.EligibleFilter) ([]uint, error)
+	CreateCredit(ctx context.Context, uow uow.Executor, credit *model.Credit) (bool, error)
+	CompleteRun(ctx context.Context, run *model.Run) error
+}
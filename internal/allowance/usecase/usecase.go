@@ -0,0 +1,327 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/domain/model"
+	allowanceRepo "github.com/artrsyf/avito-trainee-assignment/internal/allowance/repository"
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository"
+	userRepo "github.com/artrsyf/avito-trainee-assignment/internal/user/repository"
+	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+const (
+	leaseName = "allowance"
+
+	defaultLeaseTTL  = 5 * time.Minute
+	defaultBatchSize = 500
+)
+
+type AllowanceUsecaseI interface {
+	RunDue(ctx context.Context, now time.Time) error
+	RunPeriodically(ctx context.Context, interval time.Duration)
+}
+
+// AllowanceUsecase credits users by the configured campaigns. Replicas
+// share a lease, so only one of them runs campaigns at a time, and every
+// credit is recorded per campaign period, so a user is credited at most
+// once per period even if a run is interrupted and started again.
+type AllowanceUsecase struct {
+	allowanceRepo allowanceRepo.AllowanceRepositoryI
+	userRepo      userRepo.UserRepositoryI
+	ledgerRepo    ledgerRepo.LedgerRepositoryI
+	lotRepo       lotRepo.LotRepositoryI
+	uowFactory    uowI.Factory
+	campaigns     []entity.Campaign
+	leaseTTL      time.Duration
+	coinTTL       time.Duration
+	batchSize     uint
+	holder        string
+	logger        *logrus.Logger
+}
+
+func NewAllowanceUsecase(
+	allowanceRepository allowanceRepo.AllowanceRepositoryI,
+	userRepository userRepo.UserRepositoryI,
+	ledgerRepository ledgerRepo.LedgerRepositoryI,
+	lotRepository lotRepo.LotRepositoryI,
+	uowFactory uowI.Factory,
+	cfg config.AllowanceConfig,
+	expiryCfg config.ExpiryConfig,
+	logger *logrus.Logger,
+) *AllowanceUsecase {
+	leaseTTL, err := cfg.GetLeaseTTL()
+	if err != nil || leaseTTL <= 0 {
+		logger.WithError(err).Warn("Allowance lease TTL is not set, using default")
+		leaseTTL = defaultLeaseTTL
+	}
+
+	coinTTL, err := expiryCfg.GetCoinTTL()
+	if err != nil {
+		logger.WithError(err).Warn("Coin TTL is invalid, allowances won't expire")
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize == 0 {
+		batchSize = defaultBatchSize
+	}
+
+	campaigns := []entity.Campaign{}
+	names := map[string]bool{}
+	for _, campaignCfg := range cfg.Campaigns {
+		campaign := entity.Campaign{
+			Name:   campaignCfg.Name,
+			Amount: campaignCfg.Amount,
+			Period: campaignCfg.Period,
+		}
+
+		if err := campaign.Validate(); err != nil {
+			logger.WithError(err).WithField("campaign", campaign.Name).Warn("Skipping invalid allowance campaign")
+			continue
+		}
+		if names[campaign.Name] {
+			logger.WithField("campaign", campaign.Name).Warn("Skipping duplicate allowance campaign")
+			continue
+		}
+
+		names[campaign.Name] = true
+		campaigns = append(campaigns, campaign)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return &AllowanceUsecase{
+		allowanceRepo: allowanceRepository,
+		userRepo:      userRepository,
+		ledgerRepo:    ledgerRepository,
+		lotRepo:       lotRepository,
+		uowFactory:    uowFactory,
+		campaigns:     campaigns,
+		leaseTTL:      leaseTTL,
+		coinTTL:       coinTTL,
+		batchSize:     batchSize,
+		holder:        fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		logger:        logger,
+	}
+}
+
+// RunDue credits users for the current period of every campaign that
+// wasn't completed yet. It does nothing if another replica holds the lease.
+func (uc *AllowanceUsecase) RunDue(ctx context.Context, now time.Time) error {
+	if len(uc.campaigns) == 0 {
+		return nil
+	}
+
+	acquired, err := uc.allowanceRepo.AcquireLease(ctx, leaseName, uc.holder, uc.leaseTTL)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to acquire allowance lease")
+		return err
+	}
+	if !acquired {
+		uc.logger.Debug("Allowance lease is held by another replica")
+		return nil
+	}
+	defer func() {
+		// The lease must be released even if ctx is already cancelled.
+		err := uc.allowanceRepo.ReleaseLease(context.WithoutCancel(ctx), leaseName, uc.holder)
+		if err != nil {
+			uc.logger.WithError(err).Warn("Failed to release allowance lease")
+		}
+	}()
+
+	for _, campaign := range uc.campaigns {
+		if err := uc.runCampaign(ctx, campaign, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (uc *AllowanceUsecase) runCampaign(ctx context.Context, campaign entity.Campaign, now time.Time) error {
+	periodStart := campaign.PeriodStart(now)
+	period := campaign.PeriodKey(periodStart)
+
+	completed, err := uc.allowanceRepo.IsRunCompleted(ctx, campaign.Name, period)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to check allowance run")
+		return err
+	}
+	if completed {
+		return nil
+	}
+
+	var credited uint
+	filter := &entity.EligibleFilter{
+		Campaign:      campaign.Name,
+		Period:        period,
+		CreatedBefore: periodStart,
+		Limit:         uc.batchSize,
+	}
+	for {
+		userIDs, err := uc.allowanceRepo.GetEligibleUserIDs(ctx, filter)
+		if err != nil {
+			uc.logger.WithError(err).Error("Failed to get users eligible for allowance")
+			return err
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		batchCredited, err := uc.creditBatch(ctx, campaign, period, userIDs, now)
+		if err != nil {
+			return err
+		}
+		credited += batchCredited
+		filter.AfterID = userIDs[len(userIDs)-1]
+
+		// Extend the lease, so it doesn't expire while a large campaign
+		// is running.
+		acquired, err := uc.allowanceRepo.AcquireLease(ctx, leaseName, uc.holder, uc.leaseTTL)
+		if err != nil {
+			uc.logger.WithError(err).Error("Failed to extend allowance lease")
+			return err
+		}
+		if !acquired {
+			uc.logger.WithField("campaign", campaign.Name).Warn("Allowance lease was lost during run")
+			return entity.ErrLeaseLost
+		}
+	}
+
+	err = uc.allowanceRepo.CompleteRun(ctx, &model.Run{
+		Campaign:      campaign.Name,
+		Period:        period,
+		CreditedUsers: credited,
+	})
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to complete allowance run")
+		return err
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"campaign":       campaign.Name,
+		"period":         period,
+		"credited_users": credited,
+	}).Info("Successfully completed allowance run")
+
+	return nil
+}
+
+// creditBatch credits users of the batch in one unit of work and returns
+// how many of them were credited. Users who were already credited for the
+// period are skipped. Credited coins expire like granted ones.
+func (uc *AllowanceUsecase) creditBatch(
+	ctx context.Context,
+	campaign entity.Campaign,
+	period string,
+	userIDs []uint,
+	now time.Time,
+) (uint, error) {
+	uow := uc.uowFactory.NewUnitOfWork()
+
+	err := uow.Begin(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction begin error")
+		return 0, err
+	}
+
+	var credited uint
+	for _, userID := range userIDs {
+		created, err := uc.allowanceRepo.CreateCredit(ctx, uow, &model.Credit{
+			Campaign: campaign.Name,
+			Period:   period,
+			UserID:   userID,
+			Amount:   campaign.Amount,
+		})
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Error("Rollback allowance batch due credit creating")
+			return 0, err
+		}
+		if !created {
+			continue
+		}
+
+		err = uc.userRepo.Credit(ctx, uow, userID, campaign.Amount)
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Error("Rollback allowance batch due user crediting")
+			return 0, err
+		}
+
+		err = uc.lotRepo.Create(ctx, uow, userID, lotEntity.Portions{
+			lotEntity.NewGrant(campaign.Amount, now, uc.coinTTL),
+		})
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Error("Rollback allowance batch due coin lot creating")
+			return 0, err
+		}
+
+		_, err = uc.ledgerRepo.Post(ctx, uow, ledgerEntity.NewTransfer(
+			ledgerEntity.KindAllowance,
+			fmt.Sprintf("allowance:%s:%s", campaign.Name, period),
+			ledgerEntity.IssuerAccount,
+			ledgerEntity.UserAccount(userID),
+			campaign.Amount,
+		))
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Error("Rollback allowance batch due ledger posting")
+			return 0, err
+		}
+
+		credited++
+	}
+
+	err = uow.Commit()
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction commit error due allowance batch crediting")
+		return 0, err
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"campaign": campaign.Name,
+		"period":   period,
+		"credited": credited,
+	}).Debug("Credited allowance batch")
+
+	return credited, nil
+}
+
+// RunPeriodically runs due campaigns every interval until ctx is done.
+func (uc *AllowanceUsecase) RunPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := uc.RunDue(ctx, time.Now()); err != nil {
+			uc.logger.WithError(err).Warn("Allowance run failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (uc *AllowanceUsecase) rollback(uow uowI.UnitOfWork) {
+	if err := uow.Rollback(); err != nil {
+		uc.logger.WithError(err).Error("Rollback error encountered")
+	}
+}
@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/domain/model"
+	mockAllowance "github.com/artrsyf/avito-trainee-assignment/internal/allowance/repository/mock_repository"
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerModel "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/model"
+	mockLedger "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/mock_repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	mockLot "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/mock_repository"
+	mockUser "github.com/artrsyf/avito-trainee-assignment/internal/user/repository/mock_repository"
+	mockUow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/mock_uow"
+)
+
+func TestAllowanceUsecase_RunDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAllowanceRepo := mockAllowance.NewMockAllowanceRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+	mockUow := mockUow.NewMockUnitOfWork(ctrl)
+
+	cfg := config.AllowanceConfig{
+		LeaseTTL:  "5m",
+		BatchSize: 2,
+		Campaigns: []config.CampaignConfig{
+			{Name: "monthly", Amount: 200, Period: entity.PeriodMonthly},
+			{Name: "broken", Amount: 0, Period: entity.PeriodMonthly},
+		},
+	}
+	uc := NewAllowanceUsecase(
+		mockAllowanceRepo,
+		mockUserRepo,
+		mockLedgerRepo,
+		mockLotRepo,
+		mockUowFactory,
+		cfg,
+		config.ExpiryConfig{CoinTTL: "8760h"},
+		logrus.New(),
+	)
+
+	ctx := context.Background()
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	periodStart := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	testErr := errors.New("database error")
+
+	expectLease := func() {
+		mockAllowanceRepo.EXPECT().AcquireLease(ctx, "allowance", uc.holder, 5*time.Minute).Return(true, nil)
+		mockAllowanceRepo.EXPECT().ReleaseLease(gomock.Any(), "allowance", uc.holder).Return(nil)
+	}
+	expectCredit := func(userID uint) {
+		mockAllowanceRepo.EXPECT().CreateCredit(ctx, mockUow, &model.Credit{
+			Campaign: "monthly",
+			Period:   "2026-10",
+			UserID:   userID,
+			Amount:   200,
+		}).Return(true, nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, userID, uint(200)).Return(nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, userID, lotEntity.Portions{
+			lotEntity.NewGrant(200, now, 8760*time.Hour),
+		}).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, ledgerEntity.NewTransfer(
+			ledgerEntity.KindAllowance,
+			"allowance:monthly:2026-10",
+			ledgerEntity.IssuerAccount,
+			ledgerEntity.UserAccount(userID),
+			200,
+		)).Return(&ledgerModel.Entry{ID: 1}, nil)
+	}
+	filter := func(afterID uint) *entity.EligibleFilter {
+		return &entity.EligibleFilter{
+			Campaign:      "monthly",
+			Period:        "2026-10",
+			CreatedBefore: periodStart,
+			AfterID:       afterID,
+			Limit:         2,
+		}
+	}
+
+	t.Run("invalid campaigns are skipped", func(t *testing.T) {
+		if len(uc.campaigns) != 1 || uc.campaigns[0].Name != "monthly" {
+			t.Errorf("expected only monthly campaign, got %+v", uc.campaigns)
+		}
+	})
+
+	t.Run("credits eligible users in batches", func(t *testing.T) {
+		expectLease()
+		mockAllowanceRepo.EXPECT().IsRunCompleted(ctx, "monthly", "2026-10").Return(false, nil)
+
+		mockAllowanceRepo.EXPECT().GetEligibleUserIDs(ctx, filter(0)).Return([]uint{2, 3}, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		expectCredit(2)
+		mockAllowanceRepo.EXPECT().CreateCredit(ctx, mockUow, &model.Credit{
+			Campaign: "monthly",
+			Period:   "2026-10",
+			UserID:   3,
+			Amount:   200,
+		}).Return(false, nil)
+		mockUow.EXPECT().Commit().Return(nil)
+		mockAllowanceRepo.EXPECT().AcquireLease(ctx, "allowance", uc.holder, 5*time.Minute).Return(true, nil)
+
+		mockAllowanceRepo.EXPECT().GetEligibleUserIDs(ctx, filter(3)).Return([]uint{5}, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		expectCredit(5)
+		mockUow.EXPECT().Commit().Return(nil)
+		mockAllowanceRepo.EXPECT().AcquireLease(ctx, "allowance", uc.holder, 5*time.Minute).Return(true, nil)
+
+		mockAllowanceRepo.EXPECT().GetEligibleUserIDs(ctx, filter(5)).Return([]uint{}, nil)
+		mockAllowanceRepo.EXPECT().CompleteRun(ctx, &model.Run{
+			Campaign:      "monthly",
+			Period:        "2026-10",
+			CreditedUsers: 2,
+		}).Return(nil)
+
+		if err := uc.RunDue(ctx, now); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("completed run is skipped", func(t *testing.T) {
+		expectLease()
+		mockAllowanceRepo.EXPECT().IsRunCompleted(ctx, "monthly", "2026-10").Return(true, nil)
+
+		if err := uc.RunDue(ctx, now); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("lease held by another replica", func(t *testing.T) {
+		mockAllowanceRepo.EXPECT().AcquireLease(ctx, "allowance", uc.holder, 5*time.Minute).Return(false, nil)
+
+		if err := uc.RunDue(ctx, now); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("batch is rolled back when crediting fails", func(t *testing.T) {
+		expectLease()
+		mockAllowanceRepo.EXPECT().IsRunCompleted(ctx, "monthly", "2026-10").Return(false, nil)
+		mockAllowanceRepo.EXPECT().GetEligibleUserIDs(ctx, filter(0)).Return([]uint{2}, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockAllowanceRepo.EXPECT().CreateCredit(ctx, mockUow, gomock.Any()).Return(true, nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(200)).Return(testErr)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		if err := uc.RunDue(ctx, now); err != testErr {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+
+	t.Run("run stops when lease is lost", func(t *testing.T) {
+		expectLease()
+		mockAllowanceRepo.EXPECT().IsRunCompleted(ctx, "monthly", "2026-10").Return(false, nil)
+		mockAllowanceRepo.EXPECT().GetEligibleUserIDs(ctx, filter(0)).Return([]uint{2}, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		expectCredit(2)
+		mockUow.EXPECT().Commit().Return(nil)
+		mockAllowanceRepo.EXPECT().AcquireLease(ctx, "allowance", uc.holder, 5*time.Minute).Return(false, nil)
+
+		if err := uc.RunDue(ctx, now); err != entity.ErrLeaseLost {
+			t.Errorf("expected error %v, got %v", entity.ErrLeaseLost, err)
+		}
+	})
+}
+
+func TestAllowanceUsecase_RunDue_Periods(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAllowanceRepo := mockAllowance.NewMockAllowanceRepositoryI(ctrl)
+
+	cfg := config.AllowanceConfig{
+		LeaseTTL: "5m",
+		Campaigns: []config.CampaignConfig{
+			{Name: "daily", Amount: 10, Period: entity.PeriodDaily},
+			{Name: "weekly", Amount: 50, Period: entity.PeriodWeekly},
+			{Name: "monthly", Amount: 200, Period: entity.PeriodMonthly},
+		},
+	}
+	uc := NewAllowanceUsecase(mockAllowanceRepo, nil, nil, nil, nil, cfg, config.ExpiryConfig{}, logrus.New())
+
+	ctx := context.Background()
+	// Sunday, so the week started on Monday, October 12.
+	now := time.Date(2026, time.October, 18, 23, 30, 0, 0, time.UTC)
+
+	mockAllowanceRepo.EXPECT().AcquireLease(ctx, "allowance", uc.holder, 5*time.Minute).Return(true, nil)
+	mockAllowanceRepo.EXPECT().ReleaseLease(gomock.Any(), "allowance", uc.holder).Return(nil)
+	mockAllowanceRepo.EXPECT().IsRunCompleted(ctx, "daily", "2026-10-18").Return(true, nil)
+	mockAllowanceRepo.EXPECT().IsRunCompleted(ctx, "weekly", "2026-10-12").Return(true, nil)
+	mockAllowanceRepo.EXPECT().IsRunCompleted(ctx, "monthly", "2026-10").Return(true, nil)
+
+	if err := uc.RunDue(ctx, now); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
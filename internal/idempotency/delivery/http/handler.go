@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/usecase"
+	JSONResponse "github.com/artrsyf/avito-trainee-assignment/pkg/json_response"
+)
+
+const replayedHeader = "Idempotent-Replayed"
+
+type IdempotencyHandler struct {
+	idempotencyUC usecase.IdempotencyUsecaseI
+	logger        *logrus.Logger
+}
+
+func NewIdempotencyHandler(
+	idempotencyUsecase usecase.IdempotencyUsecaseI,
+	logger *logrus.Logger,
+) *IdempotencyHandler {
+	return &IdempotencyHandler{
+		idempotencyUC: idempotencyUsecase,
+		logger:        logger,
+	}
+}
+
+// Replay answers a repeated request with the response stored for its key.
+// It reports whether a response has been written; false means the request
+// is new and has to be processed.
+func (h *IdempotencyHandler) Replay(
+	ctx context.Context,
+	w http.ResponseWriter,
+	record *entity.Record,
+) bool {
+	if record == nil {
+		return false
+	}
+
+	storedRecord, err := h.idempotencyUC.Find(ctx, record)
+	switch err {
+	case nil:
+		h.logger.WithFields(logrus.Fields{
+			"user_id":         record.UserID,
+			"idempotency_key": record.Key,
+		}).Info("Replay stored response")
+
+		w.Header().Set(replayedHeader, "true")
+		if len(storedRecord.ResponseBody) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(storedRecord.ResponseStatus)
+		if _, err = w.Write(storedRecord.ResponseBody); err != nil {
+			h.logger.WithError(err).Warn("Failed to write stored response")
+		}
+	case entity.ErrIsNotExist:
+		return false
+	case entity.ErrPayloadMismatch:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusUnprocessableEntity,
+			map[string]string{"errors": "idempotency key is reused with another payload"},
+		)
+	default:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+	}
+
+	return true
+}
@@ -0,0 +1,71 @@
+package dto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/model"
+)
+
+const KeyHeader = "Idempotency-Key"
+
+const maxKeyLength = 255
+
+// NewRecord prepares the record stored for a successful request. It returns
+// nil without error when the client didn't send an idempotency key.
+func NewRecord(
+	key string,
+	userID uint,
+	endpoint string,
+	payload interface{},
+	responseStatus int,
+	responseBody []byte,
+) (*entity.Record, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	if len(key) > maxKeyLength {
+		return nil, entity.ErrInvalidKey
+	}
+
+	serializedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(endpoint))
+	hash.Write([]byte{0})
+	hash.Write(serializedPayload)
+
+	return &entity.Record{
+		UserID:         userID,
+		Key:            key,
+		RequestHash:    hex.EncodeToString(hash.Sum(nil)),
+		ResponseStatus: responseStatus,
+		ResponseBody:   responseBody,
+	}, nil
+}
+
+func RecordEntityToModel(record *entity.Record) *model.Record {
+	return &model.Record{
+		UserID:         record.UserID,
+		Key:            record.Key,
+		RequestHash:    record.RequestHash,
+		ResponseStatus: record.ResponseStatus,
+		ResponseBody:   record.ResponseBody,
+	}
+}
+
+func RecordModelToEntity(record *model.Record) *entity.Record {
+	return &entity.Record{
+		UserID:         record.UserID,
+		Key:            record.Key,
+		RequestHash:    record.RequestHash,
+		ResponseStatus: record.ResponseStatus,
+		ResponseBody:   record.ResponseBody,
+	}
+}
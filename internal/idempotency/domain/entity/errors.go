@@ -0,0 +1,10 @@
+package entity
+
+import "errors"
+
+var (
+	ErrIsNotExist      = errors.New("can't find such idempotency key")
+	ErrAlreadyCreated  = errors.New("idempotency key is already used")
+	ErrPayloadMismatch = errors.New("idempotency key is reused with another payload")
+	ErrInvalidKey      = errors.New("idempotency key is invalid")
+)
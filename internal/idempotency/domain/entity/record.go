@@ -0,0 +1,12 @@
+package entity
+
+// Record is the outcome of a request sent with an Idempotency-Key header.
+// RequestHash fingerprints the endpoint and payload, so a repeated key can be
+// told apart from a key reused for a different request.
+type Record struct {
+	UserID         uint
+	Key            string
+	RequestHash    string
+	ResponseStatus int
+	ResponseBody   []byte
+}
@@ -0,0 +1,9 @@
+package model
+
+type Record struct {
+	UserID         uint   `db:"user_id"`
+	Key            string `db:"idempotency_key"`
+	RequestHash    string `db:"request_hash"`
+	ResponseStatus int    `db:"response_status"`
+	ResponseBody   []byte `db:"response_body"`
+}
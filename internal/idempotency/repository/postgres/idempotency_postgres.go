@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/model"
+	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+type IdempotencyPostgresRepository struct {
+	DB     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewIdempotencyPostgresRepository(
+	db *sql.DB,
+	logger *logrus.Logger,
+) *IdempotencyPostgresRepository {
+	return &IdempotencyPostgresRepository{
+		DB:     db,
+		logger: logger,
+	}
+}
+
+// Create reserves the key within uow. A concurrent request with the same key
+// blocks on the primary key until uow finishes and then gets
+// ErrAlreadyCreated, so only one of them performs the operation.
+func (repo *IdempotencyPostgresRepository) Create(
+	ctx context.Context,
+	uow uowI.Executor,
+	record *model.Record,
+) error {
+	result, err := uow.ExecContext(
+		ctx,
+		`INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, response_status, response_body)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING`,
+		record.UserID, record.Key, record.RequestHash, record.ResponseStatus, record.ResponseBody,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to create idempotency key")
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get created idempotency keys count")
+		return err
+	}
+	if affected == 0 {
+		repo.logger.WithFields(logrus.Fields{
+			"user_id":         record.UserID,
+			"idempotency_key": record.Key,
+		}).Info("Idempotency key is already used")
+		return entity.ErrAlreadyCreated
+	}
+
+	repo.logger.WithFields(logrus.Fields{
+		"user_id":         record.UserID,
+		"idempotency_key": record.Key,
+	}).Debug("Created idempotency key in Postgres")
+
+	return nil
+}
+
+func (repo *IdempotencyPostgresRepository) GetByKey(
+	ctx context.Context,
+	userID uint,
+	key string,
+) (*model.Record, error) {
+	record := model.Record{}
+
+	err := repo.DB.
+		QueryRowContext(
+			ctx,
+			`SELECT user_id, idempotency_key, request_hash, response_status, response_body
+			FROM idempotency_keys
+			WHERE user_id = $1 AND idempotency_key = $2`,
+			userID, key,
+		).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.RequestHash,
+		&record.ResponseStatus,
+		&record.ResponseBody,
+	)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrIsNotExist
+	} else if err != nil {
+		repo.logger.WithError(err).Error("SQL select idempotency key error")
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// DeleteCreatedBefore deletes keys created before the given time and returns
+// how many were deleted.
+func (repo *IdempotencyPostgresRepository) DeleteCreatedBefore(
+	ctx context.Context,
+	before time.Time,
+) (int64, error) {
+	result, err := repo.DB.ExecContext(
+		ctx,
+		`DELETE FROM idempotency_keys WHERE created_at < $1`,
+		before,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to delete expired idempotency keys")
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get deleted idempotency keys count")
+		return 0, err
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/model"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+func TestIdempotencyPostgresRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewIdempotencyPostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+
+	record := &model.Record{
+		UserID:         1,
+		Key:            "key",
+		RequestHash:    "hash",
+		ResponseStatus: 200,
+		ResponseBody:   []byte("{}"),
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO idempotency_keys .* ON CONFLICT .* DO NOTHING").
+			WithArgs(1, "key", "hash", 200, []byte("{}")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Create(context.Background(), mockUOW, record)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyCreated", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO idempotency_keys .* ON CONFLICT .* DO NOTHING").
+			WithArgs(1, "key", "hash", 200, []byte("{}")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.Create(context.Background(), mockUOW, record)
+
+		assert.Equal(t, entity.ErrAlreadyCreated, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InsertError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectExec("INSERT INTO idempotency_keys .*").
+			WillReturnError(expectedErr)
+
+		err := repo.Create(context.Background(), mockUOW, record)
+
+		assert.Equal(t, expectedErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestIdempotencyPostgresRepository_GetByKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewIdempotencyPostgresRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT .* FROM idempotency_keys WHERE user_id = \\$1 AND idempotency_key = \\$2").
+			WithArgs(1, "key").
+			WillReturnRows(sqlmock.NewRows([]string{
+				"user_id", "idempotency_key", "request_hash", "response_status", "response_body",
+			}).AddRow(1, "key", "hash", 200, []byte(`{"id":1}`)))
+
+		record, err := repo.GetByKey(context.Background(), 1, "key")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Record{
+			UserID:         1,
+			Key:            "key",
+			RequestHash:    "hash",
+			ResponseStatus: 200,
+			ResponseBody:   []byte(`{"id":1}`),
+		}, record)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT .* FROM idempotency_keys .*").
+			WithArgs(1, "missing").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetByKey(context.Background(), 1, "missing")
+
+		assert.Equal(t, entity.ErrIsNotExist, err)
+	})
+
+	t.Run("QueryError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery("SELECT .* FROM idempotency_keys .*").
+			WithArgs(1, "key").
+			WillReturnError(expectedErr)
+
+		_, err := repo.GetByKey(context.Background(), 1, "key")
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestIdempotencyPostgresRepository_DeleteCreatedBefore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewIdempotencyPostgresRepository(db, logrus.New())
+	before := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM idempotency_keys WHERE created_at < \\$1").
+			WithArgs(before).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		deleted, err := repo.DeleteCreatedBefore(context.Background(), before)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), deleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectExec("DELETE FROM idempotency_keys").
+			WithArgs(before).
+			WillReturnError(expectedErr)
+
+		_, err := repo.DeleteCreatedBefore(context.Background(), before)
+
+		assert.Equal(t, expectedErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestNewIdempotencyPostgresRepository(t *testing.T) {
+	db, _, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := NewIdempotencyPostgresRepository(db, logrus.New())
+	assert.NotNil(t, repo)
+}
+
+type MockUnitOfWork struct {
+	uow.Executor
+	db *sql.DB
+}
+
+func (m *MockUnitOfWork) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return m.db.ExecContext(ctx, query, args...)
+}
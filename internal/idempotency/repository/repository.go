@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/model"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+//go:generate mockgen -source=repository.go -destination=mock_repository/idempotency_mock.go -package=mock_repository MockIdempotencyRepository
+type IdempotencyRepositoryI interface {
+	Create(ctx context.Context, uow uow.Executor, record *model.Record) error
+	GetByKey(ctx context.Context, userID uint, key string) (*model.Record, error)
+	DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error)
+}
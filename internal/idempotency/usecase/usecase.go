@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/dto"
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	idempotencyRepo "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository"
+)
+
+type IdempotencyUsecaseI interface {
+	Find(ctx context.Context, record *entity.Record) (*entity.Record, error)
+	DeleteExpired(ctx context.Context, now time.Time) error
+	RunPeriodicCleanup(ctx context.Context, interval time.Duration)
+}
+
+type IdempotencyUsecase struct {
+	idempotencyRepo idempotencyRepo.IdempotencyRepositoryI
+	keyTTL          time.Duration
+	logger          *logrus.Logger
+}
+
+func NewIdempotencyUsecase(
+	idempotencyRepository idempotencyRepo.IdempotencyRepositoryI,
+	cfg config.IdempotencyConfig,
+	logger *logrus.Logger,
+) *IdempotencyUsecase {
+	keyTTL, err := cfg.GetKeyTTL()
+	if err != nil {
+		logger.WithError(err).Warn("Idempotency key TTL is not set, keys won't be deleted")
+	}
+
+	return &IdempotencyUsecase{
+		idempotencyRepo: idempotencyRepository,
+		keyTTL:          keyTTL,
+		logger:          logger,
+	}
+}
+
+// Find returns the stored outcome of a request previously sent with the same
+// key. A key that was used for a different request yields ErrPayloadMismatch.
+func (uc *IdempotencyUsecase) Find(
+	ctx context.Context,
+	record *entity.Record,
+) (*entity.Record, error) {
+	storedRecordModel, err := uc.idempotencyRepo.GetByKey(ctx, record.UserID, record.Key)
+	if err == entity.ErrIsNotExist {
+		return nil, err
+	} else if err != nil {
+		uc.logger.WithError(err).Error("Failed to get idempotency key")
+		return nil, err
+	}
+
+	if storedRecordModel.RequestHash != record.RequestHash {
+		uc.logger.WithFields(logrus.Fields{
+			"user_id":         record.UserID,
+			"idempotency_key": record.Key,
+		}).Warn("Idempotency key is reused with another payload")
+		return nil, entity.ErrPayloadMismatch
+	}
+
+	return dto.RecordModelToEntity(storedRecordModel), nil
+}
+
+// DeleteExpired deletes keys older than the key TTL, so the table doesn't
+// grow forever. Zero TTL keeps all keys.
+func (uc *IdempotencyUsecase) DeleteExpired(ctx context.Context, now time.Time) error {
+	if uc.keyTTL <= 0 {
+		return nil
+	}
+
+	deleted, err := uc.idempotencyRepo.DeleteCreatedBefore(ctx, now.Add(-uc.keyTTL))
+	if err != nil {
+		return err
+	}
+
+	uc.logger.WithField("deleted", deleted).Debug("Deleted expired idempotency keys")
+
+	return nil
+}
+
+// RunPeriodicCleanup deletes expired keys every interval until ctx is done.
+func (uc *IdempotencyUsecase) RunPeriodicCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := uc.DeleteExpired(ctx, time.Now()); err != nil {
+			uc.logger.WithError(err).Warn("Idempotency keys cleanup failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/model"
+	mockIdempotency "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository/mock_repository"
+)
+
+func TestIdempotencyUsecase_Find(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIdempotencyRepo := mockIdempotency.NewMockIdempotencyRepositoryI(ctrl)
+
+	uc := NewIdempotencyUsecase(mockIdempotencyRepo, config.IdempotencyConfig{}, logrus.New())
+
+	ctx := context.Background()
+	record := &entity.Record{UserID: 1, Key: "key", RequestHash: "hash", ResponseStatus: 200}
+
+	t.Run("stored response is replayed", func(t *testing.T) {
+		mockIdempotencyRepo.EXPECT().GetByKey(ctx, uint(1), "key").Return(&model.Record{
+			UserID:         1,
+			Key:            "key",
+			RequestHash:    "hash",
+			ResponseStatus: 200,
+			ResponseBody:   []byte("{}"),
+		}, nil)
+
+		stored, err := uc.Find(ctx, record)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if stored.ResponseStatus != 200 || string(stored.ResponseBody) != "{}" {
+			t.Errorf("unexpected stored response: %d %s", stored.ResponseStatus, stored.ResponseBody)
+		}
+	})
+
+	t.Run("new key", func(t *testing.T) {
+		mockIdempotencyRepo.EXPECT().GetByKey(ctx, uint(1), "key").Return(nil, entity.ErrIsNotExist)
+
+		_, err := uc.Find(ctx, record)
+		if !errors.Is(err, entity.ErrIsNotExist) {
+			t.Errorf("expected ErrIsNotExist, got %v", err)
+		}
+	})
+
+	t.Run("key reused with another payload", func(t *testing.T) {
+		mockIdempotencyRepo.EXPECT().GetByKey(ctx, uint(1), "key").Return(&model.Record{
+			UserID:      1,
+			Key:         "key",
+			RequestHash: "another_hash",
+		}, nil)
+
+		_, err := uc.Find(ctx, record)
+		if !errors.Is(err, entity.ErrPayloadMismatch) {
+			t.Errorf("expected ErrPayloadMismatch, got %v", err)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		testErr := errors.New("database error")
+		mockIdempotencyRepo.EXPECT().GetByKey(ctx, uint(1), "key").Return(nil, testErr)
+
+		_, err := uc.Find(ctx, record)
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+}
+
+func TestIdempotencyUsecase_DeleteExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIdempotencyRepo := mockIdempotency.NewMockIdempotencyRepositoryI(ctrl)
+
+	ctx := context.Background()
+	now := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+
+	t.Run("keys older than ttl are deleted", func(t *testing.T) {
+		uc := NewIdempotencyUsecase(mockIdempotencyRepo, config.IdempotencyConfig{KeyTTL: "24h"}, logrus.New())
+
+		mockIdempotencyRepo.EXPECT().DeleteCreatedBefore(ctx, now.Add(-24*time.Hour)).Return(int64(3), nil)
+
+		if err := uc.DeleteExpired(ctx, now); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("zero ttl keeps keys", func(t *testing.T) {
+		uc := NewIdempotencyUsecase(mockIdempotencyRepo, config.IdempotencyConfig{}, logrus.New())
+
+		if err := uc.DeleteExpired(ctx, now); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		uc := NewIdempotencyUsecase(mockIdempotencyRepo, config.IdempotencyConfig{KeyTTL: "24h"}, logrus.New())
+
+		testErr := errors.New("database error")
+		mockIdempotencyRepo.EXPECT().DeleteCreatedBefore(ctx, now.Add(-24*time.Hour)).Return(int64(0), testErr)
+
+		if err := uc.DeleteExpired(ctx, now); !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/dto"
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/usecase"
+	JSONResponse "github.com/artrsyf/avito-trainee-assignment/pkg/json_response"
+)
+
+type LedgerHandler struct {
+	ledgerUC usecase.LedgerUsecaseI
+	logger   *logrus.Logger
+}
+
+func NewLedgerHandler(
+	ledgerUsecase usecase.LedgerUsecaseI,
+	logger *logrus.Logger,
+) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerUC: ledgerUsecase,
+		logger:   logger,
+	}
+}
+
+// GetReconciliation reports whether user balances match the ledger. A
+// mismatch is not a request error, so the response is 200 either way.
+func (h *LedgerHandler) GetReconciliation(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming GetReconciliation request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	reconciliation, err := h.ledgerUC.Reconcile(ctx)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Reconciliation error handling")
+
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.ReconciliationToResponse(reconciliation))
+}
@@ -0,0 +1,47 @@
+package dto
+
+import "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+
+type BalanceMismatchResponse struct {
+	UserID          uint   `json:"userId"`
+	Username        string `json:"username"`
+	Coins           uint   `json:"coins"`
+	LedgerBalance   int64  `json:"ledgerBalance"`
+	ExpectedBalance int64  `json:"expectedBalance"`
+}
+
+type ReconciliationResponse struct {
+	Consistent        bool                      `json:"consistent"`
+	Mismatches        []BalanceMismatchResponse `json:"mismatches"`
+	UnbalancedEntries []uint                    `json:"unbalancedEntries"`
+	IssuerBalance     int64                     `json:"issuerBalance"`
+	ShopBalance       int64                     `json:"shopBalance"`
+	UsersBalance      int64                     `json:"usersBalance"`
+}
+
+func ReconciliationToResponse(reconciliation *entity.Reconciliation) *ReconciliationResponse {
+	mismatches := make([]BalanceMismatchResponse, 0, len(reconciliation.Mismatches))
+	for _, mismatch := range reconciliation.Mismatches {
+		mismatches = append(mismatches, BalanceMismatchResponse{
+			UserID:          mismatch.UserID,
+			Username:        mismatch.Username,
+			Coins:           mismatch.Coins,
+			LedgerBalance:   mismatch.LedgerBalance,
+			ExpectedBalance: mismatch.ExpectedBalance,
+		})
+	}
+
+	unbalancedEntries := reconciliation.UnbalancedEntries
+	if unbalancedEntries == nil {
+		unbalancedEntries = []uint{}
+	}
+
+	return &ReconciliationResponse{
+		Consistent:        reconciliation.IsConsistent(),
+		Mismatches:        mismatches,
+		UnbalancedEntries: unbalancedEntries,
+		IssuerBalance:     reconciliation.IssuerBalance,
+		ShopBalance:       reconciliation.ShopBalance,
+		UsersBalance:      reconciliation.UsersBalance,
+	}
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "errors"
+
+var (
+	ErrEmptyEntry      = errors.New("ledger entry needs at least two postings")
+	ErrZeroPosting     = errors.New("ledger posting amount can't be zero")
+	ErrUnbalancedEntry = errors.New("ledger entry postings don't sum to zero")
+	ErrInvalidAccount  = errors.New("ledger account is invalid")
+)
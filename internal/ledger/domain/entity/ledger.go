@@ -0,0 +1,127 @@
+package entity
+
+import "time"
+
+const (
+	AccountUser   = "user"
+	AccountShop   = "shop"
+	AccountIssuer = "issuer"
+)
+
+const (
+	KindGrant      = "grant"
+	KindPurchase   = "purchase"
+	KindRefund     = "refund"
+	KindTransfer   = "transfer"
+	KindAdjustment = "adjustment"
+	KindAllowance  = "allowance"
+	KindExpiry     = "expiry"
+)
+
+// Account is a user account or one of the system accounts. The issuer
+// account is where coins come from, the shop account is where spent coins
+// go, so the issuer balance is always negative.
+type Account struct {
+	Kind   string
+	UserID uint
+}
+
+func UserAccount(userID uint) Account {
+	return Account{Kind: AccountUser, UserID: userID}
+}
+
+var (
+	ShopAccount   = Account{Kind: AccountShop}
+	IssuerAccount = Account{Kind: AccountIssuer}
+)
+
+func (a Account) IsValid() bool {
+	switch a.Kind {
+	case AccountUser:
+		return a.UserID != 0
+	case AccountShop, AccountIssuer:
+		return a.UserID == 0
+	default:
+		return false
+	}
+}
+
+// Posting changes the account balance by Amount, negative amounts take
+// coins from the account.
+type Posting struct {
+	Account Account
+	Amount  int64
+}
+
+// Entry is a single balance change. Its postings always sum to zero, so
+// coins are never created or lost, only moved between accounts. Reference
+// points at what caused the change, e.g. "purchase:42".
+type Entry struct {
+	ID        uint
+	Kind      string
+	Reference string
+	Postings  []Posting
+	CreatedAt time.Time
+}
+
+// NewTransfer moves amount coins from one account to another.
+func NewTransfer(kind, reference string, from, to Account, amount uint) *Entry {
+	return &Entry{
+		Kind:      kind,
+		Reference: reference,
+		Postings: []Posting{
+			{Account: from, Amount: -int64(amount)},
+			{Account: to, Amount: int64(amount)},
+		},
+	}
+}
+
+func (e *Entry) Validate() error {
+	if len(e.Postings) < 2 {
+		return ErrEmptyEntry
+	}
+
+	var sum int64
+	for _, posting := range e.Postings {
+		if !posting.Account.IsValid() {
+			return ErrInvalidAccount
+		}
+		if posting.Amount == 0 {
+			return ErrZeroPosting
+		}
+		sum += posting.Amount
+	}
+
+	if sum != 0 {
+		return ErrUnbalancedEntry
+	}
+	return nil
+}
+
+// BalanceMismatch is a user whose cached balance differs from the sum of
+// their ledger postings or from the balance recomputed from business
+// records: granted coins plus admin adjustments, allowances and received
+// coins, minus sent, expired and the price of purchases that weren't
+// refunded.
+type BalanceMismatch struct {
+	UserID          uint
+	Username        string
+	Coins           uint
+	LedgerBalance   int64
+	ExpectedBalance int64
+}
+
+// Reconciliation is the result of checking users.coins against the ledger.
+type Reconciliation struct {
+	Mismatches        []*BalanceMismatch
+	UnbalancedEntries []uint
+	IssuerBalance     int64
+	ShopBalance       int64
+	UsersBalance      int64
+}
+
+// IsConsistent reports whether every entry is balanced and every cached user
+// balance matches the ledger.
+func (r *Reconciliation) IsConsistent() bool {
+	return len(r.Mismatches) == 0 && len(r.UnbalancedEntries) == 0
+}
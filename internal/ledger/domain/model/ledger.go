@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+type Entry struct {
+	ID        uint      `db:"id"`
+	Kind      string    `db:"kind"`
+	Reference string    `db:"reference"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -0,0 +1,263 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/model"
+	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+type LedgerPostgresRepository struct {
+	DB     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewLedgerPostgresRepository(
+	db *sql.DB,
+	logger *logrus.Logger,
+) *LedgerPostgresRepository {
+	return &LedgerPostgresRepository{
+		DB:     db,
+		logger: logger,
+	}
+}
+
+// Post journals the entry within uow. It doesn't touch users.coins: the
+// caller changes the cached balance in the same unit of work, so both are
+// committed or rolled back together.
+func (repo *LedgerPostgresRepository) Post(
+	ctx context.Context,
+	uow uowI.Executor,
+	entry *entity.Entry,
+) (*model.Entry, error) {
+	if err := entry.Validate(); err != nil {
+		repo.logger.WithError(err).WithField("kind", entry.Kind).Error("Refused to post invalid ledger entry")
+		return nil, err
+	}
+
+	createdEntry := model.Entry{}
+	err := uow.QueryRowContext(
+		ctx,
+		`INSERT INTO ledger_entries (kind, reference)
+		VALUES ($1, $2)
+		RETURNING id, kind, reference, created_at`,
+		entry.Kind, entry.Reference,
+	).Scan(
+		&createdEntry.ID,
+		&createdEntry.Kind,
+		&createdEntry.Reference,
+		&createdEntry.CreatedAt,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to create ledger entry")
+		return nil, err
+	}
+
+	values := make([]string, 0, len(entry.Postings))
+	args := []interface{}{createdEntry.ID}
+	for _, posting := range entry.Postings {
+		args = append(args, posting.Account.Kind, posting.Account.UserID, posting.Amount)
+		values = append(values, fmt.Sprintf(
+			"($1, $%d, NULLIF($%d, 0), $%d)", len(args)-2, len(args)-1, len(args),
+		))
+	}
+
+	_, err = uow.ExecContext(
+		ctx,
+		"INSERT INTO ledger_postings (entry_id, account, user_id, amount) VALUES "+strings.Join(values, ", "),
+		args...,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to create ledger postings")
+		return nil, err
+	}
+
+	repo.logger.WithFields(logrus.Fields{
+		"entry_id": createdEntry.ID,
+		"kind":     createdEntry.Kind,
+	}).Debug("Posted ledger entry in Postgres")
+
+	return &createdEntry, nil
+}
+
+// GetBalanceMismatches returns users whose coins differ from the sum of
+// their postings or from the balance recomputed from adjustments,
+// allowances, transactions, purchases and expired coins. Granted coins
+// have no business record besides the ledger, so they are taken from grant
+// entries. It is a single statement, so it sees a consistent snapshot even
+// while balances change.
+func (repo *LedgerPostgresRepository) GetBalanceMismatches(
+	ctx context.Context,
+) ([]*entity.BalanceMismatch, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT id, username, coins, ledger_balance, expected_balance
+		FROM (
+			SELECT u.id, u.username, u.coins,
+				COALESCE(l.balance, 0) AS ledger_balance,
+				COALESCE(g.granted, 0) + COALESCE(a.adjusted, 0) + COALESCE(al.allowed, 0)
+					+ COALESCE(r.received, 0) - COALESCE(s.sent, 0) - COALESCE(p.spent, 0)
+					- COALESCE(e.expired, 0) AS expected_balance
+			FROM users u
+			LEFT JOIN (
+				SELECT user_id, SUM(amount) AS balance
+				FROM ledger_postings
+				GROUP BY user_id
+			) l ON l.user_id = u.id
+			LEFT JOIN (
+				SELECT lp.user_id, SUM(lp.amount) AS granted
+				FROM ledger_postings lp
+				JOIN ledger_entries le ON le.id = lp.entry_id
+				WHERE le.kind = 'grant'
+				GROUP BY lp.user_id
+			) g ON g.user_id = u.id
+			LEFT JOIN (
+				SELECT user_id, SUM(amount) AS adjusted
+				FROM balance_adjustments
+				GROUP BY user_id
+			) a ON a.user_id = u.id
+			LEFT JOIN (
+				SELECT user_id, SUM(amount) AS allowed
+				FROM allowance_credits
+				GROUP BY user_id
+			) al ON al.user_id = u.id
+			LEFT JOIN (
+				SELECT user_id, SUM(expired) AS expired
+				FROM coin_lots
+				GROUP BY user_id
+			) e ON e.user_id = u.id
+			LEFT JOIN (
+				SELECT receiver_user_id, SUM(amount) AS received
+				FROM transactions
+				GROUP BY receiver_user_id
+			) r ON r.receiver_user_id = u.id
+			LEFT JOIN (
+				SELECT sender_user_id, SUM(amount) AS sent
+				FROM transactions
+				GROUP BY sender_user_id
+			) s ON s.sender_user_id = u.id
+			LEFT JOIN (
+				SELECT purchaser_id, SUM(price) AS spent
+				FROM purchases
+				WHERE refunded_at IS NULL
+				GROUP BY purchaser_id
+			) p ON p.purchaser_id = u.id
+		) balances
+		WHERE coins <> ledger_balance OR coins <> expected_balance
+		ORDER BY id`)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select balance mismatches")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting balance mismatches")
+		}
+	}()
+
+	mismatches := []*entity.BalanceMismatch{}
+	for rows.Next() {
+		mismatch := entity.BalanceMismatch{}
+		err := rows.Scan(
+			&mismatch.UserID,
+			&mismatch.Username,
+			&mismatch.Coins,
+			&mismatch.LedgerBalance,
+			&mismatch.ExpectedBalance,
+		)
+		if err != nil {
+			repo.logger.WithError(err).Error("Failed to scan balance mismatch")
+			return nil, err
+		}
+
+		mismatches = append(mismatches, &mismatch)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate balance mismatches")
+		return nil, err
+	}
+
+	return mismatches, nil
+}
+
+// GetUnbalancedEntries returns ids of entries whose postings don't sum to
+// zero. Post never writes such entries, so any id here means the journal
+// was changed behind the service's back.
+func (repo *LedgerPostgresRepository) GetUnbalancedEntries(ctx context.Context) ([]uint, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT entry_id
+		FROM ledger_postings
+		GROUP BY entry_id
+		HAVING SUM(amount) <> 0
+		ORDER BY entry_id`)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select unbalanced ledger entries")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting unbalanced ledger entries")
+		}
+	}()
+
+	entryIDs := []uint{}
+	for rows.Next() {
+		var entryID uint
+		if err := rows.Scan(&entryID); err != nil {
+			repo.logger.WithError(err).Error("Failed to scan unbalanced ledger entry")
+			return nil, err
+		}
+
+		entryIDs = append(entryIDs, entryID)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate unbalanced ledger entries")
+		return nil, err
+	}
+
+	return entryIDs, nil
+}
+
+// GetAccountBalances sums postings by account kind, all user accounts
+// together.
+func (repo *LedgerPostgresRepository) GetAccountBalances(ctx context.Context) (map[string]int64, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT account, SUM(amount)
+		FROM ledger_postings
+		GROUP BY account`)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select ledger account balances")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting ledger account balances")
+		}
+	}()
+
+	balances := map[string]int64{}
+	for rows.Next() {
+		var account string
+		var balance int64
+		if err := rows.Scan(&account, &balance); err != nil {
+			repo.logger.WithError(err).Error("Failed to scan ledger account balance")
+			return nil, err
+		}
+
+		balances[account] = balance
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate ledger account balances")
+		return nil, err
+	}
+
+	return balances, nil
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/model"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+func TestLedgerPostgresRepository_Post(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLedgerPostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+
+	createdAt := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+	entryColumns := []string{"id", "kind", "reference", "created_at"}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO ledger_entries .* RETURNING .*").
+			WithArgs("grant", "signup").
+			WillReturnRows(sqlmock.NewRows(entryColumns).AddRow(1, "grant", "signup", createdAt))
+		mock.ExpectExec(`INSERT INTO ledger_postings \(entry_id, account, user_id, amount\) VALUES `+
+			`\(\$1, \$2, NULLIF\(\$3, 0\), \$4\), \(\$1, \$5, NULLIF\(\$6, 0\), \$7\)`).
+			WithArgs(1, "issuer", 0, -100, "user", 7, 100).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		entry, err := repo.Post(context.Background(), mockUOW, entity.NewTransfer(
+			entity.KindGrant, "signup", entity.IssuerAccount, entity.UserAccount(7), 100,
+		))
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Entry{
+			ID:        1,
+			Kind:      "grant",
+			Reference: "signup",
+			CreatedAt: createdAt,
+		}, entry)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidEntry", func(t *testing.T) {
+		_, err := repo.Post(context.Background(), mockUOW, &entity.Entry{
+			Kind: entity.KindTransfer,
+			Postings: []entity.Posting{
+				{Account: entity.UserAccount(1), Amount: -100},
+				{Account: entity.UserAccount(2), Amount: 50},
+			},
+		})
+
+		assert.Equal(t, entity.ErrUnbalancedEntry, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("EntryInsertError", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO ledger_entries .* RETURNING .*").
+			WithArgs("purchase", "purchase:3").
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.Post(context.Background(), mockUOW, entity.NewTransfer(
+			entity.KindPurchase, "purchase:3", entity.UserAccount(7), entity.ShopAccount, 50,
+		))
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+
+	t.Run("PostingsInsertError", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO ledger_entries .* RETURNING .*").
+			WithArgs("purchase", "purchase:3").
+			WillReturnRows(sqlmock.NewRows(entryColumns).AddRow(2, "purchase", "purchase:3", createdAt))
+		mock.ExpectExec("INSERT INTO ledger_postings .*").
+			WithArgs(2, "user", 7, -50, "shop", 0, 50).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.Post(context.Background(), mockUOW, entity.NewTransfer(
+			entity.KindPurchase, "purchase:3", entity.UserAccount(7), entity.ShopAccount, 50,
+		))
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestLedgerPostgresRepository_GetBalanceMismatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLedgerPostgresRepository(db, logrus.New())
+	columns := []string{"id", "username", "coins", "ledger_balance", "expected_balance"}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, username, coins, ledger_balance, expected_balance FROM .* ` +
+			`WHERE coins <> ledger_balance OR coins <> expected_balance`).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, "user1", 1000, 900, 900).
+				AddRow(2, "user2", 500, 500, 450))
+
+		mismatches, err := repo.GetBalanceMismatches(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*entity.BalanceMismatch{
+			{UserID: 1, Username: "user1", Coins: 1000, LedgerBalance: 900, ExpectedBalance: 900},
+			{UserID: 2, Username: "user2", Coins: 500, LedgerBalance: 500, ExpectedBalance: 450},
+		}, mismatches)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, username, coins, ledger_balance, expected_balance .*`).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		mismatches, err := repo.GetBalanceMismatches(context.Background())
+
+		assert.NoError(t, err)
+		assert.Empty(t, mismatches)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, username, coins, ledger_balance, expected_balance .*`).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.GetBalanceMismatches(context.Background())
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestLedgerPostgresRepository_GetUnbalancedEntries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLedgerPostgresRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT entry_id FROM ledger_postings GROUP BY entry_id HAVING SUM\(amount\) <> 0`).
+			WillReturnRows(sqlmock.NewRows([]string{"entry_id"}).AddRow(4).AddRow(9))
+
+		entryIDs, err := repo.GetUnbalancedEntries(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{4, 9}, entryIDs)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT entry_id FROM ledger_postings .*`).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.GetUnbalancedEntries(context.Background())
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestLedgerPostgresRepository_GetAccountBalances(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLedgerPostgresRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT account, SUM\(amount\) FROM ledger_postings GROUP BY account`).
+			WillReturnRows(sqlmock.NewRows([]string{"account", "sum"}).
+				AddRow("issuer", -1500).
+				AddRow("user", 1200).
+				AddRow("shop", 300))
+
+		balances, err := repo.GetAccountBalances(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int64{"issuer": -1500, "user": 1200, "shop": 300}, balances)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT account, SUM\(amount\) .*`).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.GetAccountBalances(context.Background())
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+type MockUnitOfWork struct {
+	uow.Executor
+	db *sql.DB
+}
+
+func (m *MockUnitOfWork) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return m.db.ExecContext(ctx, query, args...)
+}
+
+func (m *MockUnitOfWork) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return m.db.QueryRowContext(ctx, query, args...)
+}
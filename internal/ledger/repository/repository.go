@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/model"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+//go:generate mockgen -source=repository.go -destination=mock_repository/ledger_mock.go -package=mock_repository MockLedgerRepository
+type LedgerRepositoryI interface {
+	Post(ctx context.Context, uow uow.Executor, entry *entity.Entry) (*model.Entry, error)
+	GetBalanceMismatches(ctx context.Context) ([]*entity.BalanceMismatch, error)
+	GetUnbalancedEntries(ctx context.Context) ([]uint, error)
+	GetAccountBalances(ctx context.Context) (map[string]int64, error)
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository"
+)
+
+type LedgerUsecaseI interface {
+	Reconcile(ctx context.Context) (*entity.Reconciliation, error)
+	RunPeriodicReconciliation(ctx context.Context, interval time.Duration)
+}
+
+// LedgerUsecase checks the journal. Entries are posted by the usecases that
+// move coins, in the same unit of work as the balance change.
+type LedgerUsecase struct {
+	ledgerRepo ledgerRepo.LedgerRepositoryI
+	logger     *logrus.Logger
+}
+
+func NewLedgerUsecase(
+	ledgerRepository ledgerRepo.LedgerRepositoryI,
+	logger *logrus.Logger,
+) *LedgerUsecase {
+	return &LedgerUsecase{
+		ledgerRepo: ledgerRepository,
+		logger:     logger,
+	}
+}
+
+// Reconcile checks that every entry is balanced and that users.coins of
+// every user equals both the sum of their postings and the balance
+// recomputed from transactions and purchases.
+func (uc *LedgerUsecase) Reconcile(ctx context.Context) (*entity.Reconciliation, error) {
+	unbalancedEntries, err := uc.ledgerRepo.GetUnbalancedEntries(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to get unbalanced ledger entries")
+		return nil, err
+	}
+
+	mismatches, err := uc.ledgerRepo.GetBalanceMismatches(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to get balance mismatches")
+		return nil, err
+	}
+
+	balances, err := uc.ledgerRepo.GetAccountBalances(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to get ledger account balances")
+		return nil, err
+	}
+
+	reconciliation := &entity.Reconciliation{
+		Mismatches:        mismatches,
+		UnbalancedEntries: unbalancedEntries,
+		IssuerBalance:     balances[entity.AccountIssuer],
+		ShopBalance:       balances[entity.AccountShop],
+		UsersBalance:      balances[entity.AccountUser],
+	}
+
+	if !reconciliation.IsConsistent() {
+		uc.logger.WithFields(logrus.Fields{
+			"mismatches":         len(mismatches),
+			"unbalanced_entries": len(unbalancedEntries),
+		}).Error("Ledger doesn't reconcile with user balances")
+	}
+
+	return reconciliation, nil
+}
+
+// RunPeriodicReconciliation reconciles every interval until ctx is done and
+// logs every mismatch as an error, so alerts can be built on the logs.
+func (uc *LedgerUsecase) RunPeriodicReconciliation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reconciliation, err := uc.Reconcile(ctx)
+		if err != nil {
+			uc.logger.WithError(err).Warn("Periodic reconciliation failed")
+			continue
+		}
+
+		for _, mismatch := range reconciliation.Mismatches {
+			uc.logger.WithFields(logrus.Fields{
+				"user_id":          mismatch.UserID,
+				"username":         mismatch.Username,
+				"coins":            mismatch.Coins,
+				"ledger_balance":   mismatch.LedgerBalance,
+				"expected_balance": mismatch.ExpectedBalance,
+			}).Error("User balance doesn't reconcile")
+		}
+
+		for _, entryID := range reconciliation.UnbalancedEntries {
+			uc.logger.WithField("entry_id", entryID).Error("Ledger entry is unbalanced")
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	mockLedger "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/mock_repository"
+)
+
+func TestLedgerUsecase_Reconcile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+
+	uc := NewLedgerUsecase(mockLedgerRepo, logrus.New())
+
+	ctx := context.Background()
+	balances := map[string]int64{
+		entity.AccountIssuer: -1500,
+		entity.AccountUser:   1200,
+		entity.AccountShop:   300,
+	}
+
+	t.Run("consistent ledger", func(t *testing.T) {
+		mockLedgerRepo.EXPECT().GetUnbalancedEntries(ctx).Return([]uint{}, nil)
+		mockLedgerRepo.EXPECT().GetBalanceMismatches(ctx).Return([]*entity.BalanceMismatch{}, nil)
+		mockLedgerRepo.EXPECT().GetAccountBalances(ctx).Return(balances, nil)
+
+		reconciliation, err := uc.Reconcile(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &entity.Reconciliation{
+			Mismatches:        []*entity.BalanceMismatch{},
+			UnbalancedEntries: []uint{},
+			IssuerBalance:     -1500,
+			ShopBalance:       300,
+			UsersBalance:      1200,
+		}
+		if !reflect.DeepEqual(reconciliation, expected) {
+			t.Errorf("expected %+v, got %+v", expected, reconciliation)
+		}
+		if !reconciliation.IsConsistent() {
+			t.Error("expected consistent reconciliation")
+		}
+	})
+
+	t.Run("balance mismatch", func(t *testing.T) {
+		mismatches := []*entity.BalanceMismatch{
+			{UserID: 1, Username: "user1", Coins: 1000, LedgerBalance: 900},
+		}
+		mockLedgerRepo.EXPECT().GetUnbalancedEntries(ctx).Return([]uint{}, nil)
+		mockLedgerRepo.EXPECT().GetBalanceMismatches(ctx).Return(mismatches, nil)
+		mockLedgerRepo.EXPECT().GetAccountBalances(ctx).Return(balances, nil)
+
+		reconciliation, err := uc.Reconcile(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if reconciliation.IsConsistent() {
+			t.Error("expected inconsistent reconciliation")
+		}
+		if !reflect.DeepEqual(reconciliation.Mismatches, mismatches) {
+			t.Errorf("expected mismatches %+v, got %+v", mismatches, reconciliation.Mismatches)
+		}
+	})
+
+	t.Run("unbalanced entry", func(t *testing.T) {
+		mockLedgerRepo.EXPECT().GetUnbalancedEntries(ctx).Return([]uint{4}, nil)
+		mockLedgerRepo.EXPECT().GetBalanceMismatches(ctx).Return([]*entity.BalanceMismatch{}, nil)
+		mockLedgerRepo.EXPECT().GetAccountBalances(ctx).Return(balances, nil)
+
+		reconciliation, err := uc.Reconcile(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if reconciliation.IsConsistent() {
+			t.Error("expected inconsistent reconciliation")
+		}
+	})
+
+	t.Run("unbalanced entries error", func(t *testing.T) {
+		testErr := errors.New("database error")
+		mockLedgerRepo.EXPECT().GetUnbalancedEntries(ctx).Return(nil, testErr)
+
+		_, err := uc.Reconcile(ctx)
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+
+	t.Run("balance mismatches error", func(t *testing.T) {
+		testErr := errors.New("database error")
+		mockLedgerRepo.EXPECT().GetUnbalancedEntries(ctx).Return([]uint{}, nil)
+		mockLedgerRepo.EXPECT().GetBalanceMismatches(ctx).Return(nil, testErr)
+
+		_, err := uc.Reconcile(ctx)
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+
+	t.Run("account balances error", func(t *testing.T) {
+		testErr := errors.New("database error")
+		mockLedgerRepo.EXPECT().GetUnbalancedEntries(ctx).Return([]uint{}, nil)
+		mockLedgerRepo.EXPECT().GetBalanceMismatches(ctx).Return([]*entity.BalanceMismatch{}, nil)
+		mockLedgerRepo.EXPECT().GetAccountBalances(ctx).Return(nil, testErr)
+
+		_, err := uc.Reconcile(ctx)
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+}
+
+func TestLedgerUsecase_RunPeriodicReconciliation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+
+	uc := NewLedgerUsecase(mockLedgerRepo, logrus.New())
+
+	t.Run("reconciles until cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		runs := 0
+		mockLedgerRepo.EXPECT().GetUnbalancedEntries(ctx).Return([]uint{}, nil).Times(2)
+		mockLedgerRepo.EXPECT().GetBalanceMismatches(ctx).Return([]*entity.BalanceMismatch{
+			{UserID: 1, Username: "user1", Coins: 1000, LedgerBalance: 1000, ExpectedBalance: 900},
+		}, nil).Times(2)
+		mockLedgerRepo.EXPECT().GetAccountBalances(ctx).DoAndReturn(func(context.Context) (map[string]int64, error) {
+			runs++
+			if runs == 2 {
+				cancel()
+			}
+			return map[string]int64{}, nil
+		}).Times(2)
+
+		done := make(chan struct{})
+		go func() {
+			uc.RunPeriodicReconciliation(ctx, time.Millisecond)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("periodic reconciliation didn't stop after cancel")
+		}
+	})
+
+	t.Run("keeps running after error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		gomock.InOrder(
+			mockLedgerRepo.EXPECT().GetUnbalancedEntries(ctx).Return(nil, errors.New("database error")),
+			mockLedgerRepo.EXPECT().GetUnbalancedEntries(ctx).DoAndReturn(func(context.Context) ([]uint, error) {
+				cancel()
+				return nil, errors.New("database error")
+			}),
+		)
+
+		done := make(chan struct{})
+		go func() {
+			uc.RunPeriodicReconciliation(ctx, time.Millisecond)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("periodic reconciliation didn't stop after cancel")
+		}
+	})
+}
@@ -0,0 +1,8 @@
+package entity
+
+import "errors"
+
+var (
+	ErrNotEnoughCoins = errors.New("not enough unexpired coins")
+	ErrUntrackedCoins = errors.New("balance isn't covered by coin lots")
+)
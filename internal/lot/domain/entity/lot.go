@@ -0,0 +1,62 @@
+package entity
+
+import "time"
+
+// Portion is a part of a user's balance that expires at once. Balances are
+// stored as lots, and coins leave a balance oldest lot first, so a debit
+// returns the portions it took from the lots. Portions without ExpiresAt
+// never expire.
+type Portion struct {
+	Amount    uint
+	ExpiresAt *time.Time
+}
+
+type Portions []Portion
+
+// NewGrant returns the portion of coins granted at now. Granted coins
+// expire after ttl, or never if ttl isn't positive.
+func NewGrant(amount uint, now time.Time, ttl time.Duration) Portion {
+	if ttl <= 0 {
+		return Portion{Amount: amount}
+	}
+
+	expiresAt := now.Add(ttl)
+	return Portion{Amount: amount, ExpiresAt: &expiresAt}
+}
+
+func (p Portions) Total() uint {
+	var total uint
+	for _, portion := range p {
+		total += portion.Amount
+	}
+	return total
+}
+
+// Take splits the first amount coins off the portions, keeping their order,
+// and returns them with the rest.
+func (p Portions) Take(amount uint) (Portions, Portions) {
+	taken := Portions{}
+	for i, portion := range p {
+		if amount == 0 {
+			return taken, p[i:]
+		}
+
+		if portion.Amount > amount {
+			taken = append(taken, Portion{Amount: amount, ExpiresAt: portion.ExpiresAt})
+			rest := append(Portions{{Amount: portion.Amount - amount, ExpiresAt: portion.ExpiresAt}}, p[i+1:]...)
+			return taken, rest
+		}
+
+		taken = append(taken, portion)
+		amount -= portion.Amount
+	}
+
+	return taken, Portions{}
+}
+
+type ExpiringItem struct {
+	Amount    uint      `json:"amount"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type Expiring []ExpiringItem
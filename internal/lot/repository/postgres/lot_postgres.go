@@ -0,0 +1,307 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+type LotPostgresRepository struct {
+	DB     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewLotPostgresRepository(
+	db *sql.DB,
+	logger *logrus.Logger,
+) *LotPostgresRepository {
+	return &LotPostgresRepository{
+		DB:     db,
+		logger: logger,
+	}
+}
+
+// Create adds a lot to the user's balance for every non-empty portion. It
+// doesn't change users.coins, so it must be called in the same unit of work
+// as the user credit.
+func (repo *LotPostgresRepository) Create(
+	ctx context.Context,
+	uow uowI.Executor,
+	userID uint,
+	portions entity.Portions,
+) error {
+	values := make([]string, 0, len(portions))
+	args := []interface{}{userID}
+	for _, portion := range portions {
+		if portion.Amount == 0 {
+			continue
+		}
+		values = append(values, fmt.Sprintf("($1, $%d, $%d, $%d)", len(args)+1, len(args)+1, len(args)+2))
+		args = append(args, portion.Amount, portion.ExpiresAt)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err := uow.ExecContext(
+		ctx,
+		"INSERT INTO coin_lots (user_id, amount, remaining, expires_at) VALUES "+strings.Join(values, ", "),
+		args...,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to create coin lots")
+		return err
+	}
+
+	repo.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"lots":    len(values),
+	}).Debug("Created coin lots in Postgres")
+
+	return nil
+}
+
+// Consume takes amount coins from the user's unexpired lots, the lot that
+// expires first goes first, and returns the taken portions. The user row
+// must be locked within uow, e.g. by debiting the user, so concurrent
+// operations don't take the same coins. Expired coins that the sweeper
+// hasn't written off yet are still in users.coins, so the debit may pass
+// while the lots don't cover it, then ErrNotEnoughCoins is returned. Any
+// other shortfall means users.coins and the lots went out of sync, which
+// fails with ErrUntrackedCoins.
+func (repo *LotPostgresRepository) Consume(
+	ctx context.Context,
+	uow uowI.Executor,
+	userID uint,
+	amount uint,
+) (entity.Portions, error) {
+	portions, err := repo.takeUnexpired(ctx, uow, userID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	consumed := portions.Total()
+	if consumed == amount {
+		return portions, nil
+	}
+
+	var expired uint
+	err = uow.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(SUM(remaining), 0) FROM coin_lots
+		WHERE user_id = $1 AND remaining > 0 AND expires_at <= NOW()`,
+		userID,
+	).Scan(&expired)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to sum expired coin lots")
+		return nil, err
+	}
+
+	if expired > 0 {
+		repo.logger.WithFields(logrus.Fields{
+			"user_id":  userID,
+			"amount":   amount,
+			"consumed": consumed,
+			"expired":  expired,
+		}).Info("User balance is short of unexpired coins")
+		return nil, entity.ErrNotEnoughCoins
+	}
+
+	repo.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"amount":   amount,
+		"consumed": consumed,
+	}).Error("User balance isn't covered by coin lots")
+
+	return nil, entity.ErrUntrackedCoins
+}
+
+// takeUnexpired takes up to amount coins from the user's unexpired lots.
+// Its rows are closed before it returns, so Consume can query further.
+func (repo *LotPostgresRepository) takeUnexpired(
+	ctx context.Context,
+	uow uowI.Executor,
+	userID uint,
+	amount uint,
+) (entity.Portions, error) {
+	rows, err := uow.QueryContext(
+		ctx,
+		`WITH ordered AS (
+			SELECT id, remaining,
+				SUM(remaining) OVER (ORDER BY expires_at ASC NULLS LAST, id) - remaining AS preceding
+			FROM coin_lots
+			WHERE user_id = $1 AND remaining > 0 AND (expires_at IS NULL OR expires_at > NOW())
+		), taken AS (
+			SELECT id, LEAST(remaining, $2 - preceding) AS amount
+			FROM ordered
+			WHERE preceding < $2
+		)
+		UPDATE coin_lots l
+		SET remaining = l.remaining - t.amount
+		FROM taken t
+		WHERE l.id = t.id
+		RETURNING t.amount, l.expires_at`,
+		userID, amount,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to consume coin lots")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows consuming coin lots")
+		}
+	}()
+
+	portions := entity.Portions{}
+	for rows.Next() {
+		portion := entity.Portion{}
+		if err := rows.Scan(&portion.Amount, &portion.ExpiresAt); err != nil {
+			repo.logger.WithError(err).Error("Failed to scan consumed coin lot")
+			return nil, err
+		}
+
+		portions = append(portions, portion)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate consumed coin lots")
+		return nil, err
+	}
+
+	return portions, nil
+}
+
+// Expire writes off remaining coins of the user's lots that expired by now
+// and returns how many coins were written off. The user row must be locked
+// within uow before, like for Consume.
+func (repo *LotPostgresRepository) Expire(
+	ctx context.Context,
+	uow uowI.Executor,
+	userID uint,
+	now time.Time,
+) (uint, error) {
+	var expired uint
+	err := uow.QueryRowContext(
+		ctx,
+		`WITH due AS (
+			SELECT id, remaining
+			FROM coin_lots
+			WHERE user_id = $1 AND remaining > 0 AND expires_at <= $2
+			FOR UPDATE
+		), updated AS (
+			UPDATE coin_lots l
+			SET expired = l.expired + d.remaining, remaining = 0
+			FROM due d
+			WHERE l.id = d.id
+			RETURNING d.remaining
+		)
+		SELECT COALESCE(SUM(remaining), 0) FROM updated`,
+		userID, now,
+	).Scan(&expired)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to expire coin lots")
+		return 0, err
+	}
+
+	return expired, nil
+}
+
+// GetUsersWithExpiredLots returns up to limit users with ID greater than
+// afterID who have coins that expired by now, ordered by ID.
+func (repo *LotPostgresRepository) GetUsersWithExpiredLots(
+	ctx context.Context,
+	now time.Time,
+	afterID uint,
+	limit uint,
+) ([]uint, error) {
+	rows, err := repo.DB.QueryContext(
+		ctx,
+		`SELECT DISTINCT user_id
+		FROM coin_lots
+		WHERE remaining > 0 AND expires_at <= $1 AND user_id > $2
+		ORDER BY user_id
+		LIMIT $3`,
+		now, afterID, limit,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select users with expired coin lots")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting users with expired coin lots")
+		}
+	}()
+
+	userIDs := []uint{}
+	for rows.Next() {
+		var userID uint
+		if err := rows.Scan(&userID); err != nil {
+			repo.logger.WithError(err).Error("Failed to scan user with expired coin lots")
+			return nil, err
+		}
+
+		userIDs = append(userIDs, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate users with expired coin lots")
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// GetExpiringByUserID returns the user's coins that expire by before,
+// grouped by expiration time, soonest first. Coins that already expired
+// but weren't written off yet are included.
+func (repo *LotPostgresRepository) GetExpiringByUserID(
+	ctx context.Context,
+	userID uint,
+	before time.Time,
+) (entity.Expiring, error) {
+	rows, err := repo.DB.QueryContext(
+		ctx,
+		`SELECT SUM(remaining), expires_at
+		FROM coin_lots
+		WHERE user_id = $1 AND remaining > 0 AND expires_at <= $2
+		GROUP BY expires_at
+		ORDER BY expires_at`,
+		userID, before,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select expiring coin lots")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting expiring coin lots")
+		}
+	}()
+
+	expiring := entity.Expiring{}
+	for rows.Next() {
+		item := entity.ExpiringItem{}
+		if err := rows.Scan(&item.Amount, &item.ExpiresAt); err != nil {
+			repo.logger.WithError(err).Error("Failed to scan expiring coin lot")
+			return nil, err
+		}
+
+		expiring = append(expiring, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate expiring coin lots")
+		return nil, err
+	}
+
+	return expiring, nil
+}
@@ -0,0 +1,253 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+func TestLotPostgresRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLotPostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+	expiresAt := time.Date(2027, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO coin_lots \(user_id, amount, remaining, expires_at\) VALUES \(\$1, \$2, \$2, \$3\), \(\$1, \$4, \$4, \$5\)`).
+			WithArgs(1, 60, expiresAt, 40, nil).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err := repo.Create(context.Background(), mockUOW, 1, entity.Portions{
+			{Amount: 60, ExpiresAt: &expiresAt},
+			{Amount: 0, ExpiresAt: &expiresAt},
+			{Amount: 40},
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NothingToCreate", func(t *testing.T) {
+		err := repo.Create(context.Background(), mockUOW, 1, entity.Portions{{Amount: 0}})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO coin_lots .*").
+			WithArgs(1, 60, nil).
+			WillReturnError(sql.ErrConnDone)
+
+		err := repo.Create(context.Background(), mockUOW, 1, entity.Portions{{Amount: 60}})
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestLotPostgresRepository_Consume(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLotPostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+	expiresAt := time.Date(2027, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("WITH ordered AS .* AND \\(expires_at IS NULL OR expires_at > NOW\\(\\)\\) .* "+
+			"UPDATE coin_lots .* RETURNING t.amount, l.expires_at").
+			WithArgs(1, 100).
+			WillReturnRows(sqlmock.NewRows([]string{"amount", "expires_at"}).
+				AddRow(70, expiresAt).
+				AddRow(30, nil))
+
+		portions, err := repo.Consume(context.Background(), mockUOW, 1, 100)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Portions{
+			{Amount: 70, ExpiresAt: &expiresAt},
+			{Amount: 30},
+		}, portions)
+	})
+
+	t.Run("BalanceNotCoveredByLots", func(t *testing.T) {
+		mock.ExpectQuery("WITH ordered AS .*").
+			WithArgs(1, 100).
+			WillReturnRows(sqlmock.NewRows([]string{"amount", "expires_at"}).
+				AddRow(70, expiresAt))
+		mock.ExpectQuery("SELECT COALESCE\\(SUM\\(remaining\\), 0\\) FROM coin_lots .* AND expires_at <= NOW\\(\\)").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
+
+		_, err := repo.Consume(context.Background(), mockUOW, 1, 100)
+
+		assert.ErrorIs(t, err, entity.ErrUntrackedCoins)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OnlyExpiredLotsLeft", func(t *testing.T) {
+		mock.ExpectQuery("WITH ordered AS .*").
+			WithArgs(1, 100).
+			WillReturnRows(sqlmock.NewRows([]string{"amount", "expires_at"}))
+		mock.ExpectQuery("SELECT COALESCE\\(SUM\\(remaining\\), 0\\) FROM coin_lots").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(100))
+
+		_, err := repo.Consume(context.Background(), mockUOW, 1, 100)
+
+		assert.ErrorIs(t, err, entity.ErrNotEnoughCoins)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery("WITH ordered AS .*").
+			WithArgs(1, 100).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.Consume(context.Background(), mockUOW, 1, 100)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestLotPostgresRepository_Expire(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLotPostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("WITH due AS .* FOR UPDATE .* SELECT COALESCE\\(SUM\\(remaining\\), 0\\) FROM updated").
+			WithArgs(1, now).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(150))
+
+		expired, err := repo.Expire(context.Background(), mockUOW, 1, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(150), expired)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery("WITH due AS .*").
+			WithArgs(1, now).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.Expire(context.Background(), mockUOW, 1, now)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestLotPostgresRepository_GetUsersWithExpiredLots(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLotPostgresRepository(db, logrus.New())
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT DISTINCT user_id FROM coin_lots .* ORDER BY user_id LIMIT \\$3").
+			WithArgs(now, 10, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(11).AddRow(15))
+
+		userIDs, err := repo.GetUsersWithExpiredLots(context.Background(), now, 10, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{11, 15}, userIDs)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery("SELECT DISTINCT user_id .*").
+			WithArgs(now, 0, 2).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.GetUsersWithExpiredLots(context.Background(), now, 0, 2)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestLotPostgresRepository_GetExpiringByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLotPostgresRepository(db, logrus.New())
+	before := time.Date(2026, 11, 18, 0, 0, 0, 0, time.UTC)
+	soon := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT SUM\\(remaining\\), expires_at FROM coin_lots .* GROUP BY expires_at ORDER BY expires_at").
+			WithArgs(1, before).
+			WillReturnRows(sqlmock.NewRows([]string{"sum", "expires_at"}).AddRow(40, soon))
+
+		expiring, err := repo.GetExpiringByUserID(context.Background(), 1, before)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Expiring{{Amount: 40, ExpiresAt: soon}}, expiring)
+	})
+
+	t.Run("NothingExpiring", func(t *testing.T) {
+		mock.ExpectQuery("SELECT SUM\\(remaining\\), expires_at .*").
+			WithArgs(1, before).
+			WillReturnRows(sqlmock.NewRows([]string{"sum", "expires_at"}))
+
+		expiring, err := repo.GetExpiringByUserID(context.Background(), 1, before)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Expiring{}, expiring)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery("SELECT SUM\\(remaining\\), expires_at .*").
+			WithArgs(1, before).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.GetExpiringByUserID(context.Background(), 1, before)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+type MockUnitOfWork struct {
+	uow.Executor
+	db *sql.DB
+}
+
+func (m *MockUnitOfWork) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return m.db.ExecContext(ctx, query, args...)
+}
+
+func (m *MockUnitOfWork) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return m.db.QueryContext(ctx, query, args...)
+}
+
+func (m *MockUnitOfWork) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return m.db.QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+//go:generate mockgen -source=repository.go -destination=mock_repository/lot_mock.go -package=mock_repository MockLotRepository
+type LotRepositoryI interface {
+	Create(ctx context.Context, uow uow.Executor, userID uint, portions entity.Portions) error
+	Consume(ctx context.Context, uow uow.Executor, userID uint, amount uint) (entity.Portions, error)
+	Expire(ctx context.Context, uow uow.Executor, userID uint, now time.Time) (uint, error)
+	GetUsersWithExpiredLots(ctx context.Context, now time.Time, afterID uint, limit uint) ([]uint, error)
+	GetExpiringByUserID(ctx context.Context, userID uint, before time.Time) (entity.Expiring, error)
+}
@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository"
+	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
+	userRepo "github.com/artrsyf/avito-trainee-assignment/internal/user/repository"
+	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+const defaultBatchSize = 500
+
+type LotUsecaseI interface {
+	ExpireDue(ctx context.Context, now time.Time) error
+	RunPeriodicExpiry(ctx context.Context, interval time.Duration)
+}
+
+// LotUsecase writes off expired coins. Every user is handled in a separate
+// unit of work that locks the user first, so replicas running the sweeper
+// at the same time write off every lot once.
+type LotUsecase struct {
+	lotRepo    lotRepo.LotRepositoryI
+	userRepo   userRepo.UserRepositoryI
+	ledgerRepo ledgerRepo.LedgerRepositoryI
+	uowFactory uowI.Factory
+	batchSize  uint
+	logger     *logrus.Logger
+}
+
+func NewLotUsecase(
+	lotRepository lotRepo.LotRepositoryI,
+	userRepository userRepo.UserRepositoryI,
+	ledgerRepository ledgerRepo.LedgerRepositoryI,
+	uowFactory uowI.Factory,
+	cfg config.ExpiryConfig,
+	logger *logrus.Logger,
+) *LotUsecase {
+	batchSize := cfg.BatchSize
+	if batchSize == 0 {
+		batchSize = defaultBatchSize
+	}
+
+	return &LotUsecase{
+		lotRepo:    lotRepository,
+		userRepo:   userRepository,
+		ledgerRepo: ledgerRepository,
+		uowFactory: uowFactory,
+		batchSize:  batchSize,
+		logger:     logger,
+	}
+}
+
+// ExpireDue writes off coins of all users whose lots expired by now. A user
+// that fails is skipped until the next run, so one broken balance doesn't
+// stop the sweep.
+func (uc *LotUsecase) ExpireDue(ctx context.Context, now time.Time) error {
+	var afterID, users, failed uint
+	var expired uint
+	for {
+		userIDs, err := uc.lotRepo.GetUsersWithExpiredLots(ctx, now, afterID, uc.batchSize)
+		if err != nil {
+			uc.logger.WithError(err).Error("Failed to get users with expired coins")
+			return err
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		for _, userID := range userIDs {
+			userExpired, err := uc.expireUser(ctx, userID, now)
+			if err != nil {
+				uc.logger.WithError(err).WithField("user_id", userID).Warn("Failed to write off expired coins")
+				failed++
+				continue
+			}
+			if userExpired > 0 {
+				users++
+				expired += userExpired
+			}
+		}
+
+		afterID = userIDs[len(userIDs)-1]
+	}
+
+	if users > 0 || failed > 0 {
+		uc.logger.WithFields(logrus.Fields{
+			"users":  users,
+			"coins":  expired,
+			"failed": failed,
+		}).Info("Wrote off expired coins")
+	}
+
+	return nil
+}
+
+// expireUser writes off the user's expired lots, debits the user and
+// journals the write-off back to the issuer in one unit of work.
+func (uc *LotUsecase) expireUser(ctx context.Context, userID uint, now time.Time) (uint, error) {
+	uow := uc.uowFactory.NewUnitOfWork()
+
+	err := uow.Begin(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction begin error")
+		return 0, err
+	}
+
+	_, err = uc.userRepo.GetForUpdate(ctx, uow, userID)
+	if err == userEntity.ErrIsNotExist {
+		// The user was deleted with their lots.
+		uc.rollback(uow)
+		return 0, nil
+	}
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback expiry due user locking")
+		return 0, err
+	}
+
+	expired, err := uc.lotRepo.Expire(ctx, uow, userID, now)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback expiry due coin lots expiring")
+		return 0, err
+	}
+	if expired == 0 {
+		// Another replica has already written the lots off.
+		uc.rollback(uow)
+		return 0, nil
+	}
+
+	err = uc.userRepo.Debit(ctx, uow, userID, expired)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback expiry due user updating")
+		return 0, err
+	}
+
+	_, err = uc.ledgerRepo.Post(ctx, uow, ledgerEntity.NewTransfer(
+		ledgerEntity.KindExpiry,
+		fmt.Sprintf("expiry:%s", now.UTC().Format("2006-01-02")),
+		ledgerEntity.UserAccount(userID),
+		ledgerEntity.IssuerAccount,
+		expired,
+	))
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback expiry due ledger posting")
+		return 0, err
+	}
+
+	err = uow.Commit()
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction commit error due coins expiring")
+		return 0, err
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"coins":   expired,
+	}).Debug("Wrote off expired coins of user")
+
+	return expired, nil
+}
+
+// RunPeriodicExpiry writes off expired coins every interval until ctx is
+// done.
+func (uc *LotUsecase) RunPeriodicExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := uc.ExpireDue(ctx, time.Now()); err != nil {
+			uc.logger.WithError(err).Warn("Coin expiry sweep failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (uc *LotUsecase) rollback(uow uowI.UnitOfWork) {
+	if err := uow.Rollback(); err != nil {
+		uc.logger.WithError(err).Error("Rollback error encountered")
+	}
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerModel "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/model"
+	mockLedger "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/mock_repository"
+	mockLot "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/mock_repository"
+	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
+	userModel "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/model"
+	mockUser "github.com/artrsyf/avito-trainee-assignment/internal/user/repository/mock_repository"
+	mockUow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/mock_uow"
+)
+
+func TestLotUsecase_ExpireDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+	mockUow := mockUow.NewMockUnitOfWork(ctrl)
+
+	uc := NewLotUsecase(
+		mockLotRepo,
+		mockUserRepo,
+		mockLedgerRepo,
+		mockUowFactory,
+		config.ExpiryConfig{BatchSize: 2},
+		logrus.New(),
+	)
+
+	ctx := context.Background()
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	testErr := errors.New("database error")
+
+	t.Run("expired coins are written off", func(t *testing.T) {
+		gomock.InOrder(
+			mockLotRepo.EXPECT().GetUsersWithExpiredLots(ctx, now, uint(0), uint(2)).Return([]uint{1, 3}, nil),
+			mockLotRepo.EXPECT().GetUsersWithExpiredLots(ctx, now, uint(3), uint(2)).Return([]uint{}, nil),
+		)
+		for _, userID := range []uint{1, 3} {
+			mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+			mockUow.EXPECT().Begin(ctx).Return(nil)
+			mockUserRepo.EXPECT().GetForUpdate(ctx, mockUow, userID).Return(&userModel.User{ID: userID}, nil)
+			mockLotRepo.EXPECT().Expire(ctx, mockUow, userID, now).Return(uint(150), nil)
+			mockUserRepo.EXPECT().Debit(ctx, mockUow, userID, uint(150)).Return(nil)
+			mockLedgerRepo.EXPECT().Post(ctx, mockUow, ledgerEntity.NewTransfer(
+				ledgerEntity.KindExpiry,
+				"expiry:2026-10-18",
+				ledgerEntity.UserAccount(userID),
+				ledgerEntity.IssuerAccount,
+				150,
+			)).Return(&ledgerModel.Entry{ID: 1}, nil)
+			mockUow.EXPECT().Commit().Return(nil)
+		}
+
+		err := uc.ExpireDue(ctx, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("lots written off by another replica", func(t *testing.T) {
+		gomock.InOrder(
+			mockLotRepo.EXPECT().GetUsersWithExpiredLots(ctx, now, uint(0), uint(2)).Return([]uint{1}, nil),
+			mockLotRepo.EXPECT().GetUsersWithExpiredLots(ctx, now, uint(1), uint(2)).Return([]uint{}, nil),
+		)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(1)).Return(&userModel.User{ID: 1}, nil)
+		mockLotRepo.EXPECT().Expire(ctx, mockUow, uint(1), now).Return(uint(0), nil)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		err := uc.ExpireDue(ctx, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("deleted user is skipped", func(t *testing.T) {
+		gomock.InOrder(
+			mockLotRepo.EXPECT().GetUsersWithExpiredLots(ctx, now, uint(0), uint(2)).Return([]uint{1}, nil),
+			mockLotRepo.EXPECT().GetUsersWithExpiredLots(ctx, now, uint(1), uint(2)).Return([]uint{}, nil),
+		)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(1)).Return(nil, userEntity.ErrIsNotExist)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		err := uc.ExpireDue(ctx, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("failed user doesn't stop the sweep", func(t *testing.T) {
+		gomock.InOrder(
+			mockLotRepo.EXPECT().GetUsersWithExpiredLots(ctx, now, uint(0), uint(2)).Return([]uint{1, 3}, nil),
+			mockLotRepo.EXPECT().GetUsersWithExpiredLots(ctx, now, uint(3), uint(2)).Return([]uint{}, nil),
+		)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow).Times(2)
+		mockUow.EXPECT().Begin(ctx).Return(nil).Times(2)
+		mockUserRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(1)).Return(&userModel.User{ID: 1}, nil)
+		mockLotRepo.EXPECT().Expire(ctx, mockUow, uint(1), now).Return(uint(150), nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(150)).Return(testErr)
+		mockUow.EXPECT().Rollback().Return(nil)
+		mockUserRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(3)).Return(&userModel.User{ID: 3}, nil)
+		mockLotRepo.EXPECT().Expire(ctx, mockUow, uint(3), now).Return(uint(20), nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(3), uint(20)).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 2}, nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		err := uc.ExpireDue(ctx, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("ledger posting error", func(t *testing.T) {
+		gomock.InOrder(
+			mockLotRepo.EXPECT().GetUsersWithExpiredLots(ctx, now, uint(0), uint(2)).Return([]uint{1}, nil),
+			mockLotRepo.EXPECT().GetUsersWithExpiredLots(ctx, now, uint(1), uint(2)).Return([]uint{}, nil),
+		)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(1)).Return(&userModel.User{ID: 1}, nil)
+		mockLotRepo.EXPECT().Expire(ctx, mockUow, uint(1), now).Return(uint(150), nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(150)).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(nil, testErr)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		err := uc.ExpireDue(ctx, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("users selecting error", func(t *testing.T) {
+		mockLotRepo.EXPECT().GetUsersWithExpiredLots(ctx, now, uint(0), uint(2)).Return(nil, testErr)
+
+		err := uc.ExpireDue(ctx, now)
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/sirupsen/logrus"
 
+	sessionEntity "github.com/artrsyf/avito-trainee-assignment/internal/session/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/session/usecase"
 	JSONResponse "github.com/artrsyf/avito-trainee-assignment/pkg/json_response"
 )
 
@@ -18,8 +20,14 @@ type contextKey string
 
 const UserIDContextKey contextKey = "user_id"
 const UsernameContextKey contextKey = "username"
-
-func ValidateJWTToken(next http.Handler, logger *logrus.Logger) http.Handler {
+const SessionIDContextKey contextKey = "session_id"
+const RoleContextKey contextKey = "role"
+
+func ValidateJWTToken(
+	next http.Handler,
+	sessionUC usecase.SessionUsecaseI,
+	logger *logrus.Logger,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		logger.Info("Validate JWT for request")
 
@@ -60,6 +68,11 @@ func ValidateJWTToken(next http.Handler, logger *logrus.Logger) http.Handler {
 			return
 		}
 
+		if tokenType, ok := claims["type"].(string); ok && tokenType != "access" {
+			sendBadTokenError(w, logger, "Refresh token used as access token")
+			return
+		}
+
 		claimsUser, ok := claims["user"].(map[string]interface{})
 		if !ok {
 			sendBadTokenError(w, logger, "User claims are missing")
@@ -85,13 +98,41 @@ func ValidateJWTToken(next http.Handler, logger *logrus.Logger) http.Handler {
 			return
 		}
 
+		// Tokens issued before roles were introduced carry no role and are
+		// treated as having none until they are refreshed.
+		role, _ := claimsUser["role"].(string)
+
+		sessionID, ok := claims["sid"].(string)
+		if !ok || sessionID == "" {
+			sendBadTokenError(w, logger, "Session id is missing")
+			return
+		}
+
+		err = sessionUC.Validate(r.Context(), userID, sessionID, pureToken)
+		if err == sessionEntity.ErrNoSession {
+			sendBadTokenError(w, logger, "Token doesn't belong to a live session")
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to validate user session")
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusInternalServerError,
+				map[string]string{"errors": "internal error"},
+			)
+			return
+		}
+
 		logger.WithFields(logrus.Fields{
 			"user_id":  userID,
 			"username": username,
+			"role":     role,
 		}).Info("User authenticated")
 
 		ctx := context.WithValue(r.Context(), UserIDContextKey, userID)
 		ctx = context.WithValue(ctx, UsernameContextKey, username)
+		ctx = context.WithValue(ctx, SessionIDContextKey, sessionID)
+		ctx = context.WithValue(ctx, RoleContextKey, role)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	JSONResponse "github.com/artrsyf/avito-trainee-assignment/pkg/json_response"
+)
+
+// RequireRole lets through only users whose token carries one of the given
+// roles. It must be wrapped by ValidateJWTToken, which puts the role into
+// the request context.
+func RequireRole(
+	next http.Handler,
+	logger *logrus.Logger,
+	roles ...string,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role, ok := r.Context().Value(RoleContextKey).(string)
+		if !ok {
+			logger.Error("Role is missing in protected route context")
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusInternalServerError,
+				map[string]string{"errors": "internal error"},
+			)
+			return
+		}
+
+		for _, allowedRole := range roles {
+			if role == allowedRole {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id": r.Context().Value(UserIDContextKey),
+			"role":    role,
+		}).Warn("User without required role tried to access protected route")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusForbidden,
+			map[string]string{"errors": "forbidden"},
+		)
+	})
+}
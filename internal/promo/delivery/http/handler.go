@@ -0,0 +1,162 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/dto"
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/usecase"
+	JSONResponse "github.com/artrsyf/avito-trainee-assignment/pkg/json_response"
+)
+
+type PromoHandler struct {
+	promoUC  usecase.PromoUsecaseI
+	validate *validator.Validate
+	logger   *logrus.Logger
+}
+
+func NewPromoHandler(
+	promoUsecase usecase.PromoUsecaseI,
+	validate *validator.Validate,
+	logger *logrus.Logger,
+) *PromoHandler {
+	return &PromoHandler{
+		promoUC:  promoUsecase,
+		validate: validate,
+		logger:   logger,
+	}
+}
+
+func (h *PromoHandler) CreatePromoCode(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming CreatePromoCode request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to read request body")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+	defer func() {
+		if err = r.Body.Close(); err != nil {
+			h.logger.WithError(err).Warn("Failed to close request body")
+		}
+	}()
+
+	createRequest := &dto.CreatePromoCodeRequest{}
+	if err = json.Unmarshal(body, createRequest); err != nil {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+
+	if err = createRequest.ValidateCreatePromoCodeRequest(h.validate); err != nil {
+		h.logger.WithError(err).Warn("Failed validation for promo code request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	promoCode, err := h.promoUC.CreatePromoCode(ctx, dto.CreatePromoCodeRequestToEntity(createRequest))
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Promo code create error handling")
+
+		h.sendPromoError(w, err)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusCreated, dto.PromoCodeToResponse(promoCode))
+}
+
+func (h *PromoHandler) GetPromoCodes(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming GetPromoCodes request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	promoCodes, err := h.promoUC.GetPromoCodes(ctx)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Promo codes error handling")
+
+		h.sendPromoError(w, err)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.PromoCodesToResponse(promoCodes))
+}
+
+func (h *PromoHandler) DeactivatePromoCode(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming DeactivatePromoCode request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.promoUC.DeactivatePromoCode(ctx, mux.Vars(r)["code"])
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Promo code deactivate error handling")
+
+		h.sendPromoError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PromoHandler) sendPromoError(w http.ResponseWriter, err error) {
+	switch err {
+	case entity.ErrPromoNotFound:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusNotFound,
+			map[string]string{"errors": "promo code not found"},
+		)
+	case entity.ErrItemNotFound:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusNotFound,
+			map[string]string{"errors": "item not found"},
+		)
+	case entity.ErrPromoExists:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "promo code already exists"},
+		)
+	default:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+	}
+}
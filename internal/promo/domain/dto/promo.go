@@ -0,0 +1,163 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/model"
+)
+
+type CreatePromoCodeRequest struct {
+	Code           string     `json:"code" validate:"required,min=3,max=64,printascii,excludesall= /?#%"`
+	DiscountType   string     `json:"discountType" validate:"required,oneof=percent fixed"`
+	DiscountValue  uint       `json:"discountValue" validate:"required,lte=2147483647"`
+	Item           string     `json:"item" validate:"omitempty,max=255"`
+	ValidFrom      *time.Time `json:"validFrom"`
+	ValidUntil     *time.Time `json:"validUntil"`
+	MaxRedemptions *uint      `json:"maxRedemptions" validate:"omitempty,gt=0,lte=2147483647"`
+	MaxPerUser     *uint      `json:"maxPerUser" validate:"omitempty,gt=0,lte=2147483647"`
+}
+
+type PromoCodeResponse struct {
+	Code           string     `json:"code"`
+	DiscountType   string     `json:"discountType"`
+	DiscountValue  uint       `json:"discountValue"`
+	Item           string     `json:"item,omitempty"`
+	ValidFrom      *time.Time `json:"validFrom,omitempty"`
+	ValidUntil     *time.Time `json:"validUntil,omitempty"`
+	MaxRedemptions *uint      `json:"maxRedemptions,omitempty"`
+	MaxPerUser     *uint      `json:"maxPerUser,omitempty"`
+	Redemptions    uint       `json:"redemptions"`
+}
+
+type PromoCodesResponse struct {
+	PromoCodes []PromoCodeResponse `json:"promoCodes"`
+}
+
+// NormalizeCode makes promo codes case insensitive.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (req *CreatePromoCodeRequest) ValidateCreatePromoCodeRequest(validate *validator.Validate) error {
+	err := validate.Struct(req)
+	if err != nil {
+		if validationErrs, ok := err.(validator.ValidationErrors); ok {
+			for _, err := range validationErrs {
+				field := err.Field()
+
+				switch err.Tag() {
+				case "required":
+					return errors.New(field + " is required")
+				case "min":
+					return errors.New(field + " is too short")
+				case "max":
+					return errors.New(field + " is too long")
+				case "gt":
+					return errors.New(field + " must be greater than 0")
+				case "lte":
+					return errors.New(field + " is too large")
+				case "printascii", "excludesall":
+					return errors.New(field + " contains forbidden characters")
+				case "oneof":
+					return errors.New(field + " must be percent or fixed")
+				default:
+					return errors.New(field + " is invalid")
+				}
+			}
+		}
+		return err
+	}
+
+	if req.DiscountType == entity.DiscountPercent && req.DiscountValue > 100 {
+		return errors.New("discountValue must be at most 100 percent")
+	}
+
+	if req.ValidFrom != nil && req.ValidUntil != nil && !req.ValidUntil.After(*req.ValidFrom) {
+		return errors.New("validUntil must be after validFrom")
+	}
+
+	return nil
+}
+
+func CreatePromoCodeRequestToEntity(req *CreatePromoCodeRequest) *entity.PromoCode {
+	return &entity.PromoCode{
+		Code:           NormalizeCode(req.Code),
+		DiscountType:   req.DiscountType,
+		DiscountValue:  req.DiscountValue,
+		Item:           req.Item,
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerUser:     req.MaxPerUser,
+	}
+}
+
+func PromoCodeEntityToModel(promoCode *entity.PromoCode) *model.PromoCode {
+	promoCodeModel := &model.PromoCode{
+		Code:           promoCode.Code,
+		DiscountType:   promoCode.DiscountType,
+		DiscountValue:  promoCode.DiscountValue,
+		ValidFrom:      promoCode.ValidFrom,
+		ValidUntil:     promoCode.ValidUntil,
+		MaxRedemptions: promoCode.MaxRedemptions,
+		MaxPerUser:     promoCode.MaxPerUser,
+	}
+	if promoCode.Item != "" {
+		item := promoCode.Item
+		promoCodeModel.Item = &item
+	}
+
+	return promoCodeModel
+}
+
+func PromoCodeModelToEntity(promoCode *model.PromoCode) *entity.PromoCode {
+	promoCodeEntity := &entity.PromoCode{
+		ID:             promoCode.ID,
+		Code:           promoCode.Code,
+		DiscountType:   promoCode.DiscountType,
+		DiscountValue:  promoCode.DiscountValue,
+		ValidFrom:      promoCode.ValidFrom,
+		ValidUntil:     promoCode.ValidUntil,
+		MaxRedemptions: promoCode.MaxRedemptions,
+		MaxPerUser:     promoCode.MaxPerUser,
+		Redemptions:    promoCode.Redemptions,
+	}
+	if promoCode.PurchaseTypeID != nil {
+		promoCodeEntity.PurchaseTypeID = *promoCode.PurchaseTypeID
+	}
+	if promoCode.Item != nil {
+		promoCodeEntity.Item = *promoCode.Item
+	}
+
+	return promoCodeEntity
+}
+
+func PromoCodeToResponse(promoCode *entity.PromoCode) PromoCodeResponse {
+	return PromoCodeResponse{
+		Code:           promoCode.Code,
+		DiscountType:   promoCode.DiscountType,
+		DiscountValue:  promoCode.DiscountValue,
+		Item:           promoCode.Item,
+		ValidFrom:      promoCode.ValidFrom,
+		ValidUntil:     promoCode.ValidUntil,
+		MaxRedemptions: promoCode.MaxRedemptions,
+		MaxPerUser:     promoCode.MaxPerUser,
+		Redemptions:    promoCode.Redemptions,
+	}
+}
+
+func PromoCodesToResponse(promoCodes []*entity.PromoCode) *PromoCodesResponse {
+	response := &PromoCodesResponse{
+		PromoCodes: make([]PromoCodeResponse, 0, len(promoCodes)),
+	}
+	for _, promoCode := range promoCodes {
+		response.PromoCodes = append(response.PromoCodes, PromoCodeToResponse(promoCode))
+	}
+
+	return response
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "errors"
+
+var (
+	ErrPromoNotFound      = errors.New("promo code not found")
+	ErrPromoExists        = errors.New("promo code already exists")
+	ErrPromoInactive      = errors.New("promo code is not active")
+	ErrPromoNotApplicable = errors.New("promo code doesn't apply to this item")
+	ErrPromoExhausted     = errors.New("promo code is fully redeemed")
+	ErrPromoUserLimit     = errors.New("promo code redemption limit is reached")
+	ErrItemNotFound       = errors.New("item is missing from the store")
+)
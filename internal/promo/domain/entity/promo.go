@@ -0,0 +1,56 @@
+package entity
+
+import "time"
+
+const (
+	DiscountPercent = "percent"
+	DiscountFixed   = "fixed"
+)
+
+// PromoCode gives a discount on a single item purchase. Empty Item means the
+// code applies to any item; nil validity bounds and limits aren't enforced.
+type PromoCode struct {
+	ID             uint
+	Code           string
+	DiscountType   string
+	DiscountValue  uint
+	PurchaseTypeID uint
+	Item           string
+	ValidFrom      *time.Time
+	ValidUntil     *time.Time
+	MaxRedemptions *uint
+	MaxPerUser     *uint
+	Redemptions    uint
+}
+
+// IsActive reports whether the code may be redeemed at now.
+func (p *PromoCode) IsActive(now time.Time) bool {
+	if p.ValidFrom != nil && now.Before(*p.ValidFrom) {
+		return false
+	}
+	if p.ValidUntil != nil && !now.Before(*p.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+func (p *PromoCode) AppliesTo(purchaseTypeID uint) bool {
+	return p.PurchaseTypeID == 0 || p.PurchaseTypeID == purchaseTypeID
+}
+
+// Discount returns how many coins are taken off cost. Percent discounts are
+// rounded down and the discount never exceeds cost.
+func (p *PromoCode) Discount(cost uint) uint {
+	var discount uint
+	switch p.DiscountType {
+	case DiscountPercent:
+		discount = cost * p.DiscountValue / 100
+	case DiscountFixed:
+		discount = p.DiscountValue
+	}
+
+	if discount > cost {
+		return cost
+	}
+	return discount
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+type PromoCode struct {
+	ID             uint       `db:"id"`
+	Code           string     `db:"code"`
+	DiscountType   string     `db:"discount_type"`
+	DiscountValue  uint       `db:"discount_value"`
+	PurchaseTypeID *uint      `db:"purchase_type_id"`
+	Item           *string    `db:"item"`
+	ValidFrom      *time.Time `db:"valid_from"`
+	ValidUntil     *time.Time `db:"valid_until"`
+	MaxRedemptions *uint      `db:"max_redemptions"`
+	MaxPerUser     *uint      `db:"max_per_user"`
+	Redemptions    uint       `db:"redemptions"`
+}
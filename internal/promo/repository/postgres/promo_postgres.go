@@ -0,0 +1,274 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/model"
+	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+type PromoPostgresRepository struct {
+	DB     *sql.DB
+	logger *logrus.Logger
+}
+
+func NewPromoPostgresRepository(
+	db *sql.DB,
+	logger *logrus.Logger,
+) *PromoPostgresRepository {
+	return &PromoPostgresRepository{
+		DB:     db,
+		logger: logger,
+	}
+}
+
+// promoCodeColumns are scanned by scanPromoCode. The item name is NULL for
+// codes that apply to any item.
+const promoCodeColumns = `p.id, p.code, p.discount_type, p.discount_value, p.purchase_type_id, pt.name,
+	p.valid_from, p.valid_until, p.max_redemptions, p.max_per_user, p.redemptions
+	FROM promo_codes p
+	LEFT JOIN purchase_types pt ON pt.id = p.purchase_type_id`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPromoCode(row scanner) (*model.PromoCode, error) {
+	promoCode := model.PromoCode{}
+	err := row.Scan(
+		&promoCode.ID,
+		&promoCode.Code,
+		&promoCode.DiscountType,
+		&promoCode.DiscountValue,
+		&promoCode.PurchaseTypeID,
+		&promoCode.Item,
+		&promoCode.ValidFrom,
+		&promoCode.ValidUntil,
+		&promoCode.MaxRedemptions,
+		&promoCode.MaxPerUser,
+		&promoCode.Redemptions,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &promoCode, nil
+}
+
+// Create stores a new promo code. A code scoped to an item is bound to the
+// active item with that name, so renaming the item keeps the code working.
+func (repo *PromoPostgresRepository) Create(
+	ctx context.Context,
+	promoCode *model.PromoCode,
+) (*model.PromoCode, error) {
+	var purchaseTypeID *uint
+	if promoCode.Item != nil {
+		var id uint
+		err := repo.DB.QueryRowContext(
+			ctx,
+			"SELECT id FROM purchase_types WHERE name = $1 AND retired_at IS NULL",
+			*promoCode.Item,
+		).Scan(&id)
+		if err == sql.ErrNoRows {
+			repo.logger.WithField("item", *promoCode.Item).Warn("Trying to create promo code for not existed item")
+			return nil, entity.ErrItemNotFound
+		}
+		if err != nil {
+			repo.logger.WithError(err).Error("Failed to select promo code item")
+			return nil, err
+		}
+		purchaseTypeID = &id
+	}
+
+	createdPromoCode := model.PromoCode{Item: promoCode.Item}
+	err := repo.DB.QueryRowContext(
+		ctx,
+		`INSERT INTO promo_codes (code, discount_type, discount_value, purchase_type_id,
+			valid_from, valid_until, max_redemptions, max_per_user)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (code) DO NOTHING
+		RETURNING id, code, discount_type, discount_value, purchase_type_id,
+			valid_from, valid_until, max_redemptions, max_per_user, redemptions`,
+		promoCode.Code, promoCode.DiscountType, promoCode.DiscountValue, purchaseTypeID,
+		promoCode.ValidFrom, promoCode.ValidUntil, promoCode.MaxRedemptions, promoCode.MaxPerUser,
+	).Scan(
+		&createdPromoCode.ID,
+		&createdPromoCode.Code,
+		&createdPromoCode.DiscountType,
+		&createdPromoCode.DiscountValue,
+		&createdPromoCode.PurchaseTypeID,
+		&createdPromoCode.ValidFrom,
+		&createdPromoCode.ValidUntil,
+		&createdPromoCode.MaxRedemptions,
+		&createdPromoCode.MaxPerUser,
+		&createdPromoCode.Redemptions,
+	)
+	if err == sql.ErrNoRows {
+		repo.logger.WithField("code", promoCode.Code).Warn("Trying to create existing promo code")
+		return nil, entity.ErrPromoExists
+	}
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to insert promo code")
+		return nil, err
+	}
+
+	return &createdPromoCode, nil
+}
+
+func (repo *PromoPostgresRepository) GetByCode(
+	ctx context.Context,
+	code string,
+) (*model.PromoCode, error) {
+	promoCode, err := scanPromoCode(repo.DB.QueryRowContext(
+		ctx,
+		"SELECT "+promoCodeColumns+" WHERE p.code = $1",
+		code,
+	))
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrPromoNotFound
+	}
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select promo code")
+		return nil, err
+	}
+
+	return promoCode, nil
+}
+
+func (repo *PromoPostgresRepository) GetAll(ctx context.Context) ([]*model.PromoCode, error) {
+	rows, err := repo.DB.QueryContext(ctx, "SELECT "+promoCodeColumns+" ORDER BY p.id DESC")
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select promo codes")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting promo codes")
+		}
+	}()
+
+	promoCodes := []*model.PromoCode{}
+	for rows.Next() {
+		promoCode, err := scanPromoCode(rows)
+		if err != nil {
+			repo.logger.WithError(err).Error("Failed to scan promo code")
+			return nil, err
+		}
+
+		promoCodes = append(promoCodes, promoCode)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate promo codes")
+		return nil, err
+	}
+
+	return promoCodes, nil
+}
+
+// Deactivate ends the validity window of the code now. Purchases made with
+// the code keep referring to it.
+func (repo *PromoPostgresRepository) Deactivate(ctx context.Context, code string) error {
+	result, err := repo.DB.ExecContext(
+		ctx,
+		`UPDATE promo_codes SET valid_until = NOW()
+		WHERE code = $1 AND (valid_until IS NULL OR valid_until > NOW())`,
+		code,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to deactivate promo code")
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get deactivated promo codes count")
+		return err
+	}
+	if affected == 0 {
+		repo.logger.WithField("code", code).Warn("Trying to deactivate not existed promo code")
+		return entity.ErrPromoNotFound
+	}
+
+	return nil
+}
+
+// Redeem counts one more redemption of the code within uow. The promo code
+// row stays locked until uow finishes, so concurrent redemptions of the same
+// code are serialized and never exceed the global limit.
+func (repo *PromoPostgresRepository) Redeem(
+	ctx context.Context,
+	uow uowI.Executor,
+	promoCodeID uint,
+) error {
+	result, err := uow.ExecContext(
+		ctx,
+		`UPDATE promo_codes SET redemptions = redemptions + 1
+		WHERE id = $1 AND (max_redemptions IS NULL OR redemptions < max_redemptions)`,
+		promoCodeID,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to redeem promo code")
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get redeemed promo codes count")
+		return err
+	}
+	if affected == 0 {
+		repo.logger.WithField("promo_code_id", promoCodeID).Info("Promo code is fully redeemed")
+		return entity.ErrPromoExhausted
+	}
+
+	return nil
+}
+
+// Release gives back one redemption of the code within uow when a purchase
+// made with it is refunded.
+func (repo *PromoPostgresRepository) Release(
+	ctx context.Context,
+	uow uowI.Executor,
+	promoCodeID uint,
+) error {
+	_, err := uow.ExecContext(
+		ctx,
+		"UPDATE promo_codes SET redemptions = redemptions - 1 WHERE id = $1 AND redemptions > 0",
+		promoCodeID,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to release promo code redemption")
+		return err
+	}
+
+	return nil
+}
+
+// CountUserRedemptions returns how many purchases the user made with the
+// code, refunded ones aside. It is called after Redeem, so the count can't
+// change until uow finishes.
+func (repo *PromoPostgresRepository) CountUserRedemptions(
+	ctx context.Context,
+	uow uowI.Executor,
+	promoCodeID uint,
+	userID uint,
+) (uint, error) {
+	var count uint
+	err := uow.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM purchases
+		WHERE promo_code_id = $1 AND purchaser_id = $2 AND refunded_at IS NULL`,
+		promoCodeID, userID,
+	).Scan(&count)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to count user promo code redemptions")
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,336 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/model"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+var promoCodeColumnNames = []string{
+	"id", "code", "discount_type", "discount_value", "purchase_type_id", "name",
+	"valid_from", "valid_until", "max_redemptions", "max_per_user", "redemptions",
+}
+
+func TestPromoPostgresRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPromoPostgresRepository(db, logrus.New())
+	insertColumns := []string{
+		"id", "code", "discount_type", "discount_value", "purchase_type_id",
+		"valid_from", "valid_until", "max_redemptions", "max_per_user", "redemptions",
+	}
+
+	t.Run("SuccessForItem", func(t *testing.T) {
+		item := "hoodie"
+		itemID := uint(7)
+
+		mock.ExpectQuery(`SELECT id FROM purchase_types WHERE name = \$1 AND retired_at IS NULL`).
+			WithArgs("hoodie").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectQuery(`INSERT INTO promo_codes .* ON CONFLICT \(code\) DO NOTHING RETURNING .*`).
+			WithArgs("HOODIE30", "percent", 30, 7, nil, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows(insertColumns).
+				AddRow(1, "HOODIE30", "percent", 30, 7, nil, nil, nil, nil, 0))
+
+		promoCode, err := repo.Create(context.Background(), &model.PromoCode{
+			Code:          "HOODIE30",
+			DiscountType:  entity.DiscountPercent,
+			DiscountValue: 30,
+			Item:          &item,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.PromoCode{
+			ID:             1,
+			Code:           "HOODIE30",
+			DiscountType:   entity.DiscountPercent,
+			DiscountValue:  30,
+			PurchaseTypeID: &itemID,
+			Item:           &item,
+		}, promoCode)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ItemNotFound", func(t *testing.T) {
+		item := "unknown"
+
+		mock.ExpectQuery(`SELECT id FROM purchase_types`).
+			WithArgs("unknown").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.Create(context.Background(), &model.PromoCode{
+			Code:          "UNKNOWN",
+			DiscountType:  entity.DiscountFixed,
+			DiscountValue: 10,
+			Item:          &item,
+		})
+
+		assert.Equal(t, entity.ErrItemNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CodeExists", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO promo_codes`).
+			WithArgs("WELCOME", "fixed", 50, nil, nil, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows(insertColumns))
+
+		_, err := repo.Create(context.Background(), &model.PromoCode{
+			Code:          "WELCOME",
+			DiscountType:  entity.DiscountFixed,
+			DiscountValue: 50,
+		})
+
+		assert.Equal(t, entity.ErrPromoExists, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPromoPostgresRepository_GetByCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPromoPostgresRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		maxPerUser := uint(1)
+
+		mock.ExpectQuery(`SELECT p.id, p.code, .* WHERE p.code = \$1`).
+			WithArgs("WELCOME").
+			WillReturnRows(sqlmock.NewRows(promoCodeColumnNames).
+				AddRow(2, "WELCOME", "fixed", 50, nil, nil, nil, nil, nil, 1, 3))
+
+		promoCode, err := repo.GetByCode(context.Background(), "WELCOME")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.PromoCode{
+			ID:            2,
+			Code:          "WELCOME",
+			DiscountType:  entity.DiscountFixed,
+			DiscountValue: 50,
+			MaxPerUser:    &maxPerUser,
+			Redemptions:   3,
+		}, promoCode)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT p.id, p.code, .* WHERE p.code = \$1`).
+			WithArgs("MISSING").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetByCode(context.Background(), "MISSING")
+
+		assert.Equal(t, entity.ErrPromoNotFound, err)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT p.id, p.code, .* WHERE p.code = \$1`).
+			WithArgs("WELCOME").
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.GetByCode(context.Background(), "WELCOME")
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestPromoPostgresRepository_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPromoPostgresRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		item := "hoodie"
+		itemID := uint(7)
+
+		mock.ExpectQuery(`SELECT p.id, p.code, .* ORDER BY p.id DESC`).
+			WillReturnRows(sqlmock.NewRows(promoCodeColumnNames).
+				AddRow(2, "WELCOME", "fixed", 50, nil, nil, nil, nil, nil, nil, 0).
+				AddRow(1, "HOODIE30", "percent", 30, 7, "hoodie", nil, nil, nil, nil, 5))
+
+		promoCodes, err := repo.GetAll(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.PromoCode{
+			{ID: 2, Code: "WELCOME", DiscountType: entity.DiscountFixed, DiscountValue: 50},
+			{
+				ID:             1,
+				Code:           "HOODIE30",
+				DiscountType:   entity.DiscountPercent,
+				DiscountValue:  30,
+				PurchaseTypeID: &itemID,
+				Item:           &item,
+				Redemptions:    5,
+			},
+		}, promoCodes)
+	})
+
+	t.Run("QueryError", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT p.id, p.code, .*`).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.GetAll(context.Background())
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestPromoPostgresRepository_Deactivate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPromoPostgresRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE promo_codes SET valid_until = NOW\(\)`).
+			WithArgs("WELCOME").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Deactivate(context.Background(), "WELCOME")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE promo_codes SET valid_until = NOW\(\)`).
+			WithArgs("MISSING").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.Deactivate(context.Background(), "MISSING")
+
+		assert.Equal(t, entity.ErrPromoNotFound, err)
+	})
+}
+
+func TestPromoPostgresRepository_Redeem(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPromoPostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE promo_codes SET redemptions = redemptions \+ 1`).
+			WithArgs(3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Redeem(context.Background(), mockUOW, 3)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Exhausted", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE promo_codes SET redemptions = redemptions \+ 1`).
+			WithArgs(3).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.Redeem(context.Background(), mockUOW, 3)
+
+		assert.Equal(t, entity.ErrPromoExhausted, err)
+	})
+}
+
+func TestPromoPostgresRepository_Release(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPromoPostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE promo_codes SET redemptions = redemptions - 1 WHERE id = \$1 AND redemptions > 0`).
+			WithArgs(3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Release(context.Background(), mockUOW, 3)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectExec(`UPDATE promo_codes SET redemptions`).
+			WithArgs(3).
+			WillReturnError(expectedErr)
+
+		err := repo.Release(context.Background(), mockUOW, 3)
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestPromoPostgresRepository_CountUserRedemptions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPromoPostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM purchases\s+WHERE promo_code_id = \$1 AND purchaser_id = \$2 AND refunded_at IS NULL`).
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		count, err := repo.CountUserRedemptions(context.Background(), mockUOW, 3, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), count)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM purchases`).
+			WithArgs(3, 1).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.CountUserRedemptions(context.Background(), mockUOW, 3, 1)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+type MockUnitOfWork struct {
+	uow.Executor
+	db *sql.DB
+}
+
+func (m *MockUnitOfWork) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return m.db.ExecContext(ctx, query, args...)
+}
+
+func (m *MockUnitOfWork) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return m.db.QueryContext(ctx, query, args...)
+}
+
+func (m *MockUnitOfWork) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return m.db.QueryRowContext(ctx, query, args...)
+}
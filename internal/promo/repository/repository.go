@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/model"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
+)
+
+//go:generate mockgen -source=repository.go -destination=mock_repository/promo_mock.go -package=mock_repository MockPromoRepository
+type PromoRepositoryI interface {
+	Create(ctx context.Context, promoCode *model.PromoCode) (*model.PromoCode, error)
+	GetByCode(ctx context.Context, code string) (*model.PromoCode, error)
+	GetAll(ctx context.Context) ([]*model.PromoCode, error)
+	Deactivate(ctx context.Context, code string) error
+	Redeem(ctx context.Context, uow uow.Executor, promoCodeID uint) error
+	Release(ctx context.Context, uow uow.Executor, promoCodeID uint) error
+	CountUserRedemptions(ctx context.Context, uow uow.Executor, promoCodeID uint, userID uint) (uint, error)
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/dto"
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/entity"
+	promoRepo "github.com/artrsyf/avito-trainee-assignment/internal/promo/repository"
+)
+
+type PromoUsecaseI interface {
+	CreatePromoCode(ctx context.Context, promoCode *entity.PromoCode) (*entity.PromoCode, error)
+	GetPromoCodes(ctx context.Context) ([]*entity.PromoCode, error)
+	DeactivatePromoCode(ctx context.Context, code string) error
+}
+
+// PromoUsecase manages promo codes. Codes are redeemed by purchases, see
+// the purchase usecase.
+type PromoUsecase struct {
+	promoRepo promoRepo.PromoRepositoryI
+	logger    *logrus.Logger
+}
+
+func NewPromoUsecase(
+	promoRepository promoRepo.PromoRepositoryI,
+	logger *logrus.Logger,
+) *PromoUsecase {
+	return &PromoUsecase{
+		promoRepo: promoRepository,
+		logger:    logger,
+	}
+}
+
+func (uc *PromoUsecase) CreatePromoCode(
+	ctx context.Context,
+	promoCode *entity.PromoCode,
+) (*entity.PromoCode, error) {
+	createdPromoCode, err := uc.promoRepo.Create(ctx, dto.PromoCodeEntityToModel(promoCode))
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to create promo code")
+		return nil, err
+	}
+
+	uc.logger.WithField("code", createdPromoCode.Code).Info("Successfully create promo code")
+
+	return dto.PromoCodeModelToEntity(createdPromoCode), nil
+}
+
+func (uc *PromoUsecase) GetPromoCodes(ctx context.Context) ([]*entity.PromoCode, error) {
+	promoCodes, err := uc.promoRepo.GetAll(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to get promo codes")
+		return nil, err
+	}
+
+	promoCodeEntities := make([]*entity.PromoCode, 0, len(promoCodes))
+	for _, promoCode := range promoCodes {
+		promoCodeEntities = append(promoCodeEntities, dto.PromoCodeModelToEntity(promoCode))
+	}
+
+	return promoCodeEntities, nil
+}
+
+func (uc *PromoUsecase) DeactivatePromoCode(ctx context.Context, code string) error {
+	err := uc.promoRepo.Deactivate(ctx, dto.NormalizeCode(code))
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to deactivate promo code")
+		return err
+	}
+
+	uc.logger.WithField("code", code).Info("Successfully deactivate promo code")
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/model"
+	mockPromo "github.com/artrsyf/avito-trainee-assignment/internal/promo/repository/mock_repository"
+)
+
+func TestPromoUsecase_CreatePromoCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPromoRepo := mockPromo.NewMockPromoRepositoryI(ctrl)
+
+	uc := NewPromoUsecase(mockPromoRepo, logrus.New())
+
+	ctx := context.Background()
+	item := "hoodie"
+	itemID := uint(7)
+
+	t.Run("successful creation", func(t *testing.T) {
+		mockPromoRepo.EXPECT().Create(ctx, &model.PromoCode{
+			Code:          "HOODIE30",
+			DiscountType:  entity.DiscountPercent,
+			DiscountValue: 30,
+			Item:          &item,
+		}).Return(&model.PromoCode{
+			ID:             1,
+			Code:           "HOODIE30",
+			DiscountType:   entity.DiscountPercent,
+			DiscountValue:  30,
+			PurchaseTypeID: &itemID,
+			Item:           &item,
+		}, nil)
+
+		promoCode, err := uc.CreatePromoCode(ctx, &entity.PromoCode{
+			Code:          "HOODIE30",
+			DiscountType:  entity.DiscountPercent,
+			DiscountValue: 30,
+			Item:          "hoodie",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &entity.PromoCode{
+			ID:             1,
+			Code:           "HOODIE30",
+			DiscountType:   entity.DiscountPercent,
+			DiscountValue:  30,
+			PurchaseTypeID: 7,
+			Item:           "hoodie",
+		}
+		if !reflect.DeepEqual(promoCode, expected) {
+			t.Errorf("expected %+v, got %+v", expected, promoCode)
+		}
+	})
+
+	t.Run("code already exists", func(t *testing.T) {
+		mockPromoRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil, entity.ErrPromoExists)
+
+		_, err := uc.CreatePromoCode(ctx, &entity.PromoCode{
+			Code:          "HOODIE30",
+			DiscountType:  entity.DiscountFixed,
+			DiscountValue: 10,
+		})
+		if !errors.Is(err, entity.ErrPromoExists) {
+			t.Errorf("expected ErrPromoExists, got %v", err)
+		}
+	})
+}
+
+func TestPromoUsecase_GetPromoCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPromoRepo := mockPromo.NewMockPromoRepositoryI(ctrl)
+
+	uc := NewPromoUsecase(mockPromoRepo, logrus.New())
+
+	ctx := context.Background()
+
+	t.Run("successful listing", func(t *testing.T) {
+		mockPromoRepo.EXPECT().GetAll(ctx).Return([]*model.PromoCode{
+			{ID: 2, Code: "WELCOME", DiscountType: entity.DiscountFixed, DiscountValue: 50, Redemptions: 4},
+		}, nil)
+
+		promoCodes, err := uc.GetPromoCodes(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := []*entity.PromoCode{
+			{ID: 2, Code: "WELCOME", DiscountType: entity.DiscountFixed, DiscountValue: 50, Redemptions: 4},
+		}
+		if !reflect.DeepEqual(promoCodes, expected) {
+			t.Errorf("expected %+v, got %+v", expected, promoCodes)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockPromoRepo.EXPECT().GetAll(ctx).Return(nil, errors.New("db error"))
+
+		_, err := uc.GetPromoCodes(ctx)
+		if err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
+
+func TestPromoUsecase_DeactivatePromoCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPromoRepo := mockPromo.NewMockPromoRepositoryI(ctrl)
+
+	uc := NewPromoUsecase(mockPromoRepo, logrus.New())
+
+	ctx := context.Background()
+
+	t.Run("code is normalized", func(t *testing.T) {
+		mockPromoRepo.EXPECT().Deactivate(ctx, "HOODIE30").Return(nil)
+
+		err := uc.DeactivatePromoCode(ctx, "hoodie30")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("code not found", func(t *testing.T) {
+		mockPromoRepo.EXPECT().Deactivate(ctx, "MISSING").Return(entity.ErrPromoNotFound)
+
+		err := uc.DeactivatePromoCode(ctx, "MISSING")
+		if !errors.Is(err, entity.ErrPromoNotFound) {
+			t.Errorf("expected ErrPromoNotFound, got %v", err)
+		}
+	})
+}
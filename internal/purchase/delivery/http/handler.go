@@ -2,15 +2,22 @@ package http
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 
+	idempotencyDelivery "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/delivery/http"
+	idempotencyDTO "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/dto"
+	idempotencyEntity "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
 	"github.com/artrsyf/avito-trainee-assignment/internal/middleware"
+	promoEntity "github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/entity"
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/dto"
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/entity"
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/usecase"
@@ -18,20 +25,23 @@ import (
 )
 
 type PurchaseHandler struct {
-	purchaseUC usecase.PurchaseUsecaseI
-	validate   *validator.Validate
-	logger     *logrus.Logger
+	purchaseUC         usecase.PurchaseUsecaseI
+	idempotencyHandler *idempotencyDelivery.IdempotencyHandler
+	validate           *validator.Validate
+	logger             *logrus.Logger
 }
 
 func NewPurchaseHandler(
 	purchaseUsecase usecase.PurchaseUsecaseI,
+	idempotencyHandler *idempotencyDelivery.IdempotencyHandler,
 	validate *validator.Validate,
 	logger *logrus.Logger,
 ) *PurchaseHandler {
 	return &PurchaseHandler{
-		purchaseUC: purchaseUsecase,
-		validate:   validate,
-		logger:     logger,
+		purchaseUC:         purchaseUsecase,
+		idempotencyHandler: idempotencyHandler,
+		validate:           validate,
+		logger:             logger,
 	}
 }
 
@@ -57,6 +67,7 @@ func (h *PurchaseHandler) BuyItem(w http.ResponseWriter, r *http.Request) {
 	purchaseItemRequest := &dto.PurchaseItemRequest{
 		PurchaseTypeName: purchaseTypeName,
 		UserID:           customerUserID,
+		PromoCode:        r.URL.Query().Get("promo"),
 	}
 	if err := purchaseItemRequest.ValidatePurchaseRequest(h.validate); err != nil {
 		h.logger.WithError(err).Warn("Failed validation for purchase request")
@@ -68,20 +79,249 @@ func (h *PurchaseHandler) BuyItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.purchaseUC.Create(ctx, purchaseItemRequest)
+	idempotencyRecord, err := idempotencyDTO.NewRecord(
+		r.Header.Get(idempotencyDTO.KeyHeader),
+		customerUserID,
+		r.URL.Path,
+		purchaseItemRequest,
+		http.StatusOK,
+		nil,
+	)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to prepare idempotency key")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad idempotency key"},
+		)
+		return
+	}
+
+	if h.idempotencyHandler.Replay(ctx, w, idempotencyRecord) {
+		return
+	}
+
+	err = h.purchaseUC.Create(ctx, purchaseItemRequest, idempotencyRecord)
 	if err != nil {
 		h.logger.WithFields(logrus.Fields{
 			"error": err.Error(),
 			"stack": string(debug.Stack()),
 		}).Debug("Purchase create error handling")
 
+		h.sendPurchaseError(ctx, w, err, idempotencyRecord)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GiftItem buys an item on the user's balance and puts it into the
+// recipient's inventory.
+func (h *PurchaseHandler) GiftItem(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming GiftItem request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	customerUserID, ok := ctx.Value(middleware.UserIDContextKey).(uint)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	customerUsername, ok := ctx.Value(middleware.UsernameContextKey).(string)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	giftRequest := &dto.GiftRequest{}
+	if err := h.decodeRequestBody(r, giftRequest); err != nil {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+
+	if err := giftRequest.ValidateGiftRequest(h.validate); err != nil {
+		h.logger.WithError(err).Warn("Failed validation for gift request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	if giftRequest.ReceiverUsername == customerUsername {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "gift to yourself is not allowed"},
+		)
+		return
+	}
+
+	idempotencyRecord, err := idempotencyDTO.NewRecord(
+		r.Header.Get(idempotencyDTO.KeyHeader),
+		customerUserID,
+		r.URL.Path,
+		giftRequest,
+		http.StatusOK,
+		nil,
+	)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to prepare idempotency key")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad idempotency key"},
+		)
+		return
+	}
+
+	if h.idempotencyHandler.Replay(ctx, w, idempotencyRecord) {
+		return
+	}
+
+	err = h.purchaseUC.Create(ctx, dto.GiftRequestToPurchaseRequest(giftRequest, customerUserID), idempotencyRecord)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Gift create error handling")
+
+		h.sendPurchaseError(ctx, w, err, idempotencyRecord)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *PurchaseHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming Checkout request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	customerUserID, ok := ctx.Value(middleware.UserIDContextKey).(uint)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	checkoutRequest := &dto.CheckoutRequest{}
+	if err := h.decodeRequestBody(r, checkoutRequest); err != nil {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+	checkoutRequest.UserID = customerUserID
+
+	if err := checkoutRequest.ValidateCheckoutRequest(h.validate); err != nil {
+		h.logger.WithError(err).Warn("Failed validation for checkout request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	idempotencyRecord, err := idempotencyDTO.NewRecord(
+		r.Header.Get(idempotencyDTO.KeyHeader),
+		customerUserID,
+		r.URL.Path,
+		checkoutRequest,
+		http.StatusOK,
+		nil,
+	)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to prepare idempotency key")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad idempotency key"},
+		)
+		return
+	}
+
+	if h.idempotencyHandler.Replay(ctx, w, idempotencyRecord) {
+		return
+	}
+
+	receipt, err := h.purchaseUC.Checkout(ctx, checkoutRequest, idempotencyRecord)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Checkout error handling")
+
+		h.sendPurchaseError(ctx, w, err, idempotencyRecord)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.ReceiptToResponse(receipt))
+}
+
+func (h *PurchaseHandler) GetItems(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming GetItems request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	catalog, err := h.purchaseUC.GetCatalog(ctx)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Catalog getting error handling")
+
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.CatalogToResponse(catalog))
+}
+
+func (h *PurchaseHandler) GetItem(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming GetItem request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	purchaseTypeName := vars["name"]
+
+	catalogItem, err := h.purchaseUC.GetCatalogItem(ctx, purchaseTypeName)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Catalog item getting error handling")
+
 		switch err {
-		case entity.ErrNotEnoughBalance:
-			JSONResponse.JSONResponse(
-				w,
-				http.StatusBadRequest,
-				map[string]string{"errors": "not enough balance"},
-			)
 		case entity.ErrNotExistedProduct:
 			JSONResponse.JSONResponse(
 				w,
@@ -98,5 +338,535 @@ func (h *PurchaseHandler) BuyItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.CatalogItemToResponse(catalogItem))
+}
+
+func (h *PurchaseHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming CreateItem request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	createItemRequest := &dto.CreateItemRequest{}
+	if err := h.decodeRequestBody(r, createItemRequest); err != nil {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+
+	if err := createItemRequest.ValidateCreateItemRequest(h.validate); err != nil {
+		h.logger.WithError(err).Warn("Failed validation for create item request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	catalogItem, err := h.purchaseUC.CreateCatalogItem(ctx, dto.CreateItemRequestToEntity(createItemRequest))
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Catalog item create error handling")
+
+		h.sendCatalogError(w, err)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusCreated, dto.CatalogItemToResponse(catalogItem))
+}
+
+func (h *PurchaseHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming UpdateItem request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	purchaseTypeName := vars["name"]
+
+	updateItemRequest := &dto.UpdateItemRequest{}
+	if err := h.decodeRequestBody(r, updateItemRequest); err != nil {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+
+	if err := updateItemRequest.ValidateUpdateItemRequest(h.validate); err != nil {
+		h.logger.WithError(err).Warn("Failed validation for update item request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	catalogItem, err := h.purchaseUC.UpdateCatalogItem(
+		ctx,
+		purchaseTypeName,
+		dto.UpdateItemRequestToEntity(updateItemRequest),
+	)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Catalog item update error handling")
+
+		h.sendCatalogError(w, err)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.CatalogItemToResponse(catalogItem))
+}
+
+func (h *PurchaseHandler) RestockItem(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming RestockItem request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	purchaseTypeName := vars["name"]
+
+	restockRequest := &dto.RestockRequest{}
+	if err := h.decodeRequestBody(r, restockRequest); err != nil {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+
+	if err := restockRequest.ValidateRestockRequest(h.validate); err != nil {
+		h.logger.WithError(err).Warn("Failed validation for restock request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	catalogItem, err := h.purchaseUC.RestockCatalogItem(ctx, purchaseTypeName, restockRequest.Quantity)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Catalog item restock error handling")
+
+		h.sendCatalogError(w, err)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.CatalogItemToResponse(catalogItem))
+}
+
+func (h *PurchaseHandler) RetireItem(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming RetireItem request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	purchaseTypeName := vars["name"]
+
+	err := h.purchaseUC.RetireCatalogItem(ctx, purchaseTypeName)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Catalog item retire error handling")
+
+		h.sendCatalogError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PurchaseHandler) GetPurchases(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming GetPurchases request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(uint)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	historyFilter, err := dto.PurchaseHistoryQueryToFilter(userID, r.URL.Query())
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed validation for purchase history request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	historyPage, err := h.purchaseUC.GetPurchaseHistory(ctx, historyFilter)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Purchase history error handling")
+
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.PurchaseHistoryPageToResponse(historyPage))
+}
+
+func (h *PurchaseHandler) RefundPurchase(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming RefundPurchase request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(uint)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	purchaseID, ok := h.purchaseIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	record, err := h.purchaseUC.RefundPurchase(ctx, userID, purchaseID)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Purchase refund error handling")
+
+		h.sendOrderError(w, err)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.PurchaseRecordToResponse(record))
+}
+
+func (h *PurchaseHandler) AdminRefundPurchase(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming AdminRefundPurchase request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	purchaseID, ok := h.purchaseIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	record, err := h.purchaseUC.RefundPurchaseByAdmin(ctx, purchaseID)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Admin purchase refund error handling")
+
+		h.sendOrderError(w, err)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.PurchaseRecordToResponse(record))
+}
+
+func (h *PurchaseHandler) AdminGetPurchases(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming AdminGetPurchases request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	historyFilter, err := dto.PurchaseHistoryQueryToFilter(0, r.URL.Query())
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed validation for purchases request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	historyPage, err := h.purchaseUC.GetPurchaseHistory(ctx, historyFilter)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Admin purchases error handling")
+
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.PurchaseHistoryPageToResponse(historyPage))
+}
+
+func (h *PurchaseHandler) UpdatePurchaseStatus(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming UpdatePurchaseStatus request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	purchaseID, ok := h.purchaseIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	statusRequest := &dto.UpdatePurchaseStatusRequest{}
+	if err := h.decodeRequestBody(r, statusRequest); err != nil {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+
+	if err := statusRequest.ValidateUpdatePurchaseStatusRequest(h.validate); err != nil {
+		h.logger.WithError(err).Warn("Failed validation for purchase status request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	record, err := h.purchaseUC.UpdatePurchaseStatus(ctx, purchaseID, statusRequest.Status)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Purchase status update error handling")
+
+		h.sendOrderError(w, err)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.PurchaseRecordToResponse(record))
+}
+
+// purchaseIDFromPath writes 400 and reports false when the {id} path
+// variable is not a purchase id.
+func (h *PurchaseHandler) purchaseIDFromPath(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	purchaseID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil || purchaseID == 0 {
+		h.logger.WithField("id", mux.Vars(r)["id"]).Warn("Invalid purchase id")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "invalid purchase id"},
+		)
+		return 0, false
+	}
+
+	return uint(purchaseID), true
+}
+
+func (h *PurchaseHandler) decodeRequestBody(r *http.Request, dst interface{}) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to read request body")
+		return err
+	}
+	defer func() {
+		if err = r.Body.Close(); err != nil {
+			h.logger.WithError(err).Warn("Failed to close request body")
+		}
+	}()
+
+	return json.Unmarshal(body, dst)
+}
+
+func (h *PurchaseHandler) sendPurchaseError(
+	ctx context.Context,
+	w http.ResponseWriter,
+	err error,
+	idempotencyRecord *idempotencyEntity.Record,
+) {
+	switch err {
+	case idempotencyEntity.ErrAlreadyCreated:
+		if !h.idempotencyHandler.Replay(ctx, w, idempotencyRecord) {
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusInternalServerError,
+				map[string]string{"errors": "internal error"},
+			)
+		}
+	case entity.ErrNotEnoughBalance:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "not enough balance"},
+		)
+	case entity.ErrNotExistedProduct:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusNotFound,
+			map[string]string{"errors": "item not found"},
+		)
+	case entity.ErrUnavailableProduct:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "item is not available"},
+		)
+	case entity.ErrOutOfStock:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "item is out of stock"},
+		)
+	case entity.ErrPurchaseLimit:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "purchase limit for the item is reached"},
+		)
+	case entity.ErrRecipientNotFound:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "can't find such user"},
+		)
+	case entity.ErrSelfGift:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "gift to yourself is not allowed"},
+		)
+	case promoEntity.ErrPromoNotFound:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusNotFound,
+			map[string]string{"errors": "promo code not found"},
+		)
+	case promoEntity.ErrPromoInactive:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "promo code is not active"},
+		)
+	case promoEntity.ErrPromoNotApplicable:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "promo code doesn't apply to item"},
+		)
+	case promoEntity.ErrPromoExhausted:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "promo code is fully redeemed"},
+		)
+	case promoEntity.ErrPromoUserLimit:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "promo code redemption limit reached"},
+		)
+	default:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+	}
+}
+
+func (h *PurchaseHandler) sendCatalogError(w http.ResponseWriter, err error) {
+	switch err {
+	case entity.ErrNotExistedProduct:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusNotFound,
+			map[string]string{"errors": "item not found"},
+		)
+	case entity.ErrProductExists:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "item with such name already exists"},
+		)
+	default:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+	}
+}
+
+func (h *PurchaseHandler) sendOrderError(w http.ResponseWriter, err error) {
+	switch err {
+	case entity.ErrPurchaseNotFound:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusNotFound,
+			map[string]string{"errors": "purchase not found"},
+		)
+	case entity.ErrAlreadyRefunded:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "purchase is already refunded"},
+		)
+	case entity.ErrRefundExpired:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "refund window has expired"},
+		)
+	case entity.ErrRefundDelivered:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "delivered purchase can't be refunded"},
+		)
+	case entity.ErrStatusTransition:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusConflict,
+			map[string]string{"errors": "purchase can't be moved to this status"},
+		)
+	default:
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+	}
 }
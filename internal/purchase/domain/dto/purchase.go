@@ -1,16 +1,28 @@
 package dto
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/model"
 )
 
+// PurchaseItemRequest buys an item for the user, or for RecipientUsername
+// when it is set.
 type PurchaseItemRequest struct {
-	PurchaseTypeName string `validate:"required,min=1"`
-	UserID           uint   `validate:"required,gt=0"`
+	PurchaseTypeName  string `validate:"required,min=1"`
+	UserID            uint   `validate:"required,gt=0"`
+	PromoCode         string `json:",omitempty" validate:"omitempty,max=64"`
+	RecipientUsername string `json:",omitempty" validate:"omitempty,max=50"`
 }
 
 func (req *PurchaseItemRequest) ValidatePurchaseRequest(validate *validator.Validate) error {
@@ -25,6 +37,8 @@ func (req *PurchaseItemRequest) ValidatePurchaseRequest(validate *validator.Vali
 					return errors.New(field + " is required")
 				case "gt":
 					return errors.New(field + " must be greater than 0")
+				case "max":
+					return errors.New(field + " is too long")
 				default:
 					return errors.New(field + " is invalid")
 				}
@@ -41,3 +55,428 @@ func PurchaseItemRequestToEntity(purchaseItemRequest *PurchaseItemRequest) *enti
 		PurchaseTypeName: purchaseItemRequest.PurchaseTypeName,
 	}
 }
+
+type GiftRequest struct {
+	ReceiverUsername string `json:"toUser" validate:"required,min=3,max=50"`
+	Item             string `json:"item" validate:"required,max=255"`
+}
+
+func (req *GiftRequest) ValidateGiftRequest(validate *validator.Validate) error {
+	return validateRequest(validate, req)
+}
+
+func GiftRequestToPurchaseRequest(req *GiftRequest, userID uint) *PurchaseItemRequest {
+	return &PurchaseItemRequest{
+		PurchaseTypeName:  req.Item,
+		UserID:            userID,
+		RecipientUsername: req.ReceiverUsername,
+	}
+}
+
+type CheckoutLineRequest struct {
+	Item     string `json:"item" validate:"required,max=255"`
+	Quantity uint   `json:"quantity" validate:"required,lte=100"`
+}
+
+type CheckoutRequest struct {
+	Items  []CheckoutLineRequest `json:"items" validate:"required,min=1,max=50,dive"`
+	UserID uint                  `json:"-" validate:"required,gt=0"`
+}
+
+type ReceiptLineResponse struct {
+	Item     string `json:"item"`
+	Quantity uint   `json:"quantity"`
+	Price    uint   `json:"price"`
+}
+
+type ReceiptResponse struct {
+	Items []ReceiptLineResponse `json:"items"`
+	Total uint                  `json:"total"`
+}
+
+func (req *CheckoutRequest) ValidateCheckoutRequest(validate *validator.Validate) error {
+	return validateRequest(validate, req)
+}
+
+// CheckoutRequestToEntity merges lines of the same item and sorts them by
+// name, so concurrent baskets lock catalog rows in the same order.
+func CheckoutRequestToEntity(req *CheckoutRequest) *entity.Checkout {
+	quantities := map[string]uint{}
+	for _, line := range req.Items {
+		quantities[line.Item] += line.Quantity
+	}
+
+	checkout := &entity.Checkout{
+		PurchaserID: req.UserID,
+		Lines:       make([]entity.CheckoutLine, 0, len(quantities)),
+	}
+	for name, quantity := range quantities {
+		checkout.Lines = append(checkout.Lines, entity.CheckoutLine{
+			PurchaseTypeName: name,
+			Quantity:         quantity,
+		})
+	}
+	sort.Slice(checkout.Lines, func(i, j int) bool {
+		return checkout.Lines[i].PurchaseTypeName < checkout.Lines[j].PurchaseTypeName
+	})
+
+	return checkout
+}
+
+func ReceiptToResponse(receipt *entity.Receipt) *ReceiptResponse {
+	response := &ReceiptResponse{
+		Items: make([]ReceiptLineResponse, 0, len(receipt.Lines)),
+		Total: receipt.Total,
+	}
+	for _, line := range receipt.Lines {
+		response.Items = append(response.Items, ReceiptLineResponse{
+			Item:     line.PurchaseTypeName,
+			Quantity: line.Quantity,
+			Price:    line.Price,
+		})
+	}
+
+	return response
+}
+
+type CatalogItemResponse struct {
+	Name            string `json:"name"`
+	Cost            uint   `json:"cost"`
+	Available       bool   `json:"available"`
+	Stock           *uint  `json:"stock,omitempty"`
+	UserLimit       *uint  `json:"userLimit,omitempty"`
+	UserLimitPeriod string `json:"userLimitPeriod,omitempty"`
+}
+
+type CatalogResponse struct {
+	Items []CatalogItemResponse `json:"items"`
+}
+
+// UserLimitPeriod is a duration like "2160h", empty period makes the user
+// limit count over all time.
+type CreateItemRequest struct {
+	Name            string `json:"name" validate:"required,max=255,excludesall=/?#"`
+	Cost            *uint  `json:"cost" validate:"required,lte=2147483647"`
+	Available       *bool  `json:"available"`
+	Stock           *uint  `json:"stock" validate:"omitempty,lte=2147483647"`
+	UserLimit       *uint  `json:"userLimit" validate:"omitempty,gt=0,lte=2147483647"`
+	UserLimitPeriod string `json:"userLimitPeriod"`
+}
+
+// UpdateItemRequest removes the user limit when UserLimit is 0 and makes it
+// count over all time when UserLimitPeriod is empty.
+type UpdateItemRequest struct {
+	Name            *string `json:"name" validate:"omitempty,min=1,max=255,excludesall=/?#"`
+	Cost            *uint   `json:"cost" validate:"omitempty,lte=2147483647"`
+	Available       *bool   `json:"available"`
+	UserLimit       *uint   `json:"userLimit" validate:"omitempty,lte=2147483647"`
+	UserLimitPeriod *string `json:"userLimitPeriod"`
+}
+
+type RestockRequest struct {
+	Quantity uint `json:"quantity" validate:"required,lte=1000000"`
+}
+
+func (req *CreateItemRequest) ValidateCreateItemRequest(validate *validator.Validate) error {
+	err := validateRequest(validate, req)
+	if err != nil {
+		return err
+	}
+
+	_, err = parseUserLimitPeriod(req.UserLimitPeriod)
+	return err
+}
+
+func (req *UpdateItemRequest) ValidateUpdateItemRequest(validate *validator.Validate) error {
+	if req.Name == nil && req.Cost == nil && req.Available == nil &&
+		req.UserLimit == nil && req.UserLimitPeriod == nil {
+		return errors.New("nothing to update")
+	}
+
+	err := validateRequest(validate, req)
+	if err != nil {
+		return err
+	}
+
+	if req.UserLimitPeriod != nil {
+		_, err = parseUserLimitPeriod(*req.UserLimitPeriod)
+	}
+	return err
+}
+
+// maxUserLimitPeriod keeps the period in seconds within the INTEGER column.
+const maxUserLimitPeriod = math.MaxInt32 * time.Second
+
+// parseUserLimitPeriod parses a period of whole seconds, empty period is
+// zero.
+func parseUserLimitPeriod(period string) (time.Duration, error) {
+	if period == "" {
+		return 0, nil
+	}
+
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration < time.Second || duration > maxUserLimitPeriod {
+		return 0, errors.New("userLimitPeriod is invalid")
+	}
+
+	return duration.Truncate(time.Second), nil
+}
+
+func (req *RestockRequest) ValidateRestockRequest(validate *validator.Validate) error {
+	return validateRequest(validate, req)
+}
+
+func validateRequest(validate *validator.Validate, req interface{}) error {
+	err := validate.Struct(req)
+	if err != nil {
+		if validationErrs, ok := err.(validator.ValidationErrors); ok {
+			for _, err := range validationErrs {
+				field := err.Field()
+
+				switch err.Tag() {
+				case "required":
+					return errors.New(field + " is required")
+				case "min":
+					return errors.New(field + " is too short")
+				case "max":
+					return errors.New(field + " is too long")
+				case "gt":
+					return errors.New(field + " must be greater than 0")
+				case "lte":
+					return errors.New(field + " is too large")
+				case "excludesall":
+					return errors.New(field + " contains forbidden characters")
+				default:
+					return errors.New(field + " is invalid")
+				}
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func CreateItemRequestToEntity(req *CreateItemRequest) *entity.CatalogItem {
+	// The period is checked by ValidateCreateItemRequest.
+	userLimitPeriod, _ := parseUserLimitPeriod(req.UserLimitPeriod)
+
+	item := &entity.CatalogItem{
+		Name:            req.Name,
+		Cost:            *req.Cost,
+		Available:       true,
+		Stock:           req.Stock,
+		UserLimit:       req.UserLimit,
+		UserLimitPeriod: userLimitPeriod,
+	}
+	if req.Available != nil {
+		item.Available = *req.Available
+	}
+
+	return item
+}
+
+func UpdateItemRequestToEntity(req *UpdateItemRequest) *entity.CatalogItemUpdate {
+	update := &entity.CatalogItemUpdate{
+		Name:      req.Name,
+		Cost:      req.Cost,
+		Available: req.Available,
+		UserLimit: req.UserLimit,
+	}
+	if req.UserLimitPeriod != nil {
+		// The period is checked by ValidateUpdateItemRequest.
+		userLimitPeriod, _ := parseUserLimitPeriod(*req.UserLimitPeriod)
+		update.UserLimitPeriod = &userLimitPeriod
+	}
+
+	return update
+}
+
+func CatalogItemToPurchaseTypeModel(item *entity.CatalogItem) *model.PurchaseType {
+	purchaseType := &model.PurchaseType{
+		Name:      item.Name,
+		Cost:      item.Cost,
+		Available: item.Available,
+		Stock:     item.Stock,
+		UserLimit: item.UserLimit,
+	}
+	if item.UserLimitPeriod > 0 {
+		seconds := uint(item.UserLimitPeriod / time.Second)
+		purchaseType.UserLimitPeriod = &seconds
+	}
+
+	return purchaseType
+}
+
+func PurchaseTypeModelToCatalogItem(purchaseType *model.PurchaseType) *entity.CatalogItem {
+	item := &entity.CatalogItem{
+		Name:      purchaseType.Name,
+		Cost:      purchaseType.Cost,
+		Available: purchaseType.Available,
+		Stock:     purchaseType.Stock,
+		UserLimit: purchaseType.UserLimit,
+	}
+	if purchaseType.UserLimitPeriod != nil {
+		item.UserLimitPeriod = time.Duration(*purchaseType.UserLimitPeriod) * time.Second
+	}
+
+	return item
+}
+
+func PurchaseTypeModelsToCatalog(purchaseTypes []*model.PurchaseType) []*entity.CatalogItem {
+	catalog := make([]*entity.CatalogItem, 0, len(purchaseTypes))
+	for _, purchaseType := range purchaseTypes {
+		catalog = append(catalog, PurchaseTypeModelToCatalogItem(purchaseType))
+	}
+
+	return catalog
+}
+
+func CatalogItemToResponse(item *entity.CatalogItem) CatalogItemResponse {
+	response := CatalogItemResponse{
+		Name:      item.Name,
+		Cost:      item.Cost,
+		Available: item.Available,
+		Stock:     item.Stock,
+		UserLimit: item.UserLimit,
+	}
+	if item.UserLimit != nil && item.UserLimitPeriod > 0 {
+		response.UserLimitPeriod = item.UserLimitPeriod.String()
+	}
+
+	return response
+}
+
+func CatalogToResponse(catalog []*entity.CatalogItem) *CatalogResponse {
+	response := &CatalogResponse{
+		Items: make([]CatalogItemResponse, 0, len(catalog)),
+	}
+	for _, item := range catalog {
+		response.Items = append(response.Items, CatalogItemToResponse(item))
+	}
+
+	return response
+}
+
+const (
+	defaultPurchaseHistoryLimit = 20
+	maxPurchaseHistoryLimit     = 100
+)
+
+type PurchaseRecordResponse struct {
+	ID         uint       `json:"id"`
+	Purchaser  string     `json:"purchaser,omitempty"`
+	Recipient  string     `json:"recipient,omitempty"`
+	Item       string     `json:"item"`
+	Price      uint       `json:"price"`
+	Discount   uint       `json:"discount,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RefundedAt *time.Time `json:"refundedAt,omitempty"`
+}
+
+type UpdatePurchaseStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=ready delivered cancelled"`
+}
+
+func (req *UpdatePurchaseStatusRequest) ValidateUpdatePurchaseStatusRequest(validate *validator.Validate) error {
+	return validateRequest(validate, req)
+}
+
+type PurchaseHistoryResponse struct {
+	Purchases  []PurchaseRecordResponse `json:"purchases"`
+	NextCursor string                   `json:"nextCursor,omitempty"`
+}
+
+// PurchaseHistoryQueryToFilter parses GET /api/purchases and
+// GET /api/admin/purchases query parameters: status, cursor and limit. Zero
+// userID selects purchases of all users.
+func PurchaseHistoryQueryToFilter(userID uint, query url.Values) (*entity.PurchaseHistoryFilter, error) {
+	filter := &entity.PurchaseHistoryFilter{
+		UserID: userID,
+		Status: query.Get("status"),
+		Limit:  defaultPurchaseHistoryLimit,
+	}
+
+	switch filter.Status {
+	case "", entity.StatusPending, entity.StatusReady, entity.StatusDelivered, entity.StatusCancelled:
+	default:
+		return nil, errors.New("status must be pending, ready, delivered or cancelled")
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		parsed, err := strconv.ParseUint(limit, 10, 32)
+		if err != nil || parsed == 0 || parsed > maxPurchaseHistoryLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxPurchaseHistoryLimit)
+		}
+		filter.Limit = uint(parsed)
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		before, err := decodePurchaseHistoryCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.Before = before
+	}
+
+	return filter, nil
+}
+
+func PurchaseRecordToResponse(record *entity.PurchaseRecord) PurchaseRecordResponse {
+	return PurchaseRecordResponse{
+		ID:         record.ID,
+		Purchaser:  record.Purchaser,
+		Recipient:  record.Recipient,
+		Item:       record.PurchaseTypeName,
+		Price:      record.Price,
+		Discount:   record.Discount,
+		Status:     record.Status,
+		CreatedAt:  record.CreatedAt,
+		RefundedAt: record.RefundedAt,
+	}
+}
+
+func PurchaseModelToRecord(purchase *model.Purchase) *entity.PurchaseRecord {
+	return &entity.PurchaseRecord{
+		ID:               purchase.ID,
+		PurchaseTypeName: purchase.ItemName,
+		Price:            purchase.Price,
+		Status:           purchase.Status,
+		CreatedAt:        purchase.CreatedAt,
+		RefundedAt:       purchase.RefundedAt,
+	}
+}
+
+func PurchaseHistoryPageToResponse(page *entity.PurchaseHistoryPage) *PurchaseHistoryResponse {
+	response := &PurchaseHistoryResponse{
+		Purchases: make([]PurchaseRecordResponse, 0, len(page.Items)),
+	}
+
+	for _, record := range page.Items {
+		response.Purchases = append(response.Purchases, PurchaseRecordToResponse(record))
+	}
+
+	if page.NextCursor != nil {
+		response.NextCursor = encodePurchaseHistoryCursor(*page.NextCursor)
+	}
+
+	return response
+}
+
+func encodePurchaseHistoryCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+func decodePurchaseHistoryCursor(cursor string) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, entity.ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseUint(string(raw), 10, 32)
+	if err != nil || id == 0 {
+		return 0, entity.ErrInvalidCursor
+	}
+
+	return uint(id), nil
+}
@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// CatalogItem is a product on sale. Nil Stock means the stock isn't
+// tracked and the item never runs out. Nil UserLimit means a user may buy
+// the item any number of times, otherwise a user may buy at most UserLimit
+// items over the last UserLimitPeriod, zero period means over all time.
+type CatalogItem struct {
+	Name            string
+	Cost            uint
+	Available       bool
+	Stock           *uint
+	UserLimit       *uint
+	UserLimitPeriod time.Duration
+}
+
+// CatalogItemUpdate holds the fields an admin changes, nil fields are kept.
+// Zero UserLimit removes the limit and zero UserLimitPeriod makes it count
+// over all time.
+type CatalogItemUpdate struct {
+	Name            *string
+	Cost            *uint
+	Available       *bool
+	UserLimit       *uint
+	UserLimitPeriod *time.Duration
+}
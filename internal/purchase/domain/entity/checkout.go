@@ -0,0 +1,25 @@
+package entity
+
+// CheckoutLine asks for Quantity units of one catalog item.
+type CheckoutLine struct {
+	PurchaseTypeName string
+	Quantity         uint
+}
+
+// Checkout is a basket bought at once: either every line is purchased or
+// none of them.
+type Checkout struct {
+	PurchaserID uint
+	Lines       []CheckoutLine
+}
+
+type ReceiptLine struct {
+	PurchaseTypeName string
+	Quantity         uint
+	Price            uint
+}
+
+type Receipt struct {
+	Lines []ReceiptLine
+	Total uint
+}
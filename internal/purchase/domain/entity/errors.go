@@ -3,6 +3,18 @@ package entity
 import "errors"
 
 var (
-	ErrNotEnoughBalance  = errors.New("not enough balance")
-	ErrNotExistedProduct = errors.New("item is missing from the store")
+	ErrNotEnoughBalance   = errors.New("not enough balance")
+	ErrNotExistedProduct  = errors.New("item is missing from the store")
+	ErrUnavailableProduct = errors.New("item is not available for purchase")
+	ErrProductExists      = errors.New("item with such name already exists")
+	ErrOutOfStock         = errors.New("item is out of stock")
+	ErrPurchaseLimit      = errors.New("purchase limit for the item is reached")
+	ErrSelfGift           = errors.New("gift to yourself is not allowed")
+	ErrRecipientNotFound  = errors.New("gift recipient doesn't exist")
+	ErrPurchaseNotFound   = errors.New("purchase not found")
+	ErrAlreadyRefunded    = errors.New("purchase is already refunded")
+	ErrRefundExpired      = errors.New("refund window has expired")
+	ErrRefundDelivered    = errors.New("delivered purchase can't be refunded")
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrStatusTransition   = errors.New("purchase can't be moved to this status")
 )
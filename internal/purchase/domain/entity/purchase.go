@@ -1,8 +1,20 @@
 package entity
 
+import "time"
+
+// Purchase keeps the name and the price the item had at checkout, so
+// inventory doesn't depend on later catalog changes. Price is what the
+// customer paid, Discount is what a promo code took off; zero PromoCodeID
+// means no code was used. Non zero RecipientID makes the purchase a gift:
+// the purchaser pays and the item goes to the recipient's inventory.
 type Purchase struct {
 	PurchaserID      uint
+	RecipientID      uint
+	PurchaseTypeID   uint
 	PurchaseTypeName string
+	Price            uint
+	PromoCodeID      uint
+	Discount         uint
 }
 
 type PurchaseGroup struct {
@@ -11,3 +23,65 @@ type PurchaseGroup struct {
 }
 
 type Inventory []PurchaseGroup
+
+// Fulfilment statuses of a purchase. A purchase starts pending, is made
+// ready for pickup and then delivered; cancelling refunds it.
+const (
+	StatusPending   = "pending"
+	StatusReady     = "ready"
+	StatusDelivered = "delivered"
+	StatusCancelled = "cancelled"
+)
+
+var statusTransitions = map[string][]string{
+	StatusPending: {StatusReady, StatusCancelled},
+	StatusReady:   {StatusDelivered, StatusCancelled},
+}
+
+// CanRefundByUser reports whether the purchaser may refund a purchase in
+// status: only until the item is delivered.
+func CanRefundByUser(status string) bool {
+	return status == StatusPending || status == StatusReady
+}
+
+// CanChangeStatus reports whether a purchase in status from may be moved to
+// status to. Delivered and cancelled purchases are final.
+func CanChangeStatus(from, to string) bool {
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PurchaseRecord is a single purchase in the purchase history. Refunded
+// purchases stay in the history with RefundedAt set. Recipient is empty
+// unless the purchase is a gift.
+type PurchaseRecord struct {
+	ID               uint
+	Purchaser        string
+	Recipient        string
+	PurchaseTypeName string
+	Price            uint
+	Discount         uint
+	Status           string
+	CreatedAt        time.Time
+	RefundedAt       *time.Time
+}
+
+// PurchaseHistoryFilter selects purchases older than Before, zero Before
+// means from the newest one. Purchases of a user include gifts they made
+// and received. Zero UserID selects purchases of all users and empty Status
+// selects purchases in any status.
+type PurchaseHistoryFilter struct {
+	UserID uint
+	Status string
+	Before uint
+	Limit  uint
+}
+
+type PurchaseHistoryPage struct {
+	Items      []*PurchaseRecord
+	NextCursor *uint
+}
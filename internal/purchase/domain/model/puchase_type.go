@@ -1,7 +1,11 @@
 package model
 
 type PurchaseType struct {
-	ID   uint   `db:"id"`
-	Name string `db:"name"`
-	Cost uint   `db:"cost"`
+	ID              uint   `db:"id"`
+	Name            string `db:"name"`
+	Cost            uint   `db:"cost"`
+	Available       bool   `db:"available"`
+	Stock           *uint  `db:"stock"`
+	UserLimit       *uint  `db:"user_limit"`
+	UserLimitPeriod *uint  `db:"user_limit_period"`
 }
@@ -1,7 +1,24 @@
 package model
 
+import "time"
+
 type Purchase struct {
-	ID             uint `db:"id"`
-	PurchaserID    uint `db:"purchaser_id"`
-	PurchaseTypeID uint `db:"purchase_type_id"`
+	ID             uint       `db:"id"`
+	PurchaserID    uint       `db:"purchaser_id"`
+	PurchaseTypeID uint       `db:"purchase_type_id"`
+	ItemName       string     `db:"item_name"`
+	Price          uint       `db:"price"`
+	Status         string     `db:"status"`
+	CreatedAt      time.Time  `db:"created_at"`
+	RefundedAt     *time.Time `db:"refunded_at"`
+	PromoCodeID    uint       `db:"promo_code_id"`
+	RecipientID    uint       `db:"recipient_id"`
+}
+
+// CoinPortion is a part of the purchase price taken from one coin lot.
+// Nil ExpiresAt means the coins never expire.
+type CoinPortion struct {
+	PurchaseID uint       `db:"purchase_id"`
+	Amount     uint       `db:"amount"`
+	ExpiresAt  *time.Time `db:"expires_at"`
 }
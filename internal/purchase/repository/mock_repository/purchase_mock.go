@@ -7,6 +7,7 @@ package mock_repository
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entity "github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/entity"
 	model "github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/model"
@@ -37,6 +38,21 @@ func (m *MockPurchaseRepositoryI) EXPECT() *MockPurchaseRepositoryIMockRecorder
 	return m.recorder
 }
 
+// CountUserPurchases mocks base method.
+func (m *MockPurchaseRepositoryI) CountUserPurchases(ctx context.Context, uow uow.Executor, userID uint, purchaseTypeID uint, period time.Duration) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUserPurchases", ctx, uow, userID, purchaseTypeID, period)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUserPurchases indicates an expected call of CountUserPurchases.
+func (mr *MockPurchaseRepositoryIMockRecorder) CountUserPurchases(ctx, uow, userID, purchaseTypeID, period interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUserPurchases", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).CountUserPurchases), ctx, uow, userID, purchaseTypeID, period)
+}
+
 // Create mocks base method.
 func (m *MockPurchaseRepositoryI) Create(ctx context.Context, uow uow.Executor, purchase *entity.Purchase) (*model.Purchase, error) {
 	m.ctrl.T.Helper()
@@ -52,6 +68,109 @@ func (mr *MockPurchaseRepositoryIMockRecorder) Create(ctx, uow, purchase interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).Create), ctx, uow, purchase)
 }
 
+// CreateBatch mocks base method.
+func (m *MockPurchaseRepositoryI) CreateBatch(ctx context.Context, uow uow.Executor, purchase *entity.Purchase, quantity uint) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, uow, purchase, quantity)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockPurchaseRepositoryIMockRecorder) CreateBatch(ctx, uow, purchase, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).CreateBatch), ctx, uow, purchase, quantity)
+}
+
+// CreateCoinPortions mocks base method.
+func (m *MockPurchaseRepositoryI) CreateCoinPortions(ctx context.Context, uow uow.Executor, portions []*model.CoinPortion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCoinPortions", ctx, uow, portions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCoinPortions indicates an expected call of CreateCoinPortions.
+func (mr *MockPurchaseRepositoryIMockRecorder) CreateCoinPortions(ctx, uow, portions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCoinPortions", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).CreateCoinPortions), ctx, uow, portions)
+}
+
+// CreateProduct mocks base method.
+func (m *MockPurchaseRepositoryI) CreateProduct(ctx context.Context, purchaseType *model.PurchaseType) (*model.PurchaseType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateProduct", ctx, purchaseType)
+	ret0, _ := ret[0].(*model.PurchaseType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateProduct indicates an expected call of CreateProduct.
+func (mr *MockPurchaseRepositoryIMockRecorder) CreateProduct(ctx, purchaseType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProduct", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).CreateProduct), ctx, purchaseType)
+}
+
+// DecrementStock mocks base method.
+func (m *MockPurchaseRepositoryI) DecrementStock(ctx context.Context, uow uow.Executor, purchaseTypeID uint, quantity uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecrementStock", ctx, uow, purchaseTypeID, quantity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecrementStock indicates an expected call of DecrementStock.
+func (mr *MockPurchaseRepositoryIMockRecorder) DecrementStock(ctx, uow, purchaseTypeID, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementStock", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).DecrementStock), ctx, uow, purchaseTypeID, quantity)
+}
+
+// GetCoinPortions mocks base method.
+func (m *MockPurchaseRepositoryI) GetCoinPortions(ctx context.Context, uow uow.Executor, purchaseID uint) ([]*model.CoinPortion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCoinPortions", ctx, uow, purchaseID)
+	ret0, _ := ret[0].([]*model.CoinPortion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCoinPortions indicates an expected call of GetCoinPortions.
+func (mr *MockPurchaseRepositoryIMockRecorder) GetCoinPortions(ctx, uow, purchaseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoinPortions", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).GetCoinPortions), ctx, uow, purchaseID)
+}
+
+// GetForUpdate mocks base method.
+func (m *MockPurchaseRepositoryI) GetForUpdate(ctx context.Context, uow uow.Executor, purchaseID uint) (*model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForUpdate", ctx, uow, purchaseID)
+	ret0, _ := ret[0].(*model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForUpdate indicates an expected call of GetForUpdate.
+func (mr *MockPurchaseRepositoryIMockRecorder) GetForUpdate(ctx, uow, purchaseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForUpdate", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).GetForUpdate), ctx, uow, purchaseID)
+}
+
+// GetHistory mocks base method.
+func (m *MockPurchaseRepositoryI) GetHistory(ctx context.Context, filter *entity.PurchaseHistoryFilter) ([]*entity.PurchaseRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, filter)
+	ret0, _ := ret[0].([]*entity.PurchaseRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockPurchaseRepositoryIMockRecorder) GetHistory(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).GetHistory), ctx, filter)
+}
+
 // GetProductByType mocks base method.
 func (m *MockPurchaseRepositoryI) GetProductByType(ctx context.Context, purchaseTypeName string) (*model.PurchaseType, error) {
 	m.ctrl.T.Helper()
@@ -67,6 +186,21 @@ func (mr *MockPurchaseRepositoryIMockRecorder) GetProductByType(ctx, purchaseTyp
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductByType", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).GetProductByType), ctx, purchaseTypeName)
 }
 
+// GetProducts mocks base method.
+func (m *MockPurchaseRepositoryI) GetProducts(ctx context.Context) ([]*model.PurchaseType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProducts", ctx)
+	ret0, _ := ret[0].([]*model.PurchaseType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProducts indicates an expected call of GetProducts.
+func (mr *MockPurchaseRepositoryIMockRecorder) GetProducts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProducts", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).GetProducts), ctx)
+}
+
 // GetPurchasesByUserID mocks base method.
 func (m *MockPurchaseRepositoryI) GetPurchasesByUserID(ctx context.Context, userID uint) (entity.Inventory, error) {
 	m.ctrl.T.Helper()
@@ -81,3 +215,91 @@ func (mr *MockPurchaseRepositoryIMockRecorder) GetPurchasesByUserID(ctx, userID
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchasesByUserID", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).GetPurchasesByUserID), ctx, userID)
 }
+
+// MarkRefunded mocks base method.
+func (m *MockPurchaseRepositoryI) MarkRefunded(ctx context.Context, uow uow.Executor, purchaseID uint) (*model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRefunded", ctx, uow, purchaseID)
+	ret0, _ := ret[0].(*model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkRefunded indicates an expected call of MarkRefunded.
+func (mr *MockPurchaseRepositoryIMockRecorder) MarkRefunded(ctx, uow, purchaseID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRefunded", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).MarkRefunded), ctx, uow, purchaseID)
+}
+
+// RestockProduct mocks base method.
+func (m *MockPurchaseRepositoryI) RestockProduct(ctx context.Context, name string, quantity uint) (*model.PurchaseType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestockProduct", ctx, name, quantity)
+	ret0, _ := ret[0].(*model.PurchaseType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestockProduct indicates an expected call of RestockProduct.
+func (mr *MockPurchaseRepositoryIMockRecorder) RestockProduct(ctx, name, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestockProduct", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).RestockProduct), ctx, name, quantity)
+}
+
+// RetireProduct mocks base method.
+func (m *MockPurchaseRepositoryI) RetireProduct(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetireProduct", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetireProduct indicates an expected call of RetireProduct.
+func (mr *MockPurchaseRepositoryIMockRecorder) RetireProduct(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetireProduct", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).RetireProduct), ctx, name)
+}
+
+// ReturnStock mocks base method.
+func (m *MockPurchaseRepositoryI) ReturnStock(ctx context.Context, uow uow.Executor, purchaseTypeID uint, quantity uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReturnStock", ctx, uow, purchaseTypeID, quantity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReturnStock indicates an expected call of ReturnStock.
+func (mr *MockPurchaseRepositoryIMockRecorder) ReturnStock(ctx, uow, purchaseTypeID, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReturnStock", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).ReturnStock), ctx, uow, purchaseTypeID, quantity)
+}
+
+// UpdateProduct mocks base method.
+func (m *MockPurchaseRepositoryI) UpdateProduct(ctx context.Context, name string, update *entity.CatalogItemUpdate) (*model.PurchaseType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProduct", ctx, name, update)
+	ret0, _ := ret[0].(*model.PurchaseType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProduct indicates an expected call of UpdateProduct.
+func (mr *MockPurchaseRepositoryIMockRecorder) UpdateProduct(ctx, name, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).UpdateProduct), ctx, name, update)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockPurchaseRepositoryI) UpdateStatus(ctx context.Context, uow uow.Executor, purchaseID uint, status string) (*model.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, uow, purchaseID, status)
+	ret0, _ := ret[0].(*model.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockPurchaseRepositoryIMockRecorder) UpdateStatus(ctx, uow, purchaseID, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockPurchaseRepositoryI)(nil).UpdateStatus), ctx, uow, purchaseID, status)
+}
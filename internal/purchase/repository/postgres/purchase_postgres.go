@@ -3,7 +3,13 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/entity"
@@ -29,53 +35,183 @@ func (repo *PurchasePostgresRepository) Create(
 	purchase *entity.Purchase,
 ) (*model.Purchase, error) {
 	createdPurchase := model.Purchase{}
-	var purchaseTypeID int
 
-	err := repo.DB.QueryRowContext(
+	err := uow.QueryRowContext(
 		ctx,
-		"SELECT id FROM purchase_types WHERE name = $1",
-		purchase.PurchaseTypeName,
-	).Scan(&purchaseTypeID)
-	if err == sql.ErrNoRows {
-		repo.logger.WithError(err).Error("Failed to select not existed product")
-		return nil, entity.ErrNotExistedProduct
+		`INSERT INTO purchases (purchaser_id, purchase_type_id, item_name, price, promo_code_id, discount,
+			recipient_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, NULLIF($7, 0))
+		RETURNING id, purchaser_id, purchase_type_id, item_name, price`,
+		purchase.PurchaserID, purchase.PurchaseTypeID, purchase.PurchaseTypeName, purchase.Price,
+		purchase.PromoCodeID, purchase.Discount, purchase.RecipientID,
+	).Scan(
+		&createdPurchase.ID,
+		&createdPurchase.PurchaserID,
+		&createdPurchase.PurchaseTypeID,
+		&createdPurchase.ItemName,
+		&createdPurchase.Price,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to insert purchase")
+		return nil, err
 	}
+
+	return &createdPurchase, nil
+}
+
+// CreateBatch inserts quantity identical purchases with one statement and
+// returns their ids in ascending order.
+func (repo *PurchasePostgresRepository) CreateBatch(
+	ctx context.Context,
+	uow uowI.Executor,
+	purchase *entity.Purchase,
+	quantity uint,
+) ([]uint, error) {
+	rows, err := uow.QueryContext(
+		ctx,
+		`INSERT INTO purchases (purchaser_id, purchase_type_id, item_name, price)
+		SELECT $1, $2, $3, $4 FROM generate_series(1, $5)
+		RETURNING id`,
+		purchase.PurchaserID, purchase.PurchaseTypeID, purchase.PurchaseTypeName, purchase.Price,
+		quantity,
+	)
 	if err != nil {
-		repo.logger.WithError(err).Error("Failed to select purchase type id")
+		repo.logger.WithError(err).Error("Failed to insert purchase batch")
 		return nil, err
 	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows inserting purchase batch")
+		}
+	}()
 
-	err = uow.QueryRowContext(
+	ids := make([]uint, 0, quantity)
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			repo.logger.WithError(err).Error("Failed to scan inserted purchase id")
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate inserted purchase ids")
+		return nil, err
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids, nil
+}
+
+// CreateCoinPortions stores the coin portions purchases were paid with in
+// one statement.
+func (repo *PurchasePostgresRepository) CreateCoinPortions(
+	ctx context.Context,
+	uow uowI.Executor,
+	portions []*model.CoinPortion,
+) error {
+	if len(portions) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(portions))
+	args := make([]interface{}, 0, 3*len(portions))
+	for _, portion := range portions {
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d)", len(args)+1, len(args)+2, len(args)+3))
+		args = append(args, portion.PurchaseID, portion.Amount, portion.ExpiresAt)
+	}
+
+	_, err := uow.ExecContext(
 		ctx,
-		`INSERT INTO purchases (purchaser_id, purchase_type_id) 
-		VALUES ($1, $2) 
-		RETURNING id, purchaser_id, purchase_type_id`,
-		purchase.PurchaserID, purchaseTypeID,
-	).Scan(
-		&createdPurchase.ID,
-		&createdPurchase.PurchaserID,
-		&createdPurchase.PurchaseTypeID,
+		"INSERT INTO purchase_coin_portions (purchase_id, amount, expires_at) VALUES "+strings.Join(values, ", "),
+		args...,
 	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to insert purchase coin portions")
+		return err
+	}
 
+	return nil
+}
+
+func (repo *PurchasePostgresRepository) GetCoinPortions(
+	ctx context.Context,
+	uow uowI.Executor,
+	purchaseID uint,
+) ([]*model.CoinPortion, error) {
+	rows, err := uow.QueryContext(
+		ctx,
+		`SELECT purchase_id, amount, expires_at FROM purchase_coin_portions
+		WHERE purchase_id = $1
+		ORDER BY id`,
+		purchaseID,
+	)
 	if err != nil {
-		repo.logger.WithError(err).Error("Failed to insert purchase")
+		repo.logger.WithError(err).Error("Failed to select purchase coin portions")
 		return nil, err
 	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting purchase coin portions")
+		}
+	}()
 
-	return &createdPurchase, nil
+	portions := []*model.CoinPortion{}
+	for rows.Next() {
+		portion := model.CoinPortion{}
+		if err := rows.Scan(&portion.PurchaseID, &portion.Amount, &portion.ExpiresAt); err != nil {
+			repo.logger.WithError(err).Error("Failed to scan purchase coin portion")
+			return nil, err
+		}
+
+		portions = append(portions, &portion)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate purchase coin portions")
+		return nil, err
+	}
+
+	return portions, nil
+}
+
+// purchaseTypeColumns are scanned by scanPurchaseType.
+const purchaseTypeColumns = "id, name, cost, available, stock, user_limit, user_limit_period"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPurchaseType(row scanner) (*model.PurchaseType, error) {
+	purchaseType := model.PurchaseType{}
+	err := row.Scan(
+		&purchaseType.ID,
+		&purchaseType.Name,
+		&purchaseType.Cost,
+		&purchaseType.Available,
+		&purchaseType.Stock,
+		&purchaseType.UserLimit,
+		&purchaseType.UserLimitPeriod,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &purchaseType, nil
 }
 
 func (repo *PurchasePostgresRepository) GetProductByType(
 	ctx context.Context,
 	purchaseTypeName string,
 ) (*model.PurchaseType, error) {
-	purchaseType := model.PurchaseType{}
-
-	err := repo.DB.QueryRowContext(
+	purchaseType, err := scanPurchaseType(repo.DB.QueryRowContext(
 		ctx,
-		"SELECT id, name, cost FROM purchase_types WHERE name = $1",
+		"SELECT "+purchaseTypeColumns+" FROM purchase_types WHERE name = $1 AND retired_at IS NULL",
 		purchaseTypeName,
-	).Scan(&purchaseType.ID, &purchaseType.Name, &purchaseType.Cost)
+	))
 	if err == sql.ErrNoRows {
 		repo.logger.WithError(err).Error("Failed to select not existed product")
 		return nil, entity.ErrNotExistedProduct
@@ -85,19 +221,264 @@ func (repo *PurchasePostgresRepository) GetProductByType(
 		return nil, err
 	}
 
-	return &purchaseType, nil
+	return purchaseType, nil
+}
+
+func (repo *PurchasePostgresRepository) GetProducts(
+	ctx context.Context,
+) ([]*model.PurchaseType, error) {
+	rows, err := repo.DB.QueryContext(
+		ctx,
+		"SELECT "+purchaseTypeColumns+" FROM purchase_types WHERE retired_at IS NULL ORDER BY name",
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select products")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting products")
+		}
+	}()
+
+	products := []*model.PurchaseType{}
+	for rows.Next() {
+		purchaseType, err := scanPurchaseType(rows)
+		if err != nil {
+			repo.logger.WithError(err).Error("Failed to select product")
+			return nil, err
+		}
+
+		products = append(products, purchaseType)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate products")
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// uniqueViolation is the Postgres error code for a unique constraint conflict.
+const uniqueViolation = "23505"
+
+func (repo *PurchasePostgresRepository) CreateProduct(
+	ctx context.Context,
+	purchaseType *model.PurchaseType,
+) (*model.PurchaseType, error) {
+	createdPurchaseType, err := scanPurchaseType(repo.DB.QueryRowContext(
+		ctx,
+		`INSERT INTO purchase_types (name, cost, available, stock, user_limit, user_limit_period)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (name) WHERE retired_at IS NULL DO NOTHING
+		RETURNING `+purchaseTypeColumns,
+		purchaseType.Name, purchaseType.Cost, purchaseType.Available, purchaseType.Stock,
+		purchaseType.UserLimit, purchaseType.UserLimitPeriod,
+	))
+	if err == sql.ErrNoRows {
+		repo.logger.WithField("name", purchaseType.Name).Warn("Trying to create existing product")
+		return nil, entity.ErrProductExists
+	}
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to insert product")
+		return nil, err
+	}
+
+	return createdPurchaseType, nil
+}
+
+func (repo *PurchasePostgresRepository) UpdateProduct(
+	ctx context.Context,
+	name string,
+	update *entity.CatalogItemUpdate,
+) (*model.PurchaseType, error) {
+	setClauses := []string{}
+	args := []interface{}{}
+
+	if update.Name != nil {
+		args = append(args, *update.Name)
+		setClauses = append(setClauses, fmt.Sprintf("name = $%d", len(args)))
+	}
+	if update.Cost != nil {
+		args = append(args, *update.Cost)
+		setClauses = append(setClauses, fmt.Sprintf("cost = $%d", len(args)))
+	}
+	if update.Available != nil {
+		args = append(args, *update.Available)
+		setClauses = append(setClauses, fmt.Sprintf("available = $%d", len(args)))
+	}
+	if update.UserLimit != nil {
+		args = append(args, *update.UserLimit)
+		setClauses = append(setClauses, fmt.Sprintf("user_limit = NULLIF($%d, 0)", len(args)))
+	}
+	if update.UserLimitPeriod != nil {
+		args = append(args, uint(*update.UserLimitPeriod/time.Second))
+		setClauses = append(setClauses, fmt.Sprintf("user_limit_period = NULLIF($%d, 0)", len(args)))
+	}
+
+	if len(setClauses) == 0 {
+		return repo.GetProductByType(ctx, name)
+	}
+
+	args = append(args, name)
+	query := fmt.Sprintf(
+		`UPDATE purchase_types SET %s
+		WHERE name = $%d AND retired_at IS NULL
+		RETURNING %s`,
+		strings.Join(setClauses, ", "), len(args), purchaseTypeColumns,
+	)
+
+	updatedPurchaseType, err := scanPurchaseType(repo.DB.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		repo.logger.WithField("name", name).Warn("Trying to update not existed product")
+		return nil, entity.ErrNotExistedProduct
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		repo.logger.WithField("name", name).Warn("Trying to rename product to existing name")
+		return nil, entity.ErrProductExists
+	}
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to update product")
+		return nil, err
+	}
+
+	return updatedPurchaseType, nil
+}
+
+// RestockProduct adds quantity to the product stock. A product without
+// stock tracking starts being tracked from zero.
+func (repo *PurchasePostgresRepository) RestockProduct(
+	ctx context.Context,
+	name string,
+	quantity uint,
+) (*model.PurchaseType, error) {
+	restockedPurchaseType, err := scanPurchaseType(repo.DB.QueryRowContext(
+		ctx,
+		`UPDATE purchase_types SET stock = COALESCE(stock, 0) + $1
+		WHERE name = $2 AND retired_at IS NULL
+		RETURNING `+purchaseTypeColumns,
+		quantity, name,
+	))
+	if err == sql.ErrNoRows {
+		repo.logger.WithField("name", name).Warn("Trying to restock not existed product")
+		return nil, entity.ErrNotExistedProduct
+	}
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to restock product")
+		return nil, err
+	}
+
+	return restockedPurchaseType, nil
 }
 
+// CountUserPurchases returns how many items of the purchase type the user
+// got over the last period and didn't return, zero period counts all
+// purchases. Gifts count for their recipient, not for the giver. It doesn't
+// lock anything, callers serialize purchases of the user within uow.
+func (repo *PurchasePostgresRepository) CountUserPurchases(
+	ctx context.Context,
+	uow uowI.Executor,
+	userID uint,
+	purchaseTypeID uint,
+	period time.Duration,
+) (uint, error) {
+	query := `SELECT COUNT(*) FROM purchases
+		WHERE ((purchaser_id = $1 AND recipient_id IS NULL) OR recipient_id = $1)
+			AND purchase_type_id = $2 AND refunded_at IS NULL`
+	args := []interface{}{userID, purchaseTypeID}
+	if period > 0 {
+		args = append(args, uint(period/time.Second))
+		query += fmt.Sprintf(" AND created_at > NOW() - $%d * INTERVAL '1 second'", len(args))
+	}
+
+	var count uint
+	err := uow.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to count user purchases")
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// DecrementStock takes quantity items from the product stock. Products
+// without stock tracking are never out of stock.
+func (repo *PurchasePostgresRepository) DecrementStock(
+	ctx context.Context,
+	uow uowI.Executor,
+	purchaseTypeID uint,
+	quantity uint,
+) error {
+	result, err := uow.ExecContext(
+		ctx,
+		`UPDATE purchase_types SET stock = stock - $2
+		WHERE id = $1 AND (stock IS NULL OR stock >= $2)`,
+		purchaseTypeID, quantity,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to decrement product stock")
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get affected rows decrementing stock")
+		return err
+	}
+
+	if rowsAffected == 0 {
+		repo.logger.WithField("purchase_type_id", purchaseTypeID).Info("Product is out of stock")
+		return entity.ErrOutOfStock
+	}
+
+	return nil
+}
+
+// RetireProduct soft-deletes the product: it disappears from the catalog and
+// can't be bought, but purchases keep referencing it.
+func (repo *PurchasePostgresRepository) RetireProduct(
+	ctx context.Context,
+	name string,
+) error {
+	result, err := repo.DB.ExecContext(
+		ctx,
+		"UPDATE purchase_types SET retired_at = NOW() WHERE name = $1 AND retired_at IS NULL",
+		name,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to retire product")
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get affected rows retiring product")
+		return err
+	}
+
+	if rowsAffected == 0 {
+		repo.logger.WithField("name", name).Warn("Trying to retire not existed product")
+		return entity.ErrNotExistedProduct
+	}
+
+	return nil
+}
+
+// GetPurchasesByUserID returns the items the user owns: their own purchases
+// and gifts they received, but not gifts they made.
 func (repo *PurchasePostgresRepository) GetPurchasesByUserID(
 	ctx context.Context,
 	userID uint,
 ) (entity.Inventory, error) {
 	rows, err := repo.DB.QueryContext(ctx, `
-		SELECT pt.name, COUNT(p.id) as quantity
-		FROM purchases p
-		JOIN purchase_types pt ON p.purchase_type_id = pt.id
-		WHERE p.purchaser_id = $1
-		GROUP BY pt.name`, userID)
+		SELECT item_name, COUNT(id) as quantity
+		FROM purchases
+		WHERE ((purchaser_id = $1 AND recipient_id IS NULL) OR recipient_id = $1)
+			AND refunded_at IS NULL
+		GROUP BY item_name`, userID)
 	if err != nil {
 		repo.logger.WithError(err).Error("Failed to select user inventory")
 		return nil, err
@@ -122,3 +503,195 @@ func (repo *PurchasePostgresRepository) GetPurchasesByUserID(
 
 	return inventory, nil
 }
+
+// purchaseColumns are scanned by scanPurchase. Purchase type id is zero
+// when the type has been deleted, recipient id is zero unless it's a gift.
+const purchaseColumns = `id, purchaser_id, COALESCE(purchase_type_id, 0), item_name, price,
+	status, created_at, refunded_at, COALESCE(promo_code_id, 0), COALESCE(recipient_id, 0)`
+
+func scanPurchase(row *sql.Row) (*model.Purchase, error) {
+	purchase := model.Purchase{}
+	err := row.Scan(
+		&purchase.ID,
+		&purchase.PurchaserID,
+		&purchase.PurchaseTypeID,
+		&purchase.ItemName,
+		&purchase.Price,
+		&purchase.Status,
+		&purchase.CreatedAt,
+		&purchase.RefundedAt,
+		&purchase.PromoCodeID,
+		&purchase.RecipientID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &purchase, nil
+}
+
+// GetForUpdate locks the purchase row until the unit of work ends.
+func (repo *PurchasePostgresRepository) GetForUpdate(
+	ctx context.Context,
+	uow uowI.Executor,
+	purchaseID uint,
+) (*model.Purchase, error) {
+	purchase, err := scanPurchase(uow.QueryRowContext(
+		ctx,
+		"SELECT "+purchaseColumns+" FROM purchases WHERE id = $1 FOR UPDATE",
+		purchaseID,
+	))
+	if err == sql.ErrNoRows {
+		repo.logger.WithField("purchase_id", purchaseID).Warn("Trying to get not existed purchase")
+		return nil, entity.ErrPurchaseNotFound
+	}
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select purchase for update")
+		return nil, err
+	}
+
+	return purchase, nil
+}
+
+// MarkRefunded sets the refund time of the purchase. A purchase refunded
+// before it was handed out is cancelled as well, a delivered one keeps its
+// status.
+func (repo *PurchasePostgresRepository) MarkRefunded(
+	ctx context.Context,
+	uow uowI.Executor,
+	purchaseID uint,
+) (*model.Purchase, error) {
+	purchase, err := scanPurchase(uow.QueryRowContext(
+		ctx,
+		`UPDATE purchases SET refunded_at = NOW(),
+			status = CASE WHEN status = 'delivered' THEN status ELSE 'cancelled' END
+		WHERE id = $1 AND refunded_at IS NULL
+		RETURNING `+purchaseColumns,
+		purchaseID,
+	))
+	if err == sql.ErrNoRows {
+		repo.logger.WithField("purchase_id", purchaseID).Warn("Trying to refund purchase twice")
+		return nil, entity.ErrAlreadyRefunded
+	}
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to mark purchase refunded")
+		return nil, err
+	}
+
+	return purchase, nil
+}
+
+func (repo *PurchasePostgresRepository) UpdateStatus(
+	ctx context.Context,
+	uow uowI.Executor,
+	purchaseID uint,
+	status string,
+) (*model.Purchase, error) {
+	purchase, err := scanPurchase(uow.QueryRowContext(
+		ctx,
+		"UPDATE purchases SET status = $2 WHERE id = $1 RETURNING "+purchaseColumns,
+		purchaseID, status,
+	))
+	if err == sql.ErrNoRows {
+		repo.logger.WithField("purchase_id", purchaseID).Warn("Trying to update not existed purchase")
+		return nil, entity.ErrPurchaseNotFound
+	}
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to update purchase status")
+		return nil, err
+	}
+
+	return purchase, nil
+}
+
+// ReturnStock puts returned items back. Products without stock tracking
+// are left as is.
+func (repo *PurchasePostgresRepository) ReturnStock(
+	ctx context.Context,
+	uow uowI.Executor,
+	purchaseTypeID uint,
+	quantity uint,
+) error {
+	_, err := uow.ExecContext(
+		ctx,
+		"UPDATE purchase_types SET stock = stock + $2 WHERE id = $1 AND stock IS NOT NULL",
+		purchaseTypeID, quantity,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to return product stock")
+		return err
+	}
+
+	return nil
+}
+
+// GetHistory returns purchases one by one, newest first, including refunded
+// ones.
+func (repo *PurchasePostgresRepository) GetHistory(
+	ctx context.Context,
+	filter *entity.PurchaseHistoryFilter,
+) ([]*entity.PurchaseRecord, error) {
+	query := `SELECT p.id, u.username, COALESCE(r.username, ''), p.item_name, p.price, p.discount,
+		p.status, p.created_at, p.refunded_at
+		FROM purchases p
+		JOIN users u ON u.id = p.purchaser_id
+		LEFT JOIN users r ON r.id = p.recipient_id
+		WHERE TRUE`
+	args := []interface{}{}
+
+	if filter.UserID != 0 {
+		args = append(args, filter.UserID)
+		query += fmt.Sprintf(" AND (p.purchaser_id = $%d OR p.recipient_id = $%d)", len(args), len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND p.status = $%d", len(args))
+	}
+	if filter.Before != 0 {
+		args = append(args, filter.Before)
+		query += fmt.Sprintf(" AND p.id < $%d", len(args))
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY p.id DESC LIMIT $%d", len(args))
+
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select purchase history")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting purchase history")
+		}
+	}()
+
+	history := []*entity.PurchaseRecord{}
+	for rows.Next() {
+		record := entity.PurchaseRecord{}
+		err := rows.Scan(
+			&record.ID,
+			&record.Purchaser,
+			&record.Recipient,
+			&record.PurchaseTypeName,
+			&record.Price,
+			&record.Discount,
+			&record.Status,
+			&record.CreatedAt,
+			&record.RefundedAt,
+		)
+		if err != nil {
+			repo.logger.WithError(err).Error("Failed to scan purchase history")
+			return nil, err
+		}
+
+		history = append(history, &record)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate purchase history")
+		return nil, err
+	}
+
+	return history, nil
+}
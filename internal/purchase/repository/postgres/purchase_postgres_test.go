@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 
@@ -25,18 +27,16 @@ func TestPurchasePostgresRepository_Create(t *testing.T) {
 	mockUOW := &MockUnitOfWork{db: db}
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectQuery("SELECT id FROM purchase_types WHERE name = \\$1").
-			WithArgs("t-shirt").
-			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
-
 		mock.ExpectQuery("INSERT INTO purchases .* RETURNING .*").
-			WithArgs(1, 1).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "purchaser_id", "purchase_type_id"}).
-				AddRow(1, 1, 1))
+			WithArgs(1, 1, "t-shirt", 80, 0, 0, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "purchaser_id", "purchase_type_id", "item_name", "price"}).
+				AddRow(1, 1, 1, "t-shirt", 80))
 
 		purchase, err := repo.Create(context.Background(), mockUOW, &entity.Purchase{
 			PurchaserID:      1,
+			PurchaseTypeID:   1,
 			PurchaseTypeName: "t-shirt",
+			Price:            80,
 		})
 
 		assert.NoError(t, err)
@@ -44,41 +44,169 @@ func TestPurchasePostgresRepository_Create(t *testing.T) {
 			ID:             1,
 			PurchaserID:    1,
 			PurchaseTypeID: 1,
+			ItemName:       "t-shirt",
+			Price:          80,
 		}, purchase)
 	})
 
-	t.Run("PurchaseTypeNotFound", func(t *testing.T) {
-		mock.ExpectQuery("SELECT id FROM purchase_types WHERE name = \\$1").
-			WithArgs("invalid-type").
-			WillReturnError(sql.ErrNoRows)
+	t.Run("InsertError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery("INSERT INTO purchases .* RETURNING .*").
+			WithArgs(1, 1, "t-shirt", 80, 0, 0, 0).
+			WillReturnError(expectedErr)
 
 		_, err := repo.Create(context.Background(), mockUOW, &entity.Purchase{
 			PurchaserID:      1,
-			PurchaseTypeName: "invalid-type",
+			PurchaseTypeID:   1,
+			PurchaseTypeName: "t-shirt",
+			Price:            80,
 		})
 
-		assert.ErrorIs(t, err, entity.ErrNotExistedProduct)
+		assert.Equal(t, expectedErr, err)
 	})
 
-	t.Run("InsertError", func(t *testing.T) {
-		mock.ExpectQuery("SELECT id FROM purchase_types WHERE name = \\$1").
-			WithArgs("t-shirt").
-			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
-
-		expectedErr := sql.ErrConnDone
-		mock.ExpectQuery("INSERT INTO purchases .* RETURNING .*").
-			WithArgs(1, 1).
-			WillReturnError(expectedErr)
+	t.Run("WithPromoCode", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO purchases .* NULLIF\\(\\$5, 0\\), \\$6, NULLIF\\(\\$7, 0\\)\\) RETURNING .*").
+			WithArgs(1, 6, "hoody", 210, 3, 90, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "purchaser_id", "purchase_type_id", "item_name", "price"}).
+				AddRow(2, 1, 6, "hoody", 210))
 
 		_, err := repo.Create(context.Background(), mockUOW, &entity.Purchase{
 			PurchaserID:      1,
-			PurchaseTypeName: "t-shirt",
+			PurchaseTypeID:   6,
+			PurchaseTypeName: "hoody",
+			Price:            210,
+			PromoCodeID:      3,
+			Discount:         90,
 		})
 
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPurchasePostgresRepository_CreateBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+	purchase := &entity.Purchase{
+		PurchaserID:      1,
+		PurchaseTypeID:   2,
+		PurchaseTypeName: "pen",
+		Price:            10,
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO purchases .* SELECT \\$1, \\$2, \\$3, \\$4 FROM generate_series\\(1, \\$5\\) RETURNING id").
+			WithArgs(1, 2, "pen", 10, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12).AddRow(10).AddRow(11).AddRow(13).AddRow(14))
+
+		ids, err := repo.CreateBatch(context.Background(), mockUOW, purchase, 5)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{10, 11, 12, 13, 14}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery("INSERT INTO purchases .*").
+			WithArgs(1, 2, "pen", 10, 5).
+			WillReturnError(expectedErr)
+
+		_, err := repo.CreateBatch(context.Background(), mockUOW, purchase, 5)
+
 		assert.Equal(t, expectedErr, err)
 	})
 }
 
+func TestPurchasePostgresRepository_CreateCoinPortions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+	expiresAt := time.Date(2026, time.February, 14, 12, 0, 0, 0, time.UTC)
+	portions := []*model.CoinPortion{
+		{PurchaseID: 10, Amount: 7, ExpiresAt: &expiresAt},
+		{PurchaseID: 10, Amount: 3},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO purchase_coin_portions \\(purchase_id, amount, expires_at\\) "+
+			"VALUES \\(\\$1, \\$2, \\$3\\), \\(\\$4, \\$5, \\$6\\)").
+			WithArgs(10, 7, expiresAt, 10, 3, nil).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err := repo.CreateCoinPortions(context.Background(), mockUOW, portions)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NothingToInsert", func(t *testing.T) {
+		err := repo.CreateCoinPortions(context.Background(), mockUOW, nil)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO purchase_coin_portions").
+			WillReturnError(sql.ErrConnDone)
+
+		err := repo.CreateCoinPortions(context.Background(), mockUOW, portions)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
+func TestPurchasePostgresRepository_GetCoinPortions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+	expiresAt := time.Date(2026, time.February, 14, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT purchase_id, amount, expires_at FROM purchase_coin_portions WHERE purchase_id = \\$1 ORDER BY id").
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"purchase_id", "amount", "expires_at"}).
+				AddRow(10, 7, expiresAt).
+				AddRow(10, 3, nil))
+
+		portions, err := repo.GetCoinPortions(context.Background(), mockUOW, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.CoinPortion{
+			{PurchaseID: 10, Amount: 7, ExpiresAt: &expiresAt},
+			{PurchaseID: 10, Amount: 3},
+		}, portions)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		mock.ExpectQuery("SELECT purchase_id, amount, expires_at FROM purchase_coin_portions").
+			WithArgs(10).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.GetCoinPortions(context.Background(), mockUOW, 10)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+	})
+}
+
 func TestPurchasePostgresRepository_GetProductByType(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -89,23 +217,24 @@ func TestPurchasePostgresRepository_GetProductByType(t *testing.T) {
 	repo := NewPurchasePostgresRepository(db, logrus.New())
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectQuery("SELECT id, name, cost FROM purchase_types WHERE name = \\$1").
+		mock.ExpectQuery("SELECT id, name, cost, available, stock, user_limit, user_limit_period FROM purchase_types WHERE name = \\$1").
 			WithArgs("t-shirt").
-			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "cost"}).
-				AddRow(1, "t-shirt", 80))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "cost", "available", "stock", "user_limit", "user_limit_period"}).
+				AddRow(1, "t-shirt", 80, true, nil, nil, nil))
 
 		pt, err := repo.GetProductByType(context.Background(), "t-shirt")
 
 		assert.NoError(t, err)
 		assert.Equal(t, &model.PurchaseType{
-			ID:   1,
-			Name: "t-shirt",
-			Cost: 80,
+			ID:        1,
+			Name:      "t-shirt",
+			Cost:      80,
+			Available: true,
 		}, pt)
 	})
 
 	t.Run("NotFound", func(t *testing.T) {
-		mock.ExpectQuery("SELECT id, name, cost FROM purchase_types WHERE name = \\$1").
+		mock.ExpectQuery("SELECT id, name, cost, available, stock, user_limit, user_limit_period FROM purchase_types WHERE name = \\$1").
 			WithArgs("invalid-type").
 			WillReturnError(sql.ErrNoRows)
 
@@ -116,7 +245,7 @@ func TestPurchasePostgresRepository_GetProductByType(t *testing.T) {
 
 	t.Run("DatabaseError", func(t *testing.T) {
 		expectedErr := sql.ErrConnDone
-		mock.ExpectQuery("SELECT id, name, cost FROM purchase_types WHERE name = \\$1").
+		mock.ExpectQuery("SELECT id, name, cost, available, stock, user_limit, user_limit_period FROM purchase_types WHERE name = \\$1").
 			WithArgs("t-shirt").
 			WillReturnError(expectedErr)
 
@@ -126,6 +255,390 @@ func TestPurchasePostgresRepository_GetProductByType(t *testing.T) {
 	})
 }
 
+func TestPurchasePostgresRepository_GetProducts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	columns := []string{"id", "name", "cost", "available", "stock", "user_limit", "user_limit_period"}
+
+	t.Run("Success", func(t *testing.T) {
+		cupStock := uint(3)
+		mock.ExpectQuery("SELECT id, name, cost, available, stock, user_limit, user_limit_period FROM purchase_types WHERE retired_at IS NULL ORDER BY name").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(2, "cup", 20, true, 3, nil, nil).
+				AddRow(1, "t-shirt", 80, false, nil, nil, nil))
+
+		products, err := repo.GetProducts(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.PurchaseType{
+			{ID: 2, Name: "cup", Cost: 20, Available: true, Stock: &cupStock},
+			{ID: 1, Name: "t-shirt", Cost: 80, Available: false},
+		}, products)
+	})
+
+	t.Run("EmptyResult", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, cost, available, stock, user_limit, user_limit_period FROM purchase_types WHERE retired_at IS NULL ORDER BY name").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		products, err := repo.GetProducts(context.Background())
+
+		assert.NoError(t, err)
+		assert.Empty(t, products)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery("SELECT id, name, cost, available, stock, user_limit, user_limit_period FROM purchase_types WHERE retired_at IS NULL ORDER BY name").
+			WillReturnError(expectedErr)
+
+		_, err := repo.GetProducts(context.Background())
+
+		assert.Equal(t, expectedErr, err)
+	})
+
+	t.Run("ScanError", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, cost, available, stock, user_limit, user_limit_period FROM purchase_types WHERE retired_at IS NULL ORDER BY name").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, "t-shirt", "invalid_cost", true, nil, nil, nil))
+
+		_, err := repo.GetProducts(context.Background())
+
+		assert.ErrorContains(t, err, "converting driver.Value type string")
+	})
+}
+
+func TestPurchasePostgresRepository_CreateProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	stock := uint(100)
+	newProduct := &model.PurchaseType{Name: "sticker", Cost: 5, Available: true, Stock: &stock}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO purchase_types .* ON CONFLICT \\(name\\) WHERE retired_at IS NULL DO NOTHING").
+			WithArgs("sticker", 5, true, 100, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "cost", "available", "stock", "user_limit", "user_limit_period"}).
+				AddRow(11, "sticker", 5, true, 100, nil, nil))
+
+		product, err := repo.CreateProduct(context.Background(), newProduct)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.PurchaseType{ID: 11, Name: "sticker", Cost: 5, Available: true, Stock: &stock}, product)
+	})
+
+	t.Run("AlreadyExists", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO purchase_types .*").
+			WithArgs("sticker", 5, true, 100, nil, nil).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.CreateProduct(context.Background(), newProduct)
+
+		assert.ErrorIs(t, err, entity.ErrProductExists)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery("INSERT INTO purchase_types .*").
+			WithArgs("sticker", 5, true, 100, nil, nil).
+			WillReturnError(expectedErr)
+
+		_, err := repo.CreateProduct(context.Background(), newProduct)
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestPurchasePostgresRepository_UpdateProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	columns := []string{"id", "name", "cost", "available", "stock", "user_limit", "user_limit_period"}
+	newName := "hoodie"
+	newCost := uint(350)
+	available := false
+
+	t.Run("SuccessAllFields", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE purchase_types SET name = \\$1, cost = \\$2, available = \\$3 "+
+			"WHERE name = \\$4 AND retired_at IS NULL RETURNING id, name, cost, available, stock, user_limit, user_limit_period").
+			WithArgs("hoodie", 350, false, "hoody").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(6, "hoodie", 350, false, nil, nil, nil))
+
+		product, err := repo.UpdateProduct(context.Background(), "hoody", &entity.CatalogItemUpdate{
+			Name:      &newName,
+			Cost:      &newCost,
+			Available: &available,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.PurchaseType{ID: 6, Name: "hoodie", Cost: 350, Available: false}, product)
+	})
+
+	t.Run("SuccessCostOnly", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE purchase_types SET cost = \\$1 WHERE name = \\$2").
+			WithArgs(350, "hoody").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(6, "hoody", 350, true, nil, nil, nil))
+
+		product, err := repo.UpdateProduct(context.Background(), "hoody", &entity.CatalogItemUpdate{
+			Cost: &newCost,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(350), product.Cost)
+	})
+
+	t.Run("SuccessUserLimit", func(t *testing.T) {
+		userLimit := uint(3)
+		userLimitPeriod := 90 * 24 * time.Hour
+		expectedPeriod := uint(7776000)
+
+		mock.ExpectQuery("UPDATE purchase_types SET user_limit = NULLIF\\(\\$1, 0\\), "+
+			"user_limit_period = NULLIF\\(\\$2, 0\\) WHERE name = \\$3").
+			WithArgs(3, 7776000, "hoody").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(6, "hoody", 300, true, nil, 3, 7776000))
+
+		product, err := repo.UpdateProduct(context.Background(), "hoody", &entity.CatalogItemUpdate{
+			UserLimit:       &userLimit,
+			UserLimitPeriod: &userLimitPeriod,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.PurchaseType{
+			ID:              6,
+			Name:            "hoody",
+			Cost:            300,
+			Available:       true,
+			UserLimit:       &userLimit,
+			UserLimitPeriod: &expectedPeriod,
+		}, product)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE purchase_types SET cost = \\$1 WHERE name = \\$2").
+			WithArgs(350, "unknown").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.UpdateProduct(context.Background(), "unknown", &entity.CatalogItemUpdate{
+			Cost: &newCost,
+		})
+
+		assert.ErrorIs(t, err, entity.ErrNotExistedProduct)
+	})
+
+	t.Run("NameConflict", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE purchase_types SET name = \\$1 WHERE name = \\$2").
+			WithArgs("hoodie", "hoody").
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		_, err := repo.UpdateProduct(context.Background(), "hoody", &entity.CatalogItemUpdate{
+			Name: &newName,
+		})
+
+		assert.ErrorIs(t, err, entity.ErrProductExists)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery("UPDATE purchase_types SET cost = \\$1 WHERE name = \\$2").
+			WithArgs(350, "hoody").
+			WillReturnError(expectedErr)
+
+		_, err := repo.UpdateProduct(context.Background(), "hoody", &entity.CatalogItemUpdate{
+			Cost: &newCost,
+		})
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestPurchasePostgresRepository_RestockProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	columns := []string{"id", "name", "cost", "available", "stock", "user_limit", "user_limit_period"}
+
+	t.Run("Success", func(t *testing.T) {
+		stock := uint(25)
+		mock.ExpectQuery("UPDATE purchase_types SET stock = COALESCE\\(stock, 0\\) \\+ \\$1 "+
+			"WHERE name = \\$2 AND retired_at IS NULL RETURNING id, name, cost, available, stock, user_limit, user_limit_period").
+			WithArgs(10, "hoody").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(6, "hoody", 300, true, 25, nil, nil))
+
+		product, err := repo.RestockProduct(context.Background(), "hoody", 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.PurchaseType{ID: 6, Name: "hoody", Cost: 300, Available: true, Stock: &stock}, product)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE purchase_types SET stock").
+			WithArgs(10, "unknown").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.RestockProduct(context.Background(), "unknown", 10)
+
+		assert.ErrorIs(t, err, entity.ErrNotExistedProduct)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery("UPDATE purchase_types SET stock").
+			WithArgs(10, "hoody").
+			WillReturnError(expectedErr)
+
+		_, err := repo.RestockProduct(context.Background(), "hoody", 10)
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestPurchasePostgresRepository_CountUserPurchases(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+
+	t.Run("SuccessOverAllTime", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM purchases "+
+			"WHERE \\(\\(purchaser_id = \\$1 AND recipient_id IS NULL\\) OR recipient_id = \\$1\\) "+
+			"AND purchase_type_id = \\$2 "+
+			"AND refunded_at IS NULL$").
+			WithArgs(1, 6).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		count, err := repo.CountUserPurchases(context.Background(), mockUOW, 1, 6, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), count)
+	})
+
+	t.Run("SuccessOverPeriod", func(t *testing.T) {
+		mock.ExpectQuery("AND refunded_at IS NULL AND created_at > NOW\\(\\) - \\$3 \\* INTERVAL '1 second'").
+			WithArgs(1, 6, 86400).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		count, err := repo.CountUserPurchases(context.Background(), mockUOW, 1, 6, 24*time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), count)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM purchases").
+			WithArgs(1, 6).
+			WillReturnError(expectedErr)
+
+		_, err := repo.CountUserPurchases(context.Background(), mockUOW, 1, 6, 0)
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestPurchasePostgresRepository_DecrementStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec("UPDATE purchase_types SET stock = stock - \\$2 "+
+			"WHERE id = \\$1 AND \\(stock IS NULL OR stock >= \\$2\\)").
+			WithArgs(6, 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.DecrementStock(context.Background(), mockUOW, 6, 2)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("OutOfStock", func(t *testing.T) {
+		mock.ExpectExec("UPDATE purchase_types SET stock = stock - \\$2").
+			WithArgs(6, 2).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.DecrementStock(context.Background(), mockUOW, 6, 2)
+
+		assert.ErrorIs(t, err, entity.ErrOutOfStock)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectExec("UPDATE purchase_types SET stock = stock - \\$2").
+			WithArgs(6, 2).
+			WillReturnError(expectedErr)
+
+		err := repo.DecrementStock(context.Background(), mockUOW, 6, 2)
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestPurchasePostgresRepository_RetireProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec("UPDATE purchase_types SET retired_at = NOW\\(\\) WHERE name = \\$1 AND retired_at IS NULL").
+			WithArgs("hoody").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.RetireProduct(context.Background(), "hoody")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectExec("UPDATE purchase_types SET retired_at = NOW\\(\\)").
+			WithArgs("unknown").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.RetireProduct(context.Background(), "unknown")
+
+		assert.ErrorIs(t, err, entity.ErrNotExistedProduct)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectExec("UPDATE purchase_types SET retired_at = NOW\\(\\)").
+			WithArgs("hoody").
+			WillReturnError(expectedErr)
+
+		err := repo.RetireProduct(context.Background(), "hoody")
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
 func TestPurchasePostgresRepository_GetPurchasesByUserId(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -137,11 +650,12 @@ func TestPurchasePostgresRepository_GetPurchasesByUserId(t *testing.T) {
 	userID := uint(1)
 
 	t.Run("SuccessWithData", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"name", "quantity"}).
+		rows := sqlmock.NewRows([]string{"item_name", "quantity"}).
 			AddRow("t-shirt", 3).
 			AddRow("cup", 5)
 
-		mock.ExpectQuery(`SELECT pt.name, COUNT\(p.id\) as quantity .*`).
+		mock.ExpectQuery(`SELECT item_name, COUNT\(id\) as quantity .* ` +
+			`WHERE \(\(purchaser_id = \$1 AND recipient_id IS NULL\) OR recipient_id = \$1\) AND refunded_at IS NULL`).
 			WithArgs(userID).
 			WillReturnRows(rows)
 
@@ -155,9 +669,9 @@ func TestPurchasePostgresRepository_GetPurchasesByUserId(t *testing.T) {
 	})
 
 	t.Run("EmptyResult", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"name", "quantity"})
+		rows := sqlmock.NewRows([]string{"item_name", "quantity"})
 
-		mock.ExpectQuery(`SELECT pt.name, COUNT\(p.id\) as quantity .*`).
+		mock.ExpectQuery(`SELECT item_name, COUNT\(id\) as quantity .*`).
 			WithArgs(userID).
 			WillReturnRows(rows)
 
@@ -169,7 +683,7 @@ func TestPurchasePostgresRepository_GetPurchasesByUserId(t *testing.T) {
 
 	t.Run("QueryError", func(t *testing.T) {
 		expectedErr := sql.ErrConnDone
-		mock.ExpectQuery(`SELECT pt.name, COUNT\(p.id\) as quantity .*`).
+		mock.ExpectQuery(`SELECT item_name, COUNT\(id\) as quantity .*`).
 			WithArgs(userID).
 			WillReturnError(expectedErr)
 
@@ -179,10 +693,10 @@ func TestPurchasePostgresRepository_GetPurchasesByUserId(t *testing.T) {
 	})
 
 	t.Run("ScanError", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"name", "quantity"}).
+		rows := sqlmock.NewRows([]string{"item_name", "quantity"}).
 			AddRow(nil, 5)
 
-		mock.ExpectQuery(`SELECT pt.name, COUNT\(p.id\) as quantity .*`).
+		mock.ExpectQuery(`SELECT item_name, COUNT\(id\) as quantity .*`).
 			WithArgs(userID).
 			WillReturnRows(rows)
 
@@ -192,6 +706,299 @@ func TestPurchasePostgresRepository_GetPurchasesByUserId(t *testing.T) {
 	})
 }
 
+func TestPurchasePostgresRepository_GetForUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+	createdAt := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, purchaser_id, COALESCE\\(purchase_type_id, 0\\), .* FOR UPDATE").
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows(purchaseTestColumns).
+				AddRow(7, 1, 3, "pen", 10, "pending", createdAt, nil, 2, 4))
+
+		purchase, err := repo.GetForUpdate(context.Background(), mockUOW, 7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Purchase{
+			ID:             7,
+			PurchaserID:    1,
+			PurchaseTypeID: 3,
+			ItemName:       "pen",
+			Price:          10,
+			Status:         "pending",
+			CreatedAt:      createdAt,
+			PromoCodeID:    2,
+			RecipientID:    4,
+		}, purchase)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, purchaser_id").
+			WithArgs(7).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetForUpdate(context.Background(), mockUOW, 7)
+
+		assert.ErrorIs(t, err, entity.ErrPurchaseNotFound)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery("SELECT id, purchaser_id").
+			WithArgs(7).
+			WillReturnError(expectedErr)
+
+		_, err := repo.GetForUpdate(context.Background(), mockUOW, 7)
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestPurchasePostgresRepository_MarkRefunded(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+	createdAt := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+	refundedAt := createdAt.Add(time.Hour)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE purchases SET refunded_at = NOW\\(\\),.*" +
+			"WHERE id = \\$1 AND refunded_at IS NULL").
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows(purchaseTestColumns).
+				AddRow(7, 1, 3, "pen", 10, "cancelled", createdAt, refundedAt, 0, 0))
+
+		purchase, err := repo.MarkRefunded(context.Background(), mockUOW, 7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "cancelled", purchase.Status)
+		assert.Equal(t, &refundedAt, purchase.RefundedAt)
+	})
+
+	t.Run("AlreadyRefunded", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE purchases SET refunded_at").
+			WithArgs(7).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.MarkRefunded(context.Background(), mockUOW, 7)
+
+		assert.ErrorIs(t, err, entity.ErrAlreadyRefunded)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery("UPDATE purchases SET refunded_at").
+			WithArgs(7).
+			WillReturnError(expectedErr)
+
+		_, err := repo.MarkRefunded(context.Background(), mockUOW, 7)
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestPurchasePostgresRepository_UpdateStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+	createdAt := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE purchases SET status = \\$2 WHERE id = \\$1 RETURNING").
+			WithArgs(7, "ready").
+			WillReturnRows(sqlmock.NewRows(purchaseTestColumns).
+				AddRow(7, 1, 3, "pen", 10, "ready", createdAt, nil, 0, 0))
+
+		purchase, err := repo.UpdateStatus(context.Background(), mockUOW, 7, "ready")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ready", purchase.Status)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE purchases SET status").
+			WithArgs(7, "ready").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.UpdateStatus(context.Background(), mockUOW, 7, "ready")
+
+		assert.ErrorIs(t, err, entity.ErrPurchaseNotFound)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery("UPDATE purchases SET status").
+			WithArgs(7, "ready").
+			WillReturnError(expectedErr)
+
+		_, err := repo.UpdateStatus(context.Background(), mockUOW, 7, "ready")
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestPurchasePostgresRepository_ReturnStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec("UPDATE purchase_types SET stock = stock \\+ \\$2 WHERE id = \\$1 AND stock IS NOT NULL").
+			WithArgs(3, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.ReturnStock(context.Background(), mockUOW, 3, 1)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectExec("UPDATE purchase_types SET stock = stock \\+ \\$2").
+			WithArgs(3, 1).
+			WillReturnError(expectedErr)
+
+		err := repo.ReturnStock(context.Background(), mockUOW, 3, 1)
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestPurchasePostgresRepository_GetHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPurchasePostgresRepository(db, logrus.New())
+	columns := []string{
+		"id", "username", "recipient", "item_name", "price", "discount", "status", "created_at", "refunded_at",
+	}
+	createdAt := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+	refundedAt := createdAt.Add(time.Hour)
+
+	t.Run("UserFirstPage", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(9, "user2", "user1", "cup", 20, 0, "pending", createdAt, nil).
+			AddRow(8, "user1", "", "cup", 20, 0, "cancelled", createdAt, refundedAt).
+			AddRow(7, "user1", "", "pen", 7, 3, "pending", createdAt, nil)
+
+		mock.ExpectQuery(`LEFT JOIN users r ON r.id = p.recipient_id `+
+			`WHERE TRUE AND \(p.purchaser_id = \$1 OR p.recipient_id = \$1\) ORDER BY p.id DESC LIMIT \$2`).
+			WithArgs(1, 10).
+			WillReturnRows(rows)
+
+		result, err := repo.GetHistory(context.Background(), &entity.PurchaseHistoryFilter{
+			UserID: 1,
+			Limit:  10,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*entity.PurchaseRecord{
+			{
+				ID:               9,
+				Purchaser:        "user2",
+				Recipient:        "user1",
+				PurchaseTypeName: "cup",
+				Price:            20,
+				Status:           "pending",
+				CreatedAt:        createdAt,
+			},
+			{
+				ID:               8,
+				Purchaser:        "user1",
+				PurchaseTypeName: "cup",
+				Price:            20,
+				Status:           "cancelled",
+				CreatedAt:        createdAt,
+				RefundedAt:       &refundedAt,
+			},
+			{
+				ID:               7,
+				Purchaser:        "user1",
+				PurchaseTypeName: "pen",
+				Price:            7,
+				Discount:         3,
+				Status:           "pending",
+				CreatedAt:        createdAt,
+			},
+		}, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NextPage", func(t *testing.T) {
+		mock.ExpectQuery(`WHERE TRUE AND \(p.purchaser_id = \$1 OR p.recipient_id = \$1\) AND p.id < \$2 ORDER BY p.id DESC LIMIT \$3`).
+			WithArgs(1, 7, 10).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		result, err := repo.GetHistory(context.Background(), &entity.PurchaseHistoryFilter{
+			UserID: 1,
+			Before: 7,
+			Limit:  10,
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AllUsersByStatus", func(t *testing.T) {
+		mock.ExpectQuery(`WHERE TRUE AND p.status = \$1 ORDER BY p.id DESC LIMIT \$2`).
+			WithArgs("pending", 10).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetHistory(context.Background(), &entity.PurchaseHistoryFilter{
+			Status: "pending",
+			Limit:  10,
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("QueryError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery(`SELECT p.id, u.username, COALESCE\(r.username, ''\), p.item_name`).
+			WithArgs(1, 10).
+			WillReturnError(expectedErr)
+
+		_, err := repo.GetHistory(context.Background(), &entity.PurchaseHistoryFilter{
+			UserID: 1,
+			Limit:  10,
+		})
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+var purchaseTestColumns = []string{
+	"id", "purchaser_id", "purchase_type_id", "item_name", "price", "status", "created_at", "refunded_at",
+	"promo_code_id", "recipient_id",
+}
+
 type MockUnitOfWork struct {
 	uow.Executor
 	db *sql.DB
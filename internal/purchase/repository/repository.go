@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/entity"
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/model"
@@ -11,6 +12,21 @@ import (
 //go:generate mockgen -source=repository.go -destination=mock_repository/purchase_mock.go -package=mock_repository MockPurchaseRepository
 type PurchaseRepositoryI interface {
 	Create(ctx context.Context, uow uow.Executor, purchase *entity.Purchase) (*model.Purchase, error)
+	CreateBatch(ctx context.Context, uow uow.Executor, purchase *entity.Purchase, quantity uint) ([]uint, error)
+	CreateCoinPortions(ctx context.Context, uow uow.Executor, portions []*model.CoinPortion) error
+	GetCoinPortions(ctx context.Context, uow uow.Executor, purchaseID uint) ([]*model.CoinPortion, error)
 	GetProductByType(ctx context.Context, purchaseTypeName string) (*model.PurchaseType, error)
+	GetProducts(ctx context.Context) ([]*model.PurchaseType, error)
+	CreateProduct(ctx context.Context, purchaseType *model.PurchaseType) (*model.PurchaseType, error)
+	UpdateProduct(ctx context.Context, name string, update *entity.CatalogItemUpdate) (*model.PurchaseType, error)
+	RestockProduct(ctx context.Context, name string, quantity uint) (*model.PurchaseType, error)
+	DecrementStock(ctx context.Context, uow uow.Executor, purchaseTypeID uint, quantity uint) error
+	CountUserPurchases(ctx context.Context, uow uow.Executor, userID uint, purchaseTypeID uint, period time.Duration) (uint, error)
+	RetireProduct(ctx context.Context, name string) error
 	GetPurchasesByUserID(ctx context.Context, userID uint) (entity.Inventory, error)
+	GetForUpdate(ctx context.Context, uow uow.Executor, purchaseID uint) (*model.Purchase, error)
+	MarkRefunded(ctx context.Context, uow uow.Executor, purchaseID uint) (*model.Purchase, error)
+	UpdateStatus(ctx context.Context, uow uow.Executor, purchaseID uint, status string) (*model.Purchase, error)
+	ReturnStock(ctx context.Context, uow uow.Executor, purchaseTypeID uint, quantity uint) error
+	GetHistory(ctx context.Context, filter *entity.PurchaseHistoryFilter) ([]*entity.PurchaseRecord, error)
 }
@@ -2,44 +2,117 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
 
+	"github.com/artrsyf/avito-trainee-assignment/config"
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/dto"
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/entity"
+	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/model"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/cache"
 	"github.com/sirupsen/logrus"
 
+	idempotencyDTO "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/dto"
+	idempotencyEntity "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	idempotencyRepo "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository"
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository"
+	promoDTO "github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/dto"
+	promoEntity "github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/entity"
+	promoRepo "github.com/artrsyf/avito-trainee-assignment/internal/promo/repository"
 	purchaseRepo "github.com/artrsyf/avito-trainee-assignment/internal/purchase/repository"
+	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
 	userRepo "github.com/artrsyf/avito-trainee-assignment/internal/user/repository"
 	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
 )
 
 type PurchaseUsecaseI interface {
-	Create(ctx context.Context, purchaseRequest *dto.PurchaseItemRequest) error
+	Create(
+		ctx context.Context,
+		purchaseRequest *dto.PurchaseItemRequest,
+		idempotencyRecord *idempotencyEntity.Record,
+	) error
+	Checkout(
+		ctx context.Context,
+		checkoutRequest *dto.CheckoutRequest,
+		idempotencyRecord *idempotencyEntity.Record,
+	) (*entity.Receipt, error)
+	GetCatalog(ctx context.Context) ([]*entity.CatalogItem, error)
+	GetCatalogItem(ctx context.Context, name string) (*entity.CatalogItem, error)
+	CreateCatalogItem(ctx context.Context, item *entity.CatalogItem) (*entity.CatalogItem, error)
+	UpdateCatalogItem(
+		ctx context.Context,
+		name string,
+		update *entity.CatalogItemUpdate,
+	) (*entity.CatalogItem, error)
+	RestockCatalogItem(ctx context.Context, name string, quantity uint) (*entity.CatalogItem, error)
+	RetireCatalogItem(ctx context.Context, name string) error
+	GetPurchaseHistory(ctx context.Context, filter *entity.PurchaseHistoryFilter) (*entity.PurchaseHistoryPage, error)
+	RefundPurchase(ctx context.Context, userID uint, purchaseID uint) (*entity.PurchaseRecord, error)
+	RefundPurchaseByAdmin(ctx context.Context, purchaseID uint) (*entity.PurchaseRecord, error)
+	UpdatePurchaseStatus(ctx context.Context, purchaseID uint, status string) (*entity.PurchaseRecord, error)
 }
 
+// catalogCacheKey is the only key of the catalog cache: the whole catalog
+// is small, so it is loaded and invalidated at once.
+const catalogCacheKey = "catalog"
+
 type PurchaseUsecase struct {
-	purchaseRepo purchaseRepo.PurchaseRepositoryI
-	userRepo     userRepo.UserRepositoryI
-	uowFactory   uowI.Factory
-	logger       *logrus.Logger
+	purchaseRepo    purchaseRepo.PurchaseRepositoryI
+	userRepo        userRepo.UserRepositoryI
+	idempotencyRepo idempotencyRepo.IdempotencyRepositoryI
+	promoRepo       promoRepo.PromoRepositoryI
+	ledgerRepo      ledgerRepo.LedgerRepositoryI
+	lotRepo         lotRepo.LotRepositoryI
+	uowFactory      uowI.Factory
+	catalog         *cache.TTLCache[string, []*entity.CatalogItem]
+	refundWindow    time.Duration
+	logger          *logrus.Logger
 }
 
 func NewPurchaseUsecase(
 	purchaseRepository purchaseRepo.PurchaseRepositoryI,
 	userRepository userRepo.UserRepositoryI,
+	idempotencyRepository idempotencyRepo.IdempotencyRepositoryI,
+	promoRepository promoRepo.PromoRepositoryI,
+	ledgerRepository ledgerRepo.LedgerRepositoryI,
+	lotRepository lotRepo.LotRepositoryI,
 	uowFactory uowI.Factory,
+	cfg config.PurchaseConfig,
 	logger *logrus.Logger,
 ) *PurchaseUsecase {
+	catalogCacheTTL, err := cfg.GetCatalogCacheTTL()
+	if err != nil {
+		logger.WithError(err).Warn("Catalog cache TTL is not set, catalog won't be cached")
+	}
+
+	refundWindow, err := cfg.GetRefundWindow()
+	if err != nil {
+		logger.WithError(err).Warn("Refund window is not set, users won't be able to refund purchases")
+	}
+
 	return &PurchaseUsecase{
-		purchaseRepo: purchaseRepository,
-		userRepo:     userRepository,
-		uowFactory:   uowFactory,
-		logger:       logger,
+		purchaseRepo:    purchaseRepository,
+		userRepo:        userRepository,
+		idempotencyRepo: idempotencyRepository,
+		promoRepo:       promoRepository,
+		ledgerRepo:      ledgerRepository,
+		lotRepo:         lotRepository,
+		uowFactory:      uowFactory,
+		catalog:         cache.NewTTLCache[string, []*entity.CatalogItem](catalogCacheTTL),
+		refundWindow:    refundWindow,
+		logger:          logger,
 	}
 }
 
 func (uc *PurchaseUsecase) Create(
 	ctx context.Context,
 	purchaseRequest *dto.PurchaseItemRequest,
+	idempotencyRecord *idempotencyEntity.Record,
 ) error {
 	purchaseEntity := dto.PurchaseItemRequestToEntity(purchaseRequest)
 
@@ -49,18 +122,50 @@ func (uc *PurchaseUsecase) Create(
 		return err
 	}
 
+	if purchaseRequest.RecipientUsername != "" {
+		recipientModel, err := uc.userRepo.GetByUsername(ctx, purchaseRequest.RecipientUsername)
+		if err == userEntity.ErrIsNotExist {
+			uc.logger.WithField("recipient", purchaseRequest.RecipientUsername).Info("Gift recipient doesn't exist")
+			return entity.ErrRecipientNotFound
+		}
+		if err != nil {
+			uc.logger.WithError(err).Error("Failed to get gift recipient by username")
+			return err
+		}
+
+		if recipientModel.ID == customerModel.ID {
+			return entity.ErrSelfGift
+		}
+
+		purchaseEntity.RecipientID = recipientModel.ID
+	}
+
 	purchaseType, err := uc.purchaseRepo.GetProductByType(ctx, purchaseEntity.PurchaseTypeName)
 	if err != nil {
 		uc.logger.WithError(err).Error("Failed to get product by type name")
 		return err
 	}
 
-	if customerModel.Coins < purchaseType.Cost {
-		uc.logger.WithError(err).Error("Customer doesn't have enough balance")
-		return entity.ErrNotEnoughBalance
+	if !purchaseType.Available {
+		uc.logger.WithField("product", purchaseType.Name).Info("Product is not available")
+		return entity.ErrUnavailableProduct
 	}
 
-	customerModel.Coins -= purchaseType.Cost
+	purchaseEntity.PurchaseTypeID = purchaseType.ID
+	purchaseEntity.PurchaseTypeName = purchaseType.Name
+	purchaseEntity.Price = purchaseType.Cost
+
+	var promoCode *promoEntity.PromoCode
+	if purchaseRequest.PromoCode != "" {
+		promoCode, err = uc.getApplicablePromoCode(ctx, purchaseRequest.PromoCode, purchaseType.ID)
+		if err != nil {
+			return err
+		}
+
+		purchaseEntity.PromoCodeID = promoCode.ID
+		purchaseEntity.Discount = promoCode.Discount(purchaseType.Cost)
+		purchaseEntity.Price -= purchaseEntity.Discount
+	}
 
 	uow := uc.uowFactory.NewUnitOfWork()
 
@@ -70,36 +175,847 @@ func (uc *PurchaseUsecase) Create(
 		return err
 	}
 
-	err = uc.userRepo.Update(ctx, uow, customerModel)
+	if idempotencyRecord != nil {
+		err = uc.idempotencyRepo.Create(ctx, uow, idempotencyDTO.RecordEntityToModel(idempotencyRecord))
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Warn("Rollback due idempotency key reserving")
+			return err
+		}
+	}
+
+	if purchaseType.Stock != nil {
+		err = uc.purchaseRepo.DecrementStock(ctx, uow, purchaseType.ID, 1)
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Warn("Rollback purchase due stock decrementing")
+			return err
+		}
+	}
+
+	if promoCode != nil {
+		err = uc.redeemPromoCode(ctx, uow, promoCode, customerModel.ID)
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Warn("Rollback purchase due promo code redeeming")
+			return err
+		}
+	}
+
+	err = uc.debitCustomer(ctx, uow, purchaseType, customerModel.ID, purchaseEntity.RecipientID, purchaseEntity.Price)
 	if err != nil {
-		rbErr := uow.Rollback()
-		if rbErr != nil {
-			uc.logger.WithError(rbErr).Error("Rollback error encountered")
+		uc.rollback(uow)
+		if err == userEntity.ErrNotEnoughBalance {
+			uc.logger.Info("Customer doesn't have enough balance")
+			return entity.ErrNotEnoughBalance
 		}
 		uc.logger.WithError(err).Error("Rollback money transfer due user updating")
 		return err
 	}
 
-	purchaseModel, err := uc.purchaseRepo.Create(ctx, uow, purchaseEntity)
+	portions, err := uc.lotRepo.Consume(ctx, uow, customerModel.ID, purchaseEntity.Price)
 	if err != nil {
-		rbErr := uow.Rollback()
-		if rbErr != nil {
-			uc.logger.WithError(rbErr).Error("Rollback error encountered")
+		uc.rollback(uow)
+		if err == lotEntity.ErrNotEnoughCoins {
+			uc.logger.Info("Customer doesn't have enough unexpired coins")
+			return entity.ErrNotEnoughBalance
 		}
+		uc.logger.WithError(err).Error("Rollback purchase due coin lots consuming")
+		return err
+	}
+
+	ownerID := customerModel.ID
+	if purchaseEntity.RecipientID != 0 {
+		ownerID = purchaseEntity.RecipientID
+	}
+
+	err = uc.checkUserLimit(ctx, uow, purchaseType, ownerID, 1)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Warn("Rollback purchase due user limit checking")
+		return err
+	}
+
+	purchaseModel, err := uc.purchaseRepo.Create(ctx, uow, purchaseEntity)
+	if err != nil {
+		uc.rollback(uow)
 		uc.logger.WithError(err).Error("Rollback money transfer due purchase creating")
 		return err
 	}
 
+	coinPortions, _ := paidPortions([]uint{purchaseModel.ID}, purchaseEntity.Price, portions)
+	err = uc.purchaseRepo.CreateCoinPortions(ctx, uow, coinPortions)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback purchase due coin portions recording")
+		return err
+	}
+
+	err = uc.journal(
+		ctx,
+		uow,
+		ledgerEntity.KindPurchase,
+		fmt.Sprintf("purchase:%d", purchaseModel.ID),
+		ledgerEntity.UserAccount(customerModel.ID),
+		ledgerEntity.ShopAccount,
+		purchaseEntity.Price,
+	)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback purchase due ledger posting")
+		return err
+	}
+
 	err = uow.Commit()
 	if err != nil {
 		uc.logger.WithError(err).Error("Transaction commit error")
 		return err
 	}
 
+	if purchaseType.Stock != nil {
+		uc.catalog.Clear()
+	}
+
 	uc.logger.WithFields(logrus.Fields{
 		"customer_id":     purchaseModel.PurchaserID,
+		"recipient_id":    purchaseEntity.RecipientID,
 		"product_type_id": purchaseModel.PurchaseTypeID,
+		"price":           purchaseModel.Price,
 	}).Info("Successfully purchase product")
 
 	return nil
 }
+
+// Checkout buys the whole basket in one unit of work: stock, balance and
+// purchases are changed together or not at all.
+func (uc *PurchaseUsecase) Checkout(
+	ctx context.Context,
+	checkoutRequest *dto.CheckoutRequest,
+	idempotencyRecord *idempotencyEntity.Record,
+) (*entity.Receipt, error) {
+	checkout := dto.CheckoutRequestToEntity(checkoutRequest)
+
+	customerModel, err := uc.userRepo.GetByID(ctx, checkout.PurchaserID)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to get customer user by id")
+		return nil, err
+	}
+
+	receipt := &entity.Receipt{}
+	purchaseTypes := make([]*model.PurchaseType, 0, len(checkout.Lines))
+	hasStockLimits := false
+	for _, line := range checkout.Lines {
+		purchaseType, err := uc.purchaseRepo.GetProductByType(ctx, line.PurchaseTypeName)
+		if err != nil {
+			uc.logger.WithError(err).WithField(
+				"product", line.PurchaseTypeName,
+			).Error("Failed to get product by type name")
+			return nil, err
+		}
+
+		if !purchaseType.Available {
+			uc.logger.WithField("product", purchaseType.Name).Info("Product is not available")
+			return nil, entity.ErrUnavailableProduct
+		}
+
+		if purchaseType.Stock != nil {
+			hasStockLimits = true
+		}
+
+		purchaseTypes = append(purchaseTypes, purchaseType)
+		receipt.Lines = append(receipt.Lines, entity.ReceiptLine{
+			PurchaseTypeName: purchaseType.Name,
+			Quantity:         line.Quantity,
+			Price:            purchaseType.Cost,
+		})
+		receipt.Total += purchaseType.Cost * line.Quantity
+	}
+
+	// Balances and ledger amounts are INTEGER columns, no balance can
+	// cover a bigger total.
+	if receipt.Total > math.MaxInt32 {
+		uc.logger.WithField("total", receipt.Total).Info("Checkout total exceeds any balance")
+		return nil, entity.ErrNotEnoughBalance
+	}
+
+	if idempotencyRecord != nil {
+		// The receipt is known before anything is written, so it is stored
+		// with the key and replayed as is.
+		idempotencyRecord.ResponseBody, err = json.Marshal(dto.ReceiptToResponse(receipt))
+		if err != nil {
+			uc.logger.WithError(err).Error("Failed to serialize receipt")
+			return nil, err
+		}
+	}
+
+	uow := uc.uowFactory.NewUnitOfWork()
+
+	err = uow.Begin(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction begin error")
+		return nil, err
+	}
+
+	if idempotencyRecord != nil {
+		err = uc.idempotencyRepo.Create(ctx, uow, idempotencyDTO.RecordEntityToModel(idempotencyRecord))
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Warn("Rollback due idempotency key reserving")
+			return nil, err
+		}
+	}
+
+	for i, purchaseType := range purchaseTypes {
+		if purchaseType.Stock == nil {
+			continue
+		}
+
+		err = uc.purchaseRepo.DecrementStock(ctx, uow, purchaseType.ID, receipt.Lines[i].Quantity)
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).WithField(
+				"product", purchaseType.Name,
+			).Warn("Rollback checkout due stock decrementing")
+			return nil, err
+		}
+	}
+
+	err = uc.userRepo.Debit(ctx, uow, customerModel.ID, receipt.Total)
+	if err != nil {
+		uc.rollback(uow)
+		if err == userEntity.ErrNotEnoughBalance {
+			uc.logger.Info("Customer doesn't have enough balance for checkout")
+			return nil, entity.ErrNotEnoughBalance
+		}
+		uc.logger.WithError(err).Error("Rollback checkout due user updating")
+		return nil, err
+	}
+
+	portions, err := uc.lotRepo.Consume(ctx, uow, customerModel.ID, receipt.Total)
+	if err != nil {
+		uc.rollback(uow)
+		if err == lotEntity.ErrNotEnoughCoins {
+			uc.logger.Info("Customer doesn't have enough unexpired coins for checkout")
+			return nil, entity.ErrNotEnoughBalance
+		}
+		uc.logger.WithError(err).Error("Rollback checkout due coin lots consuming")
+		return nil, err
+	}
+
+	for i, purchaseType := range purchaseTypes {
+		err = uc.checkUserLimit(ctx, uow, purchaseType, customerModel.ID, receipt.Lines[i].Quantity)
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).WithField(
+				"product", purchaseType.Name,
+			).Warn("Rollback checkout due user limit checking")
+			return nil, err
+		}
+	}
+
+	var purchaseIDs []uint
+	var coinPortions []*model.CoinPortion
+	for i, purchaseType := range purchaseTypes {
+		ids, err := uc.purchaseRepo.CreateBatch(ctx, uow, &entity.Purchase{
+			PurchaserID:      customerModel.ID,
+			PurchaseTypeID:   purchaseType.ID,
+			PurchaseTypeName: purchaseType.Name,
+			Price:            purchaseType.Cost,
+		}, receipt.Lines[i].Quantity)
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Error("Rollback checkout due purchases creating")
+			return nil, err
+		}
+
+		var paid []*model.CoinPortion
+		paid, portions = paidPortions(ids, purchaseType.Cost, portions)
+		coinPortions = append(coinPortions, paid...)
+		purchaseIDs = append(purchaseIDs, ids...)
+	}
+
+	err = uc.purchaseRepo.CreateCoinPortions(ctx, uow, coinPortions)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback checkout due coin portions recording")
+		return nil, err
+	}
+
+	err = uc.journal(
+		ctx,
+		uow,
+		ledgerEntity.KindPurchase,
+		fmt.Sprintf("checkout:%d", purchaseIDs[0]),
+		ledgerEntity.UserAccount(customerModel.ID),
+		ledgerEntity.ShopAccount,
+		receipt.Total,
+	)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback checkout due ledger posting")
+		return nil, err
+	}
+
+	err = uow.Commit()
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction commit error")
+		return nil, err
+	}
+
+	if hasStockLimits {
+		uc.catalog.Clear()
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"customer_id": customerModel.ID,
+		"lines":       len(receipt.Lines),
+		"total":       receipt.Total,
+	}).Info("Successfully checkout basket")
+
+	return receipt, nil
+}
+
+// paidPortions assigns the coin portions spent on the purchases to them in
+// order, price coins per purchase, and returns the portions left over. A
+// refund gives back exactly the portions of the purchase, so it doesn't
+// extend the life of the coins.
+func paidPortions(
+	purchaseIDs []uint,
+	price uint,
+	portions lotEntity.Portions,
+) ([]*model.CoinPortion, lotEntity.Portions) {
+	coinPortions := []*model.CoinPortion{}
+	for _, purchaseID := range purchaseIDs {
+		var paid lotEntity.Portions
+		paid, portions = portions.Take(price)
+		for _, portion := range paid {
+			coinPortions = append(coinPortions, &model.CoinPortion{
+				PurchaseID: purchaseID,
+				Amount:     portion.Amount,
+				ExpiresAt:  portion.ExpiresAt,
+			})
+		}
+	}
+
+	return coinPortions, portions
+}
+
+// debitCustomer debits the customer within uow. A gift of an item with user
+// limit locks the recipient as well, since the limit counts the items the
+// recipient got. Both rows are locked in ascending user id order, like
+// transfers do, so crossed gifts can't deadlock.
+func (uc *PurchaseUsecase) debitCustomer(
+	ctx context.Context,
+	uow uowI.Executor,
+	purchaseType *model.PurchaseType,
+	customerID uint,
+	recipientID uint,
+	price uint,
+) error {
+	lockRecipient := func() error {
+		if recipientID == 0 || purchaseType.UserLimit == nil {
+			return nil
+		}
+		_, err := uc.userRepo.GetForUpdate(ctx, uow, recipientID)
+		return err
+	}
+
+	if recipientID < customerID {
+		if err := lockRecipient(); err != nil {
+			return err
+		}
+		return uc.userRepo.Debit(ctx, uow, customerID, price)
+	}
+
+	if err := uc.userRepo.Debit(ctx, uow, customerID, price); err != nil {
+		return err
+	}
+	return lockRecipient()
+}
+
+// checkUserLimit fails with ErrPurchaseLimit if userID getting quantity more
+// items would exceed the user limit of the purchase type. It must be called
+// after debitCustomer within uow: the user row is locked by then, so
+// concurrent purchases for the same user wait and count each other.
+func (uc *PurchaseUsecase) checkUserLimit(
+	ctx context.Context,
+	uow uowI.Executor,
+	purchaseType *model.PurchaseType,
+	userID uint,
+	quantity uint,
+) error {
+	if purchaseType.UserLimit == nil {
+		return nil
+	}
+
+	item := dto.PurchaseTypeModelToCatalogItem(purchaseType)
+	bought, err := uc.purchaseRepo.CountUserPurchases(ctx, uow, userID, purchaseType.ID, item.UserLimitPeriod)
+	if err != nil {
+		return err
+	}
+	if bought+quantity > *item.UserLimit {
+		uc.logger.WithFields(logrus.Fields{
+			"product": purchaseType.Name,
+			"user_id": userID,
+			"bought":  bought,
+		}).Info("User reached purchase limit for product")
+		return entity.ErrPurchaseLimit
+	}
+
+	return nil
+}
+
+// getApplicablePromoCode returns the promo code if it can be used for the
+// purchase type now. Redemption limits are checked by redeemPromoCode.
+func (uc *PurchaseUsecase) getApplicablePromoCode(
+	ctx context.Context,
+	code string,
+	purchaseTypeID uint,
+) (*promoEntity.PromoCode, error) {
+	promoCodeModel, err := uc.promoRepo.GetByCode(ctx, promoDTO.NormalizeCode(code))
+	if err != nil {
+		uc.logger.WithError(err).WithField("code", code).Warn("Failed to get promo code")
+		return nil, err
+	}
+
+	promoCode := promoDTO.PromoCodeModelToEntity(promoCodeModel)
+	if !promoCode.IsActive(time.Now()) {
+		uc.logger.WithField("code", promoCode.Code).Info("Promo code is not active")
+		return nil, promoEntity.ErrPromoInactive
+	}
+
+	if !promoCode.AppliesTo(purchaseTypeID) {
+		uc.logger.WithField("code", promoCode.Code).Info("Promo code doesn't apply to product")
+		return nil, promoEntity.ErrPromoNotApplicable
+	}
+
+	return promoCode, nil
+}
+
+// redeemPromoCode counts the redemption within uow. The promo code row is
+// locked by Redeem first, so the per user count can't be raced by another
+// purchase with the same code.
+func (uc *PurchaseUsecase) redeemPromoCode(
+	ctx context.Context,
+	uow uowI.Executor,
+	promoCode *promoEntity.PromoCode,
+	userID uint,
+) error {
+	err := uc.promoRepo.Redeem(ctx, uow, promoCode.ID)
+	if err != nil {
+		return err
+	}
+
+	if promoCode.MaxPerUser == nil {
+		return nil
+	}
+
+	redemptions, err := uc.promoRepo.CountUserRedemptions(ctx, uow, promoCode.ID, userID)
+	if err != nil {
+		return err
+	}
+	if redemptions >= *promoCode.MaxPerUser {
+		uc.logger.WithFields(logrus.Fields{
+			"code":    promoCode.Code,
+			"user_id": userID,
+		}).Info("User reached promo code redemption limit")
+		return promoEntity.ErrPromoUserLimit
+	}
+
+	return nil
+}
+
+// journal posts coins paid for purchases or refunded within uow, next to the
+// balance change. Free purchases don't move coins and aren't journaled.
+func (uc *PurchaseUsecase) journal(
+	ctx context.Context,
+	uow uowI.Executor,
+	kind string,
+	reference string,
+	from ledgerEntity.Account,
+	to ledgerEntity.Account,
+	amount uint,
+) error {
+	if amount == 0 {
+		return nil
+	}
+
+	_, err := uc.ledgerRepo.Post(ctx, uow, ledgerEntity.NewTransfer(kind, reference, from, to, amount))
+	return err
+}
+
+func (uc *PurchaseUsecase) rollback(uow uowI.UnitOfWork) {
+	if err := uow.Rollback(); err != nil {
+		uc.logger.WithError(err).Error("Rollback error encountered")
+	}
+}
+
+func (uc *PurchaseUsecase) GetCatalog(ctx context.Context) ([]*entity.CatalogItem, error) {
+	if catalog, ok := uc.catalog.Get(catalogCacheKey); ok {
+		return catalog, nil
+	}
+
+	products, err := uc.purchaseRepo.GetProducts(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to get products")
+		return nil, err
+	}
+
+	catalog := dto.PurchaseTypeModelsToCatalog(products)
+	uc.catalog.Set(catalogCacheKey, catalog)
+
+	return catalog, nil
+}
+
+func (uc *PurchaseUsecase) GetCatalogItem(ctx context.Context, name string) (*entity.CatalogItem, error) {
+	catalog, err := uc.GetCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range catalog {
+		if item.Name == name {
+			return item, nil
+		}
+	}
+
+	return nil, entity.ErrNotExistedProduct
+}
+
+func (uc *PurchaseUsecase) CreateCatalogItem(
+	ctx context.Context,
+	item *entity.CatalogItem,
+) (*entity.CatalogItem, error) {
+	createdPurchaseType, err := uc.purchaseRepo.CreateProduct(ctx, dto.CatalogItemToPurchaseTypeModel(item))
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to create product")
+		return nil, err
+	}
+
+	uc.catalog.Clear()
+
+	uc.logger.WithFields(logrus.Fields{
+		"product": createdPurchaseType.Name,
+		"cost":    createdPurchaseType.Cost,
+	}).Info("Successfully create product")
+
+	return dto.PurchaseTypeModelToCatalogItem(createdPurchaseType), nil
+}
+
+func (uc *PurchaseUsecase) UpdateCatalogItem(
+	ctx context.Context,
+	name string,
+	update *entity.CatalogItemUpdate,
+) (*entity.CatalogItem, error) {
+	updatedPurchaseType, err := uc.purchaseRepo.UpdateProduct(ctx, name, update)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to update product")
+		return nil, err
+	}
+
+	uc.catalog.Clear()
+
+	uc.logger.WithFields(logrus.Fields{
+		"product":  name,
+		"new_name": updatedPurchaseType.Name,
+		"cost":     updatedPurchaseType.Cost,
+	}).Info("Successfully update product")
+
+	return dto.PurchaseTypeModelToCatalogItem(updatedPurchaseType), nil
+}
+
+func (uc *PurchaseUsecase) RestockCatalogItem(
+	ctx context.Context,
+	name string,
+	quantity uint,
+) (*entity.CatalogItem, error) {
+	restockedPurchaseType, err := uc.purchaseRepo.RestockProduct(ctx, name, quantity)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to restock product")
+		return nil, err
+	}
+
+	uc.catalog.Clear()
+
+	uc.logger.WithFields(logrus.Fields{
+		"product":  name,
+		"quantity": quantity,
+		"stock":    *restockedPurchaseType.Stock,
+	}).Info("Successfully restock product")
+
+	return dto.PurchaseTypeModelToCatalogItem(restockedPurchaseType), nil
+}
+
+func (uc *PurchaseUsecase) RetireCatalogItem(ctx context.Context, name string) error {
+	err := uc.purchaseRepo.RetireProduct(ctx, name)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to retire product")
+		return err
+	}
+
+	uc.catalog.Clear()
+
+	uc.logger.WithField("product", name).Info("Successfully retire product")
+
+	return nil
+}
+
+// GetPurchaseHistory returns one page of purchases, refunded ones included.
+// One extra row is requested to find out whether there is a next page.
+func (uc *PurchaseUsecase) GetPurchaseHistory(
+	ctx context.Context,
+	filter *entity.PurchaseHistoryFilter,
+) (*entity.PurchaseHistoryPage, error) {
+	pageFilter := *filter
+	pageFilter.Limit = filter.Limit + 1
+
+	records, err := uc.purchaseRepo.GetHistory(ctx, &pageFilter)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to get purchase history")
+		return nil, err
+	}
+
+	page := &entity.PurchaseHistoryPage{Items: records}
+	if uint(len(records)) > filter.Limit {
+		page.Items = records[:filter.Limit]
+		nextCursor := page.Items[len(page.Items)-1].ID
+		page.NextCursor = &nextCursor
+	}
+
+	return page, nil
+}
+
+// RefundPurchase refunds the user's own purchase if it was made within the
+// refund window and isn't delivered yet. Purchases of other users look as
+// if they don't exist.
+func (uc *PurchaseUsecase) RefundPurchase(
+	ctx context.Context,
+	userID uint,
+	purchaseID uint,
+) (*entity.PurchaseRecord, error) {
+	return uc.refund(ctx, purchaseID, func(purchase *model.Purchase) error {
+		if purchase.PurchaserID != userID {
+			uc.logger.WithFields(logrus.Fields{
+				"user_id":     userID,
+				"purchase_id": purchase.ID,
+			}).Warn("Trying to refund purchase of another user")
+			return entity.ErrPurchaseNotFound
+		}
+
+		if uc.refundWindow <= 0 || time.Since(purchase.CreatedAt) > uc.refundWindow {
+			uc.logger.WithField("purchase_id", purchase.ID).Info("Refund window has expired")
+			return entity.ErrRefundExpired
+		}
+
+		// Cancelled purchases are reported as already refunded by refund.
+		if purchase.RefundedAt == nil && !entity.CanRefundByUser(purchase.Status) {
+			uc.logger.WithField("purchase_id", purchase.ID).Info("Delivered purchase can't be refunded")
+			return entity.ErrRefundDelivered
+		}
+
+		return nil
+	})
+}
+
+// RefundPurchaseByAdmin refunds any purchase regardless of its age and
+// status.
+func (uc *PurchaseUsecase) RefundPurchaseByAdmin(
+	ctx context.Context,
+	purchaseID uint,
+) (*entity.PurchaseRecord, error) {
+	return uc.refund(ctx, purchaseID, func(*model.Purchase) error {
+		return nil
+	})
+}
+
+// refund marks the purchase refunded, returns the item to stock and the
+// promo code redemption, and credits its snapshot price back within one
+// unit of work. The purchase row is
+// locked first, so concurrent refunds of the same purchase credit once.
+// The stock is returned before the credit: purchases lock the product
+// before the customer, and refunds must take the locks in the same order.
+// Delivered items stay with the user and aren't returned to stock.
+func (uc *PurchaseUsecase) refund(
+	ctx context.Context,
+	purchaseID uint,
+	check func(purchase *model.Purchase) error,
+) (*entity.PurchaseRecord, error) {
+	uow := uc.uowFactory.NewUnitOfWork()
+
+	err := uow.Begin(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction begin error")
+		return nil, err
+	}
+
+	purchase, err := uc.purchaseRepo.GetForUpdate(ctx, uow, purchaseID)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Warn("Rollback refund due purchase selecting")
+		return nil, err
+	}
+
+	err = check(purchase)
+	if err != nil {
+		uc.rollback(uow)
+		return nil, err
+	}
+
+	if purchase.RefundedAt != nil {
+		uc.rollback(uow)
+		uc.logger.WithField("purchase_id", purchase.ID).Info("Purchase is already refunded")
+		return nil, entity.ErrAlreadyRefunded
+	}
+
+	refundedPurchase, err := uc.purchaseRepo.MarkRefunded(ctx, uow, purchase.ID)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Warn("Rollback refund due purchase updating")
+		return nil, err
+	}
+
+	if purchase.PurchaseTypeID != 0 && purchase.Status != entity.StatusDelivered {
+		err = uc.purchaseRepo.ReturnStock(ctx, uow, purchase.PurchaseTypeID, 1)
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Error("Rollback refund due stock returning")
+			return nil, err
+		}
+	}
+
+	if purchase.PromoCodeID != 0 {
+		err = uc.promoRepo.Release(ctx, uow, purchase.PromoCodeID)
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Error("Rollback refund due promo code releasing")
+			return nil, err
+		}
+	}
+
+	err = uc.userRepo.Credit(ctx, uow, purchase.PurchaserID, purchase.Price)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback refund due user updating")
+		return nil, err
+	}
+
+	coinPortions, err := uc.purchaseRepo.GetCoinPortions(ctx, uow, purchase.ID)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback refund due coin portions selecting")
+		return nil, err
+	}
+
+	portions := make(lotEntity.Portions, 0, len(coinPortions))
+	for _, coinPortion := range coinPortions {
+		portions = append(portions, lotEntity.Portion{
+			Amount:    coinPortion.Amount,
+			ExpiresAt: coinPortion.ExpiresAt,
+		})
+	}
+
+	err = uc.lotRepo.Create(ctx, uow, purchase.PurchaserID, portions)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback refund due coin lot creating")
+		return nil, err
+	}
+
+	err = uc.journal(
+		ctx,
+		uow,
+		ledgerEntity.KindRefund,
+		fmt.Sprintf("purchase:%d", purchase.ID),
+		ledgerEntity.ShopAccount,
+		ledgerEntity.UserAccount(purchase.PurchaserID),
+		purchase.Price,
+	)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback refund due ledger posting")
+		return nil, err
+	}
+
+	err = uow.Commit()
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction commit error")
+		return nil, err
+	}
+
+	uc.catalog.Clear()
+
+	uc.logger.WithFields(logrus.Fields{
+		"purchase_id":  purchase.ID,
+		"purchaser_id": purchase.PurchaserID,
+		"recipient_id": purchase.RecipientID,
+		"price":        purchase.Price,
+	}).Info("Successfully refund purchase")
+
+	return dto.PurchaseModelToRecord(refundedPurchase), nil
+}
+
+// UpdatePurchaseStatus moves the purchase along the fulfilment workflow.
+// Cancelling refunds the purchase in the same unit of work.
+func (uc *PurchaseUsecase) UpdatePurchaseStatus(
+	ctx context.Context,
+	purchaseID uint,
+	status string,
+) (*entity.PurchaseRecord, error) {
+	checkTransition := func(purchase *model.Purchase) error {
+		if !entity.CanChangeStatus(purchase.Status, status) {
+			uc.logger.WithFields(logrus.Fields{
+				"purchase_id": purchase.ID,
+				"from":        purchase.Status,
+				"to":          status,
+			}).Info("Invalid purchase status transition")
+			return entity.ErrStatusTransition
+		}
+		return nil
+	}
+
+	if status == entity.StatusCancelled {
+		return uc.refund(ctx, purchaseID, checkTransition)
+	}
+
+	uow := uc.uowFactory.NewUnitOfWork()
+
+	err := uow.Begin(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction begin error")
+		return nil, err
+	}
+
+	purchase, err := uc.purchaseRepo.GetForUpdate(ctx, uow, purchaseID)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Warn("Rollback status update due purchase selecting")
+		return nil, err
+	}
+
+	err = checkTransition(purchase)
+	if err != nil {
+		uc.rollback(uow)
+		return nil, err
+	}
+
+	updatedPurchase, err := uc.purchaseRepo.UpdateStatus(ctx, uow, purchase.ID, status)
+	if err != nil {
+		uc.rollback(uow)
+		uc.logger.WithError(err).Error("Rollback status update due purchase updating")
+		return nil, err
+	}
+
+	err = uow.Commit()
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction commit error")
+		return nil, err
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"purchase_id":  purchase.ID,
+		"recipient_id": purchase.RecipientID,
+		"status":       status,
+	}).Info("Successfully update purchase status")
+
+	return dto.PurchaseModelToRecord(updatedPurchase), nil
+}
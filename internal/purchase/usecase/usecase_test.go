@@ -3,18 +3,33 @@ package usecase
 import (
 	"context"
 	"errors"
+	"math"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
 
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	idempotencyEntity "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	idempotencyModel "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/model"
+	mockIdempotency "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository/mock_repository"
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerModel "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/model"
+	mockLedger "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/mock_repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	mockLot "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/mock_repository"
+	promoEntity "github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/entity"
+	promoModel "github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/model"
+	mockPromo "github.com/artrsyf/avito-trainee-assignment/internal/promo/repository/mock_repository"
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/dto"
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/entity"
 	purchaseModel "github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/model"
 	mockPurchase "github.com/artrsyf/avito-trainee-assignment/internal/purchase/repository/mock_repository"
+	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
 	userModel "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/model"
 	mockUser "github.com/artrsyf/avito-trainee-assignment/internal/user/repository/mock_repository"
-	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
 	mockUow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/mock_uow"
 )
 
@@ -24,10 +39,14 @@ func TestPurchaseUsecase_Create(t *testing.T) {
 
 	mockPurchaseRepo := mockPurchase.NewMockPurchaseRepositoryI(ctrl)
 	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockIdempotencyRepo := mockIdempotency.NewMockIdempotencyRepositoryI(ctrl)
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
+	mockPromoRepo := mockPromo.NewMockPromoRepositoryI(ctrl)
 	mockUowFactory := mockUow.NewMockFactory(ctrl)
 	mockUow := mockUow.NewMockUnitOfWork(ctrl)
 
-	uc := NewPurchaseUsecase(mockPurchaseRepo, mockUserRepo, mockUowFactory, logrus.New())
+	uc := NewPurchaseUsecase(mockPurchaseRepo, mockUserRepo, mockIdempotencyRepo, mockPromoRepo, mockLedgerRepo, mockLotRepo, mockUowFactory, config.PurchaseConfig{}, logrus.New())
 
 	ctx := context.Background()
 	testRequest := &dto.PurchaseItemRequest{
@@ -35,13 +54,18 @@ func TestPurchaseUsecase_Create(t *testing.T) {
 		PurchaseTypeName: "premium",
 	}
 
+	stock := uint(10)
 	testPurchaseType := &purchaseModel.PurchaseType{
-		Name: "premium",
-		Cost: 100,
+		ID:        7,
+		Name:      "premium",
+		Cost:      100,
+		Available: true,
+		Stock:     &stock,
 	}
 
 	t.Run("successful purchase", func(t *testing.T) {
 		user := &userModel.User{ID: 1, Coins: 200}
+		soon, later := time.Now().Add(time.Hour), time.Now().Add(2*time.Hour)
 
 		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
 		mockUow.EXPECT().Begin(ctx).Return(nil)
@@ -49,40 +73,144 @@ func TestPurchaseUsecase_Create(t *testing.T) {
 
 		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
 		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
-		mockUserRepo.EXPECT().Update(ctx, mockUow, gomock.Any()).DoAndReturn(
-			func(_ context.Context, _ uow.UnitOfWork, u *userModel.User) error {
-				if u.Coins != 100 {
-					t.Error("user coins not updated correctly")
-				}
-				return nil
-			})
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(lotEntity.Portions{
+			{Amount: 30, ExpiresAt: &soon},
+			{Amount: 70, ExpiresAt: &later},
+		}, nil)
 		mockPurchaseRepo.EXPECT().Create(ctx, mockUow, &entity.Purchase{
 			PurchaserID:      1,
+			PurchaseTypeID:   7,
 			PurchaseTypeName: "premium",
+			Price:            100,
 		}).Return(&purchaseModel.Purchase{ID: 1}, nil)
+		mockPurchaseRepo.EXPECT().CreateCoinPortions(ctx, mockUow, []*purchaseModel.CoinPortion{
+			{PurchaseID: 1, Amount: 30, ExpiresAt: &soon},
+			{PurchaseID: 1, Amount: 70, ExpiresAt: &later},
+		}).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, ledgerEntity.NewTransfer(
+			ledgerEntity.KindPurchase,
+			"purchase:1",
+			ledgerEntity.UserAccount(1),
+			ledgerEntity.ShopAccount,
+			100,
+		)).Return(&ledgerModel.Entry{ID: 1}, nil)
 
-		err := uc.Create(ctx, testRequest)
+		err := uc.Create(ctx, testRequest, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
 
+	t.Run("idempotency key is reserved with purchase", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+		record := &idempotencyEntity.Record{UserID: 1, Key: "key", RequestHash: "hash", ResponseStatus: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockIdempotencyRepo.EXPECT().Create(ctx, mockUow, &idempotencyModel.Record{
+			UserID:         1,
+			Key:            "key",
+			RequestHash:    "hash",
+			ResponseStatus: 200,
+		}).Return(nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockPurchaseRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&purchaseModel.Purchase{ID: 1}, nil)
+		mockPurchaseRepo.EXPECT().CreateCoinPortions(ctx, mockUow, gomock.Any()).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 1}, nil)
+
+		err := uc.Create(ctx, testRequest, record)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("idempotency key is already used", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+		record := &idempotencyEntity.Record{UserID: 1, Key: "key", RequestHash: "hash", ResponseStatus: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockIdempotencyRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(idempotencyEntity.ErrAlreadyCreated)
+
+		err := uc.Create(ctx, testRequest, record)
+		if !errors.Is(err, idempotencyEntity.ErrAlreadyCreated) {
+			t.Errorf("expected ErrAlreadyCreated, got %v", err)
+		}
+	})
+
 	t.Run("insufficient balance", func(t *testing.T) {
 		user := &userModel.User{ID: 1, Coins: 50}
 
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
 		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
 		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(userEntity.ErrNotEnoughBalance)
 
-		err := uc.Create(ctx, testRequest)
+		err := uc.Create(ctx, testRequest, nil)
 		if !errors.Is(err, entity.ErrNotEnoughBalance) {
 			t.Errorf("expected ErrNotEnoughBalance, got %v", err)
 		}
 	})
 
+	t.Run("out of stock", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(entity.ErrOutOfStock)
+
+		err := uc.Create(ctx, testRequest, nil)
+		if !errors.Is(err, entity.ErrOutOfStock) {
+			t.Errorf("expected ErrOutOfStock, got %v", err)
+		}
+	})
+
+	t.Run("unlimited product doesn't touch stock", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+		unlimitedPurchaseType := &purchaseModel.PurchaseType{ID: 7, Name: "premium", Cost: 100, Available: true}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(unlimitedPurchaseType, nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockPurchaseRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&purchaseModel.Purchase{ID: 1}, nil)
+		mockPurchaseRepo.EXPECT().CreateCoinPortions(ctx, mockUow, gomock.Any()).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 1}, nil)
+
+		err := uc.Create(ctx, testRequest, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
 	t.Run("user not found", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(nil, errors.New("not found"))
 
-		err := uc.Create(ctx, testRequest)
+		err := uc.Create(ctx, testRequest, nil)
 		if err == nil {
 			t.Error("expected error but got nil")
 		}
@@ -94,12 +222,27 @@ func TestPurchaseUsecase_Create(t *testing.T) {
 		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
 		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(nil, errors.New("not found"))
 
-		err := uc.Create(ctx, testRequest)
+		err := uc.Create(ctx, testRequest, nil)
 		if err == nil {
 			t.Error("expected error but got nil")
 		}
 	})
 
+	t.Run("product is not available", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(&purchaseModel.PurchaseType{
+			Name: "premium",
+			Cost: 100,
+		}, nil)
+
+		err := uc.Create(ctx, testRequest, nil)
+		if err != entity.ErrUnavailableProduct {
+			t.Errorf("expected ErrUnavailableProduct, got %v", err)
+		}
+	})
+
 	t.Run("begin transaction error", func(t *testing.T) {
 		user := &userModel.User{ID: 1, Coins: 200}
 
@@ -109,7 +252,7 @@ func TestPurchaseUsecase_Create(t *testing.T) {
 		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
 		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
 
-		err := uc.Create(ctx, testRequest)
+		err := uc.Create(ctx, testRequest, nil)
 		if err == nil {
 			t.Error("expected error but got nil")
 		}
@@ -124,9 +267,10 @@ func TestPurchaseUsecase_Create(t *testing.T) {
 
 		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
 		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
-		mockUserRepo.EXPECT().Update(ctx, mockUow, gomock.Any()).Return(errors.New("update error"))
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(errors.New("update error"))
 
-		err := uc.Create(ctx, testRequest)
+		err := uc.Create(ctx, testRequest, nil)
 		if err == nil {
 			t.Error("expected error but got nil")
 		}
@@ -141,10 +285,12 @@ func TestPurchaseUsecase_Create(t *testing.T) {
 
 		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
 		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
-		mockUserRepo.EXPECT().Update(ctx, mockUow, gomock.Any()).Return(nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
 		mockPurchaseRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(nil, errors.New("create error"))
 
-		err := uc.Create(ctx, testRequest)
+		err := uc.Create(ctx, testRequest, nil)
 		if err == nil {
 			t.Error("expected error but got nil")
 		}
@@ -159,12 +305,1182 @@ func TestPurchaseUsecase_Create(t *testing.T) {
 
 		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
 		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
-		mockUserRepo.EXPECT().Update(ctx, mockUow, gomock.Any()).Return(nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
 		mockPurchaseRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&purchaseModel.Purchase{ID: 1}, nil)
+		mockPurchaseRepo.EXPECT().CreateCoinPortions(ctx, mockUow, gomock.Any()).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 1}, nil)
 
-		err := uc.Create(ctx, testRequest)
+		err := uc.Create(ctx, testRequest, nil)
 		if err == nil {
 			t.Error("expected error but got nil")
 		}
 	})
+
+	t.Run("ledger posting error", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockPurchaseRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&purchaseModel.Purchase{ID: 1}, nil)
+		mockPurchaseRepo.EXPECT().CreateCoinPortions(ctx, mockUow, gomock.Any()).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(nil, errors.New("db error"))
+
+		err := uc.Create(ctx, testRequest, nil)
+		if err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+
+	userLimit := uint(1)
+	userLimitPeriod := uint(86400)
+	limitedPurchaseType := &purchaseModel.PurchaseType{
+		ID:              7,
+		Name:            "premium",
+		Cost:            100,
+		Available:       true,
+		UserLimit:       &userLimit,
+		UserLimitPeriod: &userLimitPeriod,
+	}
+
+	t.Run("purchase within user limit", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(limitedPurchaseType, nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockPurchaseRepo.EXPECT().CountUserPurchases(ctx, mockUow, uint(1), uint(7), 24*time.Hour).Return(uint(0), nil)
+		mockPurchaseRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&purchaseModel.Purchase{ID: 1}, nil)
+		mockPurchaseRepo.EXPECT().CreateCoinPortions(ctx, mockUow, gomock.Any()).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 1}, nil)
+
+		err := uc.Create(ctx, testRequest, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("user limit reached", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(limitedPurchaseType, nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockPurchaseRepo.EXPECT().CountUserPurchases(ctx, mockUow, uint(1), uint(7), 24*time.Hour).Return(uint(1), nil)
+
+		err := uc.Create(ctx, testRequest, nil)
+		if err != entity.ErrPurchaseLimit {
+			t.Errorf("expected ErrPurchaseLimit, got %v", err)
+		}
+	})
+
+	promoRequest := &dto.PurchaseItemRequest{
+		UserID:           1,
+		PurchaseTypeName: "premium",
+		PromoCode:        " spring30 ",
+	}
+	maxPerUser := uint(1)
+	testPromoCode := &promoModel.PromoCode{
+		ID:            3,
+		Code:          "SPRING30",
+		DiscountType:  promoEntity.DiscountPercent,
+		DiscountValue: 30,
+		MaxPerUser:    &maxPerUser,
+	}
+
+	t.Run("purchase with promo code", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockPromoRepo.EXPECT().GetByCode(ctx, "SPRING30").Return(testPromoCode, nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(nil)
+		mockPromoRepo.EXPECT().Redeem(ctx, mockUow, uint(3)).Return(nil)
+		mockPromoRepo.EXPECT().CountUserRedemptions(ctx, mockUow, uint(3), uint(1)).Return(uint(0), nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(70)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(70)).Return(nil, nil)
+		mockPurchaseRepo.EXPECT().Create(ctx, mockUow, &entity.Purchase{
+			PurchaserID:      1,
+			PurchaseTypeID:   7,
+			PurchaseTypeName: "premium",
+			Price:            70,
+			PromoCodeID:      3,
+			Discount:         30,
+		}).Return(&purchaseModel.Purchase{ID: 1}, nil)
+		mockPurchaseRepo.EXPECT().CreateCoinPortions(ctx, mockUow, gomock.Any()).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 1}, nil)
+
+		err := uc.Create(ctx, promoRequest, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("promo code not found", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockPromoRepo.EXPECT().GetByCode(ctx, "SPRING30").Return(nil, promoEntity.ErrPromoNotFound)
+
+		err := uc.Create(ctx, promoRequest, nil)
+		if err != promoEntity.ErrPromoNotFound {
+			t.Errorf("expected ErrPromoNotFound, got %v", err)
+		}
+	})
+
+	t.Run("promo code is expired", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+		validUntil := time.Now().Add(-time.Hour)
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockPromoRepo.EXPECT().GetByCode(ctx, "SPRING30").Return(&promoModel.PromoCode{
+			ID:            3,
+			Code:          "SPRING30",
+			DiscountType:  promoEntity.DiscountPercent,
+			DiscountValue: 30,
+			ValidUntil:    &validUntil,
+		}, nil)
+
+		err := uc.Create(ctx, promoRequest, nil)
+		if err != promoEntity.ErrPromoInactive {
+			t.Errorf("expected ErrPromoInactive, got %v", err)
+		}
+	})
+
+	t.Run("promo code is scoped to another item", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+		otherItemID := uint(8)
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockPromoRepo.EXPECT().GetByCode(ctx, "SPRING30").Return(&promoModel.PromoCode{
+			ID:             3,
+			Code:           "SPRING30",
+			DiscountType:   promoEntity.DiscountFixed,
+			DiscountValue:  10,
+			PurchaseTypeID: &otherItemID,
+		}, nil)
+
+		err := uc.Create(ctx, promoRequest, nil)
+		if err != promoEntity.ErrPromoNotApplicable {
+			t.Errorf("expected ErrPromoNotApplicable, got %v", err)
+		}
+	})
+
+	t.Run("promo code is fully redeemed", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockPromoRepo.EXPECT().GetByCode(ctx, "SPRING30").Return(testPromoCode, nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(nil)
+		mockPromoRepo.EXPECT().Redeem(ctx, mockUow, uint(3)).Return(promoEntity.ErrPromoExhausted)
+
+		err := uc.Create(ctx, promoRequest, nil)
+		if err != promoEntity.ErrPromoExhausted {
+			t.Errorf("expected ErrPromoExhausted, got %v", err)
+		}
+	})
+
+	t.Run("promo code user limit reached", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockPromoRepo.EXPECT().GetByCode(ctx, "SPRING30").Return(testPromoCode, nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(nil)
+		mockPromoRepo.EXPECT().Redeem(ctx, mockUow, uint(3)).Return(nil)
+		mockPromoRepo.EXPECT().CountUserRedemptions(ctx, mockUow, uint(3), uint(1)).Return(uint(1), nil)
+
+		err := uc.Create(ctx, promoRequest, nil)
+		if err != promoEntity.ErrPromoUserLimit {
+			t.Errorf("expected ErrPromoUserLimit, got %v", err)
+		}
+	})
+
+	giftRequest := &dto.PurchaseItemRequest{
+		UserID:            1,
+		PurchaseTypeName:  "premium",
+		RecipientUsername: "colleague",
+	}
+
+	t.Run("gift to another user", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "colleague").Return(&userModel.User{ID: 2}, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(testPurchaseType, nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(7), uint(1)).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockPurchaseRepo.EXPECT().Create(ctx, mockUow, &entity.Purchase{
+			PurchaserID:      1,
+			RecipientID:      2,
+			PurchaseTypeID:   7,
+			PurchaseTypeName: "premium",
+			Price:            100,
+		}).Return(&purchaseModel.Purchase{ID: 1}, nil)
+		mockPurchaseRepo.EXPECT().CreateCoinPortions(ctx, mockUow, gomock.Any()).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 1}, nil)
+
+		err := uc.Create(ctx, giftRequest, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("gift counts user limit of recipient", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Coins: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "colleague").Return(&userModel.User{ID: 2}, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "premium").Return(limitedPurchaseType, nil)
+		gomock.InOrder(
+			mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil),
+			mockUserRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(2)).Return(&userModel.User{ID: 2}, nil),
+		)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockPurchaseRepo.EXPECT().CountUserPurchases(ctx, mockUow, uint(2), uint(7), 24*time.Hour).Return(uint(1), nil)
+
+		err := uc.Create(ctx, giftRequest, nil)
+		if !errors.Is(err, entity.ErrPurchaseLimit) {
+			t.Errorf("expected ErrPurchaseLimit, got %v", err)
+		}
+	})
+
+	t.Run("gift recipient not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(&userModel.User{ID: 1, Coins: 200}, nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "colleague").Return(nil, userEntity.ErrIsNotExist)
+
+		err := uc.Create(ctx, giftRequest, nil)
+		if err != entity.ErrRecipientNotFound {
+			t.Errorf("expected ErrRecipientNotFound, got %v", err)
+		}
+	})
+
+	t.Run("gift to yourself", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(&userModel.User{ID: 1, Coins: 200}, nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "colleague").Return(&userModel.User{ID: 1}, nil)
+
+		err := uc.Create(ctx, giftRequest, nil)
+		if err != entity.ErrSelfGift {
+			t.Errorf("expected ErrSelfGift, got %v", err)
+		}
+	})
+}
+
+func TestPurchaseUsecase_Checkout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPurchaseRepo := mockPurchase.NewMockPurchaseRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockIdempotencyRepo := mockIdempotency.NewMockIdempotencyRepositoryI(ctrl)
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+	mockUow := mockUow.NewMockUnitOfWork(ctrl)
+
+	uc := NewPurchaseUsecase(mockPurchaseRepo, mockUserRepo, mockIdempotencyRepo, nil, mockLedgerRepo, mockLotRepo, mockUowFactory, config.PurchaseConfig{}, logrus.New())
+
+	ctx := context.Background()
+	user := &userModel.User{ID: 1, Coins: 1000}
+	penStock := uint(10)
+	pen := &purchaseModel.PurchaseType{ID: 3, Name: "pen", Cost: 10, Available: true, Stock: &penStock}
+	cup := &purchaseModel.PurchaseType{ID: 4, Name: "cup", Cost: 20, Available: true}
+	testRequest := &dto.CheckoutRequest{
+		UserID: 1,
+		Items: []dto.CheckoutLineRequest{
+			{Item: "pen", Quantity: 2},
+			{Item: "cup", Quantity: 1},
+			{Item: "pen", Quantity: 3},
+		},
+	}
+
+	t.Run("successful checkout", func(t *testing.T) {
+		record := &idempotencyEntity.Record{UserID: 1, Key: "key", RequestHash: "hash", ResponseStatus: 200}
+		soon := time.Now().Add(time.Hour)
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "cup").Return(cup, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "pen").Return(pen, nil)
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockIdempotencyRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(3), uint(5)).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(70)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(70)).Return(lotEntity.Portions{
+			{Amount: 35, ExpiresAt: &soon},
+			{Amount: 35},
+		}, nil)
+		mockPurchaseRepo.EXPECT().CreateBatch(ctx, mockUow, &entity.Purchase{
+			PurchaserID:      1,
+			PurchaseTypeID:   4,
+			PurchaseTypeName: "cup",
+			Price:            20,
+		}, uint(1)).Return([]uint{21}, nil)
+		mockPurchaseRepo.EXPECT().CreateBatch(ctx, mockUow, &entity.Purchase{
+			PurchaserID:      1,
+			PurchaseTypeID:   3,
+			PurchaseTypeName: "pen",
+			Price:            10,
+		}, uint(5)).Return([]uint{22, 23, 24, 25, 26}, nil)
+		mockPurchaseRepo.EXPECT().CreateCoinPortions(ctx, mockUow, []*purchaseModel.CoinPortion{
+			{PurchaseID: 21, Amount: 20, ExpiresAt: &soon},
+			{PurchaseID: 22, Amount: 10, ExpiresAt: &soon},
+			{PurchaseID: 23, Amount: 5, ExpiresAt: &soon},
+			{PurchaseID: 23, Amount: 5},
+			{PurchaseID: 24, Amount: 10},
+			{PurchaseID: 25, Amount: 10},
+			{PurchaseID: 26, Amount: 10},
+		}).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, ledgerEntity.NewTransfer(
+			ledgerEntity.KindPurchase,
+			"checkout:21",
+			ledgerEntity.UserAccount(1),
+			ledgerEntity.ShopAccount,
+			70,
+		)).Return(&ledgerModel.Entry{ID: 1}, nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		receipt, err := uc.Checkout(ctx, testRequest, record)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := &entity.Receipt{
+			Lines: []entity.ReceiptLine{
+				{PurchaseTypeName: "cup", Quantity: 1, Price: 20},
+				{PurchaseTypeName: "pen", Quantity: 5, Price: 10},
+			},
+			Total: 70,
+		}
+		if !reflect.DeepEqual(receipt, expected) {
+			t.Errorf("expected receipt %+v, got %+v", expected, receipt)
+		}
+		if len(record.ResponseBody) == 0 {
+			t.Error("expected receipt to be stored with idempotency key")
+		}
+	})
+
+	t.Run("insufficient balance fails whole basket", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "cup").Return(cup, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "pen").Return(pen, nil)
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(3), uint(5)).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(70)).Return(userEntity.ErrNotEnoughBalance)
+		mockUow.EXPECT().Rollback()
+
+		_, err := uc.Checkout(ctx, testRequest, nil)
+		if !errors.Is(err, entity.ErrNotEnoughBalance) {
+			t.Errorf("expected ErrNotEnoughBalance, got %v", err)
+		}
+	})
+
+	t.Run("out of stock", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "cup").Return(cup, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "pen").Return(pen, nil)
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(3), uint(5)).Return(entity.ErrOutOfStock)
+		mockUow.EXPECT().Rollback()
+
+		_, err := uc.Checkout(ctx, testRequest, nil)
+		if !errors.Is(err, entity.ErrOutOfStock) {
+			t.Errorf("expected ErrOutOfStock, got %v", err)
+		}
+	})
+
+	t.Run("basket exceeds user limit", func(t *testing.T) {
+		penLimit := uint(6)
+		limitedPen := &purchaseModel.PurchaseType{ID: 3, Name: "pen", Cost: 10, Available: true, UserLimit: &penLimit}
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "cup").Return(cup, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "pen").Return(limitedPen, nil)
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(70)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(70)).Return(nil, nil)
+		mockPurchaseRepo.EXPECT().CountUserPurchases(ctx, mockUow, uint(1), uint(3), time.Duration(0)).Return(uint(2), nil)
+		mockUow.EXPECT().Rollback()
+
+		_, err := uc.Checkout(ctx, testRequest, nil)
+		if !errors.Is(err, entity.ErrPurchaseLimit) {
+			t.Errorf("expected ErrPurchaseLimit, got %v", err)
+		}
+	})
+
+	t.Run("total beyond any balance", func(t *testing.T) {
+		expensivePen := &purchaseModel.PurchaseType{ID: 3, Name: "pen", Cost: math.MaxInt32, Available: true}
+
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "cup").Return(cup, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "pen").Return(expensivePen, nil)
+
+		_, err := uc.Checkout(ctx, testRequest, nil)
+		if !errors.Is(err, entity.ErrNotEnoughBalance) {
+			t.Errorf("expected ErrNotEnoughBalance, got %v", err)
+		}
+	})
+
+	t.Run("unknown item", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "cup").Return(nil, entity.ErrNotExistedProduct)
+
+		_, err := uc.Checkout(ctx, testRequest, nil)
+		if !errors.Is(err, entity.ErrNotExistedProduct) {
+			t.Errorf("expected ErrNotExistedProduct, got %v", err)
+		}
+	})
+
+	t.Run("unavailable item", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "cup").Return(&purchaseModel.PurchaseType{ID: 4, Name: "cup", Cost: 20}, nil)
+
+		_, err := uc.Checkout(ctx, testRequest, nil)
+		if !errors.Is(err, entity.ErrUnavailableProduct) {
+			t.Errorf("expected ErrUnavailableProduct, got %v", err)
+		}
+	})
+
+	t.Run("purchases insert error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "cup").Return(cup, nil)
+		mockPurchaseRepo.EXPECT().GetProductByType(ctx, "pen").Return(pen, nil)
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().DecrementStock(ctx, mockUow, uint(3), uint(5)).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(70)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(70)).Return(nil, nil)
+		mockPurchaseRepo.EXPECT().CreateBatch(ctx, mockUow, gomock.Any(), uint(1)).Return(nil, errors.New("insert error"))
+		mockUow.EXPECT().Rollback()
+
+		_, err := uc.Checkout(ctx, testRequest, nil)
+		if err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
+
+func TestPurchaseUsecase_GetCatalog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPurchaseRepo := mockPurchase.NewMockPurchaseRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockIdempotencyRepo := mockIdempotency.NewMockIdempotencyRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+
+	ctx := context.Background()
+	products := []*purchaseModel.PurchaseType{
+		{ID: 1, Name: "cup", Cost: 20, Available: true},
+		{ID: 2, Name: "hoody", Cost: 300, Available: false},
+	}
+	expectedCatalog := []*entity.CatalogItem{
+		{Name: "cup", Cost: 20, Available: true},
+		{Name: "hoody", Cost: 300, Available: false},
+	}
+
+	t.Run("catalog is cached", func(t *testing.T) {
+		uc := NewPurchaseUsecase(
+			mockPurchaseRepo,
+			mockUserRepo,
+			mockIdempotencyRepo,
+			nil,
+			nil,
+			nil,
+			mockUowFactory,
+			config.PurchaseConfig{CatalogCacheTTL: "1m"},
+			logrus.New(),
+		)
+
+		mockPurchaseRepo.EXPECT().GetProducts(ctx).Return(products, nil).Times(1)
+
+		for i := 0; i < 2; i++ {
+			catalog, err := uc.GetCatalog(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(catalog, expectedCatalog) {
+				t.Errorf("unexpected catalog: %+v", catalog)
+			}
+		}
+
+		item, err := uc.GetCatalogItem(ctx, "hoody")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(item, expectedCatalog[1]) {
+			t.Errorf("unexpected catalog item: %+v", item)
+		}
+
+		_, err = uc.GetCatalogItem(ctx, "umbrella")
+		if err != entity.ErrNotExistedProduct {
+			t.Errorf("expected ErrNotExistedProduct, got %v", err)
+		}
+	})
+
+	t.Run("cache disabled", func(t *testing.T) {
+		uc := NewPurchaseUsecase(
+			mockPurchaseRepo,
+			mockUserRepo,
+			mockIdempotencyRepo,
+			nil,
+			nil,
+			nil,
+			mockUowFactory,
+			config.PurchaseConfig{},
+			logrus.New(),
+		)
+
+		mockPurchaseRepo.EXPECT().GetProducts(ctx).Return(products, nil).Times(2)
+
+		for i := 0; i < 2; i++ {
+			if _, err := uc.GetCatalog(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		uc := NewPurchaseUsecase(
+			mockPurchaseRepo,
+			mockUserRepo,
+			mockIdempotencyRepo,
+			nil,
+			nil,
+			nil,
+			mockUowFactory,
+			config.PurchaseConfig{CatalogCacheTTL: "1m"},
+			logrus.New(),
+		)
+
+		mockPurchaseRepo.EXPECT().GetProducts(ctx).Return(nil, errors.New("db error"))
+
+		_, err := uc.GetCatalogItem(ctx, "cup")
+		if err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
+
+func TestPurchaseUsecase_ManageCatalog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPurchaseRepo := mockPurchase.NewMockPurchaseRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockIdempotencyRepo := mockIdempotency.NewMockIdempotencyRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+
+	uc := NewPurchaseUsecase(
+		mockPurchaseRepo,
+		mockUserRepo,
+		mockIdempotencyRepo,
+		nil,
+		nil,
+		nil,
+		mockUowFactory,
+		config.PurchaseConfig{CatalogCacheTTL: "1m"},
+		logrus.New(),
+	)
+
+	ctx := context.Background()
+	newCost := uint(350)
+
+	// expectReload asserts the catalog is loaded from the repository once and
+	// then served from the cache until the next change invalidates it.
+	expectReload := func(t *testing.T) {
+		mockPurchaseRepo.EXPECT().GetProducts(ctx).Return([]*purchaseModel.PurchaseType{}, nil)
+		for i := 0; i < 2; i++ {
+			if _, err := uc.GetCatalog(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	expectReload(t)
+
+	t.Run("create item invalidates catalog", func(t *testing.T) {
+		mockPurchaseRepo.EXPECT().
+			CreateProduct(ctx, &purchaseModel.PurchaseType{Name: "sticker", Cost: 5, Available: true}).
+			Return(&purchaseModel.PurchaseType{ID: 11, Name: "sticker", Cost: 5, Available: true}, nil)
+
+		item, err := uc.CreateCatalogItem(ctx, &entity.CatalogItem{Name: "sticker", Cost: 5, Available: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(item, &entity.CatalogItem{Name: "sticker", Cost: 5, Available: true}) {
+			t.Errorf("unexpected item: %+v", item)
+		}
+
+		expectReload(t)
+	})
+
+	t.Run("create existing item", func(t *testing.T) {
+		mockPurchaseRepo.EXPECT().CreateProduct(ctx, gomock.Any()).Return(nil, entity.ErrProductExists)
+
+		_, err := uc.CreateCatalogItem(ctx, &entity.CatalogItem{Name: "cup", Cost: 5})
+		if err != entity.ErrProductExists {
+			t.Errorf("expected ErrProductExists, got %v", err)
+		}
+	})
+
+	t.Run("update item invalidates catalog", func(t *testing.T) {
+		update := &entity.CatalogItemUpdate{Cost: &newCost}
+		mockPurchaseRepo.EXPECT().
+			UpdateProduct(ctx, "hoody", update).
+			Return(&purchaseModel.PurchaseType{ID: 6, Name: "hoody", Cost: 350, Available: true}, nil)
+
+		item, err := uc.UpdateCatalogItem(ctx, "hoody", update)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if item.Cost != 350 {
+			t.Errorf("expected cost 350, got %d", item.Cost)
+		}
+
+		expectReload(t)
+	})
+
+	t.Run("update missing item", func(t *testing.T) {
+		mockPurchaseRepo.EXPECT().UpdateProduct(ctx, "unknown", gomock.Any()).Return(nil, entity.ErrNotExistedProduct)
+
+		_, err := uc.UpdateCatalogItem(ctx, "unknown", &entity.CatalogItemUpdate{Cost: &newCost})
+		if err != entity.ErrNotExistedProduct {
+			t.Errorf("expected ErrNotExistedProduct, got %v", err)
+		}
+	})
+
+	t.Run("restock item invalidates catalog", func(t *testing.T) {
+		stock := uint(15)
+		mockPurchaseRepo.EXPECT().
+			RestockProduct(ctx, "hoody", uint(10)).
+			Return(&purchaseModel.PurchaseType{ID: 6, Name: "hoody", Cost: 350, Available: true, Stock: &stock}, nil)
+
+		item, err := uc.RestockCatalogItem(ctx, "hoody", 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if item.Stock == nil || *item.Stock != 15 {
+			t.Errorf("expected stock 15, got %v", item.Stock)
+		}
+
+		expectReload(t)
+	})
+
+	t.Run("restock missing item", func(t *testing.T) {
+		mockPurchaseRepo.EXPECT().RestockProduct(ctx, "unknown", uint(10)).Return(nil, entity.ErrNotExistedProduct)
+
+		_, err := uc.RestockCatalogItem(ctx, "unknown", 10)
+		if err != entity.ErrNotExistedProduct {
+			t.Errorf("expected ErrNotExistedProduct, got %v", err)
+		}
+	})
+
+	t.Run("retire item invalidates catalog", func(t *testing.T) {
+		mockPurchaseRepo.EXPECT().RetireProduct(ctx, "hoody").Return(nil)
+
+		if err := uc.RetireCatalogItem(ctx, "hoody"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectReload(t)
+	})
+
+	t.Run("retire missing item", func(t *testing.T) {
+		mockPurchaseRepo.EXPECT().RetireProduct(ctx, "unknown").Return(entity.ErrNotExistedProduct)
+
+		err := uc.RetireCatalogItem(ctx, "unknown")
+		if err != entity.ErrNotExistedProduct {
+			t.Errorf("expected ErrNotExistedProduct, got %v", err)
+		}
+	})
+}
+
+func TestPurchaseUsecase_Refund(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPurchaseRepo := mockPurchase.NewMockPurchaseRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockIdempotencyRepo := mockIdempotency.NewMockIdempotencyRepositoryI(ctrl)
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
+	mockPromoRepo := mockPromo.NewMockPromoRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+	mockUow := mockUow.NewMockUnitOfWork(ctrl)
+
+	uc := NewPurchaseUsecase(
+		mockPurchaseRepo,
+		mockUserRepo,
+		mockIdempotencyRepo,
+		mockPromoRepo,
+		mockLedgerRepo,
+		mockLotRepo,
+		mockUowFactory,
+		config.PurchaseConfig{RefundWindow: "24h"},
+		logrus.New(),
+	)
+
+	ctx := context.Background()
+	refundedAt := time.Now()
+	soon, later := time.Now().Add(time.Hour), time.Now().Add(2*time.Hour)
+	// The coins paid for the purchase are given back as they were spent.
+	coinPortions := []*purchaseModel.CoinPortion{
+		{PurchaseID: 7, Amount: 4, ExpiresAt: &soon},
+		{PurchaseID: 7, Amount: 6, ExpiresAt: &later},
+	}
+	portions := lotEntity.Portions{
+		{Amount: 4, ExpiresAt: &soon},
+		{Amount: 6, ExpiresAt: &later},
+	}
+	recentPurchase := func() *purchaseModel.Purchase {
+		return &purchaseModel.Purchase{
+			ID:             7,
+			PurchaserID:    1,
+			PurchaseTypeID: 3,
+			ItemName:       "pen",
+			Price:          10,
+			Status:         entity.StatusPending,
+			CreatedAt:      time.Now().Add(-time.Hour),
+		}
+	}
+	refunded := func(purchase *purchaseModel.Purchase) *purchaseModel.Purchase {
+		refundedPurchase := *purchase
+		refundedPurchase.Status = entity.StatusCancelled
+		refundedPurchase.RefundedAt = &refundedAt
+		return &refundedPurchase
+	}
+
+	t.Run("successful refund", func(t *testing.T) {
+		purchase := recentPurchase()
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchase, nil)
+		mockPurchaseRepo.EXPECT().MarkRefunded(ctx, mockUow, uint(7)).Return(refunded(purchase), nil)
+		gomock.InOrder(
+			mockPurchaseRepo.EXPECT().ReturnStock(ctx, mockUow, uint(3), uint(1)).Return(nil),
+			mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(1), uint(10)).Return(nil),
+		)
+		mockPurchaseRepo.EXPECT().GetCoinPortions(ctx, mockUow, uint(7)).Return(coinPortions, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(1), portions).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, ledgerEntity.NewTransfer(
+			ledgerEntity.KindRefund,
+			"purchase:7",
+			ledgerEntity.ShopAccount,
+			ledgerEntity.UserAccount(1),
+			10,
+		)).Return(&ledgerModel.Entry{ID: 2}, nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		record, err := uc.RefundPurchase(ctx, 1, 7)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(record, &entity.PurchaseRecord{
+			ID:               7,
+			PurchaseTypeName: "pen",
+			Price:            10,
+			Status:           entity.StatusCancelled,
+			CreatedAt:        purchase.CreatedAt,
+			RefundedAt:       &refundedAt,
+		}) {
+			t.Errorf("unexpected record: %+v", record)
+		}
+	})
+
+	t.Run("refund gives back promo code redemption", func(t *testing.T) {
+		purchase := recentPurchase()
+		purchase.PromoCodeID = 5
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchase, nil)
+		mockPurchaseRepo.EXPECT().MarkRefunded(ctx, mockUow, uint(7)).Return(refunded(purchase), nil)
+		mockPurchaseRepo.EXPECT().ReturnStock(ctx, mockUow, uint(3), uint(1)).Return(nil)
+		gomock.InOrder(
+			mockPromoRepo.EXPECT().Release(ctx, mockUow, uint(5)).Return(nil),
+			mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(1), uint(10)).Return(nil),
+		)
+		mockPurchaseRepo.EXPECT().GetCoinPortions(ctx, mockUow, uint(7)).Return(coinPortions, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(1), portions).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 2}, nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		if _, err := uc.RefundPurchase(ctx, 1, 7); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("deleted product is not restocked", func(t *testing.T) {
+		purchase := recentPurchase()
+		purchase.PurchaseTypeID = 0
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchase, nil)
+		mockPurchaseRepo.EXPECT().MarkRefunded(ctx, mockUow, uint(7)).Return(refunded(purchase), nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(1), uint(10)).Return(nil)
+		mockPurchaseRepo.EXPECT().GetCoinPortions(ctx, mockUow, uint(7)).Return(coinPortions, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(1), portions).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 2}, nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		if _, err := uc.RefundPurchase(ctx, 1, 7); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("purchase of another user", func(t *testing.T) {
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(recentPurchase(), nil)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.RefundPurchase(ctx, 2, 7)
+		if err != entity.ErrPurchaseNotFound {
+			t.Errorf("expected ErrPurchaseNotFound, got %v", err)
+		}
+	})
+
+	t.Run("refund window has expired", func(t *testing.T) {
+		purchase := recentPurchase()
+		purchase.CreatedAt = time.Now().Add(-48 * time.Hour)
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchase, nil)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.RefundPurchase(ctx, 1, 7)
+		if err != entity.ErrRefundExpired {
+			t.Errorf("expected ErrRefundExpired, got %v", err)
+		}
+	})
+
+	t.Run("admin refunds after window", func(t *testing.T) {
+		purchase := recentPurchase()
+		purchase.CreatedAt = time.Now().Add(-48 * time.Hour)
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchase, nil)
+		mockPurchaseRepo.EXPECT().MarkRefunded(ctx, mockUow, uint(7)).Return(refunded(purchase), nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(1), uint(10)).Return(nil)
+		mockPurchaseRepo.EXPECT().GetCoinPortions(ctx, mockUow, uint(7)).Return(coinPortions, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(1), portions).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 2}, nil)
+		mockPurchaseRepo.EXPECT().ReturnStock(ctx, mockUow, uint(3), uint(1)).Return(nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		if _, err := uc.RefundPurchaseByAdmin(ctx, 7); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("delivered purchase can't be refunded by user", func(t *testing.T) {
+		purchase := recentPurchase()
+		purchase.Status = entity.StatusDelivered
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchase, nil)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.RefundPurchase(ctx, 1, 7)
+		if err != entity.ErrRefundDelivered {
+			t.Errorf("expected ErrRefundDelivered, got %v", err)
+		}
+	})
+
+	t.Run("admin refund of delivered purchase keeps stock", func(t *testing.T) {
+		purchase := recentPurchase()
+		purchase.Status = entity.StatusDelivered
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchase, nil)
+		mockPurchaseRepo.EXPECT().MarkRefunded(ctx, mockUow, uint(7)).Return(refunded(purchase), nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(1), uint(10)).Return(nil)
+		mockPurchaseRepo.EXPECT().GetCoinPortions(ctx, mockUow, uint(7)).Return(coinPortions, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(1), portions).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 2}, nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		if _, err := uc.RefundPurchaseByAdmin(ctx, 7); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("already refunded", func(t *testing.T) {
+		purchase := recentPurchase()
+		purchase.RefundedAt = &refundedAt
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchase, nil)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.RefundPurchaseByAdmin(ctx, 7)
+		if err != entity.ErrAlreadyRefunded {
+			t.Errorf("expected ErrAlreadyRefunded, got %v", err)
+		}
+	})
+
+	t.Run("purchase not found", func(t *testing.T) {
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(nil, entity.ErrPurchaseNotFound)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.RefundPurchaseByAdmin(ctx, 7)
+		if err != entity.ErrPurchaseNotFound {
+			t.Errorf("expected ErrPurchaseNotFound, got %v", err)
+		}
+	})
+
+	t.Run("credit error", func(t *testing.T) {
+		dbErr := errors.New("db error")
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(recentPurchase(), nil)
+		mockPurchaseRepo.EXPECT().MarkRefunded(ctx, mockUow, uint(7)).Return(refunded(recentPurchase()), nil)
+		mockPurchaseRepo.EXPECT().ReturnStock(ctx, mockUow, uint(3), uint(1)).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(1), uint(10)).Return(dbErr)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.RefundPurchase(ctx, 1, 7)
+		if err != dbErr {
+			t.Errorf("expected db error, got %v", err)
+		}
+	})
+
+	t.Run("refunds disabled without window", func(t *testing.T) {
+		disabledUC := NewPurchaseUsecase(
+			mockPurchaseRepo,
+			mockUserRepo,
+			mockIdempotencyRepo,
+			nil,
+			mockLedgerRepo,
+			mockLotRepo,
+			mockUowFactory,
+			config.PurchaseConfig{},
+			logrus.New(),
+		)
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(recentPurchase(), nil)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := disabledUC.RefundPurchase(ctx, 1, 7)
+		if err != entity.ErrRefundExpired {
+			t.Errorf("expected ErrRefundExpired, got %v", err)
+		}
+	})
+}
+
+func TestPurchaseUsecase_GetPurchaseHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPurchaseRepo := mockPurchase.NewMockPurchaseRepositoryI(ctrl)
+
+	uc := NewPurchaseUsecase(mockPurchaseRepo, nil, nil, nil, nil, nil, nil, config.PurchaseConfig{}, logrus.New())
+
+	ctx := context.Background()
+	records := []*entity.PurchaseRecord{
+		{ID: 9, PurchaseTypeName: "cup", Price: 20},
+		{ID: 8, PurchaseTypeName: "pen", Price: 10},
+		{ID: 7, PurchaseTypeName: "pen", Price: 10},
+	}
+
+	t.Run("has next page", func(t *testing.T) {
+		mockPurchaseRepo.EXPECT().
+			GetHistory(ctx, &entity.PurchaseHistoryFilter{UserID: 1, Limit: 3}).
+			Return(records, nil)
+
+		page, err := uc.GetPurchaseHistory(ctx, &entity.PurchaseHistoryFilter{UserID: 1, Limit: 2})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.Items) != 2 || page.NextCursor == nil || *page.NextCursor != 8 {
+			t.Errorf("unexpected page: %+v", page)
+		}
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		mockPurchaseRepo.EXPECT().
+			GetHistory(ctx, &entity.PurchaseHistoryFilter{UserID: 1, Limit: 11}).
+			Return(records, nil)
+
+		page, err := uc.GetPurchaseHistory(ctx, &entity.PurchaseHistoryFilter{UserID: 1, Limit: 10})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.Items) != 3 || page.NextCursor != nil {
+			t.Errorf("unexpected page: %+v", page)
+		}
+	})
+}
+
+func TestPurchaseUsecase_UpdatePurchaseStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPurchaseRepo := mockPurchase.NewMockPurchaseRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockIdempotencyRepo := mockIdempotency.NewMockIdempotencyRepositoryI(ctrl)
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+	mockUow := mockUow.NewMockUnitOfWork(ctrl)
+
+	uc := NewPurchaseUsecase(mockPurchaseRepo, mockUserRepo, mockIdempotencyRepo, nil, mockLedgerRepo, mockLotRepo, mockUowFactory, config.PurchaseConfig{}, logrus.New())
+
+	ctx := context.Background()
+	purchaseInStatus := func(status string) *purchaseModel.Purchase {
+		return &purchaseModel.Purchase{
+			ID:             7,
+			PurchaserID:    1,
+			PurchaseTypeID: 3,
+			ItemName:       "pen",
+			Price:          10,
+			Status:         status,
+			CreatedAt:      time.Now().Add(-48 * time.Hour),
+		}
+	}
+
+	t.Run("pending purchase is made ready", func(t *testing.T) {
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchaseInStatus(entity.StatusPending), nil)
+		mockPurchaseRepo.EXPECT().
+			UpdateStatus(ctx, mockUow, uint(7), entity.StatusReady).
+			Return(purchaseInStatus(entity.StatusReady), nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		record, err := uc.UpdatePurchaseStatus(ctx, 7, entity.StatusReady)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if record.Status != entity.StatusReady {
+			t.Errorf("expected status ready, got %s", record.Status)
+		}
+	})
+
+	t.Run("pending purchase can't be delivered", func(t *testing.T) {
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchaseInStatus(entity.StatusPending), nil)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.UpdatePurchaseStatus(ctx, 7, entity.StatusDelivered)
+		if err != entity.ErrStatusTransition {
+			t.Errorf("expected ErrStatusTransition, got %v", err)
+		}
+	})
+
+	t.Run("cancelling refunds purchase", func(t *testing.T) {
+		refundedAt := time.Now()
+		cancelled := purchaseInStatus(entity.StatusCancelled)
+		cancelled.RefundedAt = &refundedAt
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchaseInStatus(entity.StatusReady), nil)
+		mockPurchaseRepo.EXPECT().MarkRefunded(ctx, mockUow, uint(7)).Return(cancelled, nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(1), uint(10)).Return(nil)
+		mockPurchaseRepo.EXPECT().
+			GetCoinPortions(ctx, mockUow, uint(7)).
+			Return([]*purchaseModel.CoinPortion{{PurchaseID: 7, Amount: 10}}, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(1), lotEntity.Portions{{Amount: 10}}).Return(nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 2}, nil)
+		mockPurchaseRepo.EXPECT().ReturnStock(ctx, mockUow, uint(3), uint(1)).Return(nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		record, err := uc.UpdatePurchaseStatus(ctx, 7, entity.StatusCancelled)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if record.Status != entity.StatusCancelled || record.RefundedAt == nil {
+			t.Errorf("unexpected record: %+v", record)
+		}
+	})
+
+	t.Run("delivered purchase can't be cancelled", func(t *testing.T) {
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(purchaseInStatus(entity.StatusDelivered), nil)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.UpdatePurchaseStatus(ctx, 7, entity.StatusCancelled)
+		if err != entity.ErrStatusTransition {
+			t.Errorf("expected ErrStatusTransition, got %v", err)
+		}
+	})
+
+	t.Run("purchase not found", func(t *testing.T) {
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockPurchaseRepo.EXPECT().GetForUpdate(ctx, mockUow, uint(7)).Return(nil, entity.ErrPurchaseNotFound)
+		mockUow.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.UpdatePurchaseStatus(ctx, 7, entity.StatusReady)
+		if err != entity.ErrPurchaseNotFound {
+			t.Errorf("expected ErrPurchaseNotFound, got %v", err)
+		}
+	})
 }
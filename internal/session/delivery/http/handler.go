@@ -3,15 +3,22 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"math"
+	"net"
 	"net/http"
 	"runtime/debug"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	"github.com/artrsyf/avito-trainee-assignment/internal/middleware"
 	"github.com/artrsyf/avito-trainee-assignment/internal/session/domain/dto"
 	sessionEntity "github.com/artrsyf/avito-trainee-assignment/internal/session/domain/entity"
 	"github.com/artrsyf/avito-trainee-assignment/internal/session/usecase"
@@ -20,20 +27,28 @@ import (
 )
 
 type SessionHandler struct {
-	sessionUC usecase.SessionUsecaseI
-	validate  *validator.Validate
-	logger    *logrus.Logger
+	sessionUC      usecase.SessionUsecaseI
+	validate       *validator.Validate
+	trustedProxies []*net.IPNet
+	logger         *logrus.Logger
 }
 
 func NewSessionHandler(
 	sessionUsecase usecase.SessionUsecaseI,
 	validate *validator.Validate,
+	lockoutCfg config.LockoutConfig,
 	logger *logrus.Logger,
 ) *SessionHandler {
+	trustedProxies, err := lockoutCfg.GetTrustedProxies()
+	if err != nil {
+		logger.WithError(err).Warn("Invalid trusted proxies, X-Forwarded-For is ignored")
+	}
+
 	return &SessionHandler{
-		sessionUC: sessionUsecase,
-		validate:  validate,
-		logger:    logger,
+		sessionUC:      sessionUsecase,
+		validate:       validate,
+		trustedProxies: trustedProxies,
+		logger:         logger,
 	}
 }
 
@@ -79,6 +94,9 @@ func (h *SessionHandler) Auth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	authRequest.UserAgent = r.UserAgent()
+	authRequest.IP = h.clientIP(r)
+
 	createdSessionEntity, err := h.sessionUC.LoginOrSignup(ctx, authRequest)
 	if err != nil {
 		h.logger.WithFields(logrus.Fields{
@@ -86,6 +104,17 @@ func (h *SessionHandler) Auth(w http.ResponseWriter, r *http.Request) {
 			"stack": string(debug.Stack()),
 		}).Debug("LoginOrSignup error handling")
 
+		var lockedErr *sessionEntity.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			w.Header().Set("Retry-After", retryAfterSeconds(lockedErr.RetryAfter))
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusTooManyRequests,
+				map[string]string{"errors": "too many login attempts"},
+			)
+			return
+		}
+
 		switch err {
 		case sessionEntity.ErrWrongCredentials:
 			JSONResponse.JSONResponse(
@@ -99,6 +128,18 @@ func (h *SessionHandler) Auth(w http.ResponseWriter, r *http.Request) {
 				http.StatusConflict,
 				map[string]string{"errors": "user conflict"},
 			)
+		case sessionEntity.ErrUsernameNotAllowed:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusBadRequest,
+				map[string]string{"errors": "username is not allowed"},
+			)
+		case sessionEntity.ErrRegistrationClosed:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusForbidden,
+				map[string]string{"errors": "registration is closed"},
+			)
 		default:
 			JSONResponse.JSONResponse(
 				w,
@@ -128,6 +169,492 @@ func (h *SessionHandler) Auth(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *SessionHandler) Register(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming Register request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to read request body")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+	defer func() {
+		if err = r.Body.Close(); err != nil {
+			h.logger.WithError(err).Warn("Failed to close request body")
+		}
+	}()
+
+	registerRequest := &dto.RegisterRequest{}
+	if err = json.Unmarshal(body, registerRequest); err != nil {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+
+	if err = registerRequest.ValidateRegisterRequest(h.validate); err != nil {
+		h.logger.WithError(err).Warn("Failed validation for register request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	registerRequest.UserAgent = r.UserAgent()
+	registerRequest.IP = h.clientIP(r)
+
+	createdSessionEntity, err := h.sessionUC.Register(ctx, registerRequest)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Register error handling")
+
+		var lockedErr *sessionEntity.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			w.Header().Set("Retry-After", retryAfterSeconds(lockedErr.RetryAfter))
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusTooManyRequests,
+				map[string]string{"errors": "too many registration attempts"},
+			)
+			return
+		}
+
+		switch err {
+		case userEntity.ErrAlreadyCreated:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusConflict,
+				map[string]string{"errors": "user already exists"},
+			)
+		case sessionEntity.ErrUsernameNotAllowed:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusBadRequest,
+				map[string]string{"errors": "username is not allowed"},
+			)
+		case sessionEntity.ErrInvalidInviteCode:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusForbidden,
+				map[string]string{"errors": "invalid invite code"},
+			)
+		case sessionEntity.ErrRegistrationClosed:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusForbidden,
+				map[string]string{"errors": "registration is closed"},
+			)
+		default:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusInternalServerError,
+				map[string]string{"errors": "internal error"},
+			)
+		}
+		return
+	}
+
+	setSessionCookies(w, createdSessionEntity, h.logger)
+
+	response, err := json.Marshal(dto.SessionEntityToResponse(createdSessionEntity))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to marshal register response")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if _, err = w.Write(response); err != nil {
+		h.logger.WithError(err).Error("Failed to write register response")
+	}
+}
+
+func (h *SessionHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming Refresh request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to read request body")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad request"},
+		)
+		return
+	}
+	defer func() {
+		if err = r.Body.Close(); err != nil {
+			h.logger.WithError(err).Warn("Failed to close request body")
+		}
+	}()
+
+	refreshRequest := &dto.RefreshRequest{}
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, refreshRequest); err != nil {
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusBadRequest,
+				map[string]string{"errors": "bad request"},
+			)
+			return
+		}
+	}
+
+	if refreshRequest.RefreshToken == "" {
+		if cookie, cookieErr := r.Cookie("refresh_token"); cookieErr == nil {
+			refreshRequest.RefreshToken = cookie.Value
+		}
+	}
+
+	if refreshRequest.RefreshToken == "" {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusUnauthorized,
+			map[string]string{"errors": "missing refresh token"},
+		)
+		return
+	}
+
+	refreshedSessionEntity, err := h.sessionUC.Refresh(ctx, refreshRequest.RefreshToken)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Refresh error handling")
+
+		switch err {
+		case sessionEntity.ErrInvalidRefreshToken,
+			sessionEntity.ErrRefreshTokenReused,
+			sessionEntity.ErrNoSession:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusUnauthorized,
+				map[string]string{"errors": "bad refresh token"},
+			)
+		default:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusInternalServerError,
+				map[string]string{"errors": "internal error"},
+			)
+		}
+		return
+	}
+
+	setSessionCookies(w, refreshedSessionEntity, h.logger)
+
+	response, err := json.Marshal(dto.SessionEntityToResponse(refreshedSessionEntity))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to marshal refresh response")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(response); err != nil {
+		h.logger.WithError(err).Error("Failed to write refresh response")
+	}
+}
+
+func (h *SessionHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming Logout request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(uint)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	sessionID, ok := ctx.Value(middleware.SessionIDContextKey).(string)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	err := h.sessionUC.Logout(ctx, userID, sessionID)
+	if err != nil && err != sessionEntity.ErrNoSession {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Logout error handling")
+
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	clearSessionCookies(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *SessionHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming LogoutAll request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(uint)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	if err := h.sessionUC.LogoutAll(ctx, userID); err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("LogoutAll error handling")
+
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	clearSessionCookies(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *SessionHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming GetSessions request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(uint)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	currentSessionID, ok := ctx.Value(middleware.SessionIDContextKey).(string)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	sessions, err := h.sessionUC.GetSessions(ctx, userID)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Error("GetSessions error handling")
+
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	JSONResponse.JSONResponse(
+		w,
+		http.StatusOK,
+		dto.SessionEntitiesToResponse(sessions, currentSessionID),
+	)
+}
+
+func (h *SessionHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming DeleteSession request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	sessionID := mux.Vars(r)["id"]
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(uint)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	err := h.sessionUC.Logout(ctx, userID, sessionID)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("DeleteSession error handling")
+
+		switch err {
+		case sessionEntity.ErrNoSession:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusNotFound,
+				map[string]string{"errors": "session not found"},
+			)
+		default:
+			JSONResponse.JSONResponse(
+				w,
+				http.StatusInternalServerError,
+				map[string]string{"errors": "internal error"},
+			)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *SessionHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming UnlockUser request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.sessionUC.UnlockUser(ctx, mux.Vars(r)["username"])
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("UnlockUser error handling")
+
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SessionHandler) UnlockIP(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming UnlockIP request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	ip := mux.Vars(r)["ip"]
+	if net.ParseIP(ip) == nil {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "invalid ip"},
+		)
+		return
+	}
+
+	err := h.sessionUC.UnlockIP(ctx, ip)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("UnlockIP error handling")
+
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// retryAfterSeconds rounds up, so the client doesn't retry before the
+// lockout is over.
+func retryAfterSeconds(retryAfter time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+}
+
+// clientIP trusts X-Forwarded-For only when the request comes from a trusted
+// proxy. The hops are walked from the nearest one and the first address that
+// isn't a trusted proxy is the client, since hops further left may be forged.
+func (h *SessionHandler) clientIP(r *http.Request) string {
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+
+	if !h.isTrustedProxy(remoteIP) {
+		return remoteIP
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop != "" && !h.isTrustedProxy(hop) {
+			return hop
+		}
+	}
+
+	return remoteIP
+}
+
+func (h *SessionHandler) isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range h.trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func setSessionCookies(
 	w http.ResponseWriter,
 	session *sessionEntity.Session,
@@ -172,3 +699,17 @@ func setSessionCookies(
 		}
 	}
 }
+
+func clearSessionCookies(w http.ResponseWriter) {
+	for _, name := range []string{"access_token", "refresh_token", "user_id"} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	mockSession "github.com/artrsyf/avito-trainee-assignment/internal/session/repository/mock_repository"
+	"github.com/artrsyf/avito-trainee-assignment/internal/session/usecase"
+)
+
+func TestSessionHandler_Auth_LockoutKey(t *testing.T) {
+	lockoutCfg := config.LockoutConfig{
+		MaxIPAttempts:  10,
+		ResetAfter:     "1h",
+		BaseDuration:   "30s",
+		MaxDuration:    "5m",
+		TrustedProxies: []string{"10.0.0.0/8"},
+	}
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  string
+		expectedIPKey string
+	}{
+		{
+			name:          "spoofed header from untrusted client is ignored",
+			remoteAddr:    "203.0.113.7:51234",
+			forwardedFor:  "198.51.100.1",
+			expectedIPKey: "ip:203.0.113.7",
+		},
+		{
+			name:          "trusted proxy forwards client address",
+			remoteAddr:    "10.0.0.2:51234",
+			forwardedFor:  "203.0.113.7",
+			expectedIPKey: "ip:203.0.113.7",
+		},
+		{
+			name:          "hops forged before trusted proxy are ignored",
+			remoteAddr:    "10.0.0.2:51234",
+			forwardedFor:  "198.51.100.1, 203.0.113.7, 10.0.0.3",
+			expectedIPKey: "ip:203.0.113.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLoginAttemptRepo := mockSession.NewMockLoginAttemptRepositoryI(ctrl)
+
+			sessionUC := usecase.NewSessionUsecase(
+				nil,
+				mockLoginAttemptRepo,
+				nil,
+				nil,
+				nil,
+				nil,
+				config.UserConfig{Lockout: lockoutCfg},
+				config.ExpiryConfig{},
+				logrus.New(),
+			)
+			handler := NewSessionHandler(sessionUC, validator.New(), lockoutCfg, logrus.New())
+
+			// The lockout is checked before anything else, so the key it's
+			// looked up by is the client IP the handler settled on.
+			mockLoginAttemptRepo.EXPECT().
+				GetLockout(gomock.Any(), []string{tt.expectedIPKey}).
+				Return(time.Minute, nil)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/auth",
+				strings.NewReader(`{"username":"alice","password":"password"}`),
+			)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			rec := httptest.NewRecorder()
+
+			handler.Auth(rec, req)
+
+			assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+			assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"os"
 	"strconv"
@@ -14,16 +16,57 @@ import (
 )
 
 type AuthRequest struct {
-	Username string `json:"username" validate:"required,min=3,max=50"`
-	Password string `json:"password" validate:"required,min=6,max=100"`
+	Username  string `json:"username" validate:"required,min=3,max=50"`
+	Password  string `json:"password" validate:"required,min=6,max=100"`
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
+}
+
+// RegisterRequest creates an account. InviteCode is required only if
+// registration is gated by an invite code.
+type RegisterRequest struct {
+	AuthRequest
+	InviteCode string `json:"inviteCode" validate:"max=100"`
 }
 
 type AuthResponse struct {
 	Token string `json:"token"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type TokenClaims struct {
+	UserID    uint
+	Username  string
+	SessionID string
+}
+
+type SessionResponse struct {
+	ID        string    `json:"id"`
+	UserAgent string    `json:"userAgent"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"createdAt"`
+	Current   bool      `json:"current"`
+}
+
+const (
+	accessTokenType  = "access"
+	refreshTokenType = "refresh"
+)
+
 func (req *AuthRequest) ValidateAuthRequest(validate *validator.Validate) error {
-	err := validate.Struct(req)
+	return validationError(validate.Struct(req))
+}
+
+func (req *RegisterRequest) ValidateRegisterRequest(validate *validator.Validate) error {
+	return validationError(validate.Struct(req))
+}
+
+// validationError turns the first failed validation into a message for the
+// client.
+func validationError(err error) error {
 	if err != nil {
 		if validationErrors, ok := err.(validator.ValidationErrors); ok {
 			for _, err := range validationErrors {
@@ -48,15 +91,28 @@ func (req *AuthRequest) ValidateAuthRequest(validate *validator.Validate) error
 	return nil
 }
 
-func createJWT(authRequest *AuthRequest, ttl time.Time, userID uint) (string, error) {
+func createJWT(
+	session *entity.Session,
+	tokenType string,
+	ttl time.Time,
+) (string, error) {
+	tokenID, err := generateID()
+	if err != nil {
+		return "", err
+	}
+
 	jwtTokenKey := []byte(os.Getenv("TOKEN_KEY"))
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user": map[string]string{
-			"username": authRequest.Username,
-			"id":       strconv.FormatUint(uint64(userID), 10),
+			"username": session.Username,
+			"id":       strconv.FormatUint(uint64(session.UserID), 10),
+			"role":     session.Role,
 		},
-		"iat": time.Now().Unix(),
-		"exp": ttl,
+		"sid":  session.ID,
+		"type": tokenType,
+		"jti":  tokenID,
+		"iat":  time.Now().Unix(),
+		"exp":  ttl.Unix(),
 	})
 	tokenString, err := token.SignedString(jwtTokenKey)
 	if err != nil {
@@ -66,38 +122,111 @@ func createJWT(authRequest *AuthRequest, ttl time.Time, userID uint) (string, er
 	return tokenString, nil
 }
 
-func createSignedSession(
-	authRequest *AuthRequest,
-	userID uint,
+// generateID is used for session ids and token ids. The latter makes every
+// issued token unique, so a rotated refresh token never collides with the
+// one it replaces.
+func generateID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+func signSession(
+	session *entity.Session,
 	accessTokenTTL,
 	refreshTokenTTL time.Time,
 ) (*entity.Session, error) {
-	accessToken, err := createJWT(authRequest, accessTokenTTL, userID)
+	signedSession := *session
+	signedSession.AccessExpiresAt = accessTokenTTL
+	signedSession.RefreshExpiresAt = refreshTokenTTL
+
+	accessToken, err := createJWT(&signedSession, accessTokenType, accessTokenTTL)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := createJWT(authRequest, refreshTokenTTL, userID)
+	refreshToken, err := createJWT(&signedSession, refreshTokenType, refreshTokenTTL)
 	if err != nil {
 		return nil, err
 	}
 
-	return &entity.Session{
-		JWTAccess:        accessToken,
-		JWTRefresh:       refreshToken,
-		UserID:           userID,
-		Username:         authRequest.Username,
-		AccessExpiresAt:  accessTokenTTL,
-		RefreshExpiresAt: refreshTokenTTL,
+	signedSession.JWTAccess = accessToken
+	signedSession.JWTRefresh = refreshToken
+
+	return &signedSession, nil
+}
+
+// ParseRefreshToken checks signature, expiration and type of the refresh
+// token. Whether the token is still the current one is decided by storage.
+func ParseRefreshToken(tokenString string) (*TokenClaims, error) {
+	tokenKey := []byte(os.Getenv("TOKEN_KEY"))
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		method, ok := token.Method.(*jwt.SigningMethodHMAC)
+		if !ok || method.Alg() != "HS256" {
+			return nil, errors.New("bad sign method")
+		}
+		return tokenKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, entity.ErrInvalidRefreshToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, entity.ErrInvalidRefreshToken
+	}
+
+	if tokenType, ok := claims["type"].(string); !ok || tokenType != refreshTokenType {
+		return nil, entity.ErrInvalidRefreshToken
+	}
+
+	claimsUser, ok := claims["user"].(map[string]interface{})
+	if !ok {
+		return nil, entity.ErrInvalidRefreshToken
+	}
+
+	userIDString, ok := claimsUser["id"].(string)
+	if !ok {
+		return nil, entity.ErrInvalidRefreshToken
+	}
+
+	userID, err := strconv.ParseUint(userIDString, 10, 32)
+	if err != nil || userID == 0 {
+		return nil, entity.ErrInvalidRefreshToken
+	}
+
+	username, ok := claimsUser["username"].(string)
+	if !ok {
+		return nil, entity.ErrInvalidRefreshToken
+	}
+
+	sessionID, ok := claims["sid"].(string)
+	if !ok || sessionID == "" {
+		return nil, entity.ErrInvalidRefreshToken
+	}
+
+	return &TokenClaims{
+		UserID:    uint(userID),
+		Username:  username,
+		SessionID: sessionID,
 	}, nil
 }
 
 func SessionEntityToModel(sessionEntity *entity.Session) *model.Session {
 	return &model.Session{
+		ID:               sessionEntity.ID,
 		JWTAccess:        sessionEntity.JWTAccess,
 		JWTRefresh:       sessionEntity.JWTRefresh,
 		UserID:           sessionEntity.UserID,
 		Username:         sessionEntity.Username,
+		Role:             sessionEntity.Role,
+		UserAgent:        sessionEntity.UserAgent,
+		IP:               sessionEntity.IP,
+		CreatedAt:        sessionEntity.CreatedAt,
 		AccessExpiresAt:  sessionEntity.AccessExpiresAt,
 		RefreshExpiresAt: sessionEntity.RefreshExpiresAt,
 	}
@@ -105,10 +234,15 @@ func SessionEntityToModel(sessionEntity *entity.Session) *model.Session {
 
 func SessionModelToEntity(sessionModel *model.Session) *entity.Session {
 	return &entity.Session{
+		ID:               sessionModel.ID,
 		JWTAccess:        sessionModel.JWTAccess,
 		JWTRefresh:       sessionModel.JWTRefresh,
 		UserID:           sessionModel.UserID,
 		Username:         sessionModel.Username,
+		Role:             sessionModel.Role,
+		UserAgent:        sessionModel.UserAgent,
+		IP:               sessionModel.IP,
+		CreatedAt:        sessionModel.CreatedAt,
 		AccessExpiresAt:  sessionModel.AccessExpiresAt,
 		RefreshExpiresAt: sessionModel.RefreshExpiresAt,
 	}
@@ -117,20 +251,38 @@ func SessionModelToEntity(sessionModel *model.Session) *entity.Session {
 func AuthRequestToEntity(
 	authRequest *AuthRequest,
 	userID uint,
+	role string,
 	accessTokenTTL,
 	refreshTokenTTL time.Time,
 ) (*entity.Session, error) {
-	signedSessionEntity, err := createSignedSession(
-		authRequest,
-		userID,
-		accessTokenTTL,
-		refreshTokenTTL,
-	)
+	sessionID, err := generateID()
 	if err != nil {
 		return nil, err
 	}
 
-	return signedSessionEntity, nil
+	return signSession(
+		&entity.Session{
+			ID:        sessionID,
+			UserID:    userID,
+			Username:  authRequest.Username,
+			Role:      role,
+			UserAgent: authRequest.UserAgent,
+			IP:        authRequest.IP,
+			CreatedAt: time.Now(),
+		},
+		accessTokenTTL,
+		refreshTokenTTL,
+	)
+}
+
+// RotateSessionEntity issues a new token pair for an existing device
+// session, keeping its id and metadata.
+func RotateSessionEntity(
+	sessionEntity *entity.Session,
+	accessTokenTTL,
+	refreshTokenTTL time.Time,
+) (*entity.Session, error) {
+	return signSession(sessionEntity, accessTokenTTL, refreshTokenTTL)
 }
 
 func SessionEntityToResponse(sessionEntity *entity.Session) *AuthResponse {
@@ -138,3 +290,21 @@ func SessionEntityToResponse(sessionEntity *entity.Session) *AuthResponse {
 		Token: sessionEntity.JWTAccess,
 	}
 }
+
+func SessionEntitiesToResponse(
+	sessionEntities []*entity.Session,
+	currentSessionID string,
+) []SessionResponse {
+	sessionsResponse := make([]SessionResponse, 0, len(sessionEntities))
+	for _, sessionEntity := range sessionEntities {
+		sessionsResponse = append(sessionsResponse, SessionResponse{
+			ID:        sessionEntity.ID,
+			UserAgent: sessionEntity.UserAgent,
+			IP:        sessionEntity.IP,
+			CreatedAt: sessionEntity.CreatedAt,
+			Current:   sessionEntity.ID == currentSessionID,
+		})
+	}
+
+	return sessionsResponse
+}
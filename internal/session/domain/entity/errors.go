@@ -3,7 +3,12 @@ package entity
 import "errors"
 
 var (
-	ErrNoSession        = errors.New("couldn't find session")
-	ErrAlreadyCreated   = errors.New("session is already created")
-	ErrWrongCredentials = errors.New("incorrect login or password")
+	ErrNoSession           = errors.New("couldn't find session")
+	ErrAlreadyCreated      = errors.New("session is already created")
+	ErrWrongCredentials    = errors.New("incorrect login or password")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+	ErrInvalidInviteCode   = errors.New("invalid invite code")
+	ErrUsernameNotAllowed  = errors.New("username is not allowed")
+	ErrRegistrationClosed  = errors.New("registration is closed")
 )
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// Failed logins are counted separately for the username and for the client
+// IP, so neither guessing passwords of one user nor trying a password
+// against many users goes unthrottled.
+func UsernameLockoutKey(username string) string {
+	return "user:" + username
+}
+
+func IPLockoutKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginLockedError is returned while logins are locked after repeated
+// failures.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return "too many failed login attempts"
+}
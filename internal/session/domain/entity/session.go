@@ -3,10 +3,15 @@ package entity
 import "time"
 
 type Session struct {
+	ID               string
 	JWTAccess        string
 	JWTRefresh       string
 	UserID           uint
 	Username         string
+	Role             string
+	UserAgent        string
+	IP               string
+	CreatedAt        time.Time
 	AccessExpiresAt  time.Time
 	RefreshExpiresAt time.Time
 }
@@ -3,10 +3,15 @@ package model
 import "time"
 
 type Session struct {
+	ID               string    `json:"id"`
 	JWTAccess        string    `json:"access_token"`
 	JWTRefresh       string    `json:"refresh_token"`
 	UserID           uint      `json:"user_id"`
 	Username         string    `json:"username"`
+	Role             string    `json:"role"`
+	UserAgent        string    `json:"user_agent"`
+	IP               string    `json:"ip"`
+	CreatedAt        time.Time `json:"created_at"`
 	AccessExpiresAt  time.Time `json:"access_expires_at"`
 	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
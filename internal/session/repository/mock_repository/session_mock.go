@@ -7,6 +7,7 @@ package mock_repository
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entity "github.com/artrsyf/avito-trainee-assignment/internal/session/domain/entity"
 	model "github.com/artrsyf/avito-trainee-assignment/internal/session/domain/model"
@@ -37,18 +38,18 @@ func (m *MockSessionRepositoryI) EXPECT() *MockSessionRepositoryIMockRecorder {
 }
 
 // Check mocks base method.
-func (m *MockSessionRepositoryI) Check(ctx context.Context, userID uint) (*model.Session, error) {
+func (m *MockSessionRepositoryI) Check(ctx context.Context, userID uint, sessionID string) (*model.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Check", ctx, userID)
+	ret := m.ctrl.Call(m, "Check", ctx, userID, sessionID)
 	ret0, _ := ret[0].(*model.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Check indicates an expected call of Check.
-func (mr *MockSessionRepositoryIMockRecorder) Check(ctx, userID interface{}) *gomock.Call {
+func (mr *MockSessionRepositoryIMockRecorder) Check(ctx, userID, sessionID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockSessionRepositoryI)(nil).Check), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockSessionRepositoryI)(nil).Check), ctx, userID, sessionID)
 }
 
 // Create mocks base method.
@@ -65,3 +66,142 @@ func (mr *MockSessionRepositoryIMockRecorder) Create(ctx, sessionEntity interfac
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSessionRepositoryI)(nil).Create), ctx, sessionEntity)
 }
+
+// Delete mocks base method.
+func (m *MockSessionRepositoryI) Delete(ctx context.Context, userID uint, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSessionRepositoryIMockRecorder) Delete(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSessionRepositoryI)(nil).Delete), ctx, userID, sessionID)
+}
+
+// DeleteAll mocks base method.
+func (m *MockSessionRepositoryI) DeleteAll(ctx context.Context, userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAll", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAll indicates an expected call of DeleteAll.
+func (mr *MockSessionRepositoryIMockRecorder) DeleteAll(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAll", reflect.TypeOf((*MockSessionRepositoryI)(nil).DeleteAll), ctx, userID)
+}
+
+// GetByUserID mocks base method.
+func (m *MockSessionRepositoryI) GetByUserID(ctx context.Context, userID uint) ([]*model.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserID", ctx, userID)
+	ret0, _ := ret[0].([]*model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserID indicates an expected call of GetByUserID.
+func (mr *MockSessionRepositoryIMockRecorder) GetByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserID", reflect.TypeOf((*MockSessionRepositoryI)(nil).GetByUserID), ctx, userID)
+}
+
+// Rotate mocks base method.
+func (m *MockSessionRepositoryI) Rotate(ctx context.Context, refreshToken string, sessionEntity *entity.Session) (*model.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate", ctx, refreshToken, sessionEntity)
+	ret0, _ := ret[0].(*model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockSessionRepositoryIMockRecorder) Rotate(ctx, refreshToken, sessionEntity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockSessionRepositoryI)(nil).Rotate), ctx, refreshToken, sessionEntity)
+}
+
+// MockLoginAttemptRepositoryI is a mock of LoginAttemptRepositoryI interface.
+type MockLoginAttemptRepositoryI struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptRepositoryIMockRecorder
+}
+
+// MockLoginAttemptRepositoryIMockRecorder is the mock recorder for MockLoginAttemptRepositoryI.
+type MockLoginAttemptRepositoryIMockRecorder struct {
+	mock *MockLoginAttemptRepositoryI
+}
+
+// NewMockLoginAttemptRepositoryI creates a new mock instance.
+func NewMockLoginAttemptRepositoryI(ctrl *gomock.Controller) *MockLoginAttemptRepositoryI {
+	mock := &MockLoginAttemptRepositoryI{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptRepositoryIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAttemptRepositoryI) EXPECT() *MockLoginAttemptRepositoryIMockRecorder {
+	return m.recorder
+}
+
+// AddFailure mocks base method.
+func (m *MockLoginAttemptRepositoryI) AddFailure(ctx context.Context, key string, resetAfter time.Duration) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddFailure", ctx, key, resetAfter)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddFailure indicates an expected call of AddFailure.
+func (mr *MockLoginAttemptRepositoryIMockRecorder) AddFailure(ctx, key, resetAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFailure", reflect.TypeOf((*MockLoginAttemptRepositoryI)(nil).AddFailure), ctx, key, resetAfter)
+}
+
+// GetLockout mocks base method.
+func (m *MockLoginAttemptRepositoryI) GetLockout(ctx context.Context, keys []string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLockout", ctx, keys)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLockout indicates an expected call of GetLockout.
+func (mr *MockLoginAttemptRepositoryIMockRecorder) GetLockout(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLockout", reflect.TypeOf((*MockLoginAttemptRepositoryI)(nil).GetLockout), ctx, keys)
+}
+
+// Lock mocks base method.
+func (m *MockLoginAttemptRepositoryI) Lock(ctx context.Context, key string, duration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, duration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockLoginAttemptRepositoryIMockRecorder) Lock(ctx, key, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockLoginAttemptRepositoryI)(nil).Lock), ctx, key, duration)
+}
+
+// Reset mocks base method.
+func (m *MockLoginAttemptRepositoryI) Reset(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLoginAttemptRepositoryIMockRecorder) Reset(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLoginAttemptRepositoryI)(nil).Reset), ctx, key)
+}
@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+type LoginAttemptRedisRepository struct {
+	client *redis.Client
+	logger *logrus.Logger
+}
+
+func NewLoginAttemptRedisRepository(
+	client *redis.Client,
+	logger *logrus.Logger,
+) *LoginAttemptRedisRepository {
+	return &LoginAttemptRedisRepository{
+		client: client,
+		logger: logger,
+	}
+}
+
+// The failures counter outlives the lockout, so the next lockout of the same
+// key is longer. The lockout key expires by itself when it's over.
+func loginFailuresKey(key string) string {
+	return "login_failures:" + key
+}
+
+func loginLockoutKey(key string) string {
+	return "login_lockout:" + key
+}
+
+// GetLockout returns the longest remaining lockout among the keys, zero if
+// none of them is locked.
+func (repo *LoginAttemptRedisRepository) GetLockout(
+	ctx context.Context,
+	keys []string,
+) (time.Duration, error) {
+	pipe := repo.client.Pipeline()
+	ttls := make([]*redis.DurationCmd, 0, len(keys))
+	for _, key := range keys {
+		ttls = append(ttls, pipe.PTTL(ctx, loginLockoutKey(key)))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		repo.logger.WithError(err).Error("Failed to get login lockouts from Redis")
+		return 0, fmt.Errorf("redis error: %w", err)
+	}
+
+	// PTTL of a missing key is negative.
+	var lockout time.Duration
+	for _, ttl := range ttls {
+		if ttl.Val() > lockout {
+			lockout = ttl.Val()
+		}
+	}
+
+	return lockout, nil
+}
+
+// AddFailure counts a failed login and returns the number of failures since
+// the key was quiet for resetAfter.
+func (repo *LoginAttemptRedisRepository) AddFailure(
+	ctx context.Context,
+	key string,
+	resetAfter time.Duration,
+) (uint, error) {
+	pipe := repo.client.TxPipeline()
+	failures := pipe.Incr(ctx, loginFailuresKey(key))
+	pipe.PExpire(ctx, loginFailuresKey(key), resetAfter)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		repo.logger.WithError(err).Error("Failed to count login failure in Redis")
+		return 0, fmt.Errorf("redis error: %w", err)
+	}
+
+	return uint(failures.Val()), nil
+}
+
+func (repo *LoginAttemptRedisRepository) Lock(
+	ctx context.Context,
+	key string,
+	duration time.Duration,
+) error {
+	if err := repo.client.Set(ctx, loginLockoutKey(key), 1, duration).Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to set login lockout in Redis")
+		return fmt.Errorf("redis error: %w", err)
+	}
+
+	repo.logger.WithFields(logrus.Fields{
+		"key":      key,
+		"duration": duration,
+	}).Debug("Set login lockout in Redis")
+
+	return nil
+}
+
+// Reset forgets the failures of the key and lifts its lockout.
+func (repo *LoginAttemptRedisRepository) Reset(
+	ctx context.Context,
+	key string,
+) error {
+	err := repo.client.Del(ctx, loginFailuresKey(key), loginLockoutKey(key)).Err()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to reset login failures in Redis")
+		return fmt.Errorf("redis error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttemptRedisRepository_GetLockout(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	repo := NewLoginAttemptRedisRepository(db, logrus.New())
+
+	t.Run("Locked", func(t *testing.T) {
+		mock.ExpectPTTL("login_lockout:user:testuser").SetVal(-2 * time.Millisecond)
+		mock.ExpectPTTL("login_lockout:ip:127.0.0.1").SetVal(90 * time.Second)
+
+		lockout, err := repo.GetLockout(ctx, []string{"user:testuser", "ip:127.0.0.1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 90*time.Second, lockout)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotLocked", func(t *testing.T) {
+		mock.ExpectPTTL("login_lockout:user:testuser").SetVal(-2 * time.Millisecond)
+
+		lockout, err := repo.GetLockout(ctx, []string{"user:testuser"})
+
+		assert.NoError(t, err)
+		assert.Zero(t, lockout)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RedisError", func(t *testing.T) {
+		mock.ExpectPTTL("login_lockout:user:testuser").SetErr(errors.New("connection error"))
+
+		_, err := repo.GetLockout(ctx, []string{"user:testuser"})
+		assert.ErrorContains(t, err, "connection error")
+	})
+}
+
+func TestLoginAttemptRedisRepository_AddFailure(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	repo := NewLoginAttemptRedisRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectTxPipeline()
+		mock.ExpectIncr("login_failures:user:testuser").SetVal(3)
+		mock.ExpectPExpire("login_failures:user:testuser", time.Hour).SetVal(true)
+		mock.ExpectTxPipelineExec()
+
+		failures, err := repo.AddFailure(ctx, "user:testuser", time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), failures)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RedisError", func(t *testing.T) {
+		mock.ExpectTxPipeline()
+		mock.ExpectIncr("login_failures:user:testuser").SetErr(errors.New("connection error"))
+
+		_, err := repo.AddFailure(ctx, "user:testuser", time.Hour)
+		assert.ErrorContains(t, err, "connection error")
+	})
+}
+
+func TestLoginAttemptRedisRepository_Lock(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	repo := NewLoginAttemptRedisRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectSet("login_lockout:ip:127.0.0.1", 1, time.Minute).SetVal("OK")
+
+		err := repo.Lock(ctx, "ip:127.0.0.1", time.Minute)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RedisError", func(t *testing.T) {
+		mock.ExpectSet("login_lockout:ip:127.0.0.1", 1, time.Minute).SetErr(errors.New("connection error"))
+
+		err := repo.Lock(ctx, "ip:127.0.0.1", time.Minute)
+		assert.ErrorContains(t, err, "connection error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestLoginAttemptRedisRepository_Reset(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	repo := NewLoginAttemptRedisRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectDel("login_failures:user:testuser", "login_lockout:user:testuser").SetVal(2)
+
+		err := repo.Reset(ctx, "user:testuser")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RedisError", func(t *testing.T) {
+		mock.ExpectDel("login_failures:user:testuser", "login_lockout:user:testuser").SetErr(errors.New("connection error"))
+
+		err := repo.Reset(ctx, "user:testuser")
+		assert.ErrorContains(t, err, "connection error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -15,6 +15,24 @@ import (
 	"github.com/artrsyf/avito-trainee-assignment/internal/session/domain/model"
 )
 
+// rotateScript swaps the stored session only if the presented refresh token
+// is still the current one. A stale token means it has been rotated already
+// and is being replayed, so the whole session is dropped.
+var rotateScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if not current then
+	return -1
+end
+if cjson.decode(current)["refresh_token"] ~= ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	redis.call("SREM", KEYS[2], ARGV[4])
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+redis.call("PEXPIRE", KEYS[2], ARGV[3])
+return 1
+`)
+
 type SessionRedisRepository struct {
 	client *redis.Client
 	logger *logrus.Logger
@@ -30,12 +48,20 @@ func NewSessionRedisRepository(
 	}
 }
 
+// Every device session lives under its own key, user_sessions:<user id>
+// indexes them so that all sessions of a user can be listed or revoked.
+func sessionKey(userID uint, sessionID string) string {
+	return "sessions:" + strconv.FormatUint(uint64(userID), 10) + ":" + sessionID
+}
+
+func userSessionsKey(userID uint) string {
+	return "user_sessions:" + strconv.FormatUint(uint64(userID), 10)
+}
+
 func (repo *SessionRedisRepository) Create(
 	ctx context.Context,
 	sessionEntity *entity.Session,
 ) (*model.Session, error) {
-	mkey := "sessions:" + strconv.FormatUint(uint64(sessionEntity.UserID), 10)
-
 	sessionModel := dto.SessionEntityToModel(sessionEntity)
 	sessionSerialized, err := json.Marshal(sessionModel)
 	if err != nil {
@@ -43,22 +69,21 @@ func (repo *SessionRedisRepository) Create(
 		return nil, fmt.Errorf("marshal error: %w", err)
 	}
 
-	ttl := time.Until(sessionModel.AccessExpiresAt)
+	ttl := time.Until(sessionModel.RefreshExpiresAt)
 
-	err = repo.client.SetEx(
-		ctx,
-		mkey,
-		sessionSerialized,
-		ttl,
-	).Err()
+	pipe := repo.client.TxPipeline()
+	pipe.SetEx(ctx, sessionKey(sessionModel.UserID, sessionModel.ID), sessionSerialized, ttl)
+	pipe.SAdd(ctx, userSessionsKey(sessionModel.UserID), sessionModel.ID)
+	pipe.Expire(ctx, userSessionsKey(sessionModel.UserID), ttl)
 
-	if err != nil {
+	if _, err = pipe.Exec(ctx); err != nil {
 		repo.logger.WithError(err).Error("Failed to set session in Redis")
 		return nil, fmt.Errorf("redis error: %w", err)
 	}
 
 	repo.logger.WithFields(logrus.Fields{
-		"user_id": sessionEntity.UserID,
+		"user_id":    sessionEntity.UserID,
+		"session_id": sessionEntity.ID,
 	}).Debug("Created session in Redis")
 
 	return sessionModel, nil
@@ -67,13 +92,13 @@ func (repo *SessionRedisRepository) Create(
 func (repo *SessionRedisRepository) Check(
 	ctx context.Context,
 	userID uint,
+	sessionID string,
 ) (*model.Session, error) {
-	mkey := "sessions:" + strconv.FormatUint(uint64(userID), 10)
-
-	data, err := repo.client.Get(ctx, mkey).Bytes()
+	data, err := repo.client.Get(ctx, sessionKey(userID, sessionID)).Bytes()
 	if err == redis.Nil {
 		repo.logger.WithFields(logrus.Fields{
-			"user_id": userID,
+			"user_id":    userID,
+			"session_id": sessionID,
 		}).Debug("Couldn't find user session")
 		return nil, entity.ErrNoSession
 	}
@@ -90,3 +115,169 @@ func (repo *SessionRedisRepository) Check(
 
 	return session, nil
 }
+
+func (repo *SessionRedisRepository) GetByUserID(
+	ctx context.Context,
+	userID uint,
+) ([]*model.Session, error) {
+	sessionIDs, err := repo.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get user sessions index from Redis")
+		return nil, fmt.Errorf("redis error: %w", err)
+	}
+
+	sessions := []*model.Session{}
+	if len(sessionIDs) == 0 {
+		return sessions, nil
+	}
+
+	keys := make([]string, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		keys = append(keys, sessionKey(userID, sessionID))
+	}
+
+	values, err := repo.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get user sessions from Redis")
+		return nil, fmt.Errorf("redis error: %w", err)
+	}
+
+	expiredSessionIDs := []interface{}{}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			expiredSessionIDs = append(expiredSessionIDs, sessionIDs[i])
+			continue
+		}
+
+		session := &model.Session{}
+		if err := json.Unmarshal([]byte(data), session); err != nil {
+			repo.logger.WithError(err).Error("Failed to deserialize session in model")
+			return nil, fmt.Errorf("unmarshal error: %w", err)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if len(expiredSessionIDs) > 0 {
+		err = repo.client.SRem(ctx, userSessionsKey(userID), expiredSessionIDs...).Err()
+		if err != nil {
+			repo.logger.WithError(err).Warn("Failed to clean up expired sessions index")
+		}
+	}
+
+	return sessions, nil
+}
+
+func (repo *SessionRedisRepository) Rotate(
+	ctx context.Context,
+	refreshToken string,
+	sessionEntity *entity.Session,
+) (*model.Session, error) {
+	sessionModel := dto.SessionEntityToModel(sessionEntity)
+	sessionSerialized, err := json.Marshal(sessionModel)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to serialize session model")
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+
+	ttl := time.Until(sessionModel.RefreshExpiresAt)
+
+	result, err := rotateScript.Run(
+		ctx,
+		repo.client,
+		[]string{
+			sessionKey(sessionModel.UserID, sessionModel.ID),
+			userSessionsKey(sessionModel.UserID),
+		},
+		refreshToken,
+		sessionSerialized,
+		ttl.Milliseconds(),
+		sessionModel.ID,
+	).Int()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to rotate session in Redis")
+		return nil, fmt.Errorf("redis error: %w", err)
+	}
+
+	switch result {
+	case -1:
+		repo.logger.WithFields(logrus.Fields{
+			"user_id":    sessionEntity.UserID,
+			"session_id": sessionEntity.ID,
+		}).Debug("Couldn't find user session to rotate")
+		return nil, entity.ErrNoSession
+	case 0:
+		repo.logger.WithFields(logrus.Fields{
+			"user_id":    sessionEntity.UserID,
+			"session_id": sessionEntity.ID,
+		}).Warn("Refresh token reuse detected, session revoked")
+		return nil, entity.ErrRefreshTokenReused
+	}
+
+	repo.logger.WithFields(logrus.Fields{
+		"user_id":    sessionEntity.UserID,
+		"session_id": sessionEntity.ID,
+	}).Debug("Rotated session in Redis")
+
+	return sessionModel, nil
+}
+
+func (repo *SessionRedisRepository) Delete(
+	ctx context.Context,
+	userID uint,
+	sessionID string,
+) error {
+	pipe := repo.client.TxPipeline()
+	deleted := pipe.Del(ctx, sessionKey(userID, sessionID))
+	pipe.SRem(ctx, userSessionsKey(userID), sessionID)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		repo.logger.WithError(err).Error("Failed to delete session from Redis")
+		return fmt.Errorf("redis error: %w", err)
+	}
+
+	if deleted.Val() == 0 {
+		repo.logger.WithFields(logrus.Fields{
+			"user_id":    userID,
+			"session_id": sessionID,
+		}).Debug("Couldn't find user session to delete")
+		return entity.ErrNoSession
+	}
+
+	repo.logger.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"session_id": sessionID,
+	}).Debug("Deleted session from Redis")
+
+	return nil
+}
+
+func (repo *SessionRedisRepository) DeleteAll(
+	ctx context.Context,
+	userID uint,
+) error {
+	sessionIDs, err := repo.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get user sessions index from Redis")
+		return fmt.Errorf("redis error: %w", err)
+	}
+
+	keys := make([]string, 0, len(sessionIDs)+1)
+	for _, sessionID := range sessionIDs {
+		keys = append(keys, sessionKey(userID, sessionID))
+	}
+	keys = append(keys, userSessionsKey(userID))
+
+	if err = repo.client.Del(ctx, keys...).Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to delete user sessions from Redis")
+		return fmt.Errorf("redis error: %w", err)
+	}
+
+	repo.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"sessions": len(sessionIDs),
+	}).Debug("Deleted all user sessions from Redis")
+
+	return nil
+}
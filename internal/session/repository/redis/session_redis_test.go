@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -23,28 +24,40 @@ func TestSessionRedisRepository_Create(t *testing.T) {
 
 	now := time.Now()
 	sessionEntity := &entity.Session{
+		ID:               "sid1",
 		JWTAccess:        "access_token",
 		JWTRefresh:       "refresh_token",
 		UserID:           1,
 		Username:         "testuser",
+		UserAgent:        "curl/8.0",
+		IP:               "127.0.0.1",
+		CreatedAt:        now,
 		AccessExpiresAt:  now.Add(1 * time.Hour),
 		RefreshExpiresAt: now.Add(24 * time.Hour),
 	}
 
 	t.Run("Success", func(t *testing.T) {
 		sessionModel := model.Session{
+			ID:               "sid1",
 			JWTAccess:        "access_token",
 			JWTRefresh:       "refresh_token",
 			UserID:           1,
 			Username:         "testuser",
+			UserAgent:        "curl/8.0",
+			IP:               "127.0.0.1",
+			CreatedAt:        now,
 			AccessExpiresAt:  now.Add(1 * time.Hour),
 			RefreshExpiresAt: now.Add(24 * time.Hour),
 		}
 
 		serialized, _ := json.Marshal(sessionModel)
-		ttl := time.Until(sessionModel.AccessExpiresAt)
+		ttl := time.Until(sessionModel.RefreshExpiresAt)
 
-		mock.ExpectSetEx("sessions:1", serialized, ttl).SetVal("OK")
+		mock.ExpectTxPipeline()
+		mock.ExpectSetEx("sessions:1:sid1", serialized, ttl).SetVal("OK")
+		mock.ExpectSAdd("user_sessions:1", "sid1").SetVal(1)
+		mock.ExpectExpire("user_sessions:1", ttl).SetVal(true)
+		mock.ExpectTxPipelineExec()
 
 		result, err := repo.Create(ctx, sessionEntity)
 
@@ -53,17 +66,9 @@ func TestSessionRedisRepository_Create(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("MarshalError", func(t *testing.T) {
-		invalidEntity := &entity.Session{
-			AccessExpiresAt: time.Now().Add(1 * time.Hour),
-		}
-
-		_, err := repo.Create(ctx, invalidEntity)
-		assert.Error(t, err)
-	})
-
 	t.Run("RedisError", func(t *testing.T) {
-		mock.ExpectSetEx("sessions:1", nil, 0).SetErr(errors.New("redis error"))
+		mock.ExpectTxPipeline()
+		mock.ExpectSetEx("sessions:1:sid1", nil, 0).SetErr(errors.New("redis error"))
 
 		_, err := repo.Create(ctx, sessionEntity)
 		assert.ErrorContains(t, err, "redis error")
@@ -78,19 +83,21 @@ func TestSessionRedisRepository_Check(t *testing.T) {
 	fixedTime := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
 
 	validSession := model.Session{
+		ID:               "sid1",
 		JWTAccess:        "access_token",
 		JWTRefresh:       "refresh_token",
 		UserID:           1,
 		Username:         "testuser",
+		CreatedAt:        fixedTime,
 		AccessExpiresAt:  fixedTime.Add(1 * time.Hour),
 		RefreshExpiresAt: fixedTime.Add(24 * time.Hour),
 	}
 	serialized, _ := json.Marshal(validSession)
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectGet("sessions:1").SetVal(string(serialized))
+		mock.ExpectGet("sessions:1:sid1").SetVal(string(serialized))
 
-		session, err := repo.Check(ctx, 1)
+		session, err := repo.Check(ctx, 1, "sid1")
 
 		assert.NoError(t, err)
 		assert.Equal(t, &validSession, session)
@@ -98,30 +105,215 @@ func TestSessionRedisRepository_Check(t *testing.T) {
 	})
 
 	t.Run("NotFound", func(t *testing.T) {
-		mock.ExpectGet("sessions:2").RedisNil()
+		mock.ExpectGet("sessions:2:sid2").RedisNil()
 
-		_, err := repo.Check(ctx, 2)
+		_, err := repo.Check(ctx, 2, "sid2")
 		assert.ErrorIs(t, err, entity.ErrNoSession)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("RedisError", func(t *testing.T) {
-		mock.ExpectGet("sessions:3").SetErr(errors.New("connection error"))
+		mock.ExpectGet("sessions:3:sid3").SetErr(errors.New("connection error"))
 
-		_, err := repo.Check(ctx, 3)
+		_, err := repo.Check(ctx, 3, "sid3")
 		assert.ErrorContains(t, err, "connection error")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("InvalidData", func(t *testing.T) {
-		mock.ExpectGet("sessions:4").SetVal("{invalid json}")
+		mock.ExpectGet("sessions:4:sid4").SetVal("{invalid json}")
 
-		_, err := repo.Check(ctx, 4)
+		_, err := repo.Check(ctx, 4, "sid4")
 		assert.Error(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
+func TestSessionRedisRepository_GetByUserID(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	repo := NewSessionRedisRepository(db, logrus.New())
+
+	fixedTime := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+
+	laptopSession := model.Session{
+		ID:        "laptop",
+		UserID:    1,
+		UserAgent: "Firefox",
+		CreatedAt: fixedTime,
+	}
+	serialized, _ := json.Marshal(laptopSession)
+
+	t.Run("SuccessWithExpiredSession", func(t *testing.T) {
+		mock.ExpectSMembers("user_sessions:1").SetVal([]string{"laptop", "phone"})
+		mock.ExpectMGet("sessions:1:laptop", "sessions:1:phone").SetVal([]interface{}{string(serialized), nil})
+		mock.ExpectSRem("user_sessions:1", "phone").SetVal(1)
+
+		sessions, err := repo.GetByUserID(ctx, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.Session{&laptopSession}, sessions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoSessions", func(t *testing.T) {
+		mock.ExpectSMembers("user_sessions:2").SetVal([]string{})
+
+		sessions, err := repo.GetByUserID(ctx, 2)
+
+		assert.NoError(t, err)
+		assert.Empty(t, sessions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RedisError", func(t *testing.T) {
+		mock.ExpectSMembers("user_sessions:3").SetErr(errors.New("connection error"))
+
+		_, err := repo.GetByUserID(ctx, 3)
+
+		assert.ErrorContains(t, err, "connection error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSessionRedisRepository_Rotate(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	repo := NewSessionRedisRepository(db, logrus.New())
+
+	now := time.Now()
+	rotatedEntity := &entity.Session{
+		ID:               "sid1",
+		JWTAccess:        "new_access_token",
+		JWTRefresh:       "new_refresh_token",
+		UserID:           1,
+		Username:         "testuser",
+		AccessExpiresAt:  now.Add(1 * time.Hour),
+		RefreshExpiresAt: now.Add(24 * time.Hour),
+	}
+	keys := []string{"sessions:1:sid1", "user_sessions:1"}
+
+	// Serialized session and ttl are computed at call time, so only the
+	// command, script, keys and presented refresh token are compared.
+	matchScriptCall := func(expected, actual []interface{}) error {
+		for _, i := range []int{0, 1, 2, 3, 4, 5} {
+			if expected[i] != actual[i] {
+				return fmt.Errorf("unexpected command %v", actual)
+			}
+		}
+		return nil
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.CustomMatch(matchScriptCall).
+			ExpectEvalSha(rotateScript.Hash(), keys, "old_refresh_token", nil, nil, "sid1").
+			SetVal(int64(1))
+
+		session, err := repo.Rotate(ctx, "old_refresh_token", rotatedEntity)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "new_refresh_token", session.JWTRefresh)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoSession", func(t *testing.T) {
+		mock.CustomMatch(matchScriptCall).
+			ExpectEvalSha(rotateScript.Hash(), keys, "old_refresh_token", nil, nil, "sid1").
+			SetVal(int64(-1))
+
+		_, err := repo.Rotate(ctx, "old_refresh_token", rotatedEntity)
+
+		assert.ErrorIs(t, err, entity.ErrNoSession)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReusedToken", func(t *testing.T) {
+		mock.CustomMatch(matchScriptCall).
+			ExpectEvalSha(rotateScript.Hash(), keys, "stale_refresh_token", nil, nil, "sid1").
+			SetVal(int64(0))
+
+		_, err := repo.Rotate(ctx, "stale_refresh_token", rotatedEntity)
+
+		assert.ErrorIs(t, err, entity.ErrRefreshTokenReused)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RedisError", func(t *testing.T) {
+		mock.CustomMatch(matchScriptCall).
+			ExpectEvalSha(rotateScript.Hash(), keys, "old_refresh_token", nil, nil, "sid1").
+			SetErr(errors.New("connection error"))
+
+		_, err := repo.Rotate(ctx, "old_refresh_token", rotatedEntity)
+
+		assert.ErrorContains(t, err, "connection error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSessionRedisRepository_Delete(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	repo := NewSessionRedisRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectTxPipeline()
+		mock.ExpectDel("sessions:1:sid1").SetVal(1)
+		mock.ExpectSRem("user_sessions:1", "sid1").SetVal(1)
+		mock.ExpectTxPipelineExec()
+
+		err := repo.Delete(ctx, 1, "sid1")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectTxPipeline()
+		mock.ExpectDel("sessions:2:sid2").SetVal(0)
+		mock.ExpectSRem("user_sessions:2", "sid2").SetVal(0)
+		mock.ExpectTxPipelineExec()
+
+		err := repo.Delete(ctx, 2, "sid2")
+
+		assert.ErrorIs(t, err, entity.ErrNoSession)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RedisError", func(t *testing.T) {
+		mock.ExpectTxPipeline()
+		mock.ExpectDel("sessions:3:sid3").SetErr(errors.New("connection error"))
+
+		err := repo.Delete(ctx, 3, "sid3")
+
+		assert.ErrorContains(t, err, "connection error")
+	})
+}
+
+func TestSessionRedisRepository_DeleteAll(t *testing.T) {
+	ctx := context.Background()
+	db, mock := redismock.NewClientMock()
+	repo := NewSessionRedisRepository(db, logrus.New())
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectSMembers("user_sessions:1").SetVal([]string{"laptop", "phone"})
+		mock.ExpectDel("sessions:1:laptop", "sessions:1:phone", "user_sessions:1").SetVal(3)
+
+		err := repo.DeleteAll(ctx, 1)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RedisError", func(t *testing.T) {
+		mock.ExpectSMembers("user_sessions:2").SetErr(errors.New("connection error"))
+
+		err := repo.DeleteAll(ctx, 2)
+
+		assert.ErrorContains(t, err, "connection error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestNewSessionRedisRepository(t *testing.T) {
 	db := redis.NewClient(&redis.Options{})
 	repo := NewSessionRedisRepository(db, logrus.New())
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/artrsyf/avito-trainee-assignment/internal/session/domain/entity"
 	"github.com/artrsyf/avito-trainee-assignment/internal/session/domain/model"
@@ -10,5 +11,18 @@ import (
 //go:generate mockgen -source=repository.go -destination=mock_repository/session_mock.go -package=mock_repository MockSessionRepository
 type SessionRepositoryI interface {
 	Create(ctx context.Context, sessionEntity *entity.Session) (*model.Session, error)
-	Check(ctx context.Context, userID uint) (*model.Session, error)
+	Check(ctx context.Context, userID uint, sessionID string) (*model.Session, error)
+	GetByUserID(ctx context.Context, userID uint) ([]*model.Session, error)
+	Rotate(ctx context.Context, refreshToken string, sessionEntity *entity.Session) (*model.Session, error)
+	Delete(ctx context.Context, userID uint, sessionID string) error
+	DeleteAll(ctx context.Context, userID uint) error
+}
+
+// LoginAttemptRepositoryI keeps failed login counters and lockouts. Keys are
+// built by entity.UsernameLockoutKey and entity.IPLockoutKey.
+type LoginAttemptRepositoryI interface {
+	GetLockout(ctx context.Context, keys []string) (time.Duration, error)
+	AddFailure(ctx context.Context, key string, resetAfter time.Duration) (uint, error)
+	Lock(ctx context.Context, key string, duration time.Duration) error
+	Reset(ctx context.Context, key string) error
 }
@@ -2,18 +2,27 @@ package usecase
 
 import (
 	"context"
+	"crypto/subtle"
+	"regexp"
 	"time"
 
 	"github.com/artrsyf/avito-trainee-assignment/config"
+	"github.com/artrsyf/avito-trainee-assignment/pkg/cache"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository"
 	sessionDTO "github.com/artrsyf/avito-trainee-assignment/internal/session/domain/dto"
 	sessionEntity "github.com/artrsyf/avito-trainee-assignment/internal/session/domain/entity"
 	sessionRepo "github.com/artrsyf/avito-trainee-assignment/internal/session/repository"
 	userDTO "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/dto"
 	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
+	userModel "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/model"
 	userRepo "github.com/artrsyf/avito-trainee-assignment/internal/user/repository"
+	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
 )
 
 type SessionUsecaseI interface {
@@ -21,33 +30,164 @@ type SessionUsecaseI interface {
 		ctx context.Context,
 		authRequest *sessionDTO.AuthRequest,
 	) (*sessionEntity.Session, error)
+	Register(
+		ctx context.Context,
+		registerRequest *sessionDTO.RegisterRequest,
+	) (*sessionEntity.Session, error)
+	Refresh(
+		ctx context.Context,
+		refreshToken string,
+	) (*sessionEntity.Session, error)
+	Validate(ctx context.Context, userID uint, sessionID, accessToken string) error
+	GetSessions(ctx context.Context, userID uint) ([]*sessionEntity.Session, error)
+	Logout(ctx context.Context, userID uint, sessionID string) error
+	LogoutAll(ctx context.Context, userID uint) error
+	UnlockUser(ctx context.Context, username string) error
+	UnlockIP(ctx context.Context, ip string) error
+}
+
+type liveSession struct {
+	userID    uint
+	sessionID string
+}
+
+// lockoutPolicy is the parsed lockout config, zero limits disable lockouts.
+type lockoutPolicy struct {
+	maxAttempts   uint
+	maxIPAttempts uint
+	resetAfter    time.Duration
+	baseDuration  time.Duration
+	maxDuration   time.Duration
+}
+
+type lockoutLimit struct {
+	key         string
+	maxAttempts uint
 }
 
 type SessionUsecase struct {
-	sessionRepo sessionRepo.SessionRepositoryI
-	userRepo    userRepo.UserRepositoryI
-	userConfig  config.UserConfig
-	logger      *logrus.Logger
+	sessionRepo      sessionRepo.SessionRepositoryI
+	loginAttemptRepo sessionRepo.LoginAttemptRepositoryI
+	userRepo         userRepo.UserRepositoryI
+	ledgerRepo       ledgerRepo.LedgerRepositoryI
+	lotRepo          lotRepo.LotRepositoryI
+	uowFactory       uowI.Factory
+	userConfig       config.UserConfig
+	coinTTL          time.Duration
+	lockout          lockoutPolicy
+	liveTokens       *cache.TTLCache[string, liveSession]
+	logger           *logrus.Logger
+
+	usernamePattern    *regexp.Regexp
+	registrationClosed bool
 }
 
 func NewSessionUsecase(
 	sessionRepository sessionRepo.SessionRepositoryI,
+	loginAttemptRepository sessionRepo.LoginAttemptRepositoryI,
 	userRepository userRepo.UserRepositoryI,
+	ledgerRepository ledgerRepo.LedgerRepositoryI,
+	lotRepository lotRepo.LotRepositoryI,
+	uowFactory uowI.Factory,
 	cfg config.UserConfig,
+	expiryCfg config.ExpiryConfig,
 	logger *logrus.Logger,
 ) *SessionUsecase {
+	sessionCacheTTL, err := cfg.Auth.GetSessionCacheTTL()
+	if err != nil {
+		logger.WithError(err).Warn("Session cache TTL is not set, live sessions won't be cached")
+	}
+
+	coinTTL, err := expiryCfg.GetCoinTTL()
+	if err != nil {
+		logger.WithError(err).Warn("Coin TTL is invalid, initial balances won't expire")
+	}
+
+	lockout, err := newLockoutPolicy(cfg.Lockout)
+	if err != nil {
+		logger.WithError(err).Warn("Login lockout config is invalid, logins won't be locked out")
+	}
+
+	// A broken pattern closes registration rather than letting any
+	// username in.
+	usernamePattern, patternErr := cfg.Registration.GetUsernamePattern()
+	if patternErr != nil {
+		logger.WithError(patternErr).Error("Username pattern is invalid, registration is closed")
+	}
+
 	return &SessionUsecase{
-		sessionRepo: sessionRepository,
-		userRepo:    userRepository,
-		userConfig:  cfg,
-		logger:      logger,
+		sessionRepo:      sessionRepository,
+		loginAttemptRepo: loginAttemptRepository,
+		userRepo:         userRepository,
+		ledgerRepo:       ledgerRepository,
+		lotRepo:          lotRepository,
+		uowFactory:       uowFactory,
+		userConfig:       cfg,
+		coinTTL:          coinTTL,
+		lockout:          lockout,
+		liveTokens:       cache.NewTTLCache[string, liveSession](sessionCacheTTL),
+		logger:           logger,
+
+		usernamePattern:    usernamePattern,
+		registrationClosed: patternErr != nil,
 	}
 }
 
+func newLockoutPolicy(cfg config.LockoutConfig) (lockoutPolicy, error) {
+	if cfg.MaxAttempts == 0 && cfg.MaxIPAttempts == 0 {
+		return lockoutPolicy{}, nil
+	}
+
+	resetAfter, err := cfg.GetResetAfter()
+	if err != nil {
+		return lockoutPolicy{}, err
+	}
+
+	baseDuration, err := cfg.GetBaseDuration()
+	if err != nil {
+		return lockoutPolicy{}, err
+	}
+
+	maxDuration, err := cfg.GetMaxDuration()
+	if err != nil {
+		return lockoutPolicy{}, err
+	}
+
+	return lockoutPolicy{
+		maxAttempts:   cfg.MaxAttempts,
+		maxIPAttempts: cfg.MaxIPAttempts,
+		resetAfter:    resetAfter,
+		baseDuration:  baseDuration,
+		maxDuration:   maxDuration,
+	}, nil
+}
+
+// duration doubles the base lockout for every failure past the limit.
+func (p lockoutPolicy) duration(failuresOverLimit uint) time.Duration {
+	duration := p.baseDuration
+	for i := uint(0); i < failuresOverLimit && duration < p.maxDuration; i++ {
+		duration *= 2
+	}
+	if duration > p.maxDuration {
+		duration = p.maxDuration
+	}
+
+	return duration
+}
+
+// LoginOrSignup logs the user in. An unknown username is registered only if
+// auto signup is enabled, otherwise it's treated as wrong credentials, so a
+// typo in the username doesn't create a new account. Locked out logins fail
+// with LoginLockedError before the password is checked.
 func (uc *SessionUsecase) LoginOrSignup(
 	ctx context.Context,
 	authRequest *sessionDTO.AuthRequest,
 ) (*sessionEntity.Session, error) {
+	limits := uc.lockoutLimits(authRequest)
+	if err := uc.checkLockout(ctx, limits); err != nil {
+		return nil, err
+	}
+
 	userModel, err := uc.userRepo.GetByUsername(ctx, authRequest.Username)
 	if err != nil && err != userEntity.ErrIsNotExist {
 		uc.logger.WithError(err).Error("Failed to get user by username")
@@ -57,20 +197,62 @@ func (uc *SessionUsecase) LoginOrSignup(
 	if userModel != nil {
 		if !checkPassword(authRequest.Password, userModel.PasswordHash) {
 			uc.logger.Info("Failed to authenticate user")
+			uc.registerFailure(ctx, limits)
 			return nil, sessionEntity.ErrWrongCredentials
 		}
 
-		sessionModel, checkErr := uc.sessionRepo.Check(ctx, userModel.ID)
-		if checkErr == sessionEntity.ErrNoSession {
-			return uc.grantSession(ctx, userModel.ID, authRequest)
-		}
-		if checkErr != nil {
-			uc.logger.WithError(checkErr).Error("An error occured due checking user session")
-			return nil, checkErr
-		}
+		uc.resetFailures(ctx, authRequest.Username)
+
+		return uc.grantSession(ctx, userModel.ID, userModel.Role, authRequest)
+	}
+
+	if !uc.userConfig.Registration.AutoSignup {
+		uc.logger.WithField("username", authRequest.Username).Info("Failed to authenticate unknown user")
+		uc.registerFailure(ctx, limits)
+		return nil, sessionEntity.ErrWrongCredentials
+	}
 
-		session := sessionDTO.SessionModelToEntity(sessionModel)
-		return session, nil
+	return uc.createAccount(ctx, authRequest)
+}
+
+// Register creates an account and logs the new user in. Existing usernames
+// fail with ErrAlreadyCreated. Attempts are throttled by the client IP like
+// failed logins.
+func (uc *SessionUsecase) Register(
+	ctx context.Context,
+	registerRequest *sessionDTO.RegisterRequest,
+) (*sessionEntity.Session, error) {
+	limits := uc.ipLockoutLimits(registerRequest.IP)
+	if err := uc.checkLockout(ctx, limits); err != nil {
+		return nil, err
+	}
+
+	// Every attempt counts, not only a wrong invite code: each one hashes a
+	// password, and a valid code shouldn't let one client create accounts
+	// without limit.
+	uc.registerFailure(ctx, limits)
+
+	inviteCode := uc.userConfig.Registration.InviteCode
+	if inviteCode != "" &&
+		subtle.ConstantTimeCompare([]byte(registerRequest.InviteCode), []byte(inviteCode)) != 1 {
+		uc.logger.WithField("username", registerRequest.Username).Info("Failed to register user with wrong invite code")
+		return nil, sessionEntity.ErrInvalidInviteCode
+	}
+
+	return uc.createAccount(ctx, &registerRequest.AuthRequest)
+}
+
+func (uc *SessionUsecase) createAccount(
+	ctx context.Context,
+	authRequest *sessionDTO.AuthRequest,
+) (*sessionEntity.Session, error) {
+	if uc.registrationClosed {
+		return nil, sessionEntity.ErrRegistrationClosed
+	}
+
+	if uc.usernamePattern != nil && !uc.usernamePattern.MatchString(authRequest.Username) {
+		uc.logger.WithField("username", authRequest.Username).Info("Username isn't allowed to register")
+		return nil, sessionEntity.ErrUsernameNotAllowed
 	}
 
 	user, err := userDTO.AuthRequestToEntity(
@@ -84,7 +266,11 @@ func (uc *SessionUsecase) LoginOrSignup(
 		return nil, err
 	}
 
-	createdUserModel, err := uc.userRepo.Create(ctx, user)
+	createdUserModel, err := uc.signup(ctx, user)
+	if err == userEntity.ErrAlreadyCreated {
+		uc.logger.WithField("username", authRequest.Username).Info("Username is already taken")
+		return nil, err
+	}
 	if err != nil {
 		uc.logger.WithError(err).WithField(
 			"broken user", user,
@@ -92,7 +278,161 @@ func (uc *SessionUsecase) LoginOrSignup(
 		return nil, err
 	}
 
-	return uc.grantSession(ctx, createdUserModel.ID, authRequest)
+	return uc.grantSession(ctx, createdUserModel.ID, createdUserModel.Role, authRequest)
+}
+
+// signup creates the user, journals the initial balance as a grant from
+// the issuer and puts it in a lot that expires after the coin TTL, all in
+// one unit of work.
+func (uc *SessionUsecase) signup(ctx context.Context, user *userEntity.User) (*userModel.User, error) {
+	uow := uc.uowFactory.NewUnitOfWork()
+
+	err := uow.Begin(ctx)
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction begin error")
+		return nil, err
+	}
+
+	createdUserModel, err := uc.userRepo.Create(ctx, uow, user)
+	if err != nil {
+		uc.rollback(uow)
+		return nil, err
+	}
+
+	if createdUserModel.Coins > 0 {
+		_, err = uc.ledgerRepo.Post(ctx, uow, ledgerEntity.NewTransfer(
+			ledgerEntity.KindGrant,
+			"signup",
+			ledgerEntity.IssuerAccount,
+			ledgerEntity.UserAccount(createdUserModel.ID),
+			createdUserModel.Coins,
+		))
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Error("Rollback signup due initial grant posting")
+			return nil, err
+		}
+
+		err = uc.lotRepo.Create(ctx, uow, createdUserModel.ID, lotEntity.Portions{
+			lotEntity.NewGrant(createdUserModel.Coins, time.Now(), uc.coinTTL),
+		})
+		if err != nil {
+			uc.rollback(uow)
+			uc.logger.WithError(err).Error("Rollback signup due initial coin lot creating")
+			return nil, err
+		}
+	}
+
+	err = uow.Commit()
+	if err != nil {
+		uc.logger.WithError(err).Error("Transaction commit error")
+		return nil, err
+	}
+
+	return createdUserModel, nil
+}
+
+func (uc *SessionUsecase) rollback(uow uowI.UnitOfWork) {
+	if err := uow.Rollback(); err != nil {
+		uc.logger.WithError(err).Error("Rollback error encountered")
+	}
+}
+
+// lockoutLimits returns the keys the login is throttled by. The IP is
+// unknown if the login doesn't come from the HTTP handler.
+func (uc *SessionUsecase) lockoutLimits(authRequest *sessionDTO.AuthRequest) []lockoutLimit {
+	limits := []lockoutLimit{}
+	if uc.lockout.maxAttempts > 0 {
+		limits = append(limits, lockoutLimit{
+			key:         sessionEntity.UsernameLockoutKey(authRequest.Username),
+			maxAttempts: uc.lockout.maxAttempts,
+		})
+	}
+
+	return append(limits, uc.ipLockoutLimits(authRequest.IP)...)
+}
+
+// ipLockoutLimits is all registration is throttled by, a username that
+// doesn't exist yet has nothing to protect.
+func (uc *SessionUsecase) ipLockoutLimits(ip string) []lockoutLimit {
+	if uc.lockout.maxIPAttempts == 0 || ip == "" {
+		return nil
+	}
+
+	return []lockoutLimit{{
+		key:         sessionEntity.IPLockoutKey(ip),
+		maxAttempts: uc.lockout.maxIPAttempts,
+	}}
+}
+
+func (uc *SessionUsecase) checkLockout(ctx context.Context, limits []lockoutLimit) error {
+	if len(limits) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(limits))
+	for _, limit := range limits {
+		keys = append(keys, limit.key)
+	}
+
+	retryAfter, err := uc.loginAttemptRepo.GetLockout(ctx, keys)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to check login lockout")
+		return err
+	}
+
+	if retryAfter > 0 {
+		uc.logger.WithFields(logrus.Fields{
+			"keys":        keys,
+			"retry_after": retryAfter,
+		}).Info("Rejected locked out login")
+		return &sessionEntity.LoginLockedError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// registerFailure counts the failed login or registration attempt and locks
+// out the keys that reached their limit. Errors are only logged, the client
+// gets its answer anyway.
+func (uc *SessionUsecase) registerFailure(ctx context.Context, limits []lockoutLimit) {
+	for _, limit := range limits {
+		failures, err := uc.loginAttemptRepo.AddFailure(ctx, limit.key, uc.lockout.resetAfter)
+		if err != nil {
+			uc.logger.WithError(err).Warn("Failed to count login failure")
+			continue
+		}
+
+		if failures < limit.maxAttempts {
+			continue
+		}
+
+		duration := uc.lockout.duration(failures - limit.maxAttempts)
+		if err = uc.loginAttemptRepo.Lock(ctx, limit.key, duration); err != nil {
+			uc.logger.WithError(err).Warn("Failed to lock out login")
+			continue
+		}
+
+		uc.logger.WithFields(logrus.Fields{
+			"key":      limit.key,
+			"failures": failures,
+			"duration": duration,
+		}).Warn("Locked out login after repeated failures")
+	}
+}
+
+// resetFailures forgets failures of the username after a successful login.
+// The IP counter is kept, otherwise an attacker owning one account could
+// reset it between guesses.
+func (uc *SessionUsecase) resetFailures(ctx context.Context, username string) {
+	if uc.lockout.maxAttempts == 0 {
+		return
+	}
+
+	err := uc.loginAttemptRepo.Reset(ctx, sessionEntity.UsernameLockoutKey(username))
+	if err != nil {
+		uc.logger.WithError(err).Warn("Failed to reset login failures")
+	}
 }
 
 func checkPassword(inputPassword, storedPasswordHash string) bool {
@@ -102,26 +442,222 @@ func checkPassword(inputPassword, storedPasswordHash string) bool {
 	) == nil
 }
 
-func (uc *SessionUsecase) grantSession(
+func (uc *SessionUsecase) Refresh(
 	ctx context.Context,
-	userID uint,
-	authRequest *sessionDTO.AuthRequest,
+	refreshToken string,
 ) (*sessionEntity.Session, error) {
+	claims, err := sessionDTO.ParseRefreshToken(refreshToken)
+	if err != nil {
+		uc.logger.WithError(err).Warn("Failed to parse refresh token")
+		return nil, err
+	}
+
+	accessTokenExpiration, refreshTokenExpiration, err := uc.tokenExpirations()
+	if err != nil {
+		return nil, err
+	}
+
+	currentSessionModel, err := uc.sessionRepo.Check(ctx, claims.UserID, claims.SessionID)
+	if err != nil {
+		uc.logger.WithError(err).WithField(
+			"user_id", claims.UserID,
+		).Warn("Failed to find session to refresh")
+		return nil, err
+	}
+
+	// The role is re-read on every refresh, so role changes reach tokens
+	// without forcing the user to log in again.
+	userModel, err := uc.userRepo.GetByID(ctx, claims.UserID)
+	if err == userEntity.ErrIsNotExist {
+		// The user was deleted, so the session is revoked instead of being
+		// refreshed.
+		uc.logger.WithField("user_id", claims.UserID).Info("Refreshing session of deleted user")
+		uc.forgetSession(claims.SessionID)
+		if err = uc.sessionRepo.Delete(ctx, claims.UserID, claims.SessionID); err != nil {
+			uc.logger.WithError(err).Warn("Failed to delete session of deleted user")
+		}
+		return nil, sessionEntity.ErrNoSession
+	} else if err != nil {
+		uc.logger.WithError(err).WithField(
+			"user_id", claims.UserID,
+		).Warn("Failed to get user to refresh session")
+		return nil, err
+	}
+
+	currentSession := sessionDTO.SessionModelToEntity(currentSessionModel)
+	currentSession.Role = userModel.Role
+
+	session, err := sessionDTO.RotateSessionEntity(
+		currentSession,
+		time.Now().Add(accessTokenExpiration),
+		time.Now().Add(refreshTokenExpiration),
+	)
+	if err != nil {
+		uc.logger.WithError(err).WithField(
+			"user_id", claims.UserID,
+		).Error("Failed to sign rotated session")
+		return nil, err
+	}
+
+	rotatedSessionModel, err := uc.sessionRepo.Rotate(ctx, refreshToken, session)
+	if err != nil {
+		uc.logger.WithError(err).WithField(
+			"user_id", claims.UserID,
+		).Warn("Failed to rotate user session")
+		return nil, err
+	}
+
+	uc.forgetSession(claims.SessionID)
+
+	uc.logger.WithFields(logrus.Fields{
+		"user_id":    claims.UserID,
+		"session_id": claims.SessionID,
+	}).Info("Refreshed session")
+
+	return sessionDTO.SessionModelToEntity(rotatedSessionModel), nil
+}
+
+// Validate checks that the access token still belongs to a live session.
+// Positive answers are cached locally for a short time, so a revoked token
+// may be accepted by other replicas until their cache entry expires.
+func (uc *SessionUsecase) Validate(
+	ctx context.Context,
+	userID uint,
+	sessionID,
+	accessToken string,
+) error {
+	cached, ok := uc.liveTokens.Get(accessToken)
+	if ok && cached.userID == userID && cached.sessionID == sessionID {
+		return nil
+	}
+
+	sessionModel, err := uc.sessionRepo.Check(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if sessionModel.JWTAccess != accessToken {
+		return sessionEntity.ErrNoSession
+	}
+
+	uc.liveTokens.Set(accessToken, liveSession{userID: userID, sessionID: sessionID})
+
+	return nil
+}
+
+func (uc *SessionUsecase) GetSessions(
+	ctx context.Context,
+	userID uint,
+) ([]*sessionEntity.Session, error) {
+	sessionModels, err := uc.sessionRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to get user sessions")
+		return nil, err
+	}
+
+	sessions := make([]*sessionEntity.Session, 0, len(sessionModels))
+	for _, sessionModel := range sessionModels {
+		sessions = append(sessions, sessionDTO.SessionModelToEntity(sessionModel))
+	}
+
+	return sessions, nil
+}
+
+func (uc *SessionUsecase) Logout(
+	ctx context.Context,
+	userID uint,
+	sessionID string,
+) error {
+	uc.forgetSession(sessionID)
+
+	if err := uc.sessionRepo.Delete(ctx, userID, sessionID); err != nil {
+		uc.logger.WithError(err).Warn("Failed to delete user session")
+		return err
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"session_id": sessionID,
+	}).Info("Logged out session")
+
+	return nil
+}
+
+func (uc *SessionUsecase) LogoutAll(ctx context.Context, userID uint) error {
+	uc.liveTokens.DeleteFunc(func(_ string, cached liveSession) bool {
+		return cached.userID == userID
+	})
+
+	if err := uc.sessionRepo.DeleteAll(ctx, userID); err != nil {
+		uc.logger.WithError(err).Error("Failed to delete user sessions")
+		return err
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+	}).Info("Logged out all sessions")
+
+	return nil
+}
+
+// UnlockUser lifts the lockout of the username and forgets its failures.
+func (uc *SessionUsecase) UnlockUser(ctx context.Context, username string) error {
+	return uc.unlock(ctx, sessionEntity.UsernameLockoutKey(username))
+}
+
+// UnlockIP lifts the lockout of the client IP and forgets its failures.
+func (uc *SessionUsecase) UnlockIP(ctx context.Context, ip string) error {
+	return uc.unlock(ctx, sessionEntity.IPLockoutKey(ip))
+}
+
+func (uc *SessionUsecase) unlock(ctx context.Context, key string) error {
+	if err := uc.loginAttemptRepo.Reset(ctx, key); err != nil {
+		uc.logger.WithError(err).Error("Failed to unlock login")
+		return err
+	}
+
+	uc.logger.WithField("key", key).Info("Unlocked login")
+
+	return nil
+}
+
+func (uc *SessionUsecase) forgetSession(sessionID string) {
+	uc.liveTokens.DeleteFunc(func(_ string, cached liveSession) bool {
+		return cached.sessionID == sessionID
+	})
+}
+
+func (uc *SessionUsecase) tokenExpirations() (time.Duration, time.Duration, error) {
 	accessTokenExpiration, err := uc.userConfig.Auth.GetAccessTokenExpiration()
 	if err != nil {
 		uc.logger.WithError(err).Error("Failed to parse access token expiration")
-		return nil, err
+		return 0, 0, err
 	}
 
 	refreshTokenExpiration, err := uc.userConfig.Auth.GetRefreshTokenExpiration()
 	if err != nil {
 		uc.logger.WithError(err).Error("Failed to parse refresh token expiration")
+		return 0, 0, err
+	}
+
+	return accessTokenExpiration, refreshTokenExpiration, nil
+}
+
+func (uc *SessionUsecase) grantSession(
+	ctx context.Context,
+	userID uint,
+	role string,
+	authRequest *sessionDTO.AuthRequest,
+) (*sessionEntity.Session, error) {
+	accessTokenExpiration, refreshTokenExpiration, err := uc.tokenExpirations()
+	if err != nil {
 		return nil, err
 	}
 
 	session, err := sessionDTO.AuthRequestToEntity(
 		authRequest,
 		userID,
+		role,
 		time.Now().Add(accessTokenExpiration),
 		time.Now().Add(refreshTokenExpiration),
 	)
@@ -5,12 +5,18 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/artrsyf/avito-trainee-assignment/config"
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerModel "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/model"
+	mockLedger "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/mock_repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	mockLot "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/mock_repository"
 	"github.com/artrsyf/avito-trainee-assignment/internal/session/domain/dto"
 	sessionEntity "github.com/artrsyf/avito-trainee-assignment/internal/session/domain/entity"
 	sessionModel "github.com/artrsyf/avito-trainee-assignment/internal/session/domain/model"
@@ -18,6 +24,7 @@ import (
 	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
 	userModel "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/model"
 	mockUser "github.com/artrsyf/avito-trainee-assignment/internal/user/repository/mock_repository"
+	mockUow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/mock_uow"
 )
 
 func TestSessionUsecase_LoginOrSignup(t *testing.T) {
@@ -27,6 +34,10 @@ func TestSessionUsecase_LoginOrSignup(t *testing.T) {
 
 	mockSessionRepo := mockSession.NewMockSessionRepositoryI(ctrl)
 	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+	mockUnitOfWork := mockUow.NewMockUnitOfWork(ctrl)
 
 	cfg := config.UserConfig{
 		InitCoinsBalance: 100,
@@ -34,9 +45,20 @@ func TestSessionUsecase_LoginOrSignup(t *testing.T) {
 			AccessTokenExpiration:  "1h",
 			RefreshTokenExpiration: "24h",
 		},
+		Registration: config.RegistrationConfig{AutoSignup: true},
 	}
 
-	uc := NewSessionUsecase(mockSessionRepo, mockUserRepo, cfg, logrus.New())
+	uc := NewSessionUsecase(
+		mockSessionRepo,
+		nil,
+		mockUserRepo,
+		mockLedgerRepo,
+		mockLotRepo,
+		mockUowFactory,
+		cfg,
+		config.ExpiryConfig{CoinTTL: "8760h"},
+		logrus.New(),
+	)
 
 	ctx := context.Background()
 	testAuthRequest := &dto.AuthRequest{
@@ -47,12 +69,13 @@ func TestSessionUsecase_LoginOrSignup(t *testing.T) {
 	// Set JWT secret for testing
 	os.Setenv("TOKEN_KEY", "test-secret-key")
 
-	t.Run("successful login with existing session", func(t *testing.T) {
-		user := &userModel.User{ID: 1, Username: "testuser", PasswordHash: hashPassword("testpass")}
-		session := &sessionModel.Session{UserID: 1}
+	t.Run("successful login creates new session", func(t *testing.T) {
+		user := &userModel.User{ID: 1, Username: "testuser", PasswordHash: hashPassword("testpass"), Role: userEntity.RoleModerator}
 
 		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(user, nil)
-		mockSessionRepo.EXPECT().Check(ctx, uint(1)).Return(session, nil)
+		mockSessionRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, s *sessionEntity.Session) (*sessionModel.Session, error) {
+			return dto.SessionEntityToModel(s), nil
+		})
 
 		result, err := uc.LoginOrSignup(ctx, testAuthRequest)
 		if err != nil {
@@ -62,24 +85,33 @@ func TestSessionUsecase_LoginOrSignup(t *testing.T) {
 		if result.UserID != 1 {
 			t.Errorf("expected userID 1, got %d", result.UserID)
 		}
+		if result.ID == "" {
+			t.Error("expected session id to be generated")
+		}
+		if result.Role != userEntity.RoleModerator {
+			t.Errorf("expected moderator role, got %q", result.Role)
+		}
 	})
 
-	t.Run("successful login without existing session", func(t *testing.T) {
+	t.Run("each login gets its own session", func(t *testing.T) {
 		user := &userModel.User{ID: 1, Username: "testuser", PasswordHash: hashPassword("testpass")}
 
-		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(user, nil)
-		mockSessionRepo.EXPECT().Check(ctx, uint(1)).Return(nil, sessionEntity.ErrNoSession)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(user, nil).Times(2)
 		mockSessionRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, s *sessionEntity.Session) (*sessionModel.Session, error) {
 			return dto.SessionEntityToModel(s), nil
-		})
+		}).Times(2)
 
-		result, err := uc.LoginOrSignup(ctx, testAuthRequest)
+		first, err := uc.LoginOrSignup(ctx, testAuthRequest)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		second, err := uc.LoginOrSignup(ctx, testAuthRequest)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 
-		if result.UserID != 1 {
-			t.Errorf("expected userID 1, got %d", result.UserID)
+		if first.ID == second.ID {
+			t.Error("expected different sessions for different logins")
 		}
 	})
 
@@ -96,7 +128,25 @@ func TestSessionUsecase_LoginOrSignup(t *testing.T) {
 
 	t.Run("successful signup new user", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(nil, userEntity.ErrIsNotExist)
-		mockUserRepo.EXPECT().Create(ctx, gomock.Any()).Return(&userModel.User{ID: 2}, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUnitOfWork)
+		mockUnitOfWork.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Create(ctx, mockUnitOfWork, gomock.Any()).Return(&userModel.User{ID: 2, Coins: 100}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUnitOfWork, ledgerEntity.NewTransfer(
+			ledgerEntity.KindGrant,
+			"signup",
+			ledgerEntity.IssuerAccount,
+			ledgerEntity.UserAccount(2),
+			100,
+		)).Return(&ledgerModel.Entry{ID: 1}, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUnitOfWork, uint(2), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ interface{}, _ uint, portions lotEntity.Portions) error {
+				if len(portions) != 1 || portions[0].Amount != 100 || portions[0].ExpiresAt == nil {
+					t.Errorf("expected one expiring lot of 100 coins, got %+v", portions)
+				}
+				return nil
+			},
+		)
+		mockUnitOfWork.EXPECT().Commit().Return(nil)
 		mockSessionRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, s *sessionEntity.Session) (*sessionModel.Session, error) {
 			return dto.SessionEntityToModel(s), nil
 		})
@@ -111,6 +161,37 @@ func TestSessionUsecase_LoginOrSignup(t *testing.T) {
 		}
 	})
 
+	t.Run("signup is rolled back when grant fails", func(t *testing.T) {
+		testErr := errors.New("database error")
+		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(nil, userEntity.ErrIsNotExist)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUnitOfWork)
+		mockUnitOfWork.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Create(ctx, mockUnitOfWork, gomock.Any()).Return(&userModel.User{ID: 2, Coins: 100}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUnitOfWork, gomock.Any()).Return(nil, testErr)
+		mockUnitOfWork.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.LoginOrSignup(ctx, testAuthRequest)
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+
+	t.Run("signup is rolled back when coin lot fails", func(t *testing.T) {
+		testErr := errors.New("database error")
+		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(nil, userEntity.ErrIsNotExist)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUnitOfWork)
+		mockUnitOfWork.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Create(ctx, mockUnitOfWork, gomock.Any()).Return(&userModel.User{ID: 2, Coins: 100}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUnitOfWork, gomock.Any()).Return(&ledgerModel.Entry{ID: 1}, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUnitOfWork, uint(2), gomock.Any()).Return(testErr)
+		mockUnitOfWork.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.LoginOrSignup(ctx, testAuthRequest)
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+
 	t.Run("user repo error", func(t *testing.T) {
 		testErr := errors.New("database error")
 		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(nil, testErr)
@@ -123,7 +204,10 @@ func TestSessionUsecase_LoginOrSignup(t *testing.T) {
 
 	t.Run("session create error", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(nil, userEntity.ErrIsNotExist)
-		mockUserRepo.EXPECT().Create(ctx, gomock.Any()).Return(&userModel.User{ID: 3}, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUnitOfWork)
+		mockUnitOfWork.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Create(ctx, mockUnitOfWork, gomock.Any()).Return(&userModel.User{ID: 3}, nil)
+		mockUnitOfWork.EXPECT().Commit().Return(nil)
 		mockSessionRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil, errors.New("session error"))
 
 		_, err := uc.LoginOrSignup(ctx, testAuthRequest)
@@ -137,7 +221,10 @@ func TestSessionUsecase_LoginOrSignup(t *testing.T) {
 		defer os.Setenv("TOKEN_KEY", "test-secret-key")
 
 		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(nil, userEntity.ErrIsNotExist)
-		mockUserRepo.EXPECT().Create(ctx, gomock.Any()).Return(&userModel.User{ID: 4}, nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUnitOfWork)
+		mockUnitOfWork.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Create(ctx, mockUnitOfWork, gomock.Any()).Return(&userModel.User{ID: 4}, nil)
+		mockUnitOfWork.EXPECT().Commit().Return(nil)
 		mockSessionRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil, errors.New("session error"))
 
 		_, err := uc.LoginOrSignup(ctx, testAuthRequest)
@@ -145,6 +232,593 @@ func TestSessionUsecase_LoginOrSignup(t *testing.T) {
 			t.Error("expected JWT error but got nil")
 		}
 	})
+
+	t.Run("unknown user without auto signup", func(t *testing.T) {
+		loginOnlyCfg := cfg
+		loginOnlyCfg.Registration = config.RegistrationConfig{}
+		loginOnlyUC := NewSessionUsecase(
+			mockSessionRepo,
+			nil,
+			mockUserRepo,
+			mockLedgerRepo,
+			mockLotRepo,
+			mockUowFactory,
+			loginOnlyCfg,
+			config.ExpiryConfig{},
+			logrus.New(),
+		)
+
+		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(nil, userEntity.ErrIsNotExist)
+
+		_, err := loginOnlyUC.LoginOrSignup(ctx, testAuthRequest)
+		if err != sessionEntity.ErrWrongCredentials {
+			t.Errorf("expected ErrWrongCredentials, got %v", err)
+		}
+	})
+}
+
+func TestSessionUsecase_Register(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockSession.NewMockSessionRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+	mockUnitOfWork := mockUow.NewMockUnitOfWork(ctrl)
+
+	cfg := config.UserConfig{
+		Auth: config.AuthConfig{
+			AccessTokenExpiration:  "1h",
+			RefreshTokenExpiration: "24h",
+		},
+		Registration: config.RegistrationConfig{
+			InviteCode:      "welcome",
+			UsernamePattern: `[a-z]+\.[a-z]+`,
+		},
+	}
+	newUsecase := func(cfg config.UserConfig) *SessionUsecase {
+		return NewSessionUsecase(
+			mockSessionRepo,
+			nil,
+			mockUserRepo,
+			mockLedgerRepo,
+			mockLotRepo,
+			mockUowFactory,
+			cfg,
+			config.ExpiryConfig{},
+			logrus.New(),
+		)
+	}
+	uc := newUsecase(cfg)
+
+	ctx := context.Background()
+	registerRequest := func(username, inviteCode string) *dto.RegisterRequest {
+		return &dto.RegisterRequest{
+			AuthRequest: dto.AuthRequest{Username: username, Password: "testpass"},
+			InviteCode:  inviteCode,
+		}
+	}
+
+	os.Setenv("TOKEN_KEY", "test-secret-key")
+
+	t.Run("successful register", func(t *testing.T) {
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUnitOfWork)
+		mockUnitOfWork.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Create(ctx, mockUnitOfWork, gomock.Any()).Return(&userModel.User{ID: 2}, nil)
+		mockUnitOfWork.EXPECT().Commit().Return(nil)
+		mockSessionRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, s *sessionEntity.Session) (*sessionModel.Session, error) {
+			return dto.SessionEntityToModel(s), nil
+		})
+
+		session, err := uc.Register(ctx, registerRequest("ivan.petrov", "welcome"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if session.UserID != 2 {
+			t.Errorf("expected userID 2, got %d", session.UserID)
+		}
+	})
+
+	t.Run("wrong invite code", func(t *testing.T) {
+		_, err := uc.Register(ctx, registerRequest("ivan.petrov", "welcome!"))
+		if err != sessionEntity.ErrInvalidInviteCode {
+			t.Errorf("expected ErrInvalidInviteCode, got %v", err)
+		}
+	})
+
+	t.Run("username doesn't match pattern", func(t *testing.T) {
+		_, err := uc.Register(ctx, registerRequest("ivan.petrov2", "welcome"))
+		if err != sessionEntity.ErrUsernameNotAllowed {
+			t.Errorf("expected ErrUsernameNotAllowed, got %v", err)
+		}
+	})
+
+	t.Run("username is taken", func(t *testing.T) {
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUnitOfWork)
+		mockUnitOfWork.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Create(ctx, mockUnitOfWork, gomock.Any()).Return(nil, userEntity.ErrAlreadyCreated)
+		mockUnitOfWork.EXPECT().Rollback().Return(nil)
+
+		_, err := uc.Register(ctx, registerRequest("ivan.petrov", "welcome"))
+		if err != userEntity.ErrAlreadyCreated {
+			t.Errorf("expected ErrAlreadyCreated, got %v", err)
+		}
+	})
+
+	t.Run("invalid pattern closes registration", func(t *testing.T) {
+		brokenCfg := cfg
+		brokenCfg.Registration = config.RegistrationConfig{UsernamePattern: "("}
+
+		_, err := newUsecase(brokenCfg).Register(ctx, registerRequest("ivan.petrov", ""))
+		if err != sessionEntity.ErrRegistrationClosed {
+			t.Errorf("expected ErrRegistrationClosed, got %v", err)
+		}
+	})
+}
+
+func TestSessionUsecase_Lockout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockSession.NewMockSessionRepositoryI(ctrl)
+	mockLoginAttemptRepo := mockSession.NewMockLoginAttemptRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+	mockUnitOfWork := mockUow.NewMockUnitOfWork(ctrl)
+
+	cfg := config.UserConfig{
+		Auth: config.AuthConfig{
+			AccessTokenExpiration:  "1h",
+			RefreshTokenExpiration: "24h",
+		},
+		Registration: config.RegistrationConfig{InviteCode: "welcome"},
+		Lockout: config.LockoutConfig{
+			MaxAttempts:   3,
+			MaxIPAttempts: 10,
+			ResetAfter:    "1h",
+			BaseDuration:  "30s",
+			MaxDuration:   "2m",
+		},
+	}
+
+	uc := NewSessionUsecase(
+		mockSessionRepo,
+		mockLoginAttemptRepo,
+		mockUserRepo,
+		nil,
+		nil,
+		mockUowFactory,
+		cfg,
+		config.ExpiryConfig{},
+		logrus.New(),
+	)
+
+	ctx := context.Background()
+	authRequest := &dto.AuthRequest{
+		Username: "testuser",
+		Password: "testpass",
+		IP:       "10.0.0.1",
+	}
+	keys := []string{"user:testuser", "ip:10.0.0.1"}
+	user := &userModel.User{ID: 1, Username: "testuser", PasswordHash: hashPassword("wrongpass")}
+	testErr := errors.New("redis error")
+
+	os.Setenv("TOKEN_KEY", "test-secret-key")
+
+	t.Run("locked out login skips password check", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().GetLockout(ctx, keys).Return(45*time.Second, nil)
+
+		_, err := uc.LoginOrSignup(ctx, authRequest)
+
+		var lockedErr *sessionEntity.LoginLockedError
+		if !errors.As(err, &lockedErr) {
+			t.Fatalf("expected LoginLockedError, got %v", err)
+		}
+		if lockedErr.RetryAfter != 45*time.Second {
+			t.Errorf("expected retry after 45s, got %v", lockedErr.RetryAfter)
+		}
+	})
+
+	t.Run("failure below limits is only counted", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().GetLockout(ctx, keys).Return(time.Duration(0), nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(user, nil)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "user:testuser", time.Hour).Return(uint(2), nil)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "ip:10.0.0.1", time.Hour).Return(uint(2), nil)
+
+		_, err := uc.LoginOrSignup(ctx, authRequest)
+		if err != sessionEntity.ErrWrongCredentials {
+			t.Errorf("expected ErrWrongCredentials, got %v", err)
+		}
+	})
+
+	t.Run("lockout doubles with every failure past limit", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().GetLockout(ctx, keys).Return(time.Duration(0), nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(user, nil)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "user:testuser", time.Hour).Return(uint(4), nil)
+		mockLoginAttemptRepo.EXPECT().Lock(ctx, "user:testuser", time.Minute).Return(nil)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "ip:10.0.0.1", time.Hour).Return(uint(10), nil)
+		mockLoginAttemptRepo.EXPECT().Lock(ctx, "ip:10.0.0.1", 30*time.Second).Return(nil)
+
+		_, err := uc.LoginOrSignup(ctx, authRequest)
+		if err != sessionEntity.ErrWrongCredentials {
+			t.Errorf("expected ErrWrongCredentials, got %v", err)
+		}
+	})
+
+	t.Run("lockout is capped", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().GetLockout(ctx, keys).Return(time.Duration(0), nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(user, nil)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "user:testuser", time.Hour).Return(uint(40), nil)
+		mockLoginAttemptRepo.EXPECT().Lock(ctx, "user:testuser", 2*time.Minute).Return(nil)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "ip:10.0.0.1", time.Hour).Return(uint(3), nil)
+
+		_, err := uc.LoginOrSignup(ctx, authRequest)
+		if err != sessionEntity.ErrWrongCredentials {
+			t.Errorf("expected ErrWrongCredentials, got %v", err)
+		}
+	})
+
+	t.Run("unknown user is counted as failure", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().GetLockout(ctx, keys).Return(time.Duration(0), nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(nil, userEntity.ErrIsNotExist)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "user:testuser", time.Hour).Return(uint(1), nil)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "ip:10.0.0.1", time.Hour).Return(uint(1), nil)
+
+		_, err := uc.LoginOrSignup(ctx, authRequest)
+		if err != sessionEntity.ErrWrongCredentials {
+			t.Errorf("expected ErrWrongCredentials, got %v", err)
+		}
+	})
+
+	t.Run("counting error keeps wrong credentials answer", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().GetLockout(ctx, keys).Return(time.Duration(0), nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(user, nil)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "user:testuser", time.Hour).Return(uint(0), testErr)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "ip:10.0.0.1", time.Hour).Return(uint(0), testErr)
+
+		_, err := uc.LoginOrSignup(ctx, authRequest)
+		if err != sessionEntity.ErrWrongCredentials {
+			t.Errorf("expected ErrWrongCredentials, got %v", err)
+		}
+	})
+
+	t.Run("successful login resets username failures", func(t *testing.T) {
+		validUser := &userModel.User{ID: 1, Username: "testuser", PasswordHash: hashPassword("testpass")}
+
+		mockLoginAttemptRepo.EXPECT().GetLockout(ctx, keys).Return(time.Duration(0), nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "testuser").Return(validUser, nil)
+		mockLoginAttemptRepo.EXPECT().Reset(ctx, "user:testuser").Return(nil)
+		mockSessionRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, s *sessionEntity.Session) (*sessionModel.Session, error) {
+			return dto.SessionEntityToModel(s), nil
+		})
+
+		_, err := uc.LoginOrSignup(ctx, authRequest)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("lockout check error", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().GetLockout(ctx, keys).Return(time.Duration(0), testErr)
+
+		_, err := uc.LoginOrSignup(ctx, authRequest)
+		if !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+
+	registerRequest := func(inviteCode string) *dto.RegisterRequest {
+		return &dto.RegisterRequest{AuthRequest: *authRequest, InviteCode: inviteCode}
+	}
+
+	t.Run("locked out registration is rejected", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().GetLockout(ctx, []string{"ip:10.0.0.1"}).Return(time.Minute, nil)
+
+		_, err := uc.Register(ctx, registerRequest("welcome"))
+
+		var lockedErr *sessionEntity.LoginLockedError
+		if !errors.As(err, &lockedErr) {
+			t.Fatalf("expected LoginLockedError, got %v", err)
+		}
+	})
+
+	t.Run("wrong invite code locks out ip", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().GetLockout(ctx, []string{"ip:10.0.0.1"}).Return(time.Duration(0), nil)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "ip:10.0.0.1", time.Hour).Return(uint(10), nil)
+		mockLoginAttemptRepo.EXPECT().Lock(ctx, "ip:10.0.0.1", 30*time.Second).Return(nil)
+
+		_, err := uc.Register(ctx, registerRequest("guess"))
+		if err != sessionEntity.ErrInvalidInviteCode {
+			t.Errorf("expected ErrInvalidInviteCode, got %v", err)
+		}
+	})
+
+	t.Run("successful registration is counted", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().GetLockout(ctx, []string{"ip:10.0.0.1"}).Return(time.Duration(0), nil)
+		mockLoginAttemptRepo.EXPECT().AddFailure(ctx, "ip:10.0.0.1", time.Hour).Return(uint(1), nil)
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUnitOfWork)
+		mockUnitOfWork.EXPECT().Begin(ctx).Return(nil)
+		mockUserRepo.EXPECT().Create(ctx, mockUnitOfWork, gomock.Any()).Return(&userModel.User{ID: 2}, nil)
+		mockUnitOfWork.EXPECT().Commit().Return(nil)
+		mockSessionRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, s *sessionEntity.Session) (*sessionModel.Session, error) {
+			return dto.SessionEntityToModel(s), nil
+		})
+
+		_, err := uc.Register(ctx, registerRequest("welcome"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unlock user", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().Reset(ctx, "user:testuser").Return(nil)
+
+		if err := uc.UnlockUser(ctx, "testuser"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unlock ip", func(t *testing.T) {
+		mockLoginAttemptRepo.EXPECT().Reset(ctx, "ip:10.0.0.1").Return(testErr)
+
+		if err := uc.UnlockIP(ctx, "10.0.0.1"); !errors.Is(err, testErr) {
+			t.Errorf("expected error %v, got %v", testErr, err)
+		}
+	})
+}
+
+func TestSessionUsecase_Refresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockSession.NewMockSessionRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+
+	cfg := config.UserConfig{
+		InitCoinsBalance: 100,
+		Auth: config.AuthConfig{
+			AccessTokenExpiration:  "1h",
+			RefreshTokenExpiration: "24h",
+		},
+	}
+
+	uc := NewSessionUsecase(mockSessionRepo, nil, mockUserRepo, nil, nil, nil, cfg, config.ExpiryConfig{}, logrus.New())
+
+	ctx := context.Background()
+
+	os.Setenv("TOKEN_KEY", "test-secret-key")
+
+	session, err := dto.AuthRequestToEntity(
+		&dto.AuthRequest{Username: "testuser", Password: "testpass"},
+		1,
+		userEntity.RoleEmployee,
+		time.Now().Add(time.Hour),
+		time.Now().Add(24*time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("successful refresh", func(t *testing.T) {
+		mockSessionRepo.EXPECT().Check(ctx, uint(1), session.ID).Return(dto.SessionEntityToModel(session), nil)
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(&userModel.User{ID: 1, Role: userEntity.RoleAdmin}, nil)
+		mockSessionRepo.EXPECT().Rotate(ctx, session.JWTRefresh, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, s *sessionEntity.Session) (*sessionModel.Session, error) {
+				return dto.SessionEntityToModel(s), nil
+			})
+
+		result, err := uc.Refresh(ctx, session.JWTRefresh)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.UserID != 1 || result.Username != "testuser" {
+			t.Errorf("unexpected session owner: %d %s", result.UserID, result.Username)
+		}
+		if result.ID != session.ID {
+			t.Errorf("expected session %s to be kept, got %s", session.ID, result.ID)
+		}
+		if result.JWTRefresh == session.JWTRefresh {
+			t.Error("expected refresh token to be rotated")
+		}
+		if result.Role != userEntity.RoleAdmin {
+			t.Errorf("expected role to be re-read on refresh, got %q", result.Role)
+		}
+	})
+
+	t.Run("access token is not accepted", func(t *testing.T) {
+		_, err := uc.Refresh(ctx, session.JWTAccess)
+		if !errors.Is(err, sessionEntity.ErrInvalidRefreshToken) {
+			t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+		}
+	})
+
+	t.Run("malformed token", func(t *testing.T) {
+		_, err := uc.Refresh(ctx, "not-a-jwt")
+		if !errors.Is(err, sessionEntity.ErrInvalidRefreshToken) {
+			t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		expired, err := dto.AuthRequestToEntity(
+			&dto.AuthRequest{Username: "testuser", Password: "testpass"},
+			1,
+			userEntity.RoleEmployee,
+			time.Now().Add(-2*time.Hour),
+			time.Now().Add(-time.Hour),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_, err = uc.Refresh(ctx, expired.JWTRefresh)
+		if !errors.Is(err, sessionEntity.ErrInvalidRefreshToken) {
+			t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+		}
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		mockSessionRepo.EXPECT().Check(ctx, uint(1), session.ID).Return(nil, sessionEntity.ErrNoSession)
+
+		_, err := uc.Refresh(ctx, session.JWTRefresh)
+		if !errors.Is(err, sessionEntity.ErrNoSession) {
+			t.Errorf("expected ErrNoSession, got %v", err)
+		}
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		mockSessionRepo.EXPECT().Check(ctx, uint(1), session.ID).Return(dto.SessionEntityToModel(session), nil)
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(nil, userEntity.ErrIsNotExist)
+		mockSessionRepo.EXPECT().Delete(ctx, uint(1), session.ID).Return(nil)
+
+		_, err := uc.Refresh(ctx, session.JWTRefresh)
+		if !errors.Is(err, sessionEntity.ErrNoSession) {
+			t.Errorf("expected ErrNoSession, got %v", err)
+		}
+	})
+
+	t.Run("reused token", func(t *testing.T) {
+		mockSessionRepo.EXPECT().Check(ctx, uint(1), session.ID).Return(dto.SessionEntityToModel(session), nil)
+		mockUserRepo.EXPECT().GetByID(ctx, uint(1)).Return(&userModel.User{ID: 1, Role: userEntity.RoleEmployee}, nil)
+		mockSessionRepo.EXPECT().Rotate(ctx, session.JWTRefresh, gomock.Any()).Return(nil, sessionEntity.ErrRefreshTokenReused)
+
+		_, err := uc.Refresh(ctx, session.JWTRefresh)
+		if !errors.Is(err, sessionEntity.ErrRefreshTokenReused) {
+			t.Errorf("expected ErrRefreshTokenReused, got %v", err)
+		}
+	})
+}
+
+func TestSessionUsecase_Validate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockSession.NewMockSessionRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+
+	cfg := config.UserConfig{
+		Auth: config.AuthConfig{
+			AccessTokenExpiration:  "1h",
+			RefreshTokenExpiration: "24h",
+			SessionCacheTTL:        "1m",
+		},
+	}
+
+	uc := NewSessionUsecase(mockSessionRepo, nil, mockUserRepo, nil, nil, nil, cfg, config.ExpiryConfig{}, logrus.New())
+
+	ctx := context.Background()
+	session := &sessionModel.Session{ID: "laptop", UserID: 1, JWTAccess: "live_token"}
+
+	t.Run("live session is cached", func(t *testing.T) {
+		mockSessionRepo.EXPECT().Check(ctx, uint(1), "laptop").Return(session, nil).Times(1)
+
+		if err := uc.Validate(ctx, 1, "laptop", "live_token"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := uc.Validate(ctx, 1, "laptop", "live_token"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("token of rotated session", func(t *testing.T) {
+		mockSessionRepo.EXPECT().Check(ctx, uint(1), "laptop").Return(session, nil)
+
+		err := uc.Validate(ctx, 1, "laptop", "old_token")
+		if !errors.Is(err, sessionEntity.ErrNoSession) {
+			t.Errorf("expected ErrNoSession, got %v", err)
+		}
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		mockSessionRepo.EXPECT().Check(ctx, uint(2), "phone").Return(nil, sessionEntity.ErrNoSession)
+
+		err := uc.Validate(ctx, 2, "phone", "revoked_token")
+		if !errors.Is(err, sessionEntity.ErrNoSession) {
+			t.Errorf("expected ErrNoSession, got %v", err)
+		}
+	})
+
+	t.Run("logout drops cached token", func(t *testing.T) {
+		mockSessionRepo.EXPECT().Delete(ctx, uint(1), "laptop").Return(nil)
+
+		if err := uc.Logout(ctx, 1, "laptop"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		mockSessionRepo.EXPECT().Check(ctx, uint(1), "laptop").Return(nil, sessionEntity.ErrNoSession)
+
+		err := uc.Validate(ctx, 1, "laptop", "live_token")
+		if !errors.Is(err, sessionEntity.ErrNoSession) {
+			t.Errorf("expected ErrNoSession, got %v", err)
+		}
+	})
+}
+
+func TestSessionUsecase_GetSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockSession.NewMockSessionRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+
+	uc := NewSessionUsecase(mockSessionRepo, nil, mockUserRepo, nil, nil, nil, config.UserConfig{}, config.ExpiryConfig{}, logrus.New())
+
+	ctx := context.Background()
+
+	t.Run("returns all sessions", func(t *testing.T) {
+		mockSessionRepo.EXPECT().GetByUserID(ctx, uint(1)).Return([]*sessionModel.Session{
+			{ID: "laptop", UserID: 1, UserAgent: "Firefox"},
+			{ID: "phone", UserID: 1, UserAgent: "Safari"},
+		}, nil)
+
+		sessions, err := uc.GetSessions(ctx, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(sessions) != 2 || sessions[0].ID != "laptop" || sessions[1].ID != "phone" {
+			t.Errorf("unexpected sessions: %+v", sessions)
+		}
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		mockSessionRepo.EXPECT().GetByUserID(ctx, uint(1)).Return(nil, errors.New("redis error"))
+
+		if _, err := uc.GetSessions(ctx, 1); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
+
+func TestSessionUsecase_LogoutAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mockSession.NewMockSessionRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+
+	uc := NewSessionUsecase(mockSessionRepo, nil, mockUserRepo, nil, nil, nil, config.UserConfig{}, config.ExpiryConfig{}, logrus.New())
+
+	ctx := context.Background()
+
+	t.Run("successful logout", func(t *testing.T) {
+		mockSessionRepo.EXPECT().DeleteAll(ctx, uint(1)).Return(nil)
+
+		if err := uc.LogoutAll(ctx, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		mockSessionRepo.EXPECT().DeleteAll(ctx, uint(1)).Return(errors.New("redis error"))
+
+		if err := uc.LogoutAll(ctx, 1); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
 }
 
 func hashPassword(pass string) string {
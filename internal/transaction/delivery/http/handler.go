@@ -11,6 +11,9 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
 
+	idempotencyDelivery "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/delivery/http"
+	idempotencyDTO "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/dto"
+	idempotencyEntity "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
 	"github.com/artrsyf/avito-trainee-assignment/internal/middleware"
 	"github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/dto"
 	transaction "github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/entity"
@@ -20,20 +23,23 @@ import (
 )
 
 type TransactionHandler struct {
-	transactionUC usecase.TransactionUsecaseI
-	validate      *validator.Validate
-	logger        *logrus.Logger
+	transactionUC      usecase.TransactionUsecaseI
+	idempotencyHandler *idempotencyDelivery.IdempotencyHandler
+	validate           *validator.Validate
+	logger             *logrus.Logger
 }
 
 func NewTransactionHandler(
 	transactionUsecase usecase.TransactionUsecaseI,
+	idempotencyHandler *idempotencyDelivery.IdempotencyHandler,
 	validate *validator.Validate,
 	logger *logrus.Logger,
 ) *TransactionHandler {
 	return &TransactionHandler{
-		transactionUC: transactionUsecase,
-		logger:        logger,
-		validate:      validate,
+		transactionUC:      transactionUsecase,
+		idempotencyHandler: idempotencyHandler,
+		logger:             logger,
+		validate:           validate,
 	}
 }
 
@@ -90,6 +96,16 @@ func (h *TransactionHandler) SendCoins(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	senderUserID, ok := ctx.Value(middleware.UserIDContextKey).(uint)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
 	if sendCoinsRequest.ReceiverUsername == senderUsername {
 		JSONResponse.JSONResponse(
 			w,
@@ -99,13 +115,36 @@ func (h *TransactionHandler) SendCoins(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	idempotencyRecord, err := idempotencyDTO.NewRecord(
+		r.Header.Get(idempotencyDTO.KeyHeader),
+		senderUserID,
+		r.URL.Path,
+		sendCoinsRequest,
+		http.StatusOK,
+		nil,
+	)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to prepare idempotency key")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": "bad idempotency key"},
+		)
+		return
+	}
+
+	if h.idempotencyHandler.Replay(ctx, w, idempotencyRecord) {
+		return
+	}
+
 	transactionEntity := &transaction.Transaction{
 		SenderUsername:   senderUsername,
 		ReceiverUsername: sendCoinsRequest.ReceiverUsername,
 		Amount:           sendCoinsRequest.Amount,
+		Message:          sendCoinsRequest.Message,
 	}
 
-	err = h.transactionUC.Create(ctx, transactionEntity)
+	err = h.transactionUC.Create(ctx, transactionEntity, idempotencyRecord)
 	if err != nil {
 		h.logger.WithFields(logrus.Fields{
 			"error": err.Error(),
@@ -113,6 +152,14 @@ func (h *TransactionHandler) SendCoins(w http.ResponseWriter, r *http.Request) {
 		}).Debug("Transaction create error handling")
 
 		switch err {
+		case idempotencyEntity.ErrAlreadyCreated:
+			if !h.idempotencyHandler.Replay(ctx, w, idempotencyRecord) {
+				JSONResponse.JSONResponse(
+					w,
+					http.StatusInternalServerError,
+					map[string]string{"errors": "internal error"},
+				)
+			}
 		case transaction.ErrNotEnoughBalance:
 			JSONResponse.JSONResponse(
 				w,
@@ -137,3 +184,48 @@ func (h *TransactionHandler) SendCoins(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+func (h *TransactionHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Incoming GetHistory request")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(uint)
+	if !ok {
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	historyFilter, err := dto.HistoryQueryToFilter(userID, r.URL.Query())
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed validation for history request")
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusBadRequest,
+			map[string]string{"errors": err.Error()},
+		)
+		return
+	}
+
+	historyPage, err := h.transactionUC.GetHistory(ctx, historyFilter)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"stack": string(debug.Stack()),
+		}).Debug("Transaction history error handling")
+
+		JSONResponse.JSONResponse(
+			w,
+			http.StatusInternalServerError,
+			map[string]string{"errors": "internal error"},
+		)
+		return
+	}
+
+	JSONResponse.JSONResponse(w, http.StatusOK, dto.HistoryPageToResponse(historyPage))
+}
@@ -1,17 +1,35 @@
 package dto
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator/v10"
+
+	"github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/entity"
 )
 
 type SendCoinsRequest struct {
 	ReceiverUsername string `json:"toUser" validate:"required,min=3,max=50"`
 	Amount           uint   `json:"amount" validate:"required,gt=0"`
+	Message          string `json:"message,omitempty" validate:"max=200"`
 }
 
+// ValidateSendCoinsRequest trims the optional message and rejects control
+// characters in it before validating field limits.
 func (req *SendCoinsRequest) ValidateSendCoinsRequest(validate *validator.Validate) error {
+	req.Message = strings.TrimSpace(req.Message)
+	if !utf8.ValidString(req.Message) || strings.IndexFunc(req.Message, unicode.IsControl) != -1 {
+		return errors.New("Message contains invalid characters")
+	}
+
 	err := validate.Struct(req)
 	if err != nil {
 		if validationErrs, ok := err.(validator.ValidationErrors); ok {
@@ -37,3 +55,128 @@ func (req *SendCoinsRequest) ValidateSendCoinsRequest(validate *validator.Valida
 	}
 	return nil
 }
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+type HistoryItemResponse struct {
+	ID           uint      `json:"id"`
+	Direction    string    `json:"direction"`
+	Counterparty string    `json:"counterparty"`
+	Amount       uint      `json:"amount"`
+	Message      string    `json:"message,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type HistoryResponse struct {
+	Transactions []HistoryItemResponse `json:"transactions"`
+	NextCursor   string                `json:"nextCursor,omitempty"`
+}
+
+// HistoryQueryToFilter parses GET /api/transactions query parameters:
+// direction, counterparty, from and to (RFC 3339), cursor and limit.
+func HistoryQueryToFilter(userID uint, query url.Values) (*entity.HistoryFilter, error) {
+	filter := &entity.HistoryFilter{
+		UserID:       userID,
+		Direction:    query.Get("direction"),
+		Counterparty: query.Get("counterparty"),
+		Limit:        defaultHistoryLimit,
+	}
+
+	switch filter.Direction {
+	case "", entity.DirectionSent, entity.DirectionReceived:
+	default:
+		return nil, errors.New("direction must be sent or received")
+	}
+
+	if from := query.Get("from"); from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, errors.New("from must be RFC 3339 timestamp")
+		}
+		filter.From = &parsed
+	}
+
+	if to := query.Get("to"); to != "" {
+		parsed, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, errors.New("to must be RFC 3339 timestamp")
+		}
+		filter.To = &parsed
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		parsed, err := strconv.ParseUint(limit, 10, 32)
+		if err != nil || parsed == 0 || parsed > maxHistoryLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxHistoryLimit)
+		}
+		filter.Limit = uint(parsed)
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := decodeHistoryCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = after
+	}
+
+	return filter, nil
+}
+
+func HistoryPageToResponse(page *entity.HistoryPage) *HistoryResponse {
+	response := &HistoryResponse{
+		Transactions: make([]HistoryItemResponse, 0, len(page.Items)),
+	}
+
+	for _, item := range page.Items {
+		response.Transactions = append(response.Transactions, HistoryItemResponse{
+			ID:           item.ID,
+			Direction:    item.Direction,
+			Counterparty: item.Counterparty,
+			Amount:       item.Amount,
+			Message:      item.Message,
+			CreatedAt:    item.CreatedAt,
+		})
+	}
+
+	if page.NextCursor != nil {
+		response.NextCursor = encodeHistoryCursor(page.NextCursor)
+	}
+
+	return response
+}
+
+func encodeHistoryCursor(cursor *entity.HistoryCursor) string {
+	raw := fmt.Sprintf("%d:%d", cursor.CreatedAt.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeHistoryCursor(cursor string) (*entity.HistoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, entity.ErrInvalidCursor
+	}
+
+	createdAtPart, idPart, found := strings.Cut(string(raw), ":")
+	if !found {
+		return nil, entity.ErrInvalidCursor
+	}
+
+	createdAt, err := strconv.ParseInt(createdAtPart, 10, 64)
+	if err != nil {
+		return nil, entity.ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseUint(idPart, 10, 32)
+	if err != nil {
+		return nil, entity.ErrInvalidCursor
+	}
+
+	return &entity.HistoryCursor{
+		CreatedAt: time.Unix(0, createdAt).UTC(),
+		ID:        uint(id),
+	}, nil
+}
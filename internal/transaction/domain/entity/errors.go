@@ -4,4 +4,5 @@ import "errors"
 
 var (
 	ErrNotEnoughBalance = errors.New("not enough balance")
+	ErrInvalidCursor    = errors.New("invalid history cursor")
 )
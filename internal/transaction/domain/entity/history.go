@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+const (
+	DirectionSent     = "sent"
+	DirectionReceived = "received"
+)
+
+// HistoryCursor points at the last transfer of a page. Transfers are ordered
+// by creation time and id, so the pair is unique and stable across inserts.
+type HistoryCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+type HistoryFilter struct {
+	UserID       uint
+	Direction    string
+	Counterparty string
+	From         *time.Time
+	To           *time.Time
+	After        *HistoryCursor
+	Limit        uint
+}
+
+type HistoryItem struct {
+	ID           uint
+	Direction    string
+	Counterparty string
+	Amount       uint
+	Message      string
+	CreatedAt    time.Time
+}
+
+type HistoryPage struct {
+	Items      []*HistoryItem
+	NextCursor *HistoryCursor
+}
@@ -4,6 +4,7 @@ type Transaction struct {
 	SenderUsername   string
 	ReceiverUsername string
 	Amount           uint
+	Message          string
 }
 
 type ReceivedTransactionGroup struct {
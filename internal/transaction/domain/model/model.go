@@ -1,8 +1,12 @@
 package model
 
+import "time"
+
 type Transaction struct {
-	ID             uint `db:"id"`
-	SenderUserID   uint `db:"sender_user_id"`
-	ReceiverUserID uint `db:"receiver_user_id"`
-	Amount         uint `db:"amount"`
+	ID             uint      `db:"id"`
+	SenderUserID   uint      `db:"sender_user_id"`
+	ReceiverUserID uint      `db:"receiver_user_id"`
+	Amount         uint      `db:"amount"`
+	Message        string    `db:"message"`
+	CreatedAt      time.Time `db:"created_at"`
 }
@@ -52,6 +52,21 @@ func (mr *MockTransactionRepositoryIMockRecorder) Create(ctx, uow, transaction i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTransactionRepositoryI)(nil).Create), ctx, uow, transaction)
 }
 
+// GetHistory mocks base method.
+func (m *MockTransactionRepositoryI) GetHistory(ctx context.Context, filter *entity.HistoryFilter) ([]*entity.HistoryItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, filter)
+	ret0, _ := ret[0].([]*entity.HistoryItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockTransactionRepositoryIMockRecorder) GetHistory(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockTransactionRepositoryI)(nil).GetHistory), ctx, filter)
+}
+
 // GetReceivedByUserID mocks base method.
 func (m *MockTransactionRepositoryI) GetReceivedByUserID(ctx context.Context, userID uint) (entity.ReceivedHistory, error) {
 	m.ctrl.T.Helper()
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/sirupsen/logrus"
 
@@ -34,15 +35,17 @@ func (repo *TransactionPostgresRepository) Create(
 	createdTransaction := model.Transaction{}
 	err := uow.QueryRowContext(
 		ctx,
-		`INSERT INTO transactions (sender_user_id, receiver_user_id, amount) 
-		VALUES ($1, $2, $3) 
-		RETURNING id, sender_user_id, receiver_user_id, amount`,
-		transaction.SenderUserID, transaction.ReceiverUserID, transaction.Amount,
+		`INSERT INTO transactions (sender_user_id, receiver_user_id, amount, message) 
+		VALUES ($1, $2, $3, $4) 
+		RETURNING id, sender_user_id, receiver_user_id, amount, message, created_at`,
+		transaction.SenderUserID, transaction.ReceiverUserID, transaction.Amount, transaction.Message,
 	).Scan(
 		&createdTransaction.ID,
 		&createdTransaction.SenderUserID,
 		&createdTransaction.ReceiverUserID,
 		&createdTransaction.Amount,
+		&createdTransaction.Message,
+		&createdTransaction.CreatedAt,
 	)
 	if err != nil {
 		repo.logger.WithError(err).Error("Failed to create transaction")
@@ -137,3 +140,86 @@ func (repo *TransactionPostgresRepository) GetSentByUserID(
 
 	return sentHistory, nil
 }
+
+// GetHistory returns individual transfers of the user, newest first. The
+// counterparty is empty when their account has been deleted.
+func (repo *TransactionPostgresRepository) GetHistory(
+	ctx context.Context,
+	filter *entity.HistoryFilter,
+) ([]*entity.HistoryItem, error) {
+	query := `SELECT t.id, t.amount, t.message, t.created_at,
+		CASE WHEN t.sender_user_id = $1 THEN 'sent' ELSE 'received' END,
+		COALESCE(u.username, '')
+		FROM transactions t
+		LEFT JOIN users u ON u.id = CASE
+			WHEN t.sender_user_id = $1 THEN t.receiver_user_id
+			ELSE t.sender_user_id
+		END`
+	args := []interface{}{filter.UserID}
+
+	switch filter.Direction {
+	case entity.DirectionSent:
+		query += " WHERE t.sender_user_id = $1"
+	case entity.DirectionReceived:
+		query += " WHERE t.receiver_user_id = $1"
+	default:
+		query += " WHERE (t.sender_user_id = $1 OR t.receiver_user_id = $1)"
+	}
+
+	if filter.Counterparty != "" {
+		args = append(args, filter.Counterparty)
+		query += fmt.Sprintf(" AND u.username = $%d", len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		query += fmt.Sprintf(" AND t.created_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		query += fmt.Sprintf(" AND t.created_at < $%d", len(args))
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt, filter.After.ID)
+		query += fmt.Sprintf(" AND (t.created_at, t.id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY t.created_at DESC, t.id DESC LIMIT $%d", len(args))
+
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to select transaction history")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.logger.WithError(err).Warn("Failed to close rows selecting transaction history")
+		}
+	}()
+
+	history := []*entity.HistoryItem{}
+	for rows.Next() {
+		item := entity.HistoryItem{}
+		err := rows.Scan(
+			&item.ID,
+			&item.Amount,
+			&item.Message,
+			&item.CreatedAt,
+			&item.Direction,
+			&item.Counterparty,
+		)
+		if err != nil {
+			repo.logger.WithError(err).Error("Failed to scan transaction history")
+			return nil, err
+		}
+
+		history = append(history, &item)
+	}
+
+	if err = rows.Err(); err != nil {
+		repo.logger.WithError(err).Error("Failed to iterate transaction history")
+		return nil, err
+	}
+
+	return history, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/sirupsen/logrus"
@@ -24,16 +25,19 @@ func TestTransactionPostgresRepository_Create(t *testing.T) {
 	repo := NewTransactionPostgresRepository(db, logrus.New())
 	mockUOW := &MockUnitOfWork{db: db}
 
+	createdAt := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+
 	t.Run("Success", func(t *testing.T) {
 		mock.ExpectQuery("INSERT INTO transactions .* RETURNING .*").
-			WithArgs(1, 2, 100).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "sender_user_id", "receiver_user_id", "amount"}).
-				AddRow(1, 1, 2, 100))
+			WithArgs(1, 2, 100, "thanks").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "sender_user_id", "receiver_user_id", "amount", "message", "created_at"}).
+				AddRow(1, 1, 2, 100, "thanks", createdAt))
 
 		tx, err := repo.Create(context.Background(), mockUOW, &model.Transaction{
 			SenderUserID:   1,
 			ReceiverUserID: 2,
 			Amount:         100,
+			Message:        "thanks",
 		})
 
 		assert.NoError(t, err)
@@ -42,19 +46,22 @@ func TestTransactionPostgresRepository_Create(t *testing.T) {
 			SenderUserID:   1,
 			ReceiverUserID: 2,
 			Amount:         100,
+			Message:        "thanks",
+			CreatedAt:      createdAt,
 		}, tx)
 	})
 
 	t.Run("DatabaseError", func(t *testing.T) {
 		expectedErr := sql.ErrConnDone
 		mock.ExpectQuery("INSERT INTO transactions .* RETURNING .*").
-			WithArgs(1, 2, 100).
+			WithArgs(1, 2, 100, "thanks").
 			WillReturnError(expectedErr)
 
 		_, err := repo.Create(context.Background(), mockUOW, &model.Transaction{
 			SenderUserID:   1,
 			ReceiverUserID: 2,
 			Amount:         100,
+			Message:        "thanks",
 		})
 
 		assert.Equal(t, expectedErr, err)
@@ -193,6 +200,114 @@ func TestTransactionPostgresRepository_GetSentByUserID(t *testing.T) {
 	})
 }
 
+func TestTransactionPostgresRepository_GetHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewTransactionPostgresRepository(db, logrus.New())
+	columns := []string{"id", "amount", "message", "created_at", "direction", "username"}
+	firstCreatedAt := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+	secondCreatedAt := firstCreatedAt.Add(-time.Hour)
+
+	t.Run("SuccessWithoutFilters", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(2, 100, "for the review", firstCreatedAt, "sent", "user2").
+			AddRow(1, 50, "", secondCreatedAt, "received", "user3")
+
+		mock.ExpectQuery(`SELECT t.id, t.amount, t.message, t.created_at, .* `+
+			`WHERE \(t.sender_user_id = \$1 OR t.receiver_user_id = \$1\) `+
+			`ORDER BY t.created_at DESC, t.id DESC LIMIT \$2`).
+			WithArgs(1, 10).
+			WillReturnRows(rows)
+
+		result, err := repo.GetHistory(context.Background(), &entity.HistoryFilter{
+			UserID: 1,
+			Limit:  10,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*entity.HistoryItem{
+			{ID: 2, Direction: "sent", Counterparty: "user2", Amount: 100, Message: "for the review", CreatedAt: firstCreatedAt},
+			{ID: 1, Direction: "received", Counterparty: "user3", Amount: 50, CreatedAt: secondCreatedAt},
+		}, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SuccessWithAllFilters", func(t *testing.T) {
+		from := secondCreatedAt
+		to := firstCreatedAt
+
+		mock.ExpectQuery(`WHERE t.sender_user_id = \$1 `+
+			`AND u.username = \$2 AND t.created_at >= \$3 AND t.created_at < \$4 `+
+			`AND \(t.created_at, t.id\) < \(\$5, \$6\) `+
+			`ORDER BY t.created_at DESC, t.id DESC LIMIT \$7`).
+			WithArgs(1, "user2", from, to, firstCreatedAt, 2, 5).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		result, err := repo.GetHistory(context.Background(), &entity.HistoryFilter{
+			UserID:       1,
+			Direction:    entity.DirectionSent,
+			Counterparty: "user2",
+			From:         &from,
+			To:           &to,
+			After:        &entity.HistoryCursor{CreatedAt: firstCreatedAt, ID: 2},
+			Limit:        5,
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReceivedDirection", func(t *testing.T) {
+		mock.ExpectQuery(`WHERE t.receiver_user_id = \$1 ORDER BY`).
+			WithArgs(1, 5).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetHistory(context.Background(), &entity.HistoryFilter{
+			UserID:    1,
+			Direction: entity.DirectionReceived,
+			Limit:     5,
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("QueryError", func(t *testing.T) {
+		expectedErr := sql.ErrConnDone
+		mock.ExpectQuery(`SELECT t.id, t.amount, t.message, t.created_at, .*`).
+			WithArgs(1, 10).
+			WillReturnError(expectedErr)
+
+		_, err := repo.GetHistory(context.Background(), &entity.HistoryFilter{
+			UserID: 1,
+			Limit:  10,
+		})
+
+		assert.Equal(t, expectedErr, err)
+	})
+
+	t.Run("ScanError", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(1, "invalid_amount", "", firstCreatedAt, "sent", "user2")
+
+		mock.ExpectQuery(`SELECT t.id, t.amount, t.message, t.created_at, .*`).
+			WithArgs(1, 10).
+			WillReturnRows(rows)
+
+		_, err := repo.GetHistory(context.Background(), &entity.HistoryFilter{
+			UserID: 1,
+			Limit:  10,
+		})
+
+		assert.ErrorContains(t, err, "converting driver.Value type string")
+	})
+}
+
 type MockUnitOfWork struct {
 	uow.Executor
 	db *sql.DB
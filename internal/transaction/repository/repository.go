@@ -13,4 +13,5 @@ type TransactionRepositoryI interface {
 	Create(ctx context.Context, uow uow.Executor, transaction *model.Transaction) (*model.Transaction, error)
 	GetReceivedByUserID(ctx context.Context, userID uint) (entity.ReceivedHistory, error)
 	GetSentByUserID(ctx context.Context, userID uint) (entity.SentHistory, error)
+	GetHistory(ctx context.Context, filter *entity.HistoryFilter) ([]*entity.HistoryItem, error)
 }
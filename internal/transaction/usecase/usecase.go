@@ -2,23 +2,40 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/sirupsen/logrus"
 
+	idempotencyDTO "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/dto"
+	idempotencyEntity "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	idempotencyRepo "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository"
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository"
 	"github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/entity"
 	"github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/model"
 	transactionRepo "github.com/artrsyf/avito-trainee-assignment/internal/transaction/repository"
+	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
 	userRepo "github.com/artrsyf/avito-trainee-assignment/internal/user/repository"
 	uowI "github.com/artrsyf/avito-trainee-assignment/pkg/uow"
 )
 
 type TransactionUsecaseI interface {
-	Create(ctx context.Context, transactionEntity *entity.Transaction) error
+	Create(
+		ctx context.Context,
+		transactionEntity *entity.Transaction,
+		idempotencyRecord *idempotencyEntity.Record,
+	) error
+	GetHistory(ctx context.Context, filter *entity.HistoryFilter) (*entity.HistoryPage, error)
 }
 
 type TransactionUsecase struct {
 	transactionRepo transactionRepo.TransactionRepositoryI
 	userRepo        userRepo.UserRepositoryI
+	idempotencyRepo idempotencyRepo.IdempotencyRepositoryI
+	ledgerRepo      ledgerRepo.LedgerRepositoryI
+	lotRepo         lotRepo.LotRepositoryI
 	uowFactory      uowI.Factory
 	logger          *logrus.Logger
 }
@@ -26,12 +43,18 @@ type TransactionUsecase struct {
 func NewTransactionUsecase(
 	transactionRepository transactionRepo.TransactionRepositoryI,
 	userRepository userRepo.UserRepositoryI,
+	idempotencyRepository idempotencyRepo.IdempotencyRepositoryI,
+	ledgerRepository ledgerRepo.LedgerRepositoryI,
+	lotRepository lotRepo.LotRepositoryI,
 	uowFactory uowI.Factory,
 	logger *logrus.Logger,
 ) *TransactionUsecase {
 	return &TransactionUsecase{
 		transactionRepo: transactionRepository,
 		userRepo:        userRepository,
+		idempotencyRepo: idempotencyRepository,
+		ledgerRepo:      ledgerRepository,
+		lotRepo:         lotRepository,
 		uowFactory:      uowFactory,
 		logger:          logger,
 	}
@@ -40,6 +63,7 @@ func NewTransactionUsecase(
 func (uc *TransactionUsecase) Create(
 	ctx context.Context,
 	transactionEntity *entity.Transaction,
+	idempotencyRecord *idempotencyEntity.Record,
 ) error {
 	senderUserModel, err := uc.userRepo.GetByUsername(
 		ctx,
@@ -59,14 +83,6 @@ func (uc *TransactionUsecase) Create(
 		return err
 	}
 
-	if senderUserModel.Coins < transactionEntity.Amount {
-		uc.logger.WithError(err).Error("Sender user doesn't have enough balance")
-		return entity.ErrNotEnoughBalance
-	}
-
-	senderUserModel.Coins -= transactionEntity.Amount
-	receiverUserModel.Coins += transactionEntity.Amount
-
 	uow := uc.uowFactory.NewUnitOfWork()
 
 	err = uow.Begin(ctx)
@@ -75,22 +91,34 @@ func (uc *TransactionUsecase) Create(
 		return err
 	}
 
-	err = uc.userRepo.Update(ctx, uow, senderUserModel)
-	if err != nil {
-		rbErr := uow.Rollback()
-		if rbErr != nil {
-			uc.logger.WithError(rbErr).Error("Rollback error encountered")
+	if idempotencyRecord != nil {
+		err = uc.idempotencyRepo.Create(ctx, uow, idempotencyDTO.RecordEntityToModel(idempotencyRecord))
+		if err != nil {
+			rbErr := uow.Rollback()
+			if rbErr != nil {
+				uc.logger.WithError(rbErr).Error("Rollback error encountered")
+			}
+			uc.logger.WithError(err).Warn("Rollback due idempotency key reserving")
+			return err
 		}
-		uc.logger.WithError(err).Error("Rollback money transfer due user updating")
-		return err
 	}
 
-	err = uc.userRepo.Update(ctx, uow, receiverUserModel)
+	err = uc.moveCoins(
+		ctx,
+		uow,
+		senderUserModel.ID,
+		receiverUserModel.ID,
+		transactionEntity.Amount,
+	)
 	if err != nil {
 		rbErr := uow.Rollback()
 		if rbErr != nil {
 			uc.logger.WithError(rbErr).Error("Rollback error encountered")
 		}
+		if err == userEntity.ErrNotEnoughBalance || err == lotEntity.ErrNotEnoughCoins {
+			uc.logger.Info("Sender user doesn't have enough balance")
+			return entity.ErrNotEnoughBalance
+		}
 		uc.logger.WithError(err).Error("Rollback money transfer due user updating")
 		return err
 	}
@@ -99,8 +127,9 @@ func (uc *TransactionUsecase) Create(
 		SenderUserID:   senderUserModel.ID,
 		ReceiverUserID: receiverUserModel.ID,
 		Amount:         transactionEntity.Amount,
+		Message:        transactionEntity.Message,
 	}
-	_, err = uc.transactionRepo.Create(ctx, uow, transactionModel)
+	createdTransaction, err := uc.transactionRepo.Create(ctx, uow, transactionModel)
 	if err != nil {
 		rbErr := uow.Rollback()
 		if rbErr != nil {
@@ -110,6 +139,22 @@ func (uc *TransactionUsecase) Create(
 		return err
 	}
 
+	_, err = uc.ledgerRepo.Post(ctx, uow, ledgerEntity.NewTransfer(
+		ledgerEntity.KindTransfer,
+		fmt.Sprintf("transaction:%d", createdTransaction.ID),
+		ledgerEntity.UserAccount(senderUserModel.ID),
+		ledgerEntity.UserAccount(receiverUserModel.ID),
+		transactionEntity.Amount,
+	))
+	if err != nil {
+		rbErr := uow.Rollback()
+		if rbErr != nil {
+			uc.logger.WithError(rbErr).Error("Rollback error encountered")
+		}
+		uc.logger.WithError(err).Error("Rollback money transfer due ledger posting")
+		return err
+	}
+
 	err = uow.Commit()
 	if err != nil {
 		uc.logger.WithError(err).Error("Transaction commit error due transfers creating")
@@ -124,3 +169,77 @@ func (uc *TransactionUsecase) Create(
 
 	return nil
 }
+
+// GetHistory returns one page of the user's transfers. One extra row is
+// requested to find out whether there is a next page.
+func (uc *TransactionUsecase) GetHistory(
+	ctx context.Context,
+	filter *entity.HistoryFilter,
+) (*entity.HistoryPage, error) {
+	pageFilter := *filter
+	pageFilter.Limit = filter.Limit + 1
+
+	items, err := uc.transactionRepo.GetHistory(ctx, &pageFilter)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to get transaction history")
+		return nil, err
+	}
+
+	page := &entity.HistoryPage{Items: items}
+	if uint(len(items)) > filter.Limit {
+		page.Items = items[:filter.Limit]
+		lastItem := page.Items[len(page.Items)-1]
+		page.NextCursor = &entity.HistoryCursor{
+			CreatedAt: lastItem.CreatedAt,
+			ID:        lastItem.ID,
+		}
+	}
+
+	return page, nil
+}
+
+// moveCoins debits the sender and credits the receiver within uow. The
+// receiver gets the sender's coins with their expiration, so transfers
+// don't extend the life of coins.
+func (uc *TransactionUsecase) moveCoins(
+	ctx context.Context,
+	uow uowI.Executor,
+	senderID uint,
+	receiverID uint,
+	amount uint,
+) error {
+	err := uc.updateBalances(ctx, uow, senderID, receiverID, amount)
+	if err != nil {
+		return err
+	}
+
+	portions, err := uc.lotRepo.Consume(ctx, uow, senderID, amount)
+	if err != nil {
+		return err
+	}
+
+	return uc.lotRepo.Create(ctx, uow, receiverID, portions)
+}
+
+// updateBalances updates users.coins of both users. Rows are updated in
+// ascending user id order, so concurrent transfers between the same users
+// always lock them in the same order and can't deadlock.
+func (uc *TransactionUsecase) updateBalances(
+	ctx context.Context,
+	uow uowI.Executor,
+	senderID uint,
+	receiverID uint,
+	amount uint,
+) error {
+	if senderID < receiverID {
+		if err := uc.userRepo.Debit(ctx, uow, senderID, amount); err != nil {
+			return err
+		}
+		return uc.userRepo.Credit(ctx, uow, receiverID, amount)
+	}
+
+	if err := uc.userRepo.Credit(ctx, uow, receiverID, amount); err != nil {
+		return err
+	}
+	return uc.userRepo.Debit(ctx, uow, senderID, amount)
+}
@@ -4,16 +4,25 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
 
+	idempotencyEntity "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	idempotencyModel "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/model"
+	mockIdempotency "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository/mock_repository"
+	ledgerEntity "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/entity"
+	ledgerModel "github.com/artrsyf/avito-trainee-assignment/internal/ledger/domain/model"
+	mockLedger "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/mock_repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	mockLot "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/mock_repository"
 	"github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/entity"
 	transactionModel "github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/model"
 	mockTransaction "github.com/artrsyf/avito-trainee-assignment/internal/transaction/repository/mock_repository"
+	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
 	userModel "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/model"
 	mockUser "github.com/artrsyf/avito-trainee-assignment/internal/user/repository/mock_repository"
-	"github.com/artrsyf/avito-trainee-assignment/pkg/uow"
 	mockUow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/mock_uow"
 )
 
@@ -23,10 +32,13 @@ func TestTransactionUsecase_Create(t *testing.T) {
 
 	mockTxRepo := mockTransaction.NewMockTransactionRepositoryI(ctrl)
 	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockIdempotencyRepo := mockIdempotency.NewMockIdempotencyRepositoryI(ctrl)
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
 	mockUowFactory := mockUow.NewMockFactory(ctrl)
 	mockUow := mockUow.NewMockUnitOfWork(ctrl)
 
-	uc := NewTransactionUsecase(mockTxRepo, mockUserRepo, mockUowFactory, logrus.New())
+	uc := NewTransactionUsecase(mockTxRepo, mockUserRepo, mockIdempotencyRepo, mockLedgerRepo, mockLotRepo, mockUowFactory, logrus.New())
 
 	ctx := context.Background()
 	testTransaction := &entity.Transaction{
@@ -38,6 +50,8 @@ func TestTransactionUsecase_Create(t *testing.T) {
 	t.Run("successful transaction", func(t *testing.T) {
 		sender := &userModel.User{ID: 1, Username: "sender", Coins: 200}
 		receiver := &userModel.User{ID: 2, Username: "receiver", Coins: 50}
+		expiresAt := time.Now().Add(time.Hour)
+		portions := lotEntity.Portions{{Amount: 60, ExpiresAt: &expiresAt}, {Amount: 40}}
 
 		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
 		mockUow.EXPECT().Begin(ctx).Return(nil)
@@ -45,29 +59,133 @@ func TestTransactionUsecase_Create(t *testing.T) {
 
 		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
 		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
-		mockUserRepo.EXPECT().Update(ctx, mockUow, gomock.Any()).DoAndReturn(
-			func(ctx context.Context, uow uow.UnitOfWork, user *userModel.User) error {
-				switch user.ID {
-				case 1:
-					if user.Coins != 100 {
-						t.Errorf("expected sender to have 100 coins, got %d", user.Coins)
-					}
-				case 2:
-					if user.Coins != 150 {
-						t.Errorf("expected receiver to have 150 coins, got %d", user.Coins)
-					}
-				default:
-					t.Errorf("unexpected user ID: %d", user.ID)
-				}
-				return nil
-			}).Times(2)
+		gomock.InOrder(
+			mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil),
+			mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil),
+			mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(portions, nil),
+			mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), portions).Return(nil),
+		)
 		mockTxRepo.EXPECT().Create(ctx, mockUow, &transactionModel.Transaction{
 			SenderUserID:   1,
 			ReceiverUserID: 2,
 			Amount:         100,
 		}).Return(&transactionModel.Transaction{ID: 1}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, ledgerEntity.NewTransfer(
+			ledgerEntity.KindTransfer,
+			"transaction:1",
+			ledgerEntity.UserAccount(1),
+			ledgerEntity.UserAccount(2),
+			100,
+		)).Return(&ledgerModel.Entry{ID: 1}, nil)
 
-		err := uc.Create(ctx, testTransaction)
+		err := uc.Create(ctx, testTransaction, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("transfer message is persisted", func(t *testing.T) {
+		sender := &userModel.User{ID: 1, Username: "sender", Coins: 200}
+		receiver := &userModel.User{ID: 2, Username: "receiver", Coins: 50}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), gomock.Any()).Return(nil)
+		mockTxRepo.EXPECT().Create(ctx, mockUow, &transactionModel.Transaction{
+			SenderUserID:   1,
+			ReceiverUserID: 2,
+			Amount:         100,
+			Message:        "thanks for the review",
+		}).Return(&transactionModel.Transaction{ID: 1}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 1}, nil)
+
+		err := uc.Create(ctx, &entity.Transaction{
+			SenderUsername:   "sender",
+			ReceiverUsername: "receiver",
+			Amount:           100,
+			Message:          "thanks for the review",
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("idempotency key is reserved with transfer", func(t *testing.T) {
+		sender := &userModel.User{ID: 1, Username: "sender", Coins: 200}
+		receiver := &userModel.User{ID: 2, Username: "receiver", Coins: 50}
+		record := &idempotencyEntity.Record{UserID: 1, Key: "key", RequestHash: "hash", ResponseStatus: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
+		mockIdempotencyRepo.EXPECT().Create(ctx, mockUow, &idempotencyModel.Record{
+			UserID:         1,
+			Key:            "key",
+			RequestHash:    "hash",
+			ResponseStatus: 200,
+		}).Return(nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), gomock.Any()).Return(nil)
+		mockTxRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&transactionModel.Transaction{ID: 1}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 1}, nil)
+
+		err := uc.Create(ctx, testTransaction, record)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("idempotency key is already used", func(t *testing.T) {
+		sender := &userModel.User{ID: 1, Username: "sender", Coins: 200}
+		receiver := &userModel.User{ID: 2, Username: "receiver", Coins: 50}
+		record := &idempotencyEntity.Record{UserID: 1, Key: "key", RequestHash: "hash", ResponseStatus: 200}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
+		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
+		mockIdempotencyRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(idempotencyEntity.ErrAlreadyCreated)
+
+		err := uc.Create(ctx, testTransaction, record)
+		if !errors.Is(err, idempotencyEntity.ErrAlreadyCreated) {
+			t.Errorf("expected ErrAlreadyCreated, got %v", err)
+		}
+	})
+
+	t.Run("rows are locked in id order", func(t *testing.T) {
+		sender := &userModel.User{ID: 5, Username: "sender", Coins: 200}
+		receiver := &userModel.User{ID: 2, Username: "receiver", Coins: 50}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Commit().Return(nil)
+
+		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
+		gomock.InOrder(
+			mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil),
+			mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(5), uint(100)).Return(nil),
+		)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(5), uint(100)).Return(nil, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), gomock.Any()).Return(nil)
+		mockTxRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&transactionModel.Transaction{ID: 1}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 1}, nil)
+
+		err := uc.Create(ctx, testTransaction, nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -77,10 +195,15 @@ func TestTransactionUsecase_Create(t *testing.T) {
 		sender := &userModel.User{ID: 1, Username: "sender", Coins: 50}
 		receiver := &userModel.User{ID: 2, Username: "receiver", Coins: 50}
 
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
 		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
 		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(userEntity.ErrNotEnoughBalance)
 
-		err := uc.Create(ctx, testTransaction)
+		err := uc.Create(ctx, testTransaction, nil)
 		if !errors.Is(err, entity.ErrNotEnoughBalance) {
 			t.Errorf("expected ErrNotEnoughBalance, got %v", err)
 		}
@@ -89,7 +212,7 @@ func TestTransactionUsecase_Create(t *testing.T) {
 	t.Run("sender not found", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(nil, errors.New("not found"))
 
-		err := uc.Create(ctx, testTransaction)
+		err := uc.Create(ctx, testTransaction, nil)
 		if err == nil {
 			t.Error("expected error but got nil")
 		}
@@ -100,7 +223,7 @@ func TestTransactionUsecase_Create(t *testing.T) {
 		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
 		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(nil, errors.New("not found"))
 
-		err := uc.Create(ctx, testTransaction)
+		err := uc.Create(ctx, testTransaction, nil)
 		if err == nil {
 			t.Error("expected error but got nil")
 		}
@@ -116,9 +239,51 @@ func TestTransactionUsecase_Create(t *testing.T) {
 
 		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
 		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
-		mockUserRepo.EXPECT().Update(ctx, mockUow, gomock.Any()).Return(errors.New("update error"))
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(errors.New("update error"))
 
-		err := uc.Create(ctx, testTransaction)
+		err := uc.Create(ctx, testTransaction, nil)
+		if err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+
+	t.Run("sender coins have all expired", func(t *testing.T) {
+		// The sweeper hasn't written the expired coins off yet, so the
+		// debit passes, but there are no unexpired lots to move.
+		sender := &userModel.User{ID: 1, Username: "sender", Coins: 200}
+		receiver := &userModel.User{ID: 2, Username: "receiver", Coins: 50}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
+		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, lotEntity.ErrNotEnoughCoins)
+
+		err := uc.Create(ctx, testTransaction, nil)
+		if !errors.Is(err, entity.ErrNotEnoughBalance) {
+			t.Errorf("expected ErrNotEnoughBalance, got %v", err)
+		}
+	})
+
+	t.Run("coin lots error", func(t *testing.T) {
+		sender := &userModel.User{ID: 1, Username: "sender", Coins: 200}
+		receiver := &userModel.User{ID: 2, Username: "receiver", Coins: 50}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
+		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, errors.New("consume error"))
+
+		err := uc.Create(ctx, testTransaction, nil)
 		if err == nil {
 			t.Error("expected error but got nil")
 		}
@@ -134,10 +299,13 @@ func TestTransactionUsecase_Create(t *testing.T) {
 
 		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
 		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
-		mockUserRepo.EXPECT().Update(ctx, mockUow, gomock.Any()).Times(2)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), gomock.Any()).Return(nil)
 		mockTxRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(nil, errors.New("create error"))
 
-		err := uc.Create(ctx, testTransaction)
+		err := uc.Create(ctx, testTransaction, nil)
 		if err == nil {
 			t.Error("expected error but got nil")
 		}
@@ -153,10 +321,109 @@ func TestTransactionUsecase_Create(t *testing.T) {
 
 		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
 		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
-		mockUserRepo.EXPECT().Update(ctx, mockUow, gomock.Any()).Times(2)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), gomock.Any()).Return(nil)
+		mockTxRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&transactionModel.Transaction{ID: 1}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(&ledgerModel.Entry{ID: 1}, nil)
+
+		err := uc.Create(ctx, testTransaction, nil)
+		if err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+
+	t.Run("ledger posting error", func(t *testing.T) {
+		sender := &userModel.User{ID: 1, Username: "sender", Coins: 200}
+		receiver := &userModel.User{ID: 2, Username: "receiver", Coins: 50}
+
+		mockUowFactory.EXPECT().NewUnitOfWork().Return(mockUow)
+		mockUow.EXPECT().Begin(ctx).Return(nil)
+		mockUow.EXPECT().Rollback()
+
+		mockUserRepo.EXPECT().GetByUsername(ctx, "sender").Return(sender, nil)
+		mockUserRepo.EXPECT().GetByUsername(ctx, "receiver").Return(receiver, nil)
+		mockUserRepo.EXPECT().Debit(ctx, mockUow, uint(1), uint(100)).Return(nil)
+		mockUserRepo.EXPECT().Credit(ctx, mockUow, uint(2), uint(100)).Return(nil)
+		mockLotRepo.EXPECT().Consume(ctx, mockUow, uint(1), uint(100)).Return(nil, nil)
+		mockLotRepo.EXPECT().Create(ctx, mockUow, uint(2), gomock.Any()).Return(nil)
 		mockTxRepo.EXPECT().Create(ctx, mockUow, gomock.Any()).Return(&transactionModel.Transaction{ID: 1}, nil)
+		mockLedgerRepo.EXPECT().Post(ctx, mockUow, gomock.Any()).Return(nil, errors.New("post error"))
+
+		err := uc.Create(ctx, testTransaction, nil)
+		if err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+}
+
+func TestTransactionUsecase_GetHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTxRepo := mockTransaction.NewMockTransactionRepositoryI(ctrl)
+	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
+	mockIdempotencyRepo := mockIdempotency.NewMockIdempotencyRepositoryI(ctrl)
+	mockLedgerRepo := mockLedger.NewMockLedgerRepositoryI(ctrl)
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
+	mockUowFactory := mockUow.NewMockFactory(ctrl)
+
+	uc := NewTransactionUsecase(mockTxRepo, mockUserRepo, mockIdempotencyRepo, mockLedgerRepo, mockLotRepo, mockUowFactory, logrus.New())
+
+	ctx := context.Background()
+	firstCreatedAt := time.Date(2025, time.February, 14, 12, 0, 0, 0, time.UTC)
+	items := []*entity.HistoryItem{
+		{ID: 3, Direction: entity.DirectionSent, Counterparty: "user2", Amount: 10, CreatedAt: firstCreatedAt},
+		{ID: 2, Direction: entity.DirectionReceived, Counterparty: "user3", Amount: 20, CreatedAt: firstCreatedAt.Add(-time.Minute)},
+		{ID: 1, Direction: entity.DirectionSent, Counterparty: "user2", Amount: 30, CreatedAt: firstCreatedAt.Add(-time.Hour)},
+	}
+
+	t.Run("has next page", func(t *testing.T) {
+		mockTxRepo.EXPECT().
+			GetHistory(ctx, &entity.HistoryFilter{UserID: 1, Limit: 3}).
+			Return(items, nil)
+
+		page, err := uc.GetHistory(ctx, &entity.HistoryFilter{UserID: 1, Limit: 2})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(page.Items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(page.Items))
+		}
+		if page.NextCursor == nil {
+			t.Fatal("expected next cursor but got nil")
+		}
+		if page.NextCursor.ID != 2 || !page.NextCursor.CreatedAt.Equal(items[1].CreatedAt) {
+			t.Errorf("unexpected next cursor: %+v", page.NextCursor)
+		}
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		mockTxRepo.EXPECT().
+			GetHistory(ctx, &entity.HistoryFilter{UserID: 1, Limit: 4}).
+			Return(items, nil)
+
+		page, err := uc.GetHistory(ctx, &entity.HistoryFilter{UserID: 1, Limit: 3})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(page.Items) != 3 {
+			t.Fatalf("expected 3 items, got %d", len(page.Items))
+		}
+		if page.NextCursor != nil {
+			t.Errorf("expected no next cursor, got %+v", page.NextCursor)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockTxRepo.EXPECT().
+			GetHistory(ctx, gomock.Any()).
+			Return(nil, errors.New("db error"))
 
-		err := uc.Create(ctx, testTransaction)
+		_, err := uc.GetHistory(ctx, &entity.HistoryFilter{UserID: 1, Limit: 2})
 		if err == nil {
 			t.Error("expected error but got nil")
 		}
@@ -3,6 +3,8 @@ package dto
 import (
 	"golang.org/x/crypto/bcrypt"
 
+	adjustmentEntity "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/entity"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
 	purchaseEntity "github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/entity"
 	sessionDTO "github.com/artrsyf/avito-trainee-assignment/internal/session/domain/dto"
 	transactionEntity "github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/entity"
@@ -18,26 +20,34 @@ type SendCoinsRequest struct {
 type CoinHistory struct {
 	ReceivedHistory transactionEntity.ReceivedHistory `json:"received"`
 	SentHistory     transactionEntity.SentHistory     `json:"sent"`
+	Adjustments     adjustmentEntity.History          `json:"adjustments"`
 }
 
+// ExpiringSoon lists coins that expire within the warning window, soonest
+// first.
 type GetInfoResponse struct {
-	Coins       uint                     `json:"coins"`
-	Inventory   purchaseEntity.Inventory `json:"inventory"`
-	CoinHistory CoinHistory              `json:"coinHistory"`
+	Coins        uint                     `json:"coins"`
+	ExpiringSoon lotEntity.Expiring       `json:"expiringSoon"`
+	Inventory    purchaseEntity.Inventory `json:"inventory"`
+	CoinHistory  CoinHistory              `json:"coinHistory"`
 }
 
 func CreateGetInfoResponse(
 	userCoins uint,
+	expiringSoon *lotEntity.Expiring,
 	userInventory *purchaseEntity.Inventory,
 	userSentTransactions *transactionEntity.SentHistory,
 	userReceivedTransactions *transactionEntity.ReceivedHistory,
+	userAdjustments *adjustmentEntity.History,
 ) *GetInfoResponse {
 	return &GetInfoResponse{
-		Coins:     userCoins,
-		Inventory: *userInventory,
+		Coins:        userCoins,
+		ExpiringSoon: *expiringSoon,
+		Inventory:    *userInventory,
 		CoinHistory: CoinHistory{
 			ReceivedHistory: *userReceivedTransactions,
 			SentHistory:     *userSentTransactions,
+			Adjustments:     *userAdjustments,
 		},
 	}
 }
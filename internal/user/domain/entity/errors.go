@@ -3,6 +3,7 @@ package entity
 import "errors"
 
 var (
-	ErrAlreadyCreated = errors.New("user is already created")
-	ErrIsNotExist     = errors.New("can't find such user")
+	ErrAlreadyCreated   = errors.New("user is already created")
+	ErrIsNotExist       = errors.New("can't find such user")
+	ErrNotEnoughBalance = errors.New("not enough balance")
 )
@@ -1,5 +1,11 @@
 package entity
 
+const (
+	RoleEmployee  = "employee"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
 	Username     string
 	Coins        uint
@@ -5,4 +5,5 @@ type User struct {
 	Username     string `db:"username"`
 	Coins        uint   `db:"coins"`
 	PasswordHash string `db:"password_hash"`
+	Role         string `db:"role"`
 }
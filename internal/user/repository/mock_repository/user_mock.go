@@ -38,18 +38,46 @@ func (m *MockUserRepositoryI) EXPECT() *MockUserRepositoryIMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockUserRepositoryI) Create(ctx context.Context, user *entity.User) (*model.User, error) {
+func (m *MockUserRepositoryI) Create(ctx context.Context, uow uow.Executor, user *entity.User) (*model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, user)
+	ret := m.ctrl.Call(m, "Create", ctx, uow, user)
 	ret0, _ := ret[0].(*model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockUserRepositoryIMockRecorder) Create(ctx, user interface{}) *gomock.Call {
+func (mr *MockUserRepositoryIMockRecorder) Create(ctx, uow, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepositoryI)(nil).Create), ctx, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepositoryI)(nil).Create), ctx, uow, user)
+}
+
+// Credit mocks base method.
+func (m *MockUserRepositoryI) Credit(ctx context.Context, uow uow.Executor, userID uint, amount uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Credit", ctx, uow, userID, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Credit indicates an expected call of Credit.
+func (mr *MockUserRepositoryIMockRecorder) Credit(ctx, uow, userID, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Credit", reflect.TypeOf((*MockUserRepositoryI)(nil).Credit), ctx, uow, userID, amount)
+}
+
+// Debit mocks base method.
+func (m *MockUserRepositoryI) Debit(ctx context.Context, uow uow.Executor, userID uint, amount uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Debit", ctx, uow, userID, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Debit indicates an expected call of Debit.
+func (mr *MockUserRepositoryIMockRecorder) Debit(ctx, uow, userID, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debit", reflect.TypeOf((*MockUserRepositoryI)(nil).Debit), ctx, uow, userID, amount)
 }
 
 // GetByID mocks base method.
@@ -82,16 +110,17 @@ func (mr *MockUserRepositoryIMockRecorder) GetByUsername(ctx, username interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUsername", reflect.TypeOf((*MockUserRepositoryI)(nil).GetByUsername), ctx, username)
 }
 
-// Update mocks base method.
-func (m *MockUserRepositoryI) Update(ctx context.Context, uow uow.Executor, user *model.User) error {
+// GetForUpdate mocks base method.
+func (m *MockUserRepositoryI) GetForUpdate(ctx context.Context, uow uow.Executor, id uint) (*model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, uow, user)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetForUpdate", ctx, uow, id)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Update indicates an expected call of Update.
-func (mr *MockUserRepositoryIMockRecorder) Update(ctx, uow, user interface{}) *gomock.Call {
+// GetForUpdate indicates an expected call of GetForUpdate.
+func (mr *MockUserRepositoryIMockRecorder) GetForUpdate(ctx, uow, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepositoryI)(nil).Update), ctx, uow, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForUpdate", reflect.TypeOf((*MockUserRepositoryI)(nil).GetForUpdate), ctx, uow, id)
 }
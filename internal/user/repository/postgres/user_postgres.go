@@ -26,11 +26,14 @@ func NewUserPostgresRepository(
 	}
 }
 
+// Create inserts the user within uow, so the initial balance can be
+// journaled in the same transaction.
 func (repo *UserPostgresRepository) Create(
 	ctx context.Context,
+	uow uowI.Executor,
 	user *entity.User,
 ) (*model.User, error) {
-	err := repo.DB.
+	err := uow.
 		QueryRowContext(
 			ctx,
 			"SELECT 1 FROM users WHERE username = $1",
@@ -47,17 +50,18 @@ func (repo *UserPostgresRepository) Create(
 	}
 
 	createdUser := model.User{}
-	err = repo.DB.QueryRowContext(
+	err = uow.QueryRowContext(
 		ctx,
 		`INSERT INTO users (username, coins, password_hash) 
 		VALUES ($1, $2, $3) 
-		RETURNING id, username, coins, password_hash`,
+		RETURNING id, username, coins, password_hash, role`,
 		user.Username, user.Coins, user.PasswordHash,
 	).Scan(
 		&createdUser.ID,
 		&createdUser.Username,
 		&createdUser.Coins,
 		&createdUser.PasswordHash,
+		&createdUser.Role,
 	)
 	if err != nil {
 		repo.logger.WithError(err).Error("Failed to create user")
@@ -71,24 +75,77 @@ func (repo *UserPostgresRepository) Create(
 	return &createdUser, nil
 }
 
-func (repo *UserPostgresRepository) Update(
+// Debit withdraws amount from the user balance. The balance check and the
+// write are a single statement, so concurrent debits can't overdraw the
+// account: the row is locked until uow finishes and the losing debit sees the
+// already decreased balance.
+func (repo *UserPostgresRepository) Debit(
+	ctx context.Context,
+	uow uowI.Executor,
+	userID uint,
+	amount uint,
+) error {
+	result, err := uow.ExecContext(
+		ctx,
+		"UPDATE users SET coins = coins - $1 WHERE id = $2 AND coins >= $1",
+		amount, userID,
+	)
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to debit user")
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get debited rows count")
+		return err
+	}
+	if affected == 0 {
+		repo.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"amount":  amount,
+		}).Info("User doesn't have enough balance to debit")
+		return entity.ErrNotEnoughBalance
+	}
+
+	repo.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"amount":  amount,
+	}).Debug("Debited user in Postgres")
+
+	return nil
+}
+
+func (repo *UserPostgresRepository) Credit(
 	ctx context.Context,
 	uow uowI.Executor,
-	user *model.User,
+	userID uint,
+	amount uint,
 ) error {
-	_, err := uow.ExecContext(
+	result, err := uow.ExecContext(
 		ctx,
-		"UPDATE users SET coins = $1 WHERE id = $2",
-		user.Coins, user.ID,
+		"UPDATE users SET coins = coins + $1 WHERE id = $2",
+		amount, userID,
 	)
 	if err != nil {
-		repo.logger.WithError(err).Error("Failed to update user")
+		repo.logger.WithError(err).Error("Failed to credit user")
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.logger.WithError(err).Error("Failed to get credited rows count")
 		return err
 	}
+	if affected == 0 {
+		repo.logger.WithField("user_id", userID).Error("Couldn't find user to credit")
+		return entity.ErrIsNotExist
+	}
 
 	repo.logger.WithFields(logrus.Fields{
-		"user_id": user.ID,
-	}).Debug("Updated user in Postgres")
+		"user_id": userID,
+		"amount":  amount,
+	}).Debug("Credited user in Postgres")
 
 	return nil
 }
@@ -102,9 +159,9 @@ func (repo *UserPostgresRepository) GetByID(
 	err := repo.DB.
 		QueryRowContext(
 			ctx,
-			"SELECT id, username, coins, password_hash FROM users WHERE id = $1",
+			"SELECT id, username, coins, password_hash, role FROM users WHERE id = $1",
 			id,
-		).Scan(&user.ID, &user.Username, &user.Coins, &user.PasswordHash)
+		).Scan(&user.ID, &user.Username, &user.Coins, &user.PasswordHash, &user.Role)
 	if err == sql.ErrNoRows {
 		repo.logger.WithError(err).Error("Couldn't find such user by id")
 		return nil, entity.ErrIsNotExist
@@ -116,6 +173,32 @@ func (repo *UserPostgresRepository) GetByID(
 	return &user, nil
 }
 
+// GetForUpdate selects the user and locks the row until uow finishes, so
+// balance changes of the user wait for it.
+func (repo *UserPostgresRepository) GetForUpdate(
+	ctx context.Context,
+	uow uowI.Executor,
+	id uint,
+) (*model.User, error) {
+	user := model.User{}
+
+	err := uow.
+		QueryRowContext(
+			ctx,
+			"SELECT id, username, coins, password_hash, role FROM users WHERE id = $1 FOR UPDATE",
+			id,
+		).Scan(&user.ID, &user.Username, &user.Coins, &user.PasswordHash, &user.Role)
+	if err == sql.ErrNoRows {
+		repo.logger.WithError(err).Error("Couldn't find such user to lock")
+		return nil, entity.ErrIsNotExist
+	} else if err != nil {
+		repo.logger.WithError(err).Error("SQL select user for update error")
+		return nil, err
+	}
+
+	return &user, nil
+}
+
 func (repo *UserPostgresRepository) GetByUsername(
 	ctx context.Context,
 	username string,
@@ -125,9 +208,9 @@ func (repo *UserPostgresRepository) GetByUsername(
 	err := repo.DB.
 		QueryRowContext(
 			ctx,
-			"SELECT id, username, coins, password_hash FROM users WHERE username = $1",
+			"SELECT id, username, coins, password_hash, role FROM users WHERE username = $1",
 			username,
-		).Scan(&user.ID, &user.Username, &user.Coins, &user.PasswordHash)
+		).Scan(&user.ID, &user.Username, &user.Coins, &user.PasswordHash, &user.Role)
 	if err == sql.ErrNoRows {
 		repo.logger.WithError(err).Error("Couldn't find such user by username")
 		return nil, entity.ErrIsNotExist
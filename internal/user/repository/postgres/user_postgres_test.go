@@ -30,10 +30,10 @@ func TestUserPostgresRepository_Create(t *testing.T) {
 
 		mock.ExpectQuery("INSERT INTO users .* RETURNING .*").
 			WithArgs("testuser", 1000, "hash").
-			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins", "password_hash"}).
-				AddRow(1, "testuser", 1000, "hash"))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins", "password_hash", "role"}).
+				AddRow(1, "testuser", 1000, "hash", "employee"))
 
-		user, err := repo.Create(context.Background(), &entity.User{
+		user, err := repo.Create(context.Background(), db, &entity.User{
 			Username:     "testuser",
 			Coins:        1000,
 			PasswordHash: "hash",
@@ -45,6 +45,7 @@ func TestUserPostgresRepository_Create(t *testing.T) {
 			Username:     "testuser",
 			Coins:        1000,
 			PasswordHash: "hash",
+			Role:         "employee",
 		}, user)
 	})
 
@@ -53,7 +54,7 @@ func TestUserPostgresRepository_Create(t *testing.T) {
 			WithArgs("existinguser").
 			WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
 
-		_, err := repo.Create(context.Background(), &entity.User{
+		_, err := repo.Create(context.Background(), db, &entity.User{
 			Username: "existinguser",
 		})
 
@@ -66,7 +67,7 @@ func TestUserPostgresRepository_Create(t *testing.T) {
 			WithArgs("testuser").
 			WillReturnError(expectedErr)
 
-		_, err := repo.Create(context.Background(), &entity.User{
+		_, err := repo.Create(context.Background(), db, &entity.User{
 			Username: "testuser",
 		})
 
@@ -74,7 +75,7 @@ func TestUserPostgresRepository_Create(t *testing.T) {
 	})
 }
 
-func TestUserPostgresRepository_Update(t *testing.T) {
+func TestUserPostgresRepository_Debit(t *testing.T) {
 	db, _, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -86,29 +87,79 @@ func TestUserPostgresRepository_Update(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		mockUOW.ExecContextFn = func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-			assert.Equal(t, "UPDATE users SET coins = $1 WHERE id = $2", query)
+			assert.Equal(t, "UPDATE users SET coins = coins - $1 WHERE id = $2 AND coins >= $1", query)
 			assert.Equal(t, uint(500), args[0].(uint))
 			assert.Equal(t, uint(1), args[1].(uint))
 			return sqlmock.NewResult(0, 1), nil
 		}
 
-		err := repo.Update(context.Background(), mockUOW, &model.User{
-			ID:    1,
-			Coins: 500,
-		})
+		err := repo.Debit(context.Background(), mockUOW, 1, 500)
 
 		assert.NoError(t, err)
 	})
 
+	t.Run("NotEnoughBalance", func(t *testing.T) {
+		mockUOW.ExecContextFn = func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+			return sqlmock.NewResult(0, 0), nil
+		}
+
+		err := repo.Debit(context.Background(), mockUOW, 1, 500)
+
+		assert.Equal(t, entity.ErrNotEnoughBalance, err)
+	})
+
 	t.Run("UpdateError", func(t *testing.T) {
 		expectedErr := sql.ErrTxDone
 		mockUOW.ExecContextFn = func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 			return nil, expectedErr
 		}
 
-		err := repo.Update(context.Background(), mockUOW, &model.User{
-			ID: 1,
-		})
+		err := repo.Debit(context.Background(), mockUOW, 1, 500)
+
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestUserPostgresRepository_Credit(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserPostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{}
+
+	t.Run("Success", func(t *testing.T) {
+		mockUOW.ExecContextFn = func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+			assert.Equal(t, "UPDATE users SET coins = coins + $1 WHERE id = $2", query)
+			assert.Equal(t, uint(500), args[0].(uint))
+			assert.Equal(t, uint(1), args[1].(uint))
+			return sqlmock.NewResult(0, 1), nil
+		}
+
+		err := repo.Credit(context.Background(), mockUOW, 1, 500)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("UserNotFound", func(t *testing.T) {
+		mockUOW.ExecContextFn = func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+			return sqlmock.NewResult(0, 0), nil
+		}
+
+		err := repo.Credit(context.Background(), mockUOW, 1, 500)
+
+		assert.Equal(t, entity.ErrIsNotExist, err)
+	})
+
+	t.Run("UpdateError", func(t *testing.T) {
+		expectedErr := sql.ErrTxDone
+		mockUOW.ExecContextFn = func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+			return nil, expectedErr
+		}
+
+		err := repo.Credit(context.Background(), mockUOW, 1, 500)
 
 		assert.Equal(t, expectedErr, err)
 	})
@@ -126,8 +177,8 @@ func TestUserPostgresRepository_GetById(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mock.ExpectQuery("SELECT .* FROM users WHERE id = \\$1").
 			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins", "password_hash"}).
-				AddRow(1, "testuser", 1000, "hash"))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins", "password_hash", "role"}).
+				AddRow(1, "testuser", 1000, "hash", "employee"))
 
 		user, err := repo.GetByID(context.Background(), 1)
 
@@ -137,6 +188,7 @@ func TestUserPostgresRepository_GetById(t *testing.T) {
 			Username:     "testuser",
 			Coins:        1000,
 			PasswordHash: "hash",
+			Role:         "employee",
 		}, user)
 	})
 
@@ -162,6 +214,39 @@ func TestUserPostgresRepository_GetById(t *testing.T) {
 	})
 }
 
+func TestUserPostgresRepository_GetForUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserPostgresRepository(db, logrus.New())
+	mockUOW := &MockUnitOfWork{db: db}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT .* FROM users WHERE id = \\$1 FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins", "password_hash", "role"}).
+				AddRow(1, "testuser", 1000, "hash", "employee"))
+
+		user, err := repo.GetForUpdate(context.Background(), mockUOW, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1000), user.Coins)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT .* FROM users WHERE id = \\$1 FOR UPDATE").
+			WithArgs(2).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetForUpdate(context.Background(), mockUOW, 2)
+
+		assert.Equal(t, entity.ErrIsNotExist, err)
+	})
+}
+
 func TestUserPostgresRepository_GetByUsername(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -174,8 +259,8 @@ func TestUserPostgresRepository_GetByUsername(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mock.ExpectQuery("SELECT .* FROM users WHERE username = \\$1").
 			WithArgs("testuser").
-			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins", "password_hash"}).
-				AddRow(1, "testuser", 1000, "hash"))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins", "password_hash", "role"}).
+				AddRow(1, "testuser", 1000, "hash", "employee"))
 
 		user, err := repo.GetByUsername(context.Background(), "testuser")
 
@@ -185,6 +270,7 @@ func TestUserPostgresRepository_GetByUsername(t *testing.T) {
 			Username:     "testuser",
 			Coins:        1000,
 			PasswordHash: "hash",
+			Role:         "employee",
 		}, user)
 	})
 
@@ -213,8 +299,13 @@ func TestUserPostgresRepository_GetByUsername(t *testing.T) {
 type MockUnitOfWork struct {
 	uow.Executor
 	ExecContextFn func(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	db            *sql.DB
 }
 
 func (m *MockUnitOfWork) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	return m.ExecContextFn(ctx, query, args...)
 }
+
+func (m *MockUnitOfWork) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return m.db.QueryRowContext(ctx, query, args...)
+}
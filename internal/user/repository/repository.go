@@ -10,8 +10,10 @@ import (
 
 //go:generate mockgen -source=repository.go -destination=mock_repository/user_mock.go -package=mock_repository MockUserRepository
 type UserRepositoryI interface {
-	Create(ctx context.Context, user *entity.User) (*model.User, error)
-	Update(ctx context.Context, uow uow.Executor, user *model.User) error
+	Create(ctx context.Context, uow uow.Executor, user *entity.User) (*model.User, error)
+	Debit(ctx context.Context, uow uow.Executor, userID uint, amount uint) error
+	Credit(ctx context.Context, uow uow.Executor, userID uint, amount uint) error
 	GetByID(ctx context.Context, id uint) (*model.User, error)
+	GetForUpdate(ctx context.Context, uow uow.Executor, id uint) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
 }
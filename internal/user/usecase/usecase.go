@@ -2,9 +2,14 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	adjustmentRepo "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository"
 	purchaseRepo "github.com/artrsyf/avito-trainee-assignment/internal/purchase/repository"
 	transactionRepo "github.com/artrsyf/avito-trainee-assignment/internal/transaction/repository"
 	"github.com/artrsyf/avito-trainee-assignment/internal/user/domain/dto"
@@ -18,20 +23,34 @@ type UserUsecaseI interface {
 type UserUsecase struct {
 	purchaseRepo    purchaseRepo.PurchaseRepositoryI
 	transactionRepo transactionRepo.TransactionRepositoryI
+	adjustmentRepo  adjustmentRepo.AdjustmentRepositoryI
+	lotRepo         lotRepo.LotRepositoryI
 	userRepo        userRepo.UserRepositoryI
+	warningWindow   time.Duration
 	logger          *logrus.Logger
 }
 
 func NewUserUsecase(
 	purchaseRepository purchaseRepo.PurchaseRepositoryI,
 	transactionRepository transactionRepo.TransactionRepositoryI,
+	adjustmentRepository adjustmentRepo.AdjustmentRepositoryI,
+	lotRepository lotRepo.LotRepositoryI,
 	userRepository userRepo.UserRepositoryI,
+	cfg config.ExpiryConfig,
 	logger *logrus.Logger,
 ) *UserUsecase {
+	warningWindow, err := cfg.GetWarningWindow()
+	if err != nil {
+		logger.WithError(err).Warn("Expiry warning window is not set, expiring coins won't be shown")
+	}
+
 	return &UserUsecase{
 		purchaseRepo:    purchaseRepository,
 		transactionRepo: transactionRepository,
+		adjustmentRepo:  adjustmentRepository,
+		lotRepo:         lotRepository,
 		userRepo:        userRepository,
+		warningWindow:   warningWindow,
 		logger:          logger,
 	}
 }
@@ -64,11 +83,28 @@ func (uc *UserUsecase) GetInfoByID(
 		return nil, err
 	}
 
+	userAdjustments, err := uc.adjustmentRepo.GetHistoryByUserID(ctx, userID)
+	if err != nil {
+		uc.logger.WithError(err).Error("Failed to get user balance adjustments by user id")
+		return nil, err
+	}
+
+	expiringSoon := lotEntity.Expiring{}
+	if uc.warningWindow > 0 {
+		expiringSoon, err = uc.lotRepo.GetExpiringByUserID(ctx, userID, time.Now().Add(uc.warningWindow))
+		if err != nil {
+			uc.logger.WithError(err).Error("Failed to get user expiring coins by user id")
+			return nil, err
+		}
+	}
+
 	getInfoResponse := dto.CreateGetInfoResponse(
 		userInfo.Coins,
+		&expiringSoon,
 		&userInventory,
 		&userSentTransactions,
 		&userReceivedTransactions,
+		&userAdjustments,
 	)
 
 	uc.logger.WithFields(logrus.Fields{
@@ -4,10 +4,16 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
 
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	adjustmentEntity "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/entity"
+	mockAdjustment "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/repository/mock_repository"
+	lotEntity "github.com/artrsyf/avito-trainee-assignment/internal/lot/domain/entity"
+	mockLot "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/mock_repository"
 	purchaseEntity "github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/entity"
 	mockPurchase "github.com/artrsyf/avito-trainee-assignment/internal/purchase/repository/mock_repository"
 	transactionEntity "github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/entity"
@@ -23,11 +29,16 @@ func TestUserUsecase_GetInfoById(t *testing.T) {
 	mockUserRepo := mockUser.NewMockUserRepositoryI(ctrl)
 	mockPurchaseRepo := mockPurchase.NewMockPurchaseRepositoryI(ctrl)
 	mockTransactionRepo := mockTransaction.NewMockTransactionRepositoryI(ctrl)
+	mockAdjustmentRepo := mockAdjustment.NewMockAdjustmentRepositoryI(ctrl)
+	mockLotRepo := mockLot.NewMockLotRepositoryI(ctrl)
 
 	uc := NewUserUsecase(
 		mockPurchaseRepo,
 		mockTransactionRepo,
+		mockAdjustmentRepo,
+		mockLotRepo,
 		mockUserRepo,
+		config.ExpiryConfig{WarningWindow: "720h"},
 		logrus.New(),
 	)
 
@@ -53,11 +64,15 @@ func TestUserUsecase_GetInfoById(t *testing.T) {
 		}
 		sent := &transactionEntity.SentHistory{sentTrancationGroup}
 		received := &transactionEntity.ReceivedHistory{receivedTrancationGroup}
+		adjustments := adjustmentEntity.History{{Amount: 50, Reason: "hackathon prize"}}
+		expiring := lotEntity.Expiring{{Amount: 40, ExpiresAt: time.Now().Add(24 * time.Hour)}}
 
 		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
 		mockPurchaseRepo.EXPECT().GetPurchasesByUserID(ctx, userID).Return(*inventory, nil)
 		mockTransactionRepo.EXPECT().GetSentByUserID(ctx, userID).Return(*sent, nil)
 		mockTransactionRepo.EXPECT().GetReceivedByUserID(ctx, userID).Return(*received, nil)
+		mockAdjustmentRepo.EXPECT().GetHistoryByUserID(ctx, userID).Return(adjustments, nil)
+		mockLotRepo.EXPECT().GetExpiringByUserID(ctx, userID, gomock.Any()).Return(expiring, nil)
 
 		resp, err := uc.GetInfoByID(ctx, userID)
 		if err != nil {
@@ -76,6 +91,12 @@ func TestUserUsecase_GetInfoById(t *testing.T) {
 		if len(resp.CoinHistory.ReceivedHistory) != 1 {
 			t.Error("invalid received transactions count")
 		}
+		if len(resp.CoinHistory.Adjustments) != 1 || resp.CoinHistory.Adjustments[0].Reason != "hackathon prize" {
+			t.Error("invalid adjustments")
+		}
+		if len(resp.ExpiringSoon) != 1 || resp.ExpiringSoon[0].Amount != 40 {
+			t.Error("invalid expiring coins")
+		}
 	})
 
 	t.Run("user repo error", func(t *testing.T) {
@@ -134,4 +155,35 @@ func TestUserUsecase_GetInfoById(t *testing.T) {
 			t.Errorf("expected error %v, got %v", testError, err)
 		}
 	})
+
+	t.Run("adjustments error", func(t *testing.T) {
+		user := &model.User{Coins: 100}
+
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetPurchasesByUserID(ctx, userID).Return(purchaseEntity.Inventory{}, nil)
+		mockTransactionRepo.EXPECT().GetSentByUserID(ctx, userID).Return(transactionEntity.SentHistory{}, nil)
+		mockTransactionRepo.EXPECT().GetReceivedByUserID(ctx, userID).Return(transactionEntity.ReceivedHistory{}, nil)
+		mockAdjustmentRepo.EXPECT().GetHistoryByUserID(ctx, userID).Return(nil, testError)
+
+		_, err := uc.GetInfoByID(ctx, userID)
+		if !errors.Is(err, testError) {
+			t.Errorf("expected error %v, got %v", testError, err)
+		}
+	})
+
+	t.Run("expiring coins error", func(t *testing.T) {
+		user := &model.User{Coins: 100}
+
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockPurchaseRepo.EXPECT().GetPurchasesByUserID(ctx, userID).Return(purchaseEntity.Inventory{}, nil)
+		mockTransactionRepo.EXPECT().GetSentByUserID(ctx, userID).Return(transactionEntity.SentHistory{}, nil)
+		mockTransactionRepo.EXPECT().GetReceivedByUserID(ctx, userID).Return(transactionEntity.ReceivedHistory{}, nil)
+		mockAdjustmentRepo.EXPECT().GetHistoryByUserID(ctx, userID).Return(adjustmentEntity.History{}, nil)
+		mockLotRepo.EXPECT().GetExpiringByUserID(ctx, userID, gomock.Any()).Return(nil, testError)
+
+		_, err := uc.GetInfoByID(ctx, userID)
+		if !errors.Is(err, testError) {
+			t.Errorf("expected error %v, got %v", testError, err)
+		}
+	})
 }
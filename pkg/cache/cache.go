@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type item[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// TTLCache is a small in-process cache for hot read paths. Entries are
+// dropped lazily on read and swept at most once per ttl on write.
+type TTLCache[K comparable, V any] struct {
+	mu        sync.RWMutex
+	items     map[K]item[V]
+	ttl       time.Duration
+	lastSweep time.Time
+}
+
+func NewTTLCache[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
+	return &TTLCache[K, V]{
+		items:     make(map[K]item[V]),
+		ttl:       ttl,
+		lastSweep: time.Now(),
+	}
+}
+
+func (c *TTLCache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	cached, ok := c.items[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(cached.expiresAt) {
+		var zero V
+		return zero, false
+	}
+
+	return cached.value, true
+}
+
+func (c *TTLCache[K, V]) Set(key K, value V) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastSweep) > c.ttl {
+		for k, cached := range c.items {
+			if now.After(cached.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	c.items[key] = item[V]{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	delete(c.items, key)
+	c.mu.Unlock()
+}
+
+// DeleteFunc removes every entry matching the predicate.
+func (c *TTLCache[K, V]) DeleteFunc(match func(key K, value V) bool) {
+	c.mu.Lock()
+	for k, cached := range c.items {
+		if match(k, cached.value) {
+			delete(c.items, k)
+		}
+	}
+	c.mu.Unlock()
+}
+
+func (c *TTLCache[K, V]) Clear() {
+	c.mu.Lock()
+	c.items = make(map[K]item[V])
+	c.mu.Unlock()
+}
@@ -69,6 +69,13 @@ func (u *UnitOfWork) ExecContext(ctx context.Context, query string, args ...any)
 	return u.tx.ExecContext(ctx, query, args...)
 }
 
+func (u *UnitOfWork) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if u.tx == nil {
+		return nil, fmt.Errorf("transaction not started")
+	}
+	return u.tx.QueryContext(ctx, query, args...)
+}
+
 func (u *UnitOfWork) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
 	if u.tx == nil {
 		return nil
@@ -2,7 +2,10 @@ CREATE TABLE IF NOT EXISTS users (
     id SERIAL PRIMARY KEY,
     username VARCHAR(255) NOT NULL UNIQUE,
     coins INTEGER NOT NULL DEFAULT 0 CHECK (coins >= 0),
-    password_hash TEXT NOT NULL
+    password_hash TEXT NOT NULL,
+    role VARCHAR(32) NOT NULL DEFAULT 'employee'
+        CHECK (role IN ('employee', 'moderator', 'admin')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
 CREATE TABLE IF NOT EXISTS transactions (
@@ -10,24 +13,222 @@ CREATE TABLE IF NOT EXISTS transactions (
     sender_user_id INTEGER,
     receiver_user_id INTEGER,
     amount INT NOT NULL CHECK (amount > 0),
+    message VARCHAR(200) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     FOREIGN KEY (sender_user_id) REFERENCES users (id) ON DELETE SET NULL,
     FOREIGN KEY (receiver_user_id) REFERENCES users (id) ON DELETE SET NULL
 );
 
+CREATE INDEX IF NOT EXISTS transactions_sender_history_idx
+    ON transactions (sender_user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS transactions_receiver_history_idx
+    ON transactions (receiver_user_id, created_at DESC, id DESC);
+
 CREATE TABLE purchase_types (
     id   SERIAL PRIMARY KEY,
-    name VARCHAR(255) NOT NULL UNIQUE,
-    cost INTEGER NOT NULL CHECK (cost >= 0)
+    name VARCHAR(255) NOT NULL,
+    cost INTEGER NOT NULL CHECK (cost >= 0),
+    available BOOLEAN NOT NULL DEFAULT TRUE,
+    -- NULL stock means the item isn't limited.
+    stock INTEGER CHECK (stock >= 0),
+    -- NULL user_limit means the item isn't limited per user. The limit
+    -- counts purchases over the last user_limit_period seconds, or over all
+    -- time when the period is NULL.
+    user_limit INTEGER CHECK (user_limit > 0),
+    user_limit_period INTEGER CHECK (user_limit_period > 0),
+    retired_at TIMESTAMPTZ
+);
+
+-- Retired items are kept for purchase history, so only active names are unique.
+CREATE UNIQUE INDEX IF NOT EXISTS purchase_types_active_name_idx
+    ON purchase_types (name) WHERE retired_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id               SERIAL PRIMARY KEY,
+    code             VARCHAR(64) NOT NULL UNIQUE,
+    discount_type    VARCHAR(16) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    discount_value   INTEGER NOT NULL CHECK (discount_value > 0),
+    -- NULL purchase type means the code applies to any item.
+    purchase_type_id INTEGER,
+    valid_from       TIMESTAMPTZ,
+    valid_until      TIMESTAMPTZ,
+    -- NULL limits aren't enforced.
+    max_redemptions  INTEGER CHECK (max_redemptions > 0),
+    max_per_user     INTEGER CHECK (max_per_user > 0),
+    redemptions      INTEGER NOT NULL DEFAULT 0,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (discount_type <> 'percent' OR discount_value <= 100),
+    FOREIGN KEY (purchase_type_id) REFERENCES purchase_types (id) ON DELETE CASCADE
 );
 
 CREATE TABLE IF NOT EXISTS purchases (    
     id               SERIAL PRIMARY KEY,
     purchaser_id     INTEGER NOT NULL,
     purchase_type_id INTEGER,
+    item_name        VARCHAR(255) NOT NULL,
+    price            INTEGER NOT NULL CHECK (price >= 0),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    refunded_at      TIMESTAMPTZ,
+    status           VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'ready', 'delivered', 'cancelled')),
+    promo_code_id    INTEGER,
+    discount         INTEGER NOT NULL DEFAULT 0 CHECK (discount >= 0),
+    -- NULL recipient means the purchaser bought the item for themselves,
+    -- otherwise the item is a gift and belongs to the recipient.
+    recipient_id     INTEGER,
+    CHECK (recipient_id <> purchaser_id),
+    FOREIGN KEY (recipient_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (promo_code_id) REFERENCES promo_codes (id) ON DELETE SET NULL,
     FOREIGN KEY (purchase_type_id) REFERENCES purchase_types (id) ON DELETE SET NULL,
     FOREIGN KEY (purchaser_id) REFERENCES users (id) ON DELETE CASCADE
 );
 
+CREATE INDEX IF NOT EXISTS purchases_purchaser_history_idx
+    ON purchases (purchaser_id, id DESC);
+
+CREATE INDEX IF NOT EXISTS purchases_status_idx
+    ON purchases (status, id DESC);
+
+CREATE INDEX IF NOT EXISTS purchases_recipient_history_idx
+    ON purchases (recipient_id, id DESC) WHERE recipient_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS purchases_purchaser_item_idx
+    ON purchases (purchaser_id, purchase_type_id, created_at);
+
+CREATE INDEX IF NOT EXISTS purchases_promo_code_idx
+    ON purchases (promo_code_id, purchaser_id) WHERE promo_code_id IS NOT NULL;
+
+-- Double-entry ledger. Every balance change is an entry whose postings sum
+-- to zero; users.coins is a cached projection of the user account postings
+-- and is changed in the same transaction as the entry is posted. The schema
+-- is only created on an empty database, so the ledger starts empty and every
+-- balance is journaled from the user's signup grant.
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id         SERIAL PRIMARY KEY,
+    kind       VARCHAR(20) NOT NULL
+        CHECK (kind IN ('grant', 'purchase', 'refund', 'transfer', 'adjustment', 'allowance', 'expiry')),
+    reference  VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Positive amounts add coins to the account. The issuer account is where
+-- coins come from and the shop account is where spent coins go.
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id       SERIAL PRIMARY KEY,
+    entry_id INTEGER NOT NULL,
+    account  VARCHAR(20) NOT NULL CHECK (account IN ('user', 'shop', 'issuer')),
+    user_id  INTEGER,
+    amount   BIGINT NOT NULL CHECK (amount <> 0),
+    CHECK ((account = 'user') = (user_id IS NOT NULL)),
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS ledger_postings_entry_idx
+    ON ledger_postings (entry_id);
+
+CREATE INDEX IF NOT EXISTS ledger_postings_user_idx
+    ON ledger_postings (user_id) WHERE user_id IS NOT NULL;
+
+-- Balance changes made by admins. Positive amounts credit the user,
+-- negative amounts debit them.
+CREATE TABLE IF NOT EXISTS balance_adjustments (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL,
+    admin_id   INTEGER,
+    amount     INTEGER NOT NULL CHECK (amount <> 0),
+    reason     VARCHAR(255) NOT NULL CHECK (reason <> ''),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (admin_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS balance_adjustments_user_idx
+    ON balance_adjustments (user_id, created_at DESC);
+
+-- One row per user credited by an allowance campaign. The primary key makes
+-- sure a user is credited at most once per campaign period, even if a run
+-- is interrupted or two replicas run at the same time.
+CREATE TABLE IF NOT EXISTS allowance_credits (
+    campaign   VARCHAR(50) NOT NULL,
+    period     VARCHAR(20) NOT NULL,
+    user_id    INTEGER NOT NULL,
+    amount     INTEGER NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (campaign, period, user_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS allowance_credits_user_idx
+    ON allowance_credits (user_id);
+
+CREATE TABLE IF NOT EXISTS allowance_runs (
+    campaign       VARCHAR(50) NOT NULL,
+    period         VARCHAR(20) NOT NULL,
+    credited_users INTEGER NOT NULL DEFAULT 0,
+    completed_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (campaign, period)
+);
+
+-- A lease lets only one replica run a scheduled job at a time. An expired
+-- lease can be taken over, so a crashed replica doesn't block the job.
+CREATE TABLE IF NOT EXISTS scheduler_leases (
+    name       VARCHAR(50) PRIMARY KEY,
+    holder     VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Balances are kept as lots, so coins can expire some time after they
+-- were granted. users.coins always equals the sum of remaining coins of the
+-- user's lots. Coins are spent from the lot that expires first, and expired
+-- coins are moved from remaining to expired by the expiry sweeper.
+CREATE TABLE IF NOT EXISTS coin_lots (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL,
+    amount     INTEGER NOT NULL CHECK (amount > 0),
+    remaining  INTEGER NOT NULL CHECK (remaining >= 0),
+    expired    INTEGER NOT NULL DEFAULT 0 CHECK (expired >= 0),
+    -- NULL means the coins never expire.
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (remaining + expired <= amount),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS coin_lots_user_idx
+    ON coin_lots (user_id, expires_at) WHERE remaining > 0;
+CREATE INDEX IF NOT EXISTS coin_lots_expires_idx
+    ON coin_lots (expires_at) WHERE remaining > 0;
+
+-- The coins a purchase was paid with, one row per lot they were taken from.
+-- A refund gives the coins back as the same portions, so it doesn't extend
+-- the life of the coins.
+CREATE TABLE IF NOT EXISTS purchase_coin_portions (
+    id          SERIAL PRIMARY KEY,
+    purchase_id INTEGER NOT NULL,
+    amount      INTEGER NOT NULL CHECK (amount > 0),
+    -- NULL means the coins never expire.
+    expires_at  TIMESTAMPTZ,
+    FOREIGN KEY (purchase_id) REFERENCES purchases (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS purchase_coin_portions_purchase_idx
+    ON purchase_coin_portions (purchase_id);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id         INTEGER NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash    CHAR(64) NOT NULL,
+    response_status INTEGER NOT NULL,
+    response_body   BYTEA,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, idempotency_key),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_idx
+    ON idempotency_keys (created_at);
+
 INSERT INTO purchase_types (name, cost) VALUES
     ('t-shirt', 80),
     ('cup', 20),
@@ -39,4 +240,4 @@ INSERT INTO purchase_types (name, cost) VALUES
     ('socks', 10),
     ('wallet', 50),
     ('pink-hoody', 500)
-ON CONFLICT (name) DO NOTHING;
\ No newline at end of file
+ON CONFLICT (name) WHERE retired_at IS NULL DO NOTHING;
\ No newline at end of file
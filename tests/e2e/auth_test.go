@@ -15,12 +15,18 @@ import (
 	"github.com/artrsyf/avito-trainee-assignment/config"
 
 	"github.com/artrsyf/avito-trainee-assignment/internal/session/domain/dto"
+	userEntity "github.com/artrsyf/avito-trainee-assignment/internal/user/domain/entity"
 
 	purchaseRepoI "github.com/artrsyf/avito-trainee-assignment/internal/purchase/repository"
 	sessionRepoI "github.com/artrsyf/avito-trainee-assignment/internal/session/repository"
 	transactionRepoI "github.com/artrsyf/avito-trainee-assignment/internal/transaction/repository"
 	userRepoI "github.com/artrsyf/avito-trainee-assignment/internal/user/repository"
 
+	adjustmentRepo "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/repository/postgres"
+	idempotencyRepo "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository/postgres"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/postgres"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/postgres"
+	promoRepo "github.com/artrsyf/avito-trainee-assignment/internal/promo/repository/postgres"
 	purchaseRepo "github.com/artrsyf/avito-trainee-assignment/internal/purchase/repository/postgres"
 	sessionRepo "github.com/artrsyf/avito-trainee-assignment/internal/session/repository/redis"
 	transactionRepo "github.com/artrsyf/avito-trainee-assignment/internal/transaction/repository/postgres"
@@ -28,11 +34,19 @@ import (
 
 	uow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/postgres"
 
+	adjustmentUsecase "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/usecase"
+	idempotencyUsecase "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/usecase"
+	ledgerUsecase "github.com/artrsyf/avito-trainee-assignment/internal/ledger/usecase"
+	promoUsecase "github.com/artrsyf/avito-trainee-assignment/internal/promo/usecase"
 	purchaseUsecase "github.com/artrsyf/avito-trainee-assignment/internal/purchase/usecase"
 	sessionUsecase "github.com/artrsyf/avito-trainee-assignment/internal/session/usecase"
 	transactionUsecase "github.com/artrsyf/avito-trainee-assignment/internal/transaction/usecase"
 	userUsecase "github.com/artrsyf/avito-trainee-assignment/internal/user/usecase"
 
+	adjustmentDelivery "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/delivery/http"
+	idempotencyDelivery "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/delivery/http"
+	ledgerDelivery "github.com/artrsyf/avito-trainee-assignment/internal/ledger/delivery/http"
+	promoDelivery "github.com/artrsyf/avito-trainee-assignment/internal/promo/delivery/http"
 	purchaseDelivery "github.com/artrsyf/avito-trainee-assignment/internal/purchase/delivery/http"
 	sessionDelivery "github.com/artrsyf/avito-trainee-assignment/internal/session/delivery/http"
 	transactionDelivery "github.com/artrsyf/avito-trainee-assignment/internal/transaction/delivery/http"
@@ -119,9 +133,29 @@ func TestAuthScenarios(t *testing.T) {
 		testInvalidRequestFormat(t, cfg)
 	})
 
+	t.Run("Explicit registration", func(t *testing.T) {
+		testExplicitRegistration(t, cfg)
+	})
+
+	t.Run("Login lockout", func(t *testing.T) {
+		testLoginLockout(t, cfg)
+	})
+
 	t.Run("Token validation", func(t *testing.T) {
 		testTokenValidation(t, cfg)
 	})
+
+	t.Run("Refresh token rotation", func(t *testing.T) {
+		testRefreshRotation(t, cfg)
+	})
+
+	t.Run("Logout revokes token", func(t *testing.T) {
+		testLogout(t, cfg)
+	})
+
+	t.Run("Sessions list and revoke", func(t *testing.T) {
+		testSessionsManagement(t, cfg)
+	})
 }
 
 func testSuccessfulRegistration(t *testing.T, cfg *TestConfig) {
@@ -141,9 +175,10 @@ func testSuccessfulRegistration(t *testing.T, cfg *TestConfig) {
 	assert.Equal(t, payload.Username, user.Username)
 	assert.Equal(t, cfg.UserConfig.InitCoinsBalance, user.Coins)
 
-	session, err := cfg.SessionRepo.Check(cfg.Ctx, user.ID)
+	sessions, err := cfg.SessionRepo.GetByUserID(cfg.Ctx, user.ID)
 	require.NoError(t, err)
-	assert.Equal(t, user.ID, session.UserID)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, user.ID, sessions[0].UserID)
 }
 
 func testSuccessfulLogin(t *testing.T, cfg *TestConfig) {
@@ -163,9 +198,12 @@ func testSuccessfulLogin(t *testing.T, cfg *TestConfig) {
 	user, err := cfg.UserRepo.GetByUsername(cfg.Ctx, payload.Username)
 	require.NoError(t, err)
 
-	newSession, err := cfg.SessionRepo.Check(cfg.Ctx, user.ID)
+	sessions, err := cfg.SessionRepo.GetByUserID(cfg.Ctx, user.ID)
 	require.NoError(t, err)
-	assert.True(t, newSession.AccessExpiresAt.After(time.Now()))
+	require.Len(t, sessions, 2)
+	for _, session := range sessions {
+		assert.True(t, session.AccessExpiresAt.After(time.Now()))
+	}
 }
 
 func testInvalidPassword(t *testing.T, cfg *TestConfig) {
@@ -207,7 +245,91 @@ func testDuplicateRegistration(t *testing.T, cfg *TestConfig) {
 	json.Unmarshal(rr.Body.Bytes(), &jwtResp)
 	secondToken := jwtResp["token"]
 
-	assert.Equal(t, firstToken, secondToken)
+	assert.NotEqual(t, firstToken, secondToken)
+}
+
+func testExplicitRegistration(t *testing.T, cfg *TestConfig) {
+	payload := dto.RegisterRequest{
+		AuthRequest: dto.AuthRequest{
+			Username: "registered_user",
+			Password: "registeredPass123",
+		},
+		InviteCode: "welcome",
+	}
+
+	rr := sendRegisterRequest(cfg, payload)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assertValidAuthResponse(t, rr)
+	assertSessionCookies(t, rr)
+
+	user, err := cfg.UserRepo.GetByUsername(cfg.Ctx, payload.Username)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.UserConfig.InitCoinsBalance, user.Coins)
+
+	rr = sendRegisterRequest(cfg, payload)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = sendAuthRequest(cfg, payload.AuthRequest)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	payload.Username = "uninvited_user"
+	payload.InviteCode = "wrong"
+	rr = sendRegisterRequest(cfg, payload)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	_, err = cfg.UserRepo.GetByUsername(cfg.Ctx, payload.Username)
+	assert.Equal(t, userEntity.ErrIsNotExist, err)
+}
+
+func testLoginLockout(t *testing.T, cfg *TestConfig) {
+	payload := dto.AuthRequest{
+		Username: "locked_user",
+		Password: "correctPassword",
+	}
+	rr := sendAuthRequest(cfg, payload)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	invalidPayload := dto.AuthRequest{
+		Username: "locked_user",
+		Password: "wrongPassword",
+	}
+	for i := 0; i < 3; i++ {
+		rr = sendAuthRequest(cfg, invalidPayload)
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	}
+
+	rr = sendAuthRequest(cfg, payload)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+
+	unlock := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		cfg.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	token := authUser(t, cfg, "lockout_employee", "password")
+	authUser(t, cfg, "lockout_admin", "password")
+	_, err := DB.Exec("UPDATE users SET role = 'admin' WHERE username = $1", "lockout_admin")
+	require.NoError(t, err)
+	adminToken := authUser(t, cfg, "lockout_admin", "password")
+
+	rr = unlock("/api/admin/lockouts/users/locked_user", token)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = unlock("/api/admin/lockouts/ips/not-an-ip", adminToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = unlock("/api/admin/lockouts/ips/192.0.2.1", adminToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = unlock("/api/admin/lockouts/users/locked_user", adminToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = sendAuthRequest(cfg, payload)
+	assert.Equal(t, http.StatusOK, rr.Code)
 }
 
 func testInvalidRequestFormat(t *testing.T, cfg *TestConfig) {
@@ -284,6 +406,133 @@ func testTokenValidation(t *testing.T, cfg *TestConfig) {
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
 
+func testRefreshRotation(t *testing.T, cfg *TestConfig) {
+	payload := dto.AuthRequest{
+		Username: "refresh_user",
+		Password: "refreshPass123",
+	}
+	rr := sendAuthRequest(cfg, payload)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var refreshToken string
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == "refresh_token" {
+			refreshToken = cookie.Value
+		}
+	}
+	require.NotEmpty(t, refreshToken)
+
+	rr = sendRefreshRequest(cfg, refreshToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assertValidAuthResponse(t, rr)
+	assertSessionCookies(t, rr)
+
+	rr = sendRefreshRequest(cfg, refreshToken)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func testLogout(t *testing.T, cfg *TestConfig) {
+	payload := dto.AuthRequest{
+		Username: "logout_user",
+		Password: "logoutPass123",
+	}
+	rr := sendAuthRequest(cfg, payload)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response map[string]string
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	accessToken := response["token"]
+
+	req, _ := http.NewRequest("POST", "/api/auth/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	rr = httptest.NewRecorder()
+	cfg.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, _ = http.NewRequest("GET", "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	rr = httptest.NewRecorder()
+	cfg.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func testSessionsManagement(t *testing.T, cfg *TestConfig) {
+	payload := dto.AuthRequest{
+		Username: "multi_device_user",
+		Password: "devicePass123",
+	}
+
+	var tokens []string
+	for _, userAgent := range []string{"laptop-browser", "phone-app"} {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/api/auth", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+		rr := httptest.NewRecorder()
+		cfg.Router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response dto.AuthResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		tokens = append(tokens, response.Token)
+	}
+	laptopToken, phoneToken := tokens[0], tokens[1]
+
+	req, _ := http.NewRequest("GET", "/api/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+phoneToken)
+	rr := httptest.NewRecorder()
+	cfg.Router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var sessions []dto.SessionResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &sessions))
+	require.Len(t, sessions, 2)
+
+	var laptopSessionID string
+	for _, session := range sessions {
+		if session.UserAgent == "laptop-browser" {
+			laptopSessionID = session.ID
+			assert.False(t, session.Current)
+		} else {
+			assert.True(t, session.Current)
+		}
+	}
+	require.NotEmpty(t, laptopSessionID)
+
+	req, _ = http.NewRequest("DELETE", "/api/sessions/"+laptopSessionID, nil)
+	req.Header.Set("Authorization", "Bearer "+phoneToken)
+	rr = httptest.NewRecorder()
+	cfg.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, _ = http.NewRequest("GET", "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+laptopToken)
+	rr = httptest.NewRecorder()
+	cfg.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req, _ = http.NewRequest("GET", "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+phoneToken)
+	rr = httptest.NewRecorder()
+	cfg.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, _ = http.NewRequest("DELETE", "/api/sessions/"+laptopSessionID, nil)
+	req.Header.Set("Authorization", "Bearer "+phoneToken)
+	rr = httptest.NewRecorder()
+	cfg.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func sendRefreshRequest(cfg *TestConfig, refreshToken string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(dto.RefreshRequest{RefreshToken: refreshToken})
+	req, _ := http.NewRequest("POST", "/api/auth/refresh", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	cfg.Router.ServeHTTP(rr, req)
+	return rr
+}
+
 func sendAuthRequest(cfg *TestConfig, payload dto.AuthRequest) *httptest.ResponseRecorder {
 	body, _ := json.Marshal(payload)
 	req, _ := http.NewRequest("POST", "/api/auth", bytes.NewBuffer(body))
@@ -293,6 +542,15 @@ func sendAuthRequest(cfg *TestConfig, payload dto.AuthRequest) *httptest.Respons
 	return rr
 }
 
+func sendRegisterRequest(cfg *TestConfig, payload dto.RegisterRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/api/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	cfg.Router.ServeHTTP(rr, req)
+	return rr
+}
+
 func assertValidAuthResponse(t *testing.T, rr *httptest.ResponseRecorder) {
 	var response dto.AuthResponse
 	err := json.Unmarshal(rr.Body.Bytes(), &response)
@@ -332,9 +590,15 @@ type TestConfig struct {
 
 func setupTestEnvironment() *TestConfig {
 	userRepo := userRepo.NewUserPostgresRepository(DB, logrus.New())
+	loginAttemptRepo := sessionRepo.NewLoginAttemptRedisRepository(RedisClient, logrus.New())
 	sessionRepo := sessionRepo.NewSessionRedisRepository(RedisClient, logrus.New())
 	purchaseRepo := purchaseRepo.NewPurchasePostgresRepository(DB, logrus.New())
 	transactionRepo := transactionRepo.NewTransactionPostgresRepository(DB, logrus.New())
+	idempotencyRepo := idempotencyRepo.NewIdempotencyPostgresRepository(DB, logrus.New())
+	promoRepo := promoRepo.NewPromoPostgresRepository(DB, logrus.New())
+	ledgerRepo := ledgerRepo.NewLedgerPostgresRepository(DB, logrus.New())
+	adjustmentRepo := adjustmentRepo.NewAdjustmentPostgresRepository(DB, logrus.New())
+	lotRepo := lotRepo.NewLotPostgresRepository(DB, logrus.New())
 
 	uowFactory := uow.NewFactory(DB)
 
@@ -344,56 +608,232 @@ func setupTestEnvironment() *TestConfig {
 			AccessTokenExpiration:  "1h",
 			RefreshTokenExpiration: "24h",
 		},
+		// Most scenarios rely on the first login creating the user.
+		Registration: config.RegistrationConfig{
+			AutoSignup: true,
+			InviteCode: "welcome",
+		},
+		// Every request in these tests comes from the same address, so
+		// only usernames are locked out.
+		Lockout: config.LockoutConfig{
+			MaxAttempts:  3,
+			ResetAfter:   "1h",
+			BaseDuration: "30s",
+			MaxDuration:  "5m",
+		},
 	}
 
+	expiryCfg := config.ExpiryConfig{CoinTTL: "8760h", WarningWindow: "720h"}
+
 	validator := validator.New()
 
 	sessionUC := sessionUsecase.NewSessionUsecase(
 		sessionRepo,
+		loginAttemptRepo,
 		userRepo,
+		ledgerRepo,
+		lotRepo,
+		uowFactory,
 		cfg,
+		expiryCfg,
 		logrus.New(),
 	)
 	transactionUC := transactionUsecase.NewTransactionUsecase(
 		transactionRepo,
 		userRepo,
+		idempotencyRepo,
+		ledgerRepo,
+		lotRepo,
 		uowFactory,
 		logrus.New(),
 	)
 	purchaseUC := purchaseUsecase.NewPurchaseUsecase(
 		purchaseRepo,
 		userRepo,
+		idempotencyRepo,
+		promoRepo,
+		ledgerRepo,
+		lotRepo,
 		uowFactory,
+		config.PurchaseConfig{CatalogCacheTTL: "1m", RefundWindow: "24h"},
+		logrus.New(),
+	)
+	promoUC := promoUsecase.NewPromoUsecase(
+		promoRepo,
+		logrus.New(),
+	)
+	ledgerUC := ledgerUsecase.NewLedgerUsecase(
+		ledgerRepo,
+		logrus.New(),
+	)
+	adjustmentUC := adjustmentUsecase.NewAdjustmentUsecase(
+		adjustmentRepo,
+		userRepo,
+		ledgerRepo,
+		lotRepo,
+		uowFactory,
+		expiryCfg,
+		logrus.New(),
+	)
+	idempotencyUC := idempotencyUsecase.NewIdempotencyUsecase(
+		idempotencyRepo,
+		config.IdempotencyConfig{},
 		logrus.New(),
 	)
 	userUC := userUsecase.NewUserUsecase(
 		purchaseRepo,
 		transactionRepo,
+		adjustmentRepo,
+		lotRepo,
 		userRepo,
+		expiryCfg,
 		logrus.New(),
 	)
 
 	router := mux.NewRouter()
 
-	authHandler := sessionDelivery.NewSessionHandler(sessionUC, validator, logrus.New())
-	transactionHandler := transactionDelivery.NewTransactionHandler(transactionUC, validator, logrus.New())
-	purchaseHandler := purchaseDelivery.NewPurchaseHandler(purchaseUC, validator, logrus.New())
+	authHandler := sessionDelivery.NewSessionHandler(sessionUC, validator, cfg.Lockout, logrus.New())
+	idempotencyHandler := idempotencyDelivery.NewIdempotencyHandler(idempotencyUC, logrus.New())
+	transactionHandler := transactionDelivery.NewTransactionHandler(transactionUC, idempotencyHandler, validator, logrus.New())
+	purchaseHandler := purchaseDelivery.NewPurchaseHandler(purchaseUC, idempotencyHandler, validator, logrus.New())
+	promoHandler := promoDelivery.NewPromoHandler(promoUC, validator, logrus.New())
+	ledgerHandler := ledgerDelivery.NewLedgerHandler(ledgerUC, logrus.New())
+	adjustmentHandler := adjustmentDelivery.NewAdjustmentHandler(adjustmentUC, validator, logrus.New())
 	userHandler := userDelivery.NewUserHandler(userUC, logrus.New())
 
 	router.Handle("/api/auth",
 		http.HandlerFunc(authHandler.Auth)).Methods("POST")
 
+	router.Handle("/api/register",
+		http.HandlerFunc(authHandler.Register)).Methods("POST")
+
+	router.Handle("/api/auth/refresh",
+		http.HandlerFunc(authHandler.Refresh)).Methods("POST")
+
+	router.Handle("/api/auth/logout",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(authHandler.Logout), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/auth/logout/all",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(authHandler.LogoutAll), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/sessions",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(authHandler.GetSessions), sessionUC, logrus.New())).Methods("GET")
+
+	router.Handle("/api/sessions/{id}",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(authHandler.DeleteSession), sessionUC, logrus.New())).Methods("DELETE")
+
 	router.Handle("/api/sendCoin",
 		middleware.ValidateJWTToken(
-			http.HandlerFunc(transactionHandler.SendCoins), logrus.New())).Methods("POST")
+			http.HandlerFunc(transactionHandler.SendCoins), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/items",
+		http.HandlerFunc(purchaseHandler.GetItems)).Methods("GET")
+
+	router.Handle("/api/items/{name}",
+		http.HandlerFunc(purchaseHandler.GetItem)).Methods("GET")
+
+	router.Handle("/api/admin/items",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.CreateItem), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/admin/items/{name}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.UpdateItem), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("PATCH")
+
+	router.Handle("/api/admin/items/{name}/restock",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.RestockItem), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/admin/items/{name}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.RetireItem), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("DELETE")
 
 	router.Handle("/api/buy/{item}",
 		middleware.ValidateJWTToken(
-			http.HandlerFunc(purchaseHandler.BuyItem), logrus.New())).Methods("GET")
+			http.HandlerFunc(purchaseHandler.BuyItem), sessionUC, logrus.New())).Methods("GET")
+
+	router.Handle("/api/gift",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(purchaseHandler.GiftItem), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/purchases",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(purchaseHandler.Checkout), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/purchases",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(purchaseHandler.GetPurchases), sessionUC, logrus.New())).Methods("GET")
+
+	router.Handle("/api/purchases/{id}/refund",
+		middleware.ValidateJWTToken(
+			http.HandlerFunc(purchaseHandler.RefundPurchase), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/admin/purchases/{id}/refund",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.AdminRefundPurchase), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/admin/purchases",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.AdminGetPurchases), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("GET")
+
+	router.Handle("/api/admin/purchases/{id}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(purchaseHandler.UpdatePurchaseStatus), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("PATCH")
+
+	router.Handle("/api/admin/promos",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(promoHandler.CreatePromoCode), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/admin/promos",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(promoHandler.GetPromoCodes), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("GET")
+
+	router.Handle("/api/admin/promos/{code}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(promoHandler.DeactivatePromoCode), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("DELETE")
+
+	router.Handle("/api/admin/ledger/reconciliation",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(ledgerHandler.GetReconciliation), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("GET")
+
+	router.Handle("/api/admin/users/{username}/credit",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(adjustmentHandler.CreditBalance), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/admin/users/{username}/debit",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(adjustmentHandler.DebitBalance), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("POST")
+
+	router.Handle("/api/admin/lockouts/users/{username}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(authHandler.UnlockUser), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("DELETE")
+
+	router.Handle("/api/admin/lockouts/ips/{ip}",
+		middleware.ValidateJWTToken(
+			middleware.RequireRole(
+				http.HandlerFunc(authHandler.UnlockIP), logrus.New(), userEntity.RoleAdmin), sessionUC, logrus.New())).Methods("DELETE")
 
 	router.Handle("/api/info",
 		middleware.ValidateJWTToken(
-			http.HandlerFunc(userHandler.GetInfo), logrus.New())).Methods("GET")
+			http.HandlerFunc(userHandler.GetInfo), sessionUC, logrus.New())).Methods("GET")
 
 	return &TestConfig{
 		Router:          router,
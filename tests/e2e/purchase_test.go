@@ -2,11 +2,14 @@ package e2e
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPurchaseScenarios(t *testing.T) {
@@ -31,6 +34,26 @@ func TestPurchaseScenarios(t *testing.T) {
 		assert.Equal(t, initialBalance-200, getBalance(t, cfg, token))
 	})
 
+	t.Run("Retried purchase with idempotency key", func(t *testing.T) {
+		token := authUser(t, cfg, "retry_buyer", "password")
+		initialBalance := getBalance(t, cfg, token)
+
+		buy := func(item string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/buy/"+item, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Idempotency-Key", "purchase-1")
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		assert.Equal(t, http.StatusOK, buy("powerbank").Code)
+		assert.Equal(t, http.StatusOK, buy("powerbank").Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, buy("pink-hoody").Code)
+
+		assert.Equal(t, initialBalance-200, getBalance(t, cfg, token))
+	})
+
 	t.Run("Insufficient balance", func(t *testing.T) {
 		token := authUser(t, cfg, "poor_buyer", "password")
 		initialBalance := getBalance(t, cfg, token)
@@ -161,4 +184,536 @@ func TestPurchaseScenarios(t *testing.T) {
 		assert.Contains(t, []uint{1, 2}, infoResponse.Inventory[0].Quantity)
 		assert.Contains(t, []uint{1, 2}, infoResponse.Inventory[1].Quantity)
 	})
+	t.Run("Basket checkout", func(t *testing.T) {
+		token := authUser(t, cfg, "basket_buyer", "password")
+		initialBalance := getBalance(t, cfg, token)
+
+		checkout := func(body, key string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/api/purchases", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			if key != "" {
+				req.Header.Set("Idempotency-Key", key)
+			}
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		body := `{"items":[{"item":"pen","quantity":5},{"item":"cup","quantity":2}]}`
+		rr := checkout(body, "basket-1")
+		require.Equal(t, http.StatusOK, rr.Code)
+		receipt := `{"items":[{"item":"cup","quantity":2,"price":20},{"item":"pen","quantity":5,"price":10}],"total":90}`
+		assert.JSONEq(t, receipt, rr.Body.String())
+
+		rr = checkout(body, "basket-1")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+		assert.JSONEq(t, receipt, rr.Body.String())
+
+		assert.Equal(t, initialBalance-90, getBalance(t, cfg, token))
+
+		rr = checkout(`{"items":[{"item":"pen","quantity":1},{"item":"pink-hoody","quantity":2}]}`, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, initialBalance-90, getBalance(t, cfg, token))
+
+		rr = checkout(`{"items":[{"item":"pen","quantity":1},{"item":"unknown","quantity":1}]}`, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = checkout(`{"items":[]}`, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = checkout(`{"items":[{"item":"pen","quantity":0}]}`, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Merch catalog", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/items", nil)
+		rr := httptest.NewRecorder()
+		cfg.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var catalogResponse struct {
+			Items []struct {
+				Name      string `json:"name"`
+				Cost      uint   `json:"cost"`
+				Available bool   `json:"available"`
+			} `json:"items"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &catalogResponse)
+
+		assert.NotEmpty(t, catalogResponse.Items)
+		costs := map[string]uint{}
+		for _, item := range catalogResponse.Items {
+			assert.True(t, item.Available)
+			costs[item.Name] = item.Cost
+		}
+		assert.Equal(t, uint(200), costs["powerbank"])
+		assert.Equal(t, uint(500), costs["pink-hoody"])
+
+		req = httptest.NewRequest("GET", "/api/items/powerbank", nil)
+		rr = httptest.NewRecorder()
+		cfg.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"name":"powerbank","cost":200,"available":true}`, rr.Body.String())
+
+		req = httptest.NewRequest("GET", "/api/items/unknown-item", nil)
+		rr = httptest.NewRecorder()
+		cfg.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+	t.Run("Admin catalog management", func(t *testing.T) {
+		employeeToken := authUser(t, cfg, "catalog_employee", "password")
+		staleToken := authUser(t, cfg, "catalog_admin", "password")
+		_, err := DB.Exec("UPDATE users SET role = 'admin' WHERE username = $1", "catalog_admin")
+		require.NoError(t, err)
+		adminToken := authUser(t, cfg, "catalog_admin", "password")
+
+		adminRequest := func(method, path, token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := adminRequest("POST", "/api/admin/items", employeeToken, `{"name":"e2e-sticker","cost":15}`)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = adminRequest("POST", "/api/admin/items", staleToken, `{"name":"e2e-sticker","cost":15}`)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = adminRequest("POST", "/api/admin/items", adminToken, `{"name":"e2e-sticker","cost":15}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.JSONEq(t, `{"name":"e2e-sticker","cost":15,"available":true}`, rr.Body.String())
+
+		rr = adminRequest("POST", "/api/admin/items", adminToken, `{"name":"e2e-sticker","cost":15}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = adminRequest("POST", "/api/admin/items", adminToken, `{"name":"bad/name","cost":15}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = adminRequest("GET", "/api/buy/e2e-sticker", employeeToken, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		rr = adminRequest("POST", "/api/admin/items", adminToken, `{"name":"e2e-badge","cost":5,"stock":1}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.JSONEq(t, `{"name":"e2e-badge","cost":5,"available":true,"stock":1}`, rr.Body.String())
+
+		rr = adminRequest("GET", "/api/buy/e2e-badge", employeeToken, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		rr = adminRequest("GET", "/api/buy/e2e-badge", employeeToken, "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = adminRequest("POST", "/api/admin/items/e2e-badge/restock", employeeToken, `{"quantity":3}`)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = adminRequest("POST", "/api/admin/items/e2e-badge/restock", adminToken, `{"quantity":0}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = adminRequest("POST", "/api/admin/items/e2e-badge/restock", adminToken, `{"quantity":3}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"name":"e2e-badge","cost":5,"available":true,"stock":3}`, rr.Body.String())
+
+		rr = adminRequest("GET", "/api/buy/e2e-badge", employeeToken, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		rr = adminRequest("PATCH", "/api/admin/items/e2e-sticker", adminToken, `{"name":"e2e-sticker-v2","cost":25}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"name":"e2e-sticker-v2","cost":25,"available":true}`, rr.Body.String())
+
+		rr = adminRequest("GET", "/api/items/e2e-sticker-v2", employeeToken, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = adminRequest("PATCH", "/api/admin/items/e2e-sticker-v2", adminToken, `{}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = adminRequest("DELETE", "/api/admin/items/e2e-sticker-v2", adminToken, "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		rr = adminRequest("DELETE", "/api/admin/items/e2e-sticker-v2", adminToken, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = adminRequest("GET", "/api/items/e2e-sticker-v2", employeeToken, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = adminRequest("GET", "/api/buy/e2e-sticker-v2", employeeToken, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = adminRequest("GET", "/api/info", employeeToken, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"type":"e2e-sticker-v2"`)
+	})
+	t.Run("Purchase history and refunds", func(t *testing.T) {
+		token := authUser(t, cfg, "refund_buyer", "password")
+		strangerToken := authUser(t, cfg, "refund_stranger", "password")
+		authUser(t, cfg, "refund_admin", "password")
+		_, err := DB.Exec("UPDATE users SET role = 'admin' WHERE username = $1", "refund_admin")
+		require.NoError(t, err)
+		adminToken := authUser(t, cfg, "refund_admin", "password")
+		initialBalance := getBalance(t, cfg, token)
+
+		request := func(method, path, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		type historyResponse struct {
+			Purchases []struct {
+				ID         uint    `json:"id"`
+				Item       string  `json:"item"`
+				Price      uint    `json:"price"`
+				RefundedAt *string `json:"refundedAt"`
+			} `json:"purchases"`
+			NextCursor string `json:"nextCursor"`
+		}
+		getHistory := func(query string) historyResponse {
+			rr := request("GET", "/api/purchases"+query, token)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var history historyResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &history))
+			return history
+		}
+
+		require.Equal(t, http.StatusOK, request("GET", "/api/buy/powerbank", token).Code)
+		require.Equal(t, http.StatusOK, request("GET", "/api/buy/powerbank", token).Code)
+
+		history := getHistory("?limit=1")
+		require.Len(t, history.Purchases, 1)
+		require.NotEmpty(t, history.NextCursor)
+		purchaseID := history.Purchases[0].ID
+
+		history = getHistory("?limit=1&cursor=" + history.NextCursor)
+		require.Len(t, history.Purchases, 1)
+		assert.Less(t, history.Purchases[0].ID, purchaseID)
+		assert.Empty(t, history.NextCursor)
+
+		rr := request("GET", "/api/purchases?cursor=bad", token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		refundPath := fmt.Sprintf("/api/purchases/%d/refund", purchaseID)
+
+		rr = request("POST", refundPath, strangerToken)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = request("POST", refundPath, token)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"refundedAt"`)
+
+		rr = request("POST", refundPath, token)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = request("POST", "/api/purchases/abc/refund", token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		assert.Equal(t, initialBalance-200, getBalance(t, cfg, token))
+
+		history = getHistory("")
+		require.Len(t, history.Purchases, 2)
+		assert.Equal(t, uint(200), history.Purchases[0].Price)
+		assert.NotNil(t, history.Purchases[0].RefundedAt)
+		assert.Nil(t, history.Purchases[1].RefundedAt)
+
+		adminRefundPath := fmt.Sprintf("/api/admin/purchases/%d/refund", history.Purchases[1].ID)
+
+		rr = request("POST", adminRefundPath, token)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = request("POST", adminRefundPath, adminToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		assert.Equal(t, initialBalance, getBalance(t, cfg, token))
+	})
+
+	t.Run("Order fulfilment", func(t *testing.T) {
+		token := authUser(t, cfg, "fulfilment_buyer", "password")
+		authUser(t, cfg, "fulfilment_admin", "password")
+		_, err := DB.Exec("UPDATE users SET role = 'admin' WHERE username = $1", "fulfilment_admin")
+		require.NoError(t, err)
+		adminToken := authUser(t, cfg, "fulfilment_admin", "password")
+		initialBalance := getBalance(t, cfg, token)
+
+		request := func(method, path, token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		type order struct {
+			ID        uint   `json:"id"`
+			Purchaser string `json:"purchaser"`
+			Status    string `json:"status"`
+		}
+		type ordersResponse struct {
+			Purchases []order `json:"purchases"`
+		}
+		getOrders := func(path, token string) ordersResponse {
+			rr := request("GET", path, token, "")
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var orders ordersResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &orders))
+			return orders
+		}
+
+		require.Equal(t, http.StatusOK, request("GET", "/api/buy/powerbank", token, "").Code)
+
+		orders := getOrders("/api/purchases?status=pending", token)
+		require.Len(t, orders.Purchases, 1)
+		orderID := orders.Purchases[0].ID
+		orderPath := fmt.Sprintf("/api/admin/purchases/%d", orderID)
+
+		rr := request("GET", "/api/admin/purchases?status=pending", token, "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = request("GET", "/api/admin/purchases?status=unknown", adminToken, "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		queue := getOrders("/api/admin/purchases?status=pending", adminToken)
+		assert.Contains(t, queue.Purchases, order{ID: orderID, Purchaser: "fulfilment_buyer", Status: "pending"})
+
+		rr = request("PATCH", orderPath, token, `{"status":"ready"}`)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = request("PATCH", orderPath, adminToken, `{"status":"shipped"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("PATCH", orderPath, adminToken, `{"status":"delivered"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = request("PATCH", orderPath, adminToken, `{"status":"ready"}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"status":"ready"`)
+
+		rr = request("PATCH", orderPath, adminToken, `{"status":"cancelled"}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"status":"cancelled"`)
+
+		rr = request("PATCH", orderPath, adminToken, `{"status":"cancelled"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		assert.Equal(t, initialBalance, getBalance(t, cfg, token))
+
+		orders = getOrders("/api/purchases", token)
+		require.Len(t, orders.Purchases, 1)
+		assert.Equal(t, "cancelled", orders.Purchases[0].Status)
+	})
+
+	t.Run("Promo codes", func(t *testing.T) {
+		_, err := DB.Exec("DELETE FROM promo_codes WHERE code = $1", "HOODIE20")
+		require.NoError(t, err)
+
+		token := authUser(t, cfg, "promo_buyer", "password")
+		authUser(t, cfg, "promo_admin", "password")
+		_, err = DB.Exec("UPDATE users SET role = 'admin' WHERE username = $1", "promo_admin")
+		require.NoError(t, err)
+		adminToken := authUser(t, cfg, "promo_admin", "password")
+		initialBalance := getBalance(t, cfg, token)
+
+		request := func(method, path, token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		promoBody := `{"code":"hoodie20","discountType":"percent","discountValue":20,"item":"pink-hoody","maxPerUser":1}`
+
+		rr := request("POST", "/api/admin/promos", token, promoBody)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = request("POST", "/api/admin/promos", adminToken, `{"code":"HALF","discountType":"percent","discountValue":150}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("POST", "/api/admin/promos", adminToken, promoBody)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"code":"HOODIE20"`)
+
+		rr = request("POST", "/api/admin/promos", adminToken, promoBody)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = request("GET", "/api/buy/powerbank?promo=hoodie20", token, "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = request("GET", "/api/buy/pink-hoody?promo=UNKNOWN", token, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = request("GET", "/api/buy/pink-hoody?promo=hoodie20", token, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, initialBalance-400, getBalance(t, cfg, token))
+
+		rr = request("GET", "/api/buy/pink-hoody?promo=hoodie20", token, "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = request("GET", "/api/purchases", token, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"discount":100`)
+
+		rr = request("GET", "/api/admin/promos", adminToken, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"redemptions":1`)
+
+		rr = request("DELETE", "/api/admin/promos/hoodie20", adminToken, "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		rr = request("DELETE", "/api/admin/promos/hoodie20", adminToken, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Per-user purchase limits", func(t *testing.T) {
+		token := authUser(t, cfg, "limit_buyer", "password")
+		authUser(t, cfg, "limit_admin", "password")
+		_, err := DB.Exec("UPDATE users SET role = 'admin' WHERE username = $1", "limit_admin")
+		require.NoError(t, err)
+		adminToken := authUser(t, cfg, "limit_admin", "password")
+		initialBalance := getBalance(t, cfg, token)
+
+		request := func(method, path, token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := request("POST", "/api/admin/items", adminToken,
+			`{"name":"e2e-mug","cost":10,"userLimit":2,"userLimitPeriod":"week"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("POST", "/api/admin/items", adminToken,
+			`{"name":"e2e-mug","cost":10,"userLimit":2,"userLimitPeriod":"2160h"}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.JSONEq(t,
+			`{"name":"e2e-mug","cost":10,"available":true,"userLimit":2,"userLimitPeriod":"2160h0m0s"}`,
+			rr.Body.String())
+
+		require.Equal(t, http.StatusOK, request("GET", "/api/buy/e2e-mug", token, "").Code)
+
+		rr = request("POST", "/api/purchases", token, `{"items":[{"item":"e2e-mug","quantity":2}]}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), "purchase limit")
+
+		require.Equal(t, http.StatusOK, request("GET", "/api/buy/e2e-mug", token, "").Code)
+
+		rr = request("GET", "/api/buy/e2e-mug", token, "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Equal(t, initialBalance-20, getBalance(t, cfg, token))
+
+		rr = request("PATCH", "/api/admin/items/e2e-mug", adminToken, `{"userLimit":0}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"name":"e2e-mug","cost":10,"available":true}`, rr.Body.String())
+
+		require.Equal(t, http.StatusOK, request("GET", "/api/buy/e2e-mug", token, "").Code)
+
+		rr = request("DELETE", "/api/admin/items/e2e-mug", adminToken, "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("Gifts", func(t *testing.T) {
+		token := authUser(t, cfg, "gift_giver", "password")
+		recipientToken := authUser(t, cfg, "gift_recipient", "password")
+		initialBalance := getBalance(t, cfg, token)
+		recipientBalance := getBalance(t, cfg, recipientToken)
+
+		request := func(method, path, token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := request("POST", "/api/gift", token, `{"toUser":"gift_giver","item":"powerbank"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("POST", "/api/gift", token, `{"toUser":"gift_nobody","item":"powerbank"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "can't find such user")
+
+		rr = request("POST", "/api/gift", token, `{"toUser":"gift_recipient","item":"no-such-item"}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = request("POST", "/api/gift", token, `{"toUser":"gift_recipient","item":"powerbank"}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		assert.Equal(t, initialBalance-200, getBalance(t, cfg, token))
+		assert.Equal(t, recipientBalance, getBalance(t, cfg, recipientToken))
+
+		rr = request("GET", "/api/info", recipientToken, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var infoResponse struct {
+			Inventory []struct {
+				PurchaseTypeName string `json:"type"`
+				Quantity         uint   `json:"quantity"`
+			} `json:"inventory"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &infoResponse))
+		require.Len(t, infoResponse.Inventory, 1)
+		assert.Equal(t, "powerbank", infoResponse.Inventory[0].PurchaseTypeName)
+
+		for _, userToken := range []string{token, recipientToken} {
+			rr = request("GET", "/api/purchases", userToken, "")
+			require.Equal(t, http.StatusOK, rr.Code)
+			var history struct {
+				Purchases []struct {
+					Purchaser string `json:"purchaser"`
+					Recipient string `json:"recipient"`
+					Item      string `json:"item"`
+				} `json:"purchases"`
+			}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &history))
+			require.Len(t, history.Purchases, 1)
+			assert.Equal(t, "gift_giver", history.Purchases[0].Purchaser)
+			assert.Equal(t, "gift_recipient", history.Purchases[0].Recipient)
+			assert.Equal(t, "powerbank", history.Purchases[0].Item)
+		}
+	})
+
+	t.Run("Ledger reconciliation", func(t *testing.T) {
+		token := authUser(t, cfg, "ledger_user", "password")
+		authUser(t, cfg, "ledger_admin", "password")
+		_, err := DB.Exec("UPDATE users SET role = 'admin' WHERE username = $1", "ledger_admin")
+		require.NoError(t, err)
+		adminToken := authUser(t, cfg, "ledger_admin", "password")
+
+		request := func(token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/admin/ledger/reconciliation", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		assert.Equal(t, http.StatusForbidden, request(token).Code)
+
+		rr := request(adminToken)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var reconciliation struct {
+			Consistent bool `json:"consistent"`
+			Mismatches []struct {
+				Username string `json:"username"`
+			} `json:"mismatches"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &reconciliation))
+		assert.True(t, reconciliation.Consistent)
+		assert.Empty(t, reconciliation.Mismatches)
+	})
 }
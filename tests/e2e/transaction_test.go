@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,6 +41,44 @@ func TestTransactionScenarios(t *testing.T) {
 		assert.Equal(t, receiverBalanceBefore+100, getBalance(t, cfg, receiverToken))
 	})
 
+	t.Run("Retried transfer with idempotency key", func(t *testing.T) {
+		senderToken := authUser(t, cfg, "retry_sender", "password")
+		receiverToken := authUser(t, cfg, "retry_receiver", "password")
+
+		senderBalanceBefore := getBalance(t, cfg, senderToken)
+		receiverBalanceBefore := getBalance(t, cfg, receiverToken)
+
+		sendCoins := func(amount int) *httptest.ResponseRecorder {
+			payload := map[string]interface{}{
+				"toUser": "retry_receiver",
+				"amount": amount,
+			}
+			body, _ := json.Marshal(payload)
+
+			req := httptest.NewRequest("POST", "/api/sendCoin", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer "+senderToken)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Idempotency-Key", "transfer-1")
+
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := sendCoins(100)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = sendCoins(100)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+
+		rr = sendCoins(200)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+		assert.Equal(t, senderBalanceBefore-100, getBalance(t, cfg, senderToken))
+		assert.Equal(t, receiverBalanceBefore+100, getBalance(t, cfg, receiverToken))
+	})
+
 	t.Run("Insufficient balance", func(t *testing.T) {
 		senderToken := authUser(t, cfg, "poor_user", "password")
 		_ = authUser(t, cfg, "another_user", "password")
@@ -138,6 +177,194 @@ func TestTransactionScenarios(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("Transfer with message", func(t *testing.T) {
+		senderToken := authUser(t, cfg, "memo_sender", "password")
+		receiverToken := authUser(t, cfg, "memo_receiver", "password")
+
+		sendCoins := func(message string) *httptest.ResponseRecorder {
+			payload := map[string]interface{}{
+				"toUser":  "memo_receiver",
+				"amount":  10,
+				"message": message,
+			}
+			body, _ := json.Marshal(payload)
+
+			req := httptest.NewRequest("POST", "/api/sendCoin", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer "+senderToken)
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := sendCoins("  thanks for the review  ")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		rr = sendCoins(strings.Repeat("a", 201))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = sendCoins("bad\x00message")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		req := httptest.NewRequest("GET", "/api/transactions?direction=received", nil)
+		req.Header.Set("Authorization", "Bearer "+receiverToken)
+
+		rr = httptest.NewRecorder()
+		cfg.Router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Transactions []struct {
+				Counterparty string `json:"counterparty"`
+				Message      string `json:"message"`
+			} `json:"transactions"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Len(t, response.Transactions, 1)
+		assert.Equal(t, "memo_sender", response.Transactions[0].Counterparty)
+		assert.Equal(t, "thanks for the review", response.Transactions[0].Message)
+	})
+
+	t.Run("Transaction history", func(t *testing.T) {
+		senderToken := authUser(t, cfg, "history_sender", "password")
+		_ = authUser(t, cfg, "history_receiver", "password")
+
+		for _, amount := range []int{10, 20, 30} {
+			payload := map[string]interface{}{
+				"toUser": "history_receiver",
+				"amount": amount,
+			}
+			body, _ := json.Marshal(payload)
+
+			req := httptest.NewRequest("POST", "/api/sendCoin", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer "+senderToken)
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			require.Equal(t, http.StatusOK, rr.Code)
+		}
+
+		getHistory := func(query string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/transactions"+query, nil)
+			req.Header.Set("Authorization", "Bearer "+senderToken)
+
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		type historyResponse struct {
+			Transactions []struct {
+				ID           uint   `json:"id"`
+				Direction    string `json:"direction"`
+				Counterparty string `json:"counterparty"`
+				Amount       uint   `json:"amount"`
+				CreatedAt    string `json:"createdAt"`
+			} `json:"transactions"`
+			NextCursor string `json:"nextCursor"`
+		}
+
+		rr := getHistory("?direction=sent&counterparty=history_receiver&limit=2")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var firstPage historyResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &firstPage))
+		require.Len(t, firstPage.Transactions, 2)
+		assert.Equal(t, uint(30), firstPage.Transactions[0].Amount)
+		assert.Equal(t, "sent", firstPage.Transactions[0].Direction)
+		assert.Equal(t, "history_receiver", firstPage.Transactions[0].Counterparty)
+		assert.NotZero(t, firstPage.Transactions[0].ID)
+		assert.NotEmpty(t, firstPage.Transactions[0].CreatedAt)
+		require.NotEmpty(t, firstPage.NextCursor)
+
+		rr = getHistory("?direction=sent&counterparty=history_receiver&limit=2&cursor=" + firstPage.NextCursor)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var secondPage historyResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &secondPage))
+		require.Len(t, secondPage.Transactions, 1)
+		assert.Equal(t, uint(10), secondPage.Transactions[0].Amount)
+		assert.Empty(t, secondPage.NextCursor)
+
+		for _, query := range []string{"?direction=sideways", "?limit=0", "?from=yesterday", "?cursor=broken"} {
+			rr = getHistory(query)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+
+	t.Run("Admin balance adjustments", func(t *testing.T) {
+		token := authUser(t, cfg, "adjusted_user", "password")
+		authUser(t, cfg, "adjustment_admin", "password")
+		_, err := DB.Exec("UPDATE users SET role = 'admin' WHERE username = $1", "adjustment_admin")
+		require.NoError(t, err)
+		adminToken := authUser(t, cfg, "adjustment_admin", "password")
+		initialBalance := getBalance(t, cfg, token)
+
+		request := func(path, token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", path, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			cfg.Router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := request("/api/admin/users/adjusted_user/credit", token, `{"amount": 100, "reason": "self bonus"}`)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		rr = request("/api/admin/users/adjusted_user/credit", adminToken, `{"amount": 100, "reason": "  "}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("/api/admin/users/adjusted_user/credit", adminToken, `{"amount": 0, "reason": "bonus"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("/api/admin/users/adjusted_nobody/credit", adminToken, `{"amount": 100, "reason": "bonus"}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = request("/api/admin/users/adjusted_user/credit", adminToken, `{"amount": 500, "reason": "hackathon prize"}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var adjustment struct {
+			Username string `json:"username"`
+			Amount   int64  `json:"amount"`
+			Reason   string `json:"reason"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &adjustment))
+		assert.Equal(t, "adjusted_user", adjustment.Username)
+		assert.Equal(t, int64(500), adjustment.Amount)
+		assert.Equal(t, "hackathon prize", adjustment.Reason)
+
+		rr = request("/api/admin/users/adjusted_user/debit", adminToken, `{"amount": 100000, "reason": "fix"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = request("/api/admin/users/adjusted_user/debit", adminToken, `{"amount": 200, "reason": "duplicate bonus"}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		assert.Equal(t, initialBalance+300, getBalance(t, cfg, token))
+
+		req := httptest.NewRequest("GET", "/api/info", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr = httptest.NewRecorder()
+		cfg.Router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var infoResponse struct {
+			CoinHistory struct {
+				Adjustments []struct {
+					Amount int64  `json:"amount"`
+					Reason string `json:"reason"`
+				} `json:"adjustments"`
+			} `json:"coinHistory"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &infoResponse))
+		require.Len(t, infoResponse.CoinHistory.Adjustments, 2)
+		assert.Equal(t, int64(-200), infoResponse.CoinHistory.Adjustments[0].Amount)
+		assert.Equal(t, "duplicate bonus", infoResponse.CoinHistory.Adjustments[0].Reason)
+		assert.Equal(t, int64(500), infoResponse.CoinHistory.Adjustments[1].Amount)
+	})
 }
 
 func authUser(t *testing.T, cfg *TestConfig, username, password string) string {
@@ -0,0 +1,110 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/domain/entity"
+	adjustmentRepo "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/repository/postgres"
+	"github.com/artrsyf/avito-trainee-assignment/internal/adjustment/usecase"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/postgres"
+	ledgerUsecase "github.com/artrsyf/avito-trainee-assignment/internal/ledger/usecase"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/postgres"
+	userRepo "github.com/artrsyf/avito-trainee-assignment/internal/user/repository/postgres"
+	uow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/postgres"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdjustmentUsecase_Integration(t *testing.T) {
+	userRepo := userRepo.NewUserPostgresRepository(DB, logrus.New())
+	adjustmentRepo := adjustmentRepo.NewAdjustmentPostgresRepository(DB, logrus.New())
+	ledgerRepo := ledgerRepo.NewLedgerPostgresRepository(DB, logrus.New())
+	lotRepo := lotRepo.NewLotPostgresRepository(DB, logrus.New())
+
+	uc := usecase.NewAdjustmentUsecase(adjustmentRepo, userRepo, ledgerRepo, lotRepo, uow.NewFactory(DB), config.ExpiryConfig{}, logrus.New())
+	ledgerUC := ledgerUsecase.NewLedgerUsecase(ledgerRepo, logrus.New())
+	ctx := context.Background()
+
+	t.Run("credit and debit reconcile", func(t *testing.T) {
+		SetupTestData(t, DB)
+		adminID := CreateTestUser(t, "admin", 0)
+		userID := CreateTestUser(t, "user1", 1000)
+
+		credited, err := uc.Adjust(ctx, &entity.Adjustment{
+			Username: "user1",
+			AdminID:  adminID,
+			Amount:   500,
+			Reason:   "hackathon prize",
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(500), credited.Amount)
+		require.Equal(t, adminID, credited.AdminID)
+
+		_, err = uc.Adjust(ctx, &entity.Adjustment{
+			Username: "user1",
+			AdminID:  adminID,
+			Amount:   -200,
+			Reason:   "duplicate bonus",
+		})
+		require.NoError(t, err)
+
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, uint(1300), user.Coins)
+
+		history, err := adjustmentRepo.GetHistoryByUserID(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		require.Equal(t, int64(-200), history[0].Amount)
+		require.Equal(t, "duplicate bonus", history[0].Reason)
+		require.Equal(t, int64(500), history[1].Amount)
+
+		var kind string
+		err = DB.QueryRowContext(ctx,
+			"SELECT kind FROM ledger_entries WHERE reference = $1", fmt.Sprintf("adjustment:%d", credited.ID)).Scan(&kind)
+		require.NoError(t, err)
+		require.Equal(t, "adjustment", kind)
+
+		reconciliation, err := ledgerUC.Reconcile(ctx)
+		require.NoError(t, err)
+		require.True(t, reconciliation.IsConsistent())
+	})
+
+	t.Run("debit over balance", func(t *testing.T) {
+		SetupTestData(t, DB)
+		adminID := CreateTestUser(t, "admin", 0)
+		userID := CreateTestUser(t, "user1", 100)
+
+		_, err := uc.Adjust(ctx, &entity.Adjustment{
+			Username: "user1",
+			AdminID:  adminID,
+			Amount:   -200,
+			Reason:   "fix",
+		})
+		require.ErrorIs(t, err, entity.ErrNotEnoughBalance)
+
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, uint(100), user.Coins)
+
+		history, err := adjustmentRepo.GetHistoryByUserID(ctx, userID)
+		require.NoError(t, err)
+		require.Empty(t, history)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		SetupTestData(t, DB)
+		adminID := CreateTestUser(t, "admin", 0)
+
+		_, err := uc.Adjust(ctx, &entity.Adjustment{
+			Username: "nobody",
+			AdminID:  adminID,
+			Amount:   100,
+			Reason:   "bonus",
+		})
+		require.ErrorIs(t, err, entity.ErrUserNotFound)
+	})
+}
@@ -0,0 +1,110 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/domain/entity"
+	allowanceRepo "github.com/artrsyf/avito-trainee-assignment/internal/allowance/repository/postgres"
+	"github.com/artrsyf/avito-trainee-assignment/internal/allowance/usecase"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/postgres"
+	ledgerUsecase "github.com/artrsyf/avito-trainee-assignment/internal/ledger/usecase"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/postgres"
+	userRepo "github.com/artrsyf/avito-trainee-assignment/internal/user/repository/postgres"
+	uow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/postgres"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowanceUsecase_Integration(t *testing.T) {
+	userRepo := userRepo.NewUserPostgresRepository(DB, logrus.New())
+	allowanceRepo := allowanceRepo.NewAllowancePostgresRepository(DB, logrus.New())
+	ledgerRepo := ledgerRepo.NewLedgerPostgresRepository(DB, logrus.New())
+	lotRepo := lotRepo.NewLotPostgresRepository(DB, logrus.New())
+
+	cfg := config.AllowanceConfig{
+		LeaseTTL: "1m",
+		// A batch per user checks that paging doesn't skip anybody.
+		BatchSize: 1,
+		Campaigns: []config.CampaignConfig{
+			{Name: "daily", Amount: 100, Period: entity.PeriodDaily},
+		},
+	}
+	uc := usecase.NewAllowanceUsecase(allowanceRepo, userRepo, ledgerRepo, lotRepo, uow.NewFactory(DB), cfg, config.ExpiryConfig{}, logrus.New())
+	ledgerUC := ledgerUsecase.NewLedgerUsecase(ledgerRepo, logrus.New())
+	ctx := context.Background()
+
+	// Users created by the tests exist before tomorrow's period starts.
+	tomorrow := time.Now().Add(24 * time.Hour)
+
+	requireCoins := func(t *testing.T, userID, coins uint) {
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, coins, user.Coins)
+	}
+
+	t.Run("credits every user once per period", func(t *testing.T) {
+		SetupTestData(t, DB)
+		firstID := CreateTestUser(t, "user1", 1000)
+		secondID := CreateTestUser(t, "user2", 500)
+
+		require.NoError(t, uc.RunDue(ctx, tomorrow))
+		require.NoError(t, uc.RunDue(ctx, tomorrow))
+
+		requireCoins(t, firstID, 1100)
+		requireCoins(t, secondID, 600)
+
+		var creditedUsers int
+		err := DB.QueryRowContext(ctx,
+			"SELECT credited_users FROM allowance_runs WHERE campaign = $1", "daily").Scan(&creditedUsers)
+		require.NoError(t, err)
+		require.Equal(t, 2, creditedUsers)
+
+		reconciliation, err := ledgerUC.Reconcile(ctx)
+		require.NoError(t, err)
+		require.True(t, reconciliation.IsConsistent())
+	})
+
+	t.Run("interrupted run doesn't credit twice", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "user1", 1000)
+
+		require.NoError(t, uc.RunDue(ctx, tomorrow))
+
+		// As if the replica crashed before completing the run.
+		_, err := DB.ExecContext(ctx, "DELETE FROM allowance_runs")
+		require.NoError(t, err)
+
+		require.NoError(t, uc.RunDue(ctx, tomorrow))
+
+		requireCoins(t, userID, 1100)
+	})
+
+	t.Run("users created during the period wait for the next one", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "user1", 1000)
+
+		require.NoError(t, uc.RunDue(ctx, time.Now()))
+
+		requireCoins(t, userID, 1000)
+	})
+
+	t.Run("another replica holds the lease", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "user1", 1000)
+
+		acquired, err := allowanceRepo.AcquireLease(ctx, "allowance", "another-replica", time.Minute)
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		require.NoError(t, uc.RunDue(ctx, tomorrow))
+		requireCoins(t, userID, 1000)
+
+		require.NoError(t, allowanceRepo.ReleaseLease(ctx, "allowance", "another-replica"))
+
+		require.NoError(t, uc.RunDue(ctx, tomorrow))
+		requireCoins(t, userID, 1100)
+	})
+}
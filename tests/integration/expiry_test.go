@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	idempotencyRepo "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository/postgres"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/postgres"
+	ledgerUsecase "github.com/artrsyf/avito-trainee-assignment/internal/ledger/usecase"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/postgres"
+	lotUsecase "github.com/artrsyf/avito-trainee-assignment/internal/lot/usecase"
+	"github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/entity"
+	transactionRepo "github.com/artrsyf/avito-trainee-assignment/internal/transaction/repository/postgres"
+	transactionUsecase "github.com/artrsyf/avito-trainee-assignment/internal/transaction/usecase"
+	userRepo "github.com/artrsyf/avito-trainee-assignment/internal/user/repository/postgres"
+	uow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/postgres"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLotUsecase_Integration(t *testing.T) {
+	userRepo := userRepo.NewUserPostgresRepository(DB, logrus.New())
+	idempotencyRepo := idempotencyRepo.NewIdempotencyPostgresRepository(DB, logrus.New())
+	transactionRepo := transactionRepo.NewTransactionPostgresRepository(DB, logrus.New())
+	ledgerRepo := ledgerRepo.NewLedgerPostgresRepository(DB, logrus.New())
+	lotRepo := lotRepo.NewLotPostgresRepository(DB, logrus.New())
+	uowFactory := uow.NewFactory(DB)
+
+	uc := lotUsecase.NewLotUsecase(lotRepo, userRepo, ledgerRepo, uowFactory, config.ExpiryConfig{BatchSize: 1}, logrus.New())
+	transactionUC := transactionUsecase.NewTransactionUsecase(transactionRepo, userRepo, idempotencyRepo, ledgerRepo, lotRepo, uowFactory, logrus.New())
+	ledgerUC := ledgerUsecase.NewLedgerUsecase(ledgerRepo, logrus.New())
+	ctx := context.Background()
+
+	// grantExpiring gives the user coins that expire at expiresAt.
+	grantExpiring := func(t *testing.T, userID, coins uint, expiresAt time.Time) {
+		_, err := DB.ExecContext(ctx, "UPDATE users SET coins = coins + $2 WHERE id = $1", userID, coins)
+		require.NoError(t, err)
+		GrantLedgerBalance(t, userID, coins)
+		CreateCoinLot(t, userID, coins, &expiresAt)
+	}
+
+	requireCoins := func(t *testing.T, userID, coins uint) {
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, coins, user.Coins)
+	}
+
+	requireConsistent := func(t *testing.T) {
+		reconciliation, err := ledgerUC.Reconcile(ctx)
+		require.NoError(t, err)
+		require.True(t, reconciliation.IsConsistent())
+	}
+
+	t.Run("expired coins are written off once", func(t *testing.T) {
+		SetupTestData(t, DB)
+		firstID := CreateTestUser(t, "user1", 300)
+		secondID := CreateTestUser(t, "user2", 0)
+		grantExpiring(t, firstID, 100, time.Now().Add(time.Hour))
+		grantExpiring(t, secondID, 50, time.Now().Add(time.Hour))
+
+		expiring, err := lotRepo.GetExpiringByUserID(ctx, firstID, time.Now().Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, expiring, 1)
+		require.Equal(t, uint(100), expiring[0].Amount)
+
+		later := time.Now().Add(2 * time.Hour)
+		require.NoError(t, uc.ExpireDue(ctx, later))
+		require.NoError(t, uc.ExpireDue(ctx, later))
+
+		requireCoins(t, firstID, 300)
+		requireCoins(t, secondID, 0)
+		requireConsistent(t)
+	})
+
+	t.Run("transfer passes soonest expiring coins on", func(t *testing.T) {
+		SetupTestData(t, DB)
+		senderID := CreateTestUser(t, "sender", 300)
+		receiverID := CreateTestUser(t, "receiver", 0)
+		grantExpiring(t, senderID, 100, time.Now().Add(time.Hour))
+
+		err := transactionUC.Create(ctx, &entity.Transaction{
+			SenderUsername:   "sender",
+			ReceiverUsername: "receiver",
+			Amount:           150,
+		}, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, uc.ExpireDue(ctx, time.Now().Add(2*time.Hour)))
+
+		// The sender spent the expiring coins, so only the receiver loses them.
+		requireCoins(t, senderID, 250)
+		requireCoins(t, receiverID, 50)
+		requireConsistent(t)
+	})
+}
@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	idempotencyRepo "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository/postgres"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/postgres"
+	ledgerUsecase "github.com/artrsyf/avito-trainee-assignment/internal/ledger/usecase"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/postgres"
+	promoRepo "github.com/artrsyf/avito-trainee-assignment/internal/promo/repository/postgres"
+	purchaseDTO "github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/dto"
+	purchaseRepo "github.com/artrsyf/avito-trainee-assignment/internal/purchase/repository/postgres"
+	purchaseUsecase "github.com/artrsyf/avito-trainee-assignment/internal/purchase/usecase"
+	transactionEntity "github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/entity"
+	transactionRepo "github.com/artrsyf/avito-trainee-assignment/internal/transaction/repository/postgres"
+	transactionUsecase "github.com/artrsyf/avito-trainee-assignment/internal/transaction/usecase"
+	userRepo "github.com/artrsyf/avito-trainee-assignment/internal/user/repository/postgres"
+	uow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/postgres"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerUsecase_Integration(t *testing.T) {
+	userRepo := userRepo.NewUserPostgresRepository(DB, logrus.New())
+	idempotencyRepo := idempotencyRepo.NewIdempotencyPostgresRepository(DB, logrus.New())
+	purchaseRepo := purchaseRepo.NewPurchasePostgresRepository(DB, logrus.New())
+	promoRepo := promoRepo.NewPromoPostgresRepository(DB, logrus.New())
+	transactionRepo := transactionRepo.NewTransactionPostgresRepository(DB, logrus.New())
+	ledgerRepo := ledgerRepo.NewLedgerPostgresRepository(DB, logrus.New())
+	lotRepo := lotRepo.NewLotPostgresRepository(DB, logrus.New())
+	uowFactory := uow.NewFactory(DB)
+
+	purchaseUC := purchaseUsecase.NewPurchaseUsecase(purchaseRepo, userRepo, idempotencyRepo, promoRepo, ledgerRepo, lotRepo, uowFactory, config.PurchaseConfig{RefundWindow: "24h"}, logrus.New())
+	transactionUC := transactionUsecase.NewTransactionUsecase(transactionRepo, userRepo, idempotencyRepo, ledgerRepo, lotRepo, uowFactory, logrus.New())
+	uc := ledgerUsecase.NewLedgerUsecase(ledgerRepo, logrus.New())
+	ctx := context.Background()
+
+	t.Run("balance changes reconcile", func(t *testing.T) {
+		SetupTestData(t, DB)
+		senderID := CreateTestUser(t, "sender", 1000)
+		receiverID := CreateTestUser(t, "receiver", 500)
+		CreatePurchaseType(t, DB, "t-shirt", 80)
+		CreatePurchaseType(t, DB, "cup", 20)
+
+		err := purchaseUC.Create(ctx, &purchaseDTO.PurchaseItemRequest{UserID: senderID, PurchaseTypeName: "t-shirt"}, nil)
+		require.NoError(t, err)
+		err = purchaseUC.Create(ctx, &purchaseDTO.PurchaseItemRequest{UserID: receiverID, PurchaseTypeName: "cup"}, nil)
+		require.NoError(t, err)
+
+		err = transactionUC.Create(ctx, &transactionEntity.Transaction{
+			SenderUsername:   "sender",
+			ReceiverUsername: "receiver",
+			Amount:           300,
+		}, nil)
+		require.NoError(t, err)
+
+		var purchaseID uint
+		err = DB.QueryRowContext(ctx,
+			"SELECT id FROM purchases WHERE purchaser_id = $1", senderID).Scan(&purchaseID)
+		require.NoError(t, err)
+
+		_, err = purchaseUC.RefundPurchase(ctx, senderID, purchaseID)
+		require.NoError(t, err)
+
+		reconciliation, err := uc.Reconcile(ctx)
+		require.NoError(t, err)
+		require.True(t, reconciliation.IsConsistent())
+		require.Equal(t, int64(-1500), reconciliation.IssuerBalance)
+		require.Equal(t, int64(20), reconciliation.ShopBalance)
+		require.Equal(t, int64(1480), reconciliation.UsersBalance)
+	})
+
+	t.Run("balance changed outside ledger", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "user1", 1000)
+
+		_, err := DB.ExecContext(ctx, "UPDATE users SET coins = 5000 WHERE id = $1", userID)
+		require.NoError(t, err)
+
+		reconciliation, err := uc.Reconcile(ctx)
+		require.NoError(t, err)
+		require.False(t, reconciliation.IsConsistent())
+		require.Len(t, reconciliation.Mismatches, 1)
+		require.Equal(t, userID, reconciliation.Mismatches[0].UserID)
+		require.Equal(t, uint(5000), reconciliation.Mismatches[0].Coins)
+		require.Equal(t, int64(1000), reconciliation.Mismatches[0].LedgerBalance)
+		require.Equal(t, int64(1000), reconciliation.Mismatches[0].ExpectedBalance)
+	})
+
+	t.Run("business records disagree with balance", func(t *testing.T) {
+		SetupTestData(t, DB)
+		senderID := CreateTestUser(t, "sender", 1000)
+		receiverID := CreateTestUser(t, "receiver", 500)
+
+		createTransaction(t, senderID, receiverID, 100)
+
+		reconciliation, err := uc.Reconcile(ctx)
+		require.NoError(t, err)
+		require.False(t, reconciliation.IsConsistent())
+		require.Len(t, reconciliation.Mismatches, 2)
+
+		require.Equal(t, senderID, reconciliation.Mismatches[0].UserID)
+		require.Equal(t, int64(1000), reconciliation.Mismatches[0].LedgerBalance)
+		require.Equal(t, int64(900), reconciliation.Mismatches[0].ExpectedBalance)
+
+		require.Equal(t, receiverID, reconciliation.Mismatches[1].UserID)
+		require.Equal(t, int64(600), reconciliation.Mismatches[1].ExpectedBalance)
+	})
+}
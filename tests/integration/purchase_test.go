@@ -2,8 +2,18 @@ package integration
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	idempotencyEntity "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	idempotencyRepo "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository/postgres"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/postgres"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/postgres"
+	promoEntity "github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/entity"
+	promoModel "github.com/artrsyf/avito-trainee-assignment/internal/promo/domain/model"
+	promoRepo "github.com/artrsyf/avito-trainee-assignment/internal/promo/repository/postgres"
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/dto"
 	"github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/entity"
 	purchaseRepo "github.com/artrsyf/avito-trainee-assignment/internal/purchase/repository/postgres"
@@ -16,11 +26,15 @@ import (
 
 func TestPurchaseUsecase_Integration(t *testing.T) {
 	userRepo := userRepo.NewUserPostgresRepository(DB, logrus.New())
+	idempotencyRepo := idempotencyRepo.NewIdempotencyPostgresRepository(DB, logrus.New())
 	purchaseRepo := purchaseRepo.NewPurchasePostgresRepository(DB, logrus.New())
+	promoRepo := promoRepo.NewPromoPostgresRepository(DB, logrus.New())
+	ledgerRepo := ledgerRepo.NewLedgerPostgresRepository(DB, logrus.New())
+	lotRepo := lotRepo.NewLotPostgresRepository(DB, logrus.New())
 
 	uow := uow.NewFactory(DB)
 
-	uc := usecase.NewPurchaseUsecase(purchaseRepo, userRepo, uow, logrus.New())
+	uc := usecase.NewPurchaseUsecase(purchaseRepo, userRepo, idempotencyRepo, promoRepo, ledgerRepo, lotRepo, uow, config.PurchaseConfig{RefundWindow: "24h"}, logrus.New())
 	ctx := context.Background()
 
 	t.Run("successful purchase", func(t *testing.T) {
@@ -33,7 +47,7 @@ func TestPurchaseUsecase_Integration(t *testing.T) {
 			PurchaseTypeName: "premium",
 		}
 
-		err := uc.Create(ctx, req)
+		err := uc.Create(ctx, req, nil)
 		require.NoError(t, err)
 
 		user, err := userRepo.GetByID(ctx, userID)
@@ -57,7 +71,7 @@ func TestPurchaseUsecase_Integration(t *testing.T) {
 			PurchaseTypeName: "vip",
 		}
 
-		err := uc.Create(ctx, req)
+		err := uc.Create(ctx, req, nil)
 		require.ErrorIs(t, err, entity.ErrNotEnoughBalance)
 
 		user, err := userRepo.GetByID(ctx, userID)
@@ -74,7 +88,7 @@ func TestPurchaseUsecase_Integration(t *testing.T) {
 			PurchaseTypeName: "unknown",
 		}
 
-		err := uc.Create(ctx, req)
+		err := uc.Create(ctx, req, nil)
 		require.Error(t, err)
 	})
 
@@ -87,7 +101,7 @@ func TestPurchaseUsecase_Integration(t *testing.T) {
 			PurchaseTypeName: "basic",
 		}
 
-		err := uc.Create(ctx, req)
+		err := uc.Create(ctx, req, nil)
 		require.Error(t, err)
 	})
 
@@ -104,7 +118,7 @@ func TestPurchaseUsecase_Integration(t *testing.T) {
 			PurchaseTypeName: "test",
 		}
 
-		err = uc.Create(ctx, req)
+		err = uc.Create(ctx, req, nil)
 		require.Error(t, err)
 
 		user, err := userRepo.GetByID(ctx, userID)
@@ -113,6 +127,491 @@ func TestPurchaseUsecase_Integration(t *testing.T) {
 
 		createPurchasesTable(t)
 	})
+
+	t.Run("idempotency key is rolled back with failed purchase", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "user6", 100)
+		CreatePurchaseType(t, DB, "powerbank", 200)
+
+		record := &idempotencyEntity.Record{
+			UserID:         userID,
+			Key:            "buy-key",
+			RequestHash:    strings.Repeat("a", 64),
+			ResponseStatus: 200,
+		}
+
+		err := uc.Create(ctx, &dto.PurchaseItemRequest{
+			UserID:           userID,
+			PurchaseTypeName: "powerbank",
+		}, record)
+		require.ErrorIs(t, err, entity.ErrNotEnoughBalance)
+
+		_, err = idempotencyRepo.GetByKey(ctx, userID, "buy-key")
+		require.ErrorIs(t, err, idempotencyEntity.ErrIsNotExist)
+	})
+
+	t.Run("concurrent purchases can't overdraw customer", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "user5", 1000)
+		CreatePurchaseType(t, DB, "cup", 100)
+
+		const workers = 30
+		errs := make(chan error, workers)
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- uc.Create(ctx, &dto.PurchaseItemRequest{
+					UserID:           userID,
+					PurchaseTypeName: "cup",
+				}, nil)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			require.ErrorIs(t, err, entity.ErrNotEnoughBalance)
+		}
+		require.Equal(t, 10, succeeded)
+
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, uint(0), user.Coins)
+
+		var count int
+		err = DB.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM purchases WHERE purchaser_id = $1", userID).Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, succeeded, count)
+	})
+
+	t.Run("retired item stays in inventory", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "collector", 1000)
+
+		_, err := uc.CreateCatalogItem(ctx, &entity.CatalogItem{Name: "sticker", Cost: 10, Available: true})
+		require.NoError(t, err)
+
+		_, err = uc.CreateCatalogItem(ctx, &entity.CatalogItem{Name: "sticker", Cost: 20, Available: true})
+		require.ErrorIs(t, err, entity.ErrProductExists)
+
+		newCost := uint(15)
+		item, err := uc.UpdateCatalogItem(ctx, "sticker", &entity.CatalogItemUpdate{Cost: &newCost})
+		require.NoError(t, err)
+		require.Equal(t, uint(15), item.Cost)
+
+		req := &dto.PurchaseItemRequest{UserID: userID, PurchaseTypeName: "sticker"}
+		require.NoError(t, uc.Create(ctx, req, nil))
+
+		require.NoError(t, uc.RetireCatalogItem(ctx, "sticker"))
+		require.ErrorIs(t, uc.RetireCatalogItem(ctx, "sticker"), entity.ErrNotExistedProduct)
+
+		_, err = uc.GetCatalogItem(ctx, "sticker")
+		require.ErrorIs(t, err, entity.ErrNotExistedProduct)
+
+		err = uc.Create(ctx, req, nil)
+		require.ErrorIs(t, err, entity.ErrNotExistedProduct)
+
+		inventory, err := purchaseRepo.GetPurchasesByUserID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, entity.Inventory{{PurchaseTypeName: "sticker", Quantity: 1}}, inventory)
+
+		_, err = uc.CreateCatalogItem(ctx, &entity.CatalogItem{Name: "sticker", Cost: 30, Available: true})
+		require.NoError(t, err)
+	})
+
+	t.Run("concurrent purchases can't oversell stock", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "hoarder", 10000)
+		CreatePurchaseType(t, DB, "pink-hoodie", 100)
+
+		_, err := DB.Exec("UPDATE purchase_types SET stock = 5 WHERE name = $1", "pink-hoodie")
+		require.NoError(t, err)
+
+		const workers = 20
+		errs := make(chan error, workers)
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- uc.Create(ctx, &dto.PurchaseItemRequest{
+					UserID:           userID,
+					PurchaseTypeName: "pink-hoodie",
+				}, nil)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			require.ErrorIs(t, err, entity.ErrOutOfStock)
+		}
+		require.Equal(t, 5, succeeded)
+
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, uint(9500), user.Coins)
+
+		item, err := uc.RestockCatalogItem(ctx, "pink-hoodie", 2)
+		require.NoError(t, err)
+		require.Equal(t, uint(2), *item.Stock)
+
+		require.NoError(t, uc.Create(ctx, &dto.PurchaseItemRequest{UserID: userID, PurchaseTypeName: "pink-hoodie"}, nil))
+	})
+
+	t.Run("concurrent purchases can't exceed user limit", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "collector", 10000)
+		CreatePurchaseType(t, DB, "cap", 100)
+
+		_, err := DB.Exec(
+			"UPDATE purchase_types SET user_limit = 3, user_limit_period = 7776000 WHERE name = $1", "cap")
+		require.NoError(t, err)
+
+		const workers = 10
+		errs := make(chan error, workers)
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- uc.Create(ctx, &dto.PurchaseItemRequest{
+					UserID:           userID,
+					PurchaseTypeName: "cap",
+				}, nil)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			require.ErrorIs(t, err, entity.ErrPurchaseLimit)
+		}
+		require.Equal(t, 3, succeeded)
+
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, uint(9700), user.Coins)
+
+		_, err = uc.Checkout(ctx, &dto.CheckoutRequest{
+			UserID: userID,
+			Items:  []dto.CheckoutLineRequest{{Item: "cap", Quantity: 1}},
+		}, nil)
+		require.ErrorIs(t, err, entity.ErrPurchaseLimit)
+
+		_, err = DB.Exec(
+			"UPDATE purchases SET created_at = NOW() - INTERVAL '91 days' WHERE purchaser_id = $1", userID)
+		require.NoError(t, err)
+
+		require.NoError(t, uc.Create(ctx, &dto.PurchaseItemRequest{UserID: userID, PurchaseTypeName: "cap"}, nil))
+	})
+
+	t.Run("basket checkout is atomic", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "basket", 100)
+		CreatePurchaseType(t, DB, "pen", 10)
+		CreatePurchaseType(t, DB, "notebook", 30)
+
+		_, err := DB.Exec("UPDATE purchase_types SET stock = 2 WHERE name = $1", "notebook")
+		require.NoError(t, err)
+
+		_, err = uc.Checkout(ctx, &dto.CheckoutRequest{
+			UserID: userID,
+			Items: []dto.CheckoutLineRequest{
+				{Item: "pen", Quantity: 2},
+				{Item: "notebook", Quantity: 3},
+			},
+		}, nil)
+		require.ErrorIs(t, err, entity.ErrOutOfStock)
+
+		_, err = uc.Checkout(ctx, &dto.CheckoutRequest{
+			UserID: userID,
+			Items: []dto.CheckoutLineRequest{
+				{Item: "pen", Quantity: 5},
+				{Item: "notebook", Quantity: 2},
+			},
+		}, nil)
+		require.ErrorIs(t, err, entity.ErrNotEnoughBalance)
+
+		var stock uint
+		err = DB.QueryRowContext(ctx,
+			"SELECT stock FROM purchase_types WHERE name = $1", "notebook").Scan(&stock)
+		require.NoError(t, err)
+		require.Equal(t, uint(2), stock)
+
+		receipt, err := uc.Checkout(ctx, &dto.CheckoutRequest{
+			UserID: userID,
+			Items: []dto.CheckoutLineRequest{
+				{Item: "pen", Quantity: 4},
+				{Item: "notebook", Quantity: 2},
+			},
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint(100), receipt.Total)
+
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, uint(0), user.Coins)
+
+		inventory, err := purchaseRepo.GetPurchasesByUserID(ctx, userID)
+		require.NoError(t, err)
+		require.ElementsMatch(t, entity.Inventory{
+			{PurchaseTypeName: "pen", Quantity: 4},
+			{PurchaseTypeName: "notebook", Quantity: 2},
+		}, inventory)
+	})
+
+	t.Run("purchase keeps name and price paid", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "snapshot", 1000)
+		CreatePurchaseType(t, DB, "hoodie", 300)
+
+		req := &dto.PurchaseItemRequest{UserID: userID, PurchaseTypeName: "hoodie"}
+		require.NoError(t, uc.Create(ctx, req, nil))
+
+		newName := "hoodie-v2"
+		newCost := uint(500)
+		_, err := uc.UpdateCatalogItem(ctx, "hoodie", &entity.CatalogItemUpdate{Name: &newName, Cost: &newCost})
+		require.NoError(t, err)
+
+		_, err = DB.Exec("DELETE FROM purchase_types WHERE name = $1", newName)
+		require.NoError(t, err)
+
+		var itemName string
+		var price uint
+		err = DB.QueryRowContext(ctx,
+			"SELECT item_name, price FROM purchases WHERE purchaser_id = $1", userID).Scan(&itemName, &price)
+		require.NoError(t, err)
+		require.Equal(t, "hoodie", itemName)
+		require.Equal(t, uint(300), price)
+
+		inventory, err := purchaseRepo.GetPurchasesByUserID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, entity.Inventory{{PurchaseTypeName: "hoodie", Quantity: 1}}, inventory)
+	})
+
+	t.Run("refund credits price paid and returns stock", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "refunder", 1000)
+		otherID := CreateTestUser(t, "stranger", 1000)
+		CreatePurchaseType(t, DB, "umbrella", 200)
+
+		_, err := DB.Exec("UPDATE purchase_types SET stock = 1 WHERE name = $1", "umbrella")
+		require.NoError(t, err)
+
+		require.NoError(t, uc.Create(ctx, &dto.PurchaseItemRequest{UserID: userID, PurchaseTypeName: "umbrella"}, nil))
+
+		newCost := uint(500)
+		_, err = uc.UpdateCatalogItem(ctx, "umbrella", &entity.CatalogItemUpdate{Cost: &newCost})
+		require.NoError(t, err)
+
+		page, err := uc.GetPurchaseHistory(ctx, &entity.PurchaseHistoryFilter{UserID: userID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		purchaseID := page.Items[0].ID
+
+		_, err = uc.RefundPurchase(ctx, otherID, purchaseID)
+		require.ErrorIs(t, err, entity.ErrPurchaseNotFound)
+
+		record, err := uc.RefundPurchase(ctx, userID, purchaseID)
+		require.NoError(t, err)
+		require.NotNil(t, record.RefundedAt)
+
+		_, err = uc.RefundPurchaseByAdmin(ctx, purchaseID)
+		require.ErrorIs(t, err, entity.ErrAlreadyRefunded)
+
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, uint(1000), user.Coins)
+
+		item, err := uc.GetCatalogItem(ctx, "umbrella")
+		require.NoError(t, err)
+		require.Equal(t, uint(1), *item.Stock)
+
+		inventory, err := purchaseRepo.GetPurchasesByUserID(ctx, userID)
+		require.NoError(t, err)
+		require.Empty(t, inventory)
+
+		page, err = uc.GetPurchaseHistory(ctx, &entity.PurchaseHistoryFilter{UserID: userID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		require.Equal(t, uint(200), page.Items[0].Price)
+		require.NotNil(t, page.Items[0].RefundedAt)
+		require.Equal(t, entity.StatusCancelled, page.Items[0].Status)
+	})
+
+	t.Run("fulfilment workflow", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "collector", 1000)
+		CreatePurchaseType(t, DB, "mug", 100)
+
+		require.NoError(t, uc.Create(ctx, &dto.PurchaseItemRequest{UserID: userID, PurchaseTypeName: "mug"}, nil))
+		require.NoError(t, uc.Create(ctx, &dto.PurchaseItemRequest{UserID: userID, PurchaseTypeName: "mug"}, nil))
+
+		queue, err := uc.GetPurchaseHistory(ctx, &entity.PurchaseHistoryFilter{Status: entity.StatusPending, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, queue.Items, 2)
+		require.Equal(t, "collector", queue.Items[0].Purchaser)
+		deliveredID, cancelledID := queue.Items[0].ID, queue.Items[1].ID
+
+		_, err = uc.UpdatePurchaseStatus(ctx, deliveredID, entity.StatusDelivered)
+		require.ErrorIs(t, err, entity.ErrStatusTransition)
+
+		record, err := uc.UpdatePurchaseStatus(ctx, deliveredID, entity.StatusReady)
+		require.NoError(t, err)
+		require.Equal(t, entity.StatusReady, record.Status)
+
+		record, err = uc.UpdatePurchaseStatus(ctx, deliveredID, entity.StatusDelivered)
+		require.NoError(t, err)
+		require.Equal(t, entity.StatusDelivered, record.Status)
+
+		_, err = uc.UpdatePurchaseStatus(ctx, deliveredID, entity.StatusCancelled)
+		require.ErrorIs(t, err, entity.ErrStatusTransition)
+
+		record, err = uc.UpdatePurchaseStatus(ctx, cancelledID, entity.StatusCancelled)
+		require.NoError(t, err)
+		require.Equal(t, entity.StatusCancelled, record.Status)
+		require.NotNil(t, record.RefundedAt)
+
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, uint(900), user.Coins)
+
+		_, err = uc.RefundPurchase(ctx, userID, deliveredID)
+		require.ErrorIs(t, err, entity.ErrRefundDelivered)
+
+		record, err = uc.RefundPurchaseByAdmin(ctx, deliveredID)
+		require.NoError(t, err)
+		require.Equal(t, entity.StatusDelivered, record.Status)
+
+		queue, err = uc.GetPurchaseHistory(ctx, &entity.PurchaseHistoryFilter{Status: entity.StatusPending, Limit: 10})
+		require.NoError(t, err)
+		require.Empty(t, queue.Items)
+	})
+
+	t.Run("user refund window has expired", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "late", 1000)
+		CreatePurchaseType(t, DB, "scarf", 100)
+
+		require.NoError(t, uc.Create(ctx, &dto.PurchaseItemRequest{UserID: userID, PurchaseTypeName: "scarf"}, nil))
+
+		var purchaseID uint
+		err := DB.QueryRowContext(ctx,
+			"UPDATE purchases SET created_at = NOW() - INTERVAL '2 days' WHERE purchaser_id = $1 RETURNING id",
+			userID).Scan(&purchaseID)
+		require.NoError(t, err)
+
+		_, err = uc.RefundPurchase(ctx, userID, purchaseID)
+		require.ErrorIs(t, err, entity.ErrRefundExpired)
+
+		_, err = uc.RefundPurchaseByAdmin(ctx, purchaseID)
+		require.NoError(t, err)
+
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, uint(1000), user.Coins)
+	})
+
+	t.Run("purchase with promo code", func(t *testing.T) {
+		SetupTestData(t, DB)
+		userID := CreateTestUser(t, "promo", 1000)
+		CreatePurchaseType(t, DB, "hoodie", 300)
+
+		item := "hoodie"
+		maxPerUser := uint(1)
+		_, err := promoRepo.Create(ctx, &promoModel.PromoCode{
+			Code:          "HOODIE30",
+			DiscountType:  promoEntity.DiscountPercent,
+			DiscountValue: 30,
+			Item:          &item,
+			MaxPerUser:    &maxPerUser,
+		})
+		require.NoError(t, err)
+
+		req := &dto.PurchaseItemRequest{UserID: userID, PurchaseTypeName: "hoodie", PromoCode: "hoodie30"}
+		require.NoError(t, uc.Create(ctx, req, nil))
+
+		var price, discount uint
+		err = DB.QueryRowContext(ctx,
+			"SELECT price, discount FROM purchases WHERE purchaser_id = $1", userID).Scan(&price, &discount)
+		require.NoError(t, err)
+		require.Equal(t, uint(210), price)
+		require.Equal(t, uint(90), discount)
+
+		err = uc.Create(ctx, req, nil)
+		require.ErrorIs(t, err, promoEntity.ErrPromoUserLimit)
+
+		user, err := userRepo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, uint(790), user.Coins)
+
+		promoCode, err := promoRepo.GetByCode(ctx, "HOODIE30")
+		require.NoError(t, err)
+		require.Equal(t, uint(1), promoCode.Redemptions)
+	})
+
+	t.Run("gift goes to recipient inventory", func(t *testing.T) {
+		SetupTestData(t, DB)
+		giverID := CreateTestUser(t, "giver", 1000)
+		recipientID := CreateTestUser(t, "recipient", 100)
+		CreatePurchaseType(t, DB, "cup", 20)
+
+		req := &dto.PurchaseItemRequest{UserID: giverID, PurchaseTypeName: "cup", RecipientUsername: "recipient"}
+		require.NoError(t, uc.Create(ctx, req, nil))
+
+		giver, err := userRepo.GetByID(ctx, giverID)
+		require.NoError(t, err)
+		require.Equal(t, uint(980), giver.Coins)
+
+		recipient, err := userRepo.GetByID(ctx, recipientID)
+		require.NoError(t, err)
+		require.Equal(t, uint(100), recipient.Coins)
+
+		inventory, err := purchaseRepo.GetPurchasesByUserID(ctx, recipientID)
+		require.NoError(t, err)
+		require.Equal(t, entity.Inventory{{PurchaseTypeName: "cup", Quantity: 1}}, inventory)
+
+		inventory, err = purchaseRepo.GetPurchasesByUserID(ctx, giverID)
+		require.NoError(t, err)
+		require.Empty(t, inventory)
+
+		for _, userID := range []uint{giverID, recipientID} {
+			history, err := purchaseRepo.GetHistory(ctx, &entity.PurchaseHistoryFilter{UserID: userID, Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, history, 1)
+			require.Equal(t, "giver", history[0].Purchaser)
+			require.Equal(t, "recipient", history[0].Recipient)
+		}
+
+		err = uc.Create(ctx, &dto.PurchaseItemRequest{
+			UserID:            giverID,
+			PurchaseTypeName:  "cup",
+			RecipientUsername: "nobody",
+		}, nil)
+		require.ErrorIs(t, err, entity.ErrRecipientNotFound)
+	})
 }
 
 func createPurchasesTable(t *testing.T) {
@@ -121,7 +620,14 @@ func createPurchasesTable(t *testing.T) {
 			id SERIAL PRIMARY KEY,
 			purchaser_id INTEGER NOT NULL,
 			purchase_type_id INTEGER NOT NULL,
-			created_at TIMESTAMP DEFAULT NOW()
+			item_name VARCHAR(255) NOT NULL,
+			price INTEGER NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			refunded_at TIMESTAMPTZ,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			promo_code_id INTEGER,
+			discount INTEGER NOT NULL DEFAULT 0,
+			recipient_id INTEGER
 		)
 	`)
 	require.NoError(t, err)
@@ -8,11 +8,14 @@ import (
 	"time"
 
 	"github.com/artrsyf/avito-trainee-assignment/config"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/postgres"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/postgres"
 	"github.com/artrsyf/avito-trainee-assignment/internal/session/domain/dto"
 	"github.com/artrsyf/avito-trainee-assignment/internal/session/domain/entity"
 	sessionRepo "github.com/artrsyf/avito-trainee-assignment/internal/session/repository/redis"
 	"github.com/artrsyf/avito-trainee-assignment/internal/session/usecase"
 	"github.com/artrsyf/avito-trainee-assignment/internal/user/repository/postgres"
+	uow "github.com/artrsyf/avito-trainee-assignment/pkg/uow/postgres"
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
@@ -64,7 +67,10 @@ func teardown() {
 
 func TestSessionUsecase_Integration(t *testing.T) {
 	userRepo := postgres.NewUserPostgresRepository(DB, logrus.New())
+	loginAttemptRepo := sessionRepo.NewLoginAttemptRedisRepository(RedisClient, logrus.New())
 	sessionRepo := sessionRepo.NewSessionRedisRepository(RedisClient, logrus.New())
+	ledgerRepo := ledgerRepo.NewLedgerPostgresRepository(DB, logrus.New())
+	lotRepo := lotRepo.NewLotPostgresRepository(DB, logrus.New())
 
 	cfg := config.UserConfig{
 		InitCoinsBalance: 100,
@@ -72,9 +78,10 @@ func TestSessionUsecase_Integration(t *testing.T) {
 			AccessTokenExpiration:  "5s",
 			RefreshTokenExpiration: "24h",
 		},
+		Registration: config.RegistrationConfig{AutoSignup: true},
 	}
 
-	uc := usecase.NewSessionUsecase(sessionRepo, userRepo, cfg, logrus.New())
+	uc := usecase.NewSessionUsecase(sessionRepo, loginAttemptRepo, userRepo, ledgerRepo, lotRepo, uow.NewFactory(DB), cfg, config.ExpiryConfig{}, logrus.New())
 	ctx := context.Background()
 
 	t.Run("successful signup and session creation", func(t *testing.T) {
@@ -93,7 +100,7 @@ func TestSessionUsecase_Integration(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, cfg.InitCoinsBalance, user.Coins)
 
-		redisSession, err := sessionRepo.Check(ctx, user.ID)
+		redisSession, err := sessionRepo.Check(ctx, user.ID, session.ID)
 		require.NoError(t, err)
 		require.Equal(t, session.JWTAccess, redisSession.JWTAccess)
 	})
@@ -114,7 +121,7 @@ func TestSessionUsecase_Integration(t *testing.T) {
 		user, err := userRepo.GetByUsername(ctx, "existinguser")
 		require.NoError(t, err)
 
-		redisSession, err := sessionRepo.Check(ctx, user.ID)
+		redisSession, err := sessionRepo.Check(ctx, user.ID, session.ID)
 		require.NoError(t, err)
 		require.Equal(t, session.JWTAccess, redisSession.JWTAccess)
 	})
@@ -144,25 +151,137 @@ func TestSessionUsecase_Integration(t *testing.T) {
 
 		sessionSecondInstance, err := uc.LoginOrSignup(ctx, req)
 		require.NoError(t, err)
-		require.Equal(t, sessionSecondInstance.JWTAccess, sessionFirstInstance.JWTAccess)
-		require.Equal(t, sessionSecondInstance.JWTRefresh, sessionFirstInstance.JWTRefresh)
+		require.NotEqual(t, sessionSecondInstance.ID, sessionFirstInstance.ID)
+		require.NotEqual(t, sessionSecondInstance.JWTAccess, sessionFirstInstance.JWTAccess)
+
+		sessions, err := uc.GetSessions(ctx, sessionFirstInstance.UserID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
 	})
 
-	t.Run("session expiration", func(t *testing.T) {
+	t.Run("session outlives access token", func(t *testing.T) {
 		req := &dto.AuthRequest{
 			Username: "expireduser",
 			Password: "password123",
 		}
 
-		_, err := uc.LoginOrSignup(ctx, req)
+		firstSession, err := uc.LoginOrSignup(ctx, req)
 		require.NoError(t, err)
 
-		user, err := userRepo.GetByUsername(ctx, "expireduser")
+		time.Sleep(6 * time.Second)
+
+		_, err = sessionRepo.Check(ctx, firstSession.UserID, firstSession.ID)
 		require.NoError(t, err)
 
-		time.Sleep(6 * time.Second)
+		refreshed, err := uc.Refresh(ctx, firstSession.JWTRefresh)
+		require.NoError(t, err)
+		require.NotEqual(t, firstSession.JWTAccess, refreshed.JWTAccess)
+	})
+
+	t.Run("refresh rotates tokens", func(t *testing.T) {
+		req := &dto.AuthRequest{
+			Username: "refreshuser",
+			Password: "password123",
+		}
+
+		session, err := uc.LoginOrSignup(ctx, req)
+		require.NoError(t, err)
+
+		refreshed, err := uc.Refresh(ctx, session.JWTRefresh)
+		require.NoError(t, err)
+		require.NotEqual(t, session.JWTAccess, refreshed.JWTAccess)
+		require.NotEqual(t, session.JWTRefresh, refreshed.JWTRefresh)
+
+		redisSession, err := sessionRepo.Check(ctx, refreshed.UserID, refreshed.ID)
+		require.NoError(t, err)
+		require.Equal(t, refreshed.JWTRefresh, redisSession.JWTRefresh)
+
+		_, err = uc.Refresh(ctx, session.JWTRefresh)
+		require.ErrorIs(t, err, entity.ErrRefreshTokenReused)
+
+		_, err = uc.Refresh(ctx, refreshed.JWTRefresh)
+		require.ErrorIs(t, err, entity.ErrNoSession)
+	})
+
+	t.Run("logout revokes session", func(t *testing.T) {
+		req := &dto.AuthRequest{
+			Username: "logoutuser",
+			Password: "password123",
+		}
+
+		session, err := uc.LoginOrSignup(ctx, req)
+		require.NoError(t, err)
+		require.NoError(t, uc.Validate(ctx, session.UserID, session.ID, session.JWTAccess))
+
+		err = uc.Logout(ctx, session.UserID, session.ID)
+		require.NoError(t, err)
 
-		_, err = sessionRepo.Check(ctx, user.ID)
+		err = uc.Validate(ctx, session.UserID, session.ID, session.JWTAccess)
 		require.ErrorIs(t, err, entity.ErrNoSession)
+
+		_, err = uc.Refresh(ctx, session.JWTRefresh)
+		require.ErrorIs(t, err, entity.ErrNoSession)
+	})
+
+	t.Run("logout keeps other devices signed in", func(t *testing.T) {
+		req := &dto.AuthRequest{
+			Username: "multideviceuser",
+			Password: "password123",
+		}
+
+		laptop, err := uc.LoginOrSignup(ctx, req)
+		require.NoError(t, err)
+		phone, err := uc.LoginOrSignup(ctx, req)
+		require.NoError(t, err)
+
+		require.NoError(t, uc.Logout(ctx, laptop.UserID, laptop.ID))
+
+		err = uc.Validate(ctx, laptop.UserID, laptop.ID, laptop.JWTAccess)
+		require.ErrorIs(t, err, entity.ErrNoSession)
+		require.NoError(t, uc.Validate(ctx, phone.UserID, phone.ID, phone.JWTAccess))
+
+		require.NoError(t, uc.LogoutAll(ctx, phone.UserID))
+
+		sessions, err := uc.GetSessions(ctx, phone.UserID)
+		require.NoError(t, err)
+		require.Empty(t, sessions)
+	})
+
+	t.Run("repeated failures lock out logins", func(t *testing.T) {
+		lockoutCfg := cfg
+		lockoutCfg.Lockout = config.LockoutConfig{
+			MaxAttempts:  2,
+			ResetAfter:   "1h",
+			BaseDuration: "1m",
+			MaxDuration:  "10m",
+		}
+		lockoutUC := usecase.NewSessionUsecase(sessionRepo, loginAttemptRepo, userRepo, ledgerRepo, lotRepo, uow.NewFactory(DB), lockoutCfg, config.ExpiryConfig{}, logrus.New())
+
+		req := &dto.AuthRequest{
+			Username: "lockeduser",
+			Password: "password123",
+		}
+		_, err := lockoutUC.LoginOrSignup(ctx, req)
+		require.NoError(t, err)
+
+		wrongReq := &dto.AuthRequest{
+			Username: "lockeduser",
+			Password: "wrongpassword",
+		}
+		for i := 0; i < 2; i++ {
+			_, err = lockoutUC.LoginOrSignup(ctx, wrongReq)
+			require.ErrorIs(t, err, entity.ErrWrongCredentials)
+		}
+
+		// The right password doesn't help while the lockout lasts.
+		_, err = lockoutUC.LoginOrSignup(ctx, req)
+		var lockedErr *entity.LoginLockedError
+		require.ErrorAs(t, err, &lockedErr)
+		require.InDelta(t, time.Minute, lockedErr.RetryAfter, float64(5*time.Second))
+
+		require.NoError(t, lockoutUC.UnlockUser(ctx, "lockeduser"))
+
+		_, err = lockoutUC.LoginOrSignup(ctx, req)
+		require.NoError(t, err)
 	})
 }
@@ -3,6 +3,7 @@ package integration
 import (
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
@@ -15,9 +16,17 @@ var (
 
 func SetupTestData(t *testing.T, db *sql.DB) {
 	_, err := db.Exec(`
+		DELETE FROM idempotency_keys;
+		DELETE FROM ledger_entries;
+		DELETE FROM balance_adjustments;
+		DELETE FROM allowance_credits;
+		DELETE FROM allowance_runs;
+		DELETE FROM scheduler_leases;
+		DELETE FROM coin_lots;
 		DELETE FROM users;
 		DELETE FROM purchase_types;
 		DELETE FROM purchases;
+		DELETE FROM promo_codes;
 		DELETE FROM transactions;
 	`)
 	require.NoError(t, err)
@@ -30,9 +39,41 @@ func CreateTestUser(t *testing.T, username string, coins uint) uint {
 		username, coins,
 	).Scan(&id)
 	require.NoError(t, err)
+
+	if coins > 0 {
+		GrantLedgerBalance(t, id, coins)
+		CreateCoinLot(t, id, coins, nil)
+	}
 	return id
 }
 
+// CreateCoinLot backs coins of a seeded user with a lot, so transfers and
+// purchases have lots to take the coins from. A nil expiresAt never expires.
+func CreateCoinLot(t *testing.T, userID, coins uint, expiresAt *time.Time) {
+	_, err := DB.Exec(
+		"INSERT INTO coin_lots (user_id, amount, remaining, expires_at) VALUES ($1, $2, $2, $3)",
+		userID, coins, expiresAt,
+	)
+	require.NoError(t, err)
+}
+
+// GrantLedgerBalance journals the initial balance of a seeded user,
+// so reconciliation stays consistent for test fixtures.
+func GrantLedgerBalance(t *testing.T, userID, coins uint) {
+	var entryID uint
+	err := DB.QueryRow(
+		"INSERT INTO ledger_entries (kind, reference) VALUES ('grant', 'test') RETURNING id",
+	).Scan(&entryID)
+	require.NoError(t, err)
+
+	_, err = DB.Exec(
+		`INSERT INTO ledger_postings (entry_id, account, user_id, amount)
+		VALUES ($1, 'issuer', NULL, $2), ($1, 'user', $3, $4)`,
+		entryID, -int64(coins), userID, int64(coins),
+	)
+	require.NoError(t, err)
+}
+
 func CreatePurchaseType(t *testing.T, db *sql.DB, name string, cost uint) {
 	_, err := db.Exec(
 		"INSERT INTO purchase_types (name, cost) VALUES ($1, $2) ON CONFLICT DO NOTHING",
@@ -5,8 +5,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	idempotencyEntity "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/domain/entity"
+	idempotencyRepo "github.com/artrsyf/avito-trainee-assignment/internal/idempotency/repository/postgres"
+	ledgerRepo "github.com/artrsyf/avito-trainee-assignment/internal/ledger/repository/postgres"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/postgres"
 	"github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/entity"
 	transactionRepo "github.com/artrsyf/avito-trainee-assignment/internal/transaction/repository/postgres"
 	"github.com/artrsyf/avito-trainee-assignment/internal/transaction/usecase"
@@ -19,10 +26,13 @@ import (
 
 func TestTransactionUsecase_Integration(t *testing.T) {
 	userRepo := userRepo.NewUserPostgresRepository(DB, logrus.New())
+	idempotencyRepo := idempotencyRepo.NewIdempotencyPostgresRepository(DB, logrus.New())
 	transactionRepo := transactionRepo.NewTransactionPostgresRepository(DB, logrus.New())
+	ledgerRepo := ledgerRepo.NewLedgerPostgresRepository(DB, logrus.New())
+	lotRepo := lotRepo.NewLotPostgresRepository(DB, logrus.New())
 	uowFactory := uow.NewFactory(DB)
 
-	uc := usecase.NewTransactionUsecase(transactionRepo, userRepo, uowFactory, logrus.New())
+	uc := usecase.NewTransactionUsecase(transactionRepo, userRepo, idempotencyRepo, ledgerRepo, lotRepo, uowFactory, logrus.New())
 	ctx := context.Background()
 
 	t.Run("successful transaction", func(t *testing.T) {
@@ -36,7 +46,7 @@ func TestTransactionUsecase_Integration(t *testing.T) {
 			Amount:           300,
 		}
 
-		err := uc.Create(ctx, transaction)
+		err := uc.Create(ctx, transaction, nil)
 		require.NoError(t, err)
 
 		senderUser, _ := userRepo.GetByUsername(ctx, "sender")
@@ -61,7 +71,7 @@ func TestTransactionUsecase_Integration(t *testing.T) {
 			Amount:           300,
 		}
 
-		err := uc.Create(ctx, transaction)
+		err := uc.Create(ctx, transaction, nil)
 		require.ErrorIs(t, err, entity.ErrNotEnoughBalance)
 
 		senderUser, _ := userRepo.GetByUsername(ctx, "poor_sender")
@@ -81,7 +91,7 @@ func TestTransactionUsecase_Integration(t *testing.T) {
 			Amount:           100,
 		}
 
-		err := uc.Create(ctx, transaction)
+		err := uc.Create(ctx, transaction, nil)
 		require.Error(t, err)
 	})
 
@@ -95,7 +105,7 @@ func TestTransactionUsecase_Integration(t *testing.T) {
 			Amount:           100,
 		}
 
-		err := uc.Create(ctx, transaction)
+		err := uc.Create(ctx, transaction, nil)
 		require.Error(t, err)
 	})
 
@@ -111,9 +121,9 @@ func TestTransactionUsecase_Integration(t *testing.T) {
 		}
 
 		faultyUowFactory := NewFaultyUOWFactory(DB, 2)
-		uc := usecase.NewTransactionUsecase(transactionRepo, userRepo, faultyUowFactory, logrus.New())
+		uc := usecase.NewTransactionUsecase(transactionRepo, userRepo, idempotencyRepo, ledgerRepo, lotRepo, faultyUowFactory, logrus.New())
 
-		err := uc.Create(ctx, transaction)
+		err := uc.Create(ctx, transaction, nil)
 		require.Error(t, err)
 
 		senderUser, _ := userRepo.GetByUsername(ctx, "sender")
@@ -122,6 +132,195 @@ func TestTransactionUsecase_Integration(t *testing.T) {
 		receiverUser, _ := userRepo.GetByUsername(ctx, "receiver")
 		require.Equal(t, uint(500), receiverUser.Coins)
 	})
+
+	t.Run("concurrent transfers can't overdraw sender", func(t *testing.T) {
+		SetupTestData(t, DB)
+		_ = CreateTestUser(t, "sender", 1000)
+		_ = CreateTestUser(t, "receiver", 500)
+
+		const workers = 50
+		errs := make(chan error, workers)
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- uc.Create(ctx, &entity.Transaction{
+					SenderUsername:   "sender",
+					ReceiverUsername: "receiver",
+					Amount:           100,
+				}, nil)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			require.ErrorIs(t, err, entity.ErrNotEnoughBalance)
+		}
+		require.Equal(t, 10, succeeded)
+
+		senderUser, _ := userRepo.GetByUsername(ctx, "sender")
+		require.Equal(t, uint(0), senderUser.Coins)
+
+		receiverUser, _ := userRepo.GetByUsername(ctx, "receiver")
+		require.Equal(t, uint(1500), receiverUser.Coins)
+
+		var txCount int
+		DB.QueryRow("SELECT COUNT(*) FROM transactions").Scan(&txCount)
+		require.Equal(t, succeeded, txCount)
+	})
+
+	t.Run("concurrent retries with same idempotency key transfer once", func(t *testing.T) {
+		SetupTestData(t, DB)
+		senderID := CreateTestUser(t, "sender", 1000)
+		_ = CreateTestUser(t, "receiver", 500)
+
+		record := &idempotencyEntity.Record{
+			UserID:         senderID,
+			Key:            "retry-key",
+			RequestHash:    strings.Repeat("a", 64),
+			ResponseStatus: 200,
+		}
+
+		const workers = 10
+		errs := make(chan error, workers)
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- uc.Create(ctx, &entity.Transaction{
+					SenderUsername:   "sender",
+					ReceiverUsername: "receiver",
+					Amount:           100,
+				}, record)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			require.ErrorIs(t, err, idempotencyEntity.ErrAlreadyCreated)
+		}
+		require.Equal(t, 1, succeeded)
+
+		senderUser, _ := userRepo.GetByUsername(ctx, "sender")
+		require.Equal(t, uint(900), senderUser.Coins)
+
+		stored, err := idempotencyRepo.GetByKey(ctx, senderID, "retry-key")
+		require.NoError(t, err)
+		require.Equal(t, 200, stored.ResponseStatus)
+	})
+
+	t.Run("concurrent opposite transfers conserve coins", func(t *testing.T) {
+		SetupTestData(t, DB)
+		_ = CreateTestUser(t, "alice", 1000)
+		_ = CreateTestUser(t, "bob", 1000)
+
+		const workers = 100
+		errs := make(chan error, workers)
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			sender, receiver := "alice", "bob"
+			if i%2 == 1 {
+				sender, receiver = receiver, sender
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- uc.Create(ctx, &entity.Transaction{
+					SenderUsername:   sender,
+					ReceiverUsername: receiver,
+					Amount:           10,
+				}, nil)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		alice, _ := userRepo.GetByUsername(ctx, "alice")
+		bob, _ := userRepo.GetByUsername(ctx, "bob")
+		require.Equal(t, uint(1000), alice.Coins)
+		require.Equal(t, uint(1000), bob.Coins)
+	})
+
+	t.Run("history pages through transfers with filters", func(t *testing.T) {
+		SetupTestData(t, DB)
+		aliceID := CreateTestUser(t, "alice", 1000)
+		_ = CreateTestUser(t, "bob", 1000)
+		_ = CreateTestUser(t, "carol", 1000)
+
+		transfers := []*entity.Transaction{
+			{SenderUsername: "alice", ReceiverUsername: "bob", Amount: 10},
+			{SenderUsername: "bob", ReceiverUsername: "alice", Amount: 20},
+			{SenderUsername: "alice", ReceiverUsername: "carol", Amount: 30},
+			{SenderUsername: "carol", ReceiverUsername: "alice", Amount: 40},
+			{SenderUsername: "alice", ReceiverUsername: "bob", Amount: 50},
+		}
+		for _, transfer := range transfers {
+			require.NoError(t, uc.Create(ctx, transfer, nil))
+		}
+
+		var amounts []uint
+		filter := &entity.HistoryFilter{UserID: aliceID, Limit: 2}
+		for {
+			page, err := uc.GetHistory(ctx, filter)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Items), 2)
+
+			for _, item := range page.Items {
+				amounts = append(amounts, item.Amount)
+			}
+			if page.NextCursor == nil {
+				break
+			}
+			filter.After = page.NextCursor
+		}
+		require.Equal(t, []uint{50, 40, 30, 20, 10}, amounts)
+
+		page, err := uc.GetHistory(ctx, &entity.HistoryFilter{
+			UserID:       aliceID,
+			Direction:    entity.DirectionSent,
+			Counterparty: "bob",
+			Limit:        10,
+		})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+		for _, item := range page.Items {
+			require.Equal(t, entity.DirectionSent, item.Direction)
+			require.Equal(t, "bob", item.Counterparty)
+			require.NotZero(t, item.ID)
+			require.False(t, item.CreatedAt.IsZero())
+		}
+
+		future := time.Now().Add(time.Hour)
+		page, err = uc.GetHistory(ctx, &entity.HistoryFilter{
+			UserID: aliceID,
+			From:   &future,
+			Limit:  10,
+		})
+		require.NoError(t, err)
+		require.Empty(t, page.Items)
+	})
 }
 
 type FaultyUOW struct {
@@ -183,6 +382,13 @@ func (u *FaultyUOW) ExecContext(ctx context.Context, query string, args ...any)
 	return u.tx.ExecContext(ctx, query, args...)
 }
 
+func (u *FaultyUOW) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if u.tx == nil {
+		return nil, errors.New("transaction has not been started")
+	}
+	return u.tx.QueryContext(ctx, query, args...)
+}
+
 func (u *FaultyUOW) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
 	if u.tx == nil {
 		return nil
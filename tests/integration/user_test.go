@@ -4,6 +4,9 @@ import (
 	"context"
 	"testing"
 
+	"github.com/artrsyf/avito-trainee-assignment/config"
+	adjustmentRepo "github.com/artrsyf/avito-trainee-assignment/internal/adjustment/repository/postgres"
+	lotRepo "github.com/artrsyf/avito-trainee-assignment/internal/lot/repository/postgres"
 	purchaseEntity "github.com/artrsyf/avito-trainee-assignment/internal/purchase/domain/entity"
 	purchaseRepo "github.com/artrsyf/avito-trainee-assignment/internal/purchase/repository/postgres"
 	transactionEntity "github.com/artrsyf/avito-trainee-assignment/internal/transaction/domain/entity"
@@ -19,8 +22,18 @@ func TestUserUsecase_Integration(t *testing.T) {
 	userRepo := userRepo.NewUserPostgresRepository(DB, logrus.New())
 	purchaseRepo := purchaseRepo.NewPurchasePostgresRepository(DB, logrus.New())
 	transactionRepo := transactionRepo.NewTransactionPostgresRepository(DB, logrus.New())
-
-	uc := usecase.NewUserUsecase(purchaseRepo, transactionRepo, userRepo, logrus.New())
+	adjustmentRepo := adjustmentRepo.NewAdjustmentPostgresRepository(DB, logrus.New())
+	lotRepo := lotRepo.NewLotPostgresRepository(DB, logrus.New())
+
+	uc := usecase.NewUserUsecase(
+		purchaseRepo,
+		transactionRepo,
+		adjustmentRepo,
+		lotRepo,
+		userRepo,
+		config.ExpiryConfig{WarningWindow: "720h"},
+		logrus.New(),
+	)
 	ctx := context.Background()
 
 	SetupTestData(t, DB)
@@ -35,6 +48,7 @@ func TestUserUsecase_Integration(t *testing.T) {
 		require.Empty(t, info.Inventory)
 		require.Empty(t, info.CoinHistory.SentHistory)
 		require.Empty(t, info.CoinHistory.ReceivedHistory)
+		require.Empty(t, info.CoinHistory.Adjustments)
 	})
 
 	t.Run("get user info with purchases", func(t *testing.T) {
@@ -86,8 +100,8 @@ func TestUserUsecase_Integration(t *testing.T) {
 
 func createPurchase(t *testing.T, userID uint, itemType string) {
 	_, err := DB.Exec(`
-		INSERT INTO purchases (purchaser_id, purchase_type_id)
-		SELECT $1, id FROM purchase_types WHERE name = $2
+		INSERT INTO purchases (purchaser_id, purchase_type_id, item_name, price)
+		SELECT $1, id, name, cost FROM purchase_types WHERE name = $2
 	`, userID, itemType)
 	require.NoError(t, err)
 }
@@ -20,13 +20,17 @@ const BASE_URL = 'http://localhost:8080';
 const USERS_COUNT = 5000;
 const BATCH_SIZE = 100
 const MAX_RETRIES = 3;
-
-const MERCH_ITEMS = [
-    "t-shirt", "cup", "book", "pen", "powerbank",
-    "hoody", "umbrella", "socks", "wallet", "pink-hoody"
-];
+const INVITE_CODE = __ENV.INVITE_CODE || '';
 
 export function setup() {
+    const itemsRes = http.get(`${BASE_URL}/api/items`, { tags: { type: 'setup' } });
+    if (itemsRes.status !== 200) {
+        throw new Error(`❌ Failed to load merch catalog: ${itemsRes.status}`);
+    }
+    const items = itemsRes.json('items')
+        .filter(item => item.available)
+        .map(item => item.name);
+
     console.log('🔸 Starting setup: registering users...');
     const users = Array.from({ length: USERS_COUNT }, (_, i) => ({
         username: `user${i + 1}`,
@@ -38,10 +42,11 @@ export function setup() {
         const batch = users.slice(i, i + BATCH_SIZE);
         const requests = batch.map(user => ({
             method: 'POST',
-            url: `${BASE_URL}/api/auth`,
+            url: `${BASE_URL}/api/register`,
             body: JSON.stringify({
                 username: user.username,
-                password: user.password
+                password: user.password,
+                inviteCode: INVITE_CODE
             }),
             params: {
                 headers: { 'Content-Type': 'application/json' },
@@ -53,15 +58,16 @@ export function setup() {
         
         responses.forEach((res, index) => {
             const user = batch[index];
-            if (res.status !== 200) {
+            // 409 means the user is left from a previous run.
+            if (res.status !== 201 && res.status !== 409) {
                 console.warn(`⚠️ Failed to register ${user.username}. Retrying...`);
                 for (let attempt = 1; attempt <= MAX_RETRIES; attempt++) {
                     const retryRes = http.post(
-                        `${BASE_URL}/api/auth`,
-                        JSON.stringify(user),
+                        `${BASE_URL}/api/register`,
+                        JSON.stringify({ ...user, inviteCode: INVITE_CODE }),
                         { headers: { 'Content-Type': 'application/json' } }
                     );
-                    if (retryRes.status === 200) break;
+                    if (retryRes.status === 201 || retryRes.status === 409) break;
                     if (attempt === MAX_RETRIES) {
                         throw new Error(`❌ Failed to register ${user.username} after ${MAX_RETRIES} attempts`);
                     }
@@ -75,7 +81,7 @@ export function setup() {
     }
 
     console.log('✅ Setup completed: users registered!');
-    return { users };
+    return { users, items };
 }
 
 export default function (data) {
@@ -92,7 +98,7 @@ export default function (data) {
             break;
 
         case 2:
-            const item = MERCH_ITEMS[randomIntBetween(0, MERCH_ITEMS.length - 1)];
+            const item = data.items[randomIntBetween(0, data.items.length - 1)];
             const buyRes = http.get(`${BASE_URL}/api/buy/${item}`, {
                 headers: { Authorization: `Bearer ${token}` },
             });